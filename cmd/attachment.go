@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var (
+	attachmentCmd = &cobra.Command{
+		Use:   "attachment",
+		Short: "Manage the binary attachments of a secret",
+	}
+
+	attachmentGetCmd = &cobra.Command{
+		Use:   "get [uri] <attachment>",
+		Short: "Write the content of an attachment to standard output or a file",
+		Args:  cobra.RangeArgs(1, 2),
+		Run:   RunAttachmentGet,
+	}
+
+	attachmentPutCmd = &cobra.Command{
+		Use:   "put [uri] [attachment]",
+		Short: "Attach the content of a file or standard input to a secret",
+		Long: `Attach the content of a file or standard input to a secret.
+
+The attachment is named after the file when no name is given. An existing
+attachment with the same name is replaced.`,
+		Args: cobra.RangeArgs(0, 2),
+		Run:  RunAttachmentPut,
+	}
+
+	attachmentListCmd = &cobra.Command{
+		Use:   "list [uri]",
+		Short: "List the attachments of a secret",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   RunAttachmentList,
+	}
+
+	attachmentFile string
+)
+
+func init() {
+	for _, c := range []*cobra.Command{attachmentGetCmd, attachmentPutCmd, attachmentListCmd} {
+		c.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+		c.Flags().StringVar(&id, "id", "", "The ID of the secret to use")
+		c.Flags().StringVar(&name, "name", "", "The name of the secret to use")
+		c.Flags().BoolVarP(&one, "one", "1", false, "If multiple secrets found, use the first found")
+	}
+
+	attachmentGetCmd.Flags().StringVarP(&attachmentFile, "file", "f", "", "Write the attachment to this file instead of standard output")
+	attachmentPutCmd.Flags().StringVarP(&attachmentFile, "file", "f", "", "Read the attachment from this file instead of standard input")
+
+	attachmentCmd.AddCommand(
+		attachmentGetCmd,
+		attachmentListCmd,
+		attachmentPutCmd,
+	)
+}
+
+// findAttachmentSecret returns the keeper and the one secret selected by the
+// URI or the --keeper, --id, and --name flags.
+func findAttachmentSecret(
+	cmd *cobra.Command,
+	args []string,
+) (context.Context, secrets.Keeper, secrets.Secret) {
+	uri := parseURIArg(args)
+
+	if name != "" && id != "" {
+		s.Logger.Panic("Cannot specify both --id and --name.")
+	}
+
+	if name == "" && id == "" {
+		s.Logger.Panic("Must specify either --id or --name.")
+	}
+
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	var secs []secrets.Secret
+	if uri != nil {
+		secs, err = uri.Find(ctx, kpr)
+	} else if id != "" {
+		var sec secrets.Secret
+		sec, err = kpr.GetSecret(ctx, id)
+		secs = []secrets.Secret{sec}
+	} else {
+		secs, err = kpr.GetSecretsByName(ctx, name)
+	}
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	switch {
+	case len(secs) == 0:
+		s.Logger.Panic("No secret found.")
+	case len(secs) > 1 && !one:
+		s.Logger.Panicf("Found %d secrets. Use --one to use the first.", len(secs))
+	}
+
+	return ctx, kpr, secs[0]
+}
+
+func RunAttachmentGet(cmd *cobra.Command, args []string) {
+	attName := args[len(args)-1]
+	_, _, sec := findAttachmentSecret(cmd, args[:len(args)-1])
+
+	data, hasAttachment := secrets.GetAttachment(sec, attName)
+	if !hasAttachment {
+		s.Logger.Panicf("Secret %q has no attachment named %q.", sec.Name(), attName)
+	}
+
+	if attachmentFile == "" {
+		if _, err := cmd.OutOrStdout().Write(data); err != nil {
+			s.Logger.Panic(err)
+		}
+		return
+	}
+
+	if err := os.WriteFile(attachmentFile, data, 0o600); err != nil {
+		s.Logger.Panic(err)
+	}
+}
+
+func RunAttachmentPut(cmd *cobra.Command, args []string) {
+	var attName string
+	switch {
+	case len(args) == 2:
+		attName, args = args[1], args[:1]
+	case len(args) == 1 && (name != "" || id != ""):
+		attName, args = args[0], nil
+	case attachmentFile != "":
+		attName = filepath.Base(attachmentFile)
+	default:
+		s.Logger.Panic("Must name the attachment when reading standard input.")
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+	if attachmentFile == "" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(attachmentFile)
+	}
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	ctx, kpr, sec := findAttachmentSecret(cmd, args)
+
+	sec = secrets.SetAttachment(sec, attName, data)
+	newSec, err := kpr.SetSecret(ctx, sec)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	if _, hasAttachment := secrets.GetAttachment(newSec, attName); !hasAttachment {
+		s.Logger.Panicf("The keeper named %q does not support attachments.", keeperName)
+	}
+}
+
+func RunAttachmentList(cmd *cobra.Command, args []string) {
+	_, _, sec := findAttachmentSecret(cmd, args)
+
+	for _, attName := range secrets.Attachments(sec) {
+		data, _ := secrets.GetAttachment(sec, attName)
+		s.Printer.Printf("%s (%d bytes)", attName, len(data))
+	}
+}
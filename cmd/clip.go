@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/clipboard"
+	"github.com/zostay/ghost/pkg/keeper"
+)
+
+var clipTimeout time.Duration
+
+// openTerminal returns the terminal of the command, falling back to standard
+// error if there is none. The returned function closes the terminal.
+func openTerminal() (*os.File, func()) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return os.Stderr, func() {}
+	}
+	return tty, func() { _ = tty.Close() }
+}
+
+// copyToClipboard places the value on the clipboard and clears it again after
+// clipTimeout, provided the clipboard still holds the value. If the ghost
+// service is running, the service does the copying and clearing. Otherwise, a
+// clipboard helper is used or, if there is none, an OSC 52 escape sequence is
+// written to the terminal, and the command waits to clear the clipboard.
+func copyToClipboard(ctx context.Context, value, what string, term io.Writer) {
+	if _, err := keeper.CheckServer(); err == nil {
+		cbName, err := keeper.CopyToServiceClipboard(ctx, value, clipTimeout)
+		if err == nil {
+			s.Logger.Printf("Copied %s to the clipboard with %s.%s", what, cbName, clearingIn())
+			return
+		}
+
+		s.Logger.Printf("The ghost service failed to copy to the clipboard: %v", err)
+	}
+
+	cb := clipboard.DetectOrTerminal(term)
+	if err := cb.Copy(ctx, value); err != nil {
+		s.Logger.Panic(err)
+	}
+
+	s.Logger.Printf("Copied %s to the clipboard with %s.%s", what, cb.Name(), clearingIn())
+	if clipTimeout == 0 {
+		return
+	}
+
+	// an interrupt clears the clipboard early rather than leaving it behind
+	waitCtx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	select {
+	case <-waitCtx.Done():
+	case <-time.After(clipTimeout):
+	}
+
+	cleared, err := clipboard.Clear(ctx, cb, value)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	if cleared {
+		s.Logger.Print("Cleared the clipboard.")
+	}
+}
+
+// clearingIn describes when the clipboard will be cleared.
+func clearingIn() string {
+	if clipTimeout == 0 {
+		return ""
+	}
+	return " Clearing in " + clipTimeout.String() + "."
+}
@@ -28,6 +28,7 @@ func init() {
 type LiteralOrSecretRef struct {
 	Literal string
 	Ref     config.SecretRef
+	URI     config.SecretURI
 }
 
 func setupCommands() error {
@@ -53,7 +54,7 @@ func setupCommands() error {
 		for name, desc := range pc.CmdConfig.Fields {
 			var secOpt LiteralOrSecretRef
 			subCmd.Flags().StringVar(&secOpt.Literal, name, "", desc)
-			subCmd.Flags().Var(&flag.Secret{SecretRef: &secOpt.Ref}, name+"-secret", desc+" (set from a secret lookup)")
+			subCmd.Flags().Var(&flag.Secret{SecretRef: &secOpt.Ref, URI: &secOpt.URI}, name+"-secret", desc+" (set from a secret lookup)")
 			fields[name] = &secOpt
 		}
 
@@ -115,18 +116,26 @@ func MakePreRunSetKeeperConfig(
 	return func(cmd *cobra.Command, args []string) error {
 		cfgFields := make(map[string]any, len(fields))
 		for name, opt := range fields {
-			if opt.Literal != "" && opt.Ref.KeeperName != "" {
+			hasRef := opt.Ref.KeeperName != "" || opt.URI.Name != ""
+			if opt.Literal != "" && hasRef {
 				return fmt.Errorf("cannot use both --%s and --%s-secret", name, name)
 			}
 
-			if opt.Literal != "" {
+			switch {
+			case opt.Literal != "":
 				cfgFields[name] = opt.Literal
-			}
-
-			cfgFields[name] = map[string]any{
-				"keeper": opt.Ref.KeeperName,
-				"secret": opt.Ref.SecretName,
-				"field":  opt.Ref.Field,
+			case opt.URI.Name != "":
+				cfgFields[name] = config.KeeperConfig{
+					config.SecretRefKey: opt.URI.String(),
+				}
+			case hasRef:
+				cfgFields[name] = config.KeeperConfig{
+					config.SecretRefKey: map[string]any{
+						"keeper": opt.Ref.KeeperName,
+						"secret": opt.Ref.SecretName,
+						"field":  opt.Ref.Field,
+					},
+				}
 			}
 		}
 
@@ -9,9 +9,9 @@ import (
 )
 
 var deleteCmd = &cobra.Command{
-	Use:   "delete",
+	Use:   "delete [uri]",
 	Short: "Delete a secret",
-	Args:  cobra.NoArgs,
+	Args:  cobra.MaximumNArgs(1),
 	Run:   RunDelete,
 }
 
@@ -21,7 +21,12 @@ func init() {
 	deleteCmd.Flags().StringVar(&name, "name", "", "The name of the secret to get")
 }
 
-func RunDelete(cmd *cobra.Command, _ []string) {
+func RunDelete(cmd *cobra.Command, args []string) {
+	uri := parseURIArg(args)
+	if uri != nil && uri.Field != "" {
+		s.Logger.Panic("Cannot specify a field in the secret URI when deleting a secret.")
+	}
+
 	if name != "" && id != "" {
 		s.Logger.Panic("Cannot specify both --id and --name.")
 	}
@@ -49,7 +54,21 @@ func RunDelete(cmd *cobra.Command, _ []string) {
 		s.Logger.Panic(err)
 	}
 
-	if name != "" {
+	if uri != nil {
+		secs, err := uri.Find(ctx, kpr)
+		if err != nil {
+			s.Logger.Panic(err)
+		}
+
+		switch len(secs) {
+		case 0:
+			s.Logger.Panicf("No secret matches %s.", uri)
+		case 1:
+			id = secs[0].ID()
+		default:
+			s.Logger.Panicf("Multiple secrets match %s. Please add the location or username or delete by --id", uri)
+		}
+	} else if name != "" {
 		secs, err := kpr.GetSecretsByName(ctx, name)
 		if err != nil {
 			s.Logger.Panic(err)
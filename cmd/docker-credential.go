@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/dockercredential"
+)
+
+var (
+	dockerCredentialCmd = &cobra.Command{
+		Use:   "docker-credential <store|get|erase|list|version>",
+		Short: "Act as a docker credential helper",
+		Long: `Act as a docker credential helper, keeping the credentials of container
+registries as secrets with the URL of the registry.
+
+Docker runs credential helpers named docker-credential-<name> without options,
+so link ghost to that name and set the keeper and location in the docker
+section of the configuration:
+
+    ln -s "$(which ghost)" ~/bin/docker-credential-ghost
+
+    docker:
+      keeper: myKeepass
+      location: Docker
+
+Then set "credsStore": "ghost" in ~/.docker/config.json. The master keeper and
+a location of docker are used if none are configured. If the ghost service is
+running and serves the keeper, the service is used.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"store", "get", "erase", "list", "version"},
+		Run:       RunDockerCredential,
+	}
+
+	dockerCredentialLocation string
+)
+
+func init() {
+	dockerCredentialCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	dockerCredentialCmd.Flags().StringVar(&dockerCredentialLocation, "location", "", "The location to keep credentials in")
+}
+
+func RunDockerCredential(cmd *cobra.Command, args []string) {
+	if args[0] == "version" {
+		s.Printer.Println("docker-credential-ghost v" + strings.TrimSpace(Version))
+		return
+	}
+
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.Docker.Keeper
+	}
+
+	location := dockerCredentialLocation
+	if location == "" {
+		location = c.Docker.Location
+	}
+
+	if location == "" {
+		location = "docker"
+	}
+
+	ctx, kpr := credentialHelperKeeper(cmd.Context())
+	h := dockercredential.NewHelper(kpr, location)
+	err := h.Run(ctx, args[0], os.Stdin, cmd.OutOrStdout())
+	if err != nil {
+		// docker reads the error from standard output
+		fmt.Fprintln(cmd.OutOrStdout(), err)
+		os.Exit(1)
+	}
+}
@@ -10,12 +10,20 @@ import (
 
 type Secret struct {
 	*config.SecretRef
+
+	// URI is set instead of the SecretRef when the lookup is given as a
+	// secret URI.
+	URI *config.SecretURI
 }
 
 func (s *Secret) Set(value string) error {
+	if config.IsSecretURI(value) {
+		return s.setURI(value)
+	}
+
 	parts := strings.SplitN(value, ":", 3)
 	if len(parts) != 3 {
-		return errors.New("secret lookups must be in the form of <keeper>:<secret>:<field-name>")
+		return errors.New("secret lookups must be in the form of <keeper>:<secret>:<field-name> or ghost://<keeper>/<location>/<name>#<field>")
 	}
 
 	s.KeeperName, s.SecretName, s.Field = parts[0], parts[1], parts[2]
@@ -36,7 +44,20 @@ func (s *Secret) Set(value string) error {
 	return nil
 }
 
+func (s *Secret) setURI(value string) error {
+	uri, err := config.ParseSecretURI(value)
+	if err != nil {
+		return err
+	}
+
+	*s.URI = *uri
+	return nil
+}
+
 func (s *Secret) String() string {
+	if s.URI != nil && s.URI.Name != "" {
+		return s.URI.String()
+	}
 	return fmt.Sprintf("%s:%s:%s", s.KeeperName, s.SecretName, s.Field)
 }
 
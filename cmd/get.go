@@ -1,8 +1,11 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/zostay/go-std/set"
@@ -10,6 +13,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/clipboard"
 	"github.com/zostay/ghost/pkg/config"
 	"github.com/zostay/ghost/pkg/keeper"
 	"github.com/zostay/ghost/pkg/secrets"
@@ -17,8 +21,9 @@ import (
 
 var (
 	getCmd = &cobra.Command{
-		Use:   "get",
+		Use:   "get [uri]",
 		Short: "Get a secret",
+		Args:  cobra.MaximumNArgs(1),
 		Run:   RunGet,
 	}
 
@@ -29,6 +34,7 @@ var (
 	output       string
 	one          bool
 	envPrefix    string
+	clip         bool
 )
 
 func init() {
@@ -40,9 +46,16 @@ func init() {
 	getCmd.Flags().StringVarP(&output, "output", "o", "pretty", "Output format (pretty, yaml, json, env, password)")
 	getCmd.Flags().BoolVarP(&one, "one", "1", false, "If multiple secrets found, print only the first found")
 	getCmd.Flags().StringVar(&envPrefix, "env-prefix", "", "The prefix to use when output is env")
+	getCmd.Flags().BoolVar(&clip, "clip", false, "Copy the password, or the one field given, to the clipboard instead of printing")
+	getCmd.Flags().DurationVar(&clipTimeout, "clip-timeout", clipboard.DefaultTimeout, "Clear the clipboard after this long (0 to never clear)")
 }
 
-func RunGet(cmd *cobra.Command, _ []string) {
+func RunGet(cmd *cobra.Command, args []string) {
+	uri := parseURIArg(args)
+	if uri != nil && uri.Field != "" {
+		flds = []string{uri.Field}
+	}
+
 	if name != "" && id != "" {
 		s.Logger.Panic("Cannot specify both --id and --name.")
 	}
@@ -71,7 +84,12 @@ func RunGet(cmd *cobra.Command, _ []string) {
 	}
 
 	var secs []secrets.Secret
-	if id != "" {
+	if uri != nil {
+		secs, err = uri.Find(ctx, kpr)
+		if err != nil {
+			s.Logger.Panic(err)
+		}
+	} else if id != "" {
 		sec, err := kpr.GetSecret(ctx, id)
 		if err != nil {
 			s.Logger.Panic(err)
@@ -89,6 +107,18 @@ func RunGet(cmd *cobra.Command, _ []string) {
 		secs = secs[0:1]
 	}
 
+	for _, sec := range secs {
+		if since, isStale := secrets.IsStale(sec); isStale {
+			s.Logger.Printf("WARNING: %q could not be fetched from its keeper. Using an offline snapshot that is %v old.",
+				sec.Name(), time.Since(since).Round(time.Second))
+		}
+	}
+
+	if clip {
+		clipSecret(ctx, secs)
+		return
+	}
+
 	switch output {
 	case "env":
 		if one {
@@ -140,6 +170,34 @@ func RunGet(cmd *cobra.Command, _ []string) {
 	}
 }
 
+// clipSecret copies the password of the only secret, or the only field named
+// by --fields, to the clipboard.
+func clipSecret(ctx context.Context, secs []secrets.Secret) {
+	if len(flds) > 1 {
+		s.Logger.Panic("Cannot enable --clip with more than one field.")
+	}
+
+	switch len(secs) {
+	case 0:
+		s.Logger.Panic("No secret found.")
+	case 1:
+	default:
+		s.Logger.Panicf("Found %d secrets. Use --one to copy from the first.", len(secs))
+	}
+
+	uri := &config.SecretURI{}
+	what := "the password"
+	if len(flds) == 1 {
+		uri.Field = flds[0]
+		what = "the " + flds[0]
+	}
+
+	term, closeTerm := openTerminal()
+	defer closeTerm()
+
+	copyToClipboard(ctx, uri.Value(secs[0]), fmt.Sprintf("%s of %q", what, secs[0].Name()), term)
+}
+
 func convertToMap(secs []secrets.Secret) []map[string]any {
 	fldSet := set.New[string](slices.Map(flds, strings.ToLower)...)
 
@@ -195,6 +253,10 @@ func convertToMap(secs []secrets.Secret) []map[string]any {
 		if fields != nil {
 			ms[i]["fields"] = fields
 		}
+
+		if since, isStale := secrets.IsStale(sec); isStale {
+			ms[i]["stale-since"] = since
+		}
 	}
 
 	return ms
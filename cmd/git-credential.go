@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/gitcredential"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var (
+	gitCredentialCmd = &cobra.Command{
+		Use:   "git-credential <get|store|erase>",
+		Short: "Act as a git credential helper",
+		Long: `Act as a git credential helper, reading the credential from git on standard
+input and, for get, writing the matching username and password to standard
+output.
+
+Only the secrets in the location given by --location are used. A secret there
+matches a credential when the host of its URL is the host of the credential.
+The protocol, path, and username must also be the same when both the secret
+and the credential have them. New credentials are stored there with the
+credential URL, and erase only deletes secrets there.
+
+To use it, configure git like so:
+
+    git config --global credential.helper '!ghost git-credential'
+
+If the ghost service is running and serves the keeper, the service is used so
+that the keeper need not be unlocked again.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{"get", "store", "erase"},
+		Run:       RunGitCredential,
+	}
+
+	gitCredentialLocation string
+)
+
+func init() {
+	gitCredentialCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	gitCredentialCmd.Flags().StringVar(&gitCredentialLocation, "location", "git", "The location to keep credentials in")
+}
+
+func RunGitCredential(cmd *cobra.Command, args []string) {
+	action := args[0]
+	switch action {
+	case "get", "store", "erase":
+	default:
+		// git asks that helpers ignore the actions they do not know
+		return
+	}
+
+	cred, err := gitcredential.Read(os.Stdin)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	if cred.Host == "" {
+		return
+	}
+
+	ctx, kpr := credentialHelperKeeper(cmd.Context())
+	switch action {
+	case "get":
+		getGitCredential(ctx, cmd, kpr, cred)
+	case "store":
+		storeGitCredential(ctx, kpr, cred)
+	case "erase":
+		eraseGitCredential(ctx, kpr, cred)
+	}
+}
+
+// credentialHelperKeeper returns the keeper used by a credential helper,
+// preferring the service if it serves that keeper.
+func credentialHelperKeeper(ctx context.Context) (context.Context, secrets.Keeper) {
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if kpr, isService := keeper.ServiceKeeper(keeperName); isService {
+		return ctx, kpr
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx = keeper.WithBuilder(ctx, c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	return ctx, kpr
+}
+
+// findGitCredentials returns the secrets in the location of the helper that
+// match the credential. Secrets elsewhere in the keeper, such as the logins
+// used with a web browser, are never returned, so the helper cannot erase them.
+func findGitCredentials(
+	ctx context.Context,
+	kpr secrets.Keeper,
+	cred *gitcredential.Credential,
+) []secrets.Secret {
+	var found []secrets.Secret
+	err := secrets.ForEachInLocation(ctx, kpr, gitCredentialLocation, func(sec secrets.Secret) error {
+		if cred.Matches(sec) {
+			found = append(found, sec)
+		}
+		return nil
+	})
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	return found
+}
+
+func getGitCredential(
+	ctx context.Context,
+	cmd *cobra.Command,
+	kpr secrets.Keeper,
+	cred *gitcredential.Credential,
+) {
+	sec := cred.Best(findGitCredentials(ctx, kpr, cred))
+	if sec == nil {
+		return
+	}
+
+	out := &gitcredential.Credential{
+		Username: sec.Username(),
+		Password: sec.Password(),
+	}
+
+	if err := out.Write(cmd.OutOrStdout()); err != nil {
+		s.Logger.Panic(err)
+	}
+}
+
+func storeGitCredential(
+	ctx context.Context,
+	kpr secrets.Keeper,
+	cred *gitcredential.Credential,
+) {
+	if cred.Username == "" || cred.Password == "" {
+		return
+	}
+
+	if sec := cred.Best(findGitCredentials(ctx, kpr, cred)); sec != nil {
+		if sec.Password() == cred.Password {
+			return
+		}
+
+		updated := secrets.NewSingleFromSecret(sec)
+		updated.SetPassword(cred.Password)
+		if _, err := kpr.SetSecret(ctx, updated); err != nil {
+			s.Logger.Panic(err)
+		}
+		return
+	}
+
+	u := cred.URL()
+	sec := secrets.NewSecret(u.String(), cred.Username, cred.Password,
+		secrets.WithUrl(u),
+		secrets.WithLocation(gitCredentialLocation))
+	if _, err := kpr.SetSecret(ctx, sec); err != nil {
+		s.Logger.Panic(err)
+	}
+}
+
+func eraseGitCredential(
+	ctx context.Context,
+	kpr secrets.Keeper,
+	cred *gitcredential.Credential,
+) {
+	for _, sec := range findGitCredentials(ctx, kpr, cred) {
+		if cred.Password != "" && sec.Password() != cred.Password {
+			continue
+		}
+
+		if err := kpr.DeleteSecret(ctx, sec.ID()); err != nil {
+			s.Logger.Panic(err)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/zostay/ghost/cmd/mirror"
+)
+
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Manage mirror secret keepers",
+}
+
+func init() {
+	mirrorCmd.AddCommand(mirror.RepairCmd)
+}
@@ -0,0 +1,85 @@
+package mirror
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/mirror"
+)
+
+var (
+	RepairCmd = &cobra.Command{
+		Use:   "repair",
+		Short: "Copy writes missed by members of mirror keepers to those members",
+		Args:  cobra.NoArgs,
+		Run:   RunRepair,
+	}
+
+	keeperName string
+	dryRun     bool
+)
+
+func init() {
+	RepairCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to repair the mirrors of")
+	RepairCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List the writes to repair without repairing them")
+}
+
+func RunRepair(cmd *cobra.Command, _ []string) {
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	var mirrors []*mirror.Mirror
+	_ = secrets.Walk(kpr, func(k secrets.Keeper) error {
+		if m, isMirror := k.(*mirror.Mirror); isMirror {
+			mirrors = append(mirrors, m)
+		}
+		return nil
+	})
+
+	if len(mirrors) == 0 {
+		s.Logger.Panicf("The keeper named %q does not use a mirror.", keeperName)
+	}
+
+	failed := false
+	for _, m := range mirrors {
+		for _, rep := range m.PendingRepairs() {
+			s.Printer.Printf("%s %s in %s (failed %d time(s), last at %s: %s)",
+				rep.Op, rep.ID, rep.Member, rep.Attempts,
+				rep.Failed.Format("2006-01-02 15:04:05"), rep.Error)
+		}
+
+		if dryRun {
+			continue
+		}
+
+		n, err := m.Repair(ctx)
+		s.Logger.Printf("Repaired %d missed write(s).", n)
+		if err != nil {
+			s.Logger.Print(err)
+			failed = true
+		}
+	}
+
+	if failed {
+		s.Logger.Panic("Some missed writes could not be repaired.")
+	}
+}
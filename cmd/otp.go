@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/otp"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var (
+	otpCmd = &cobra.Command{
+		Use:   "otp [uri]",
+		Short: "Print the current one-time password of a secret",
+		Long: `Print the current one-time password computed from the seed stored in a secret.
+
+The seed is found in a field named otp, totp, TOTP Seed, or otpauth, in the
+TimeOtp-Secret fields used by KeePass, in a URL with the otpauth scheme, or in
+any other field holding an otpauth URL. An otpauth URL sets the digits, period,
+and algorithm, which may be overridden with the flags.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  RunOTP,
+	}
+
+	otpDigits    int
+	otpPeriod    time.Duration
+	otpAlgorithm string
+)
+
+func init() {
+	otpCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	otpCmd.Flags().StringVar(&id, "id", "", "The ID of the secret to use")
+	otpCmd.Flags().StringVar(&name, "name", "", "The name of the secret to use")
+	otpCmd.Flags().BoolVarP(&one, "one", "1", false, "If multiple secrets found, use the first found")
+	otpCmd.Flags().StringVarP(&output, "output", "o", "pretty", "Output format (pretty, code, json)")
+	otpCmd.Flags().IntVar(&otpDigits, "digits", 0, "Override the number of digits in the code")
+	otpCmd.Flags().DurationVar(&otpPeriod, "period", 0, "Override how long each code lasts")
+	otpCmd.Flags().StringVar(&otpAlgorithm, "algorithm", "", "Override the algorithm (SHA1, SHA256, SHA512)")
+}
+
+func RunOTP(cmd *cobra.Command, args []string) {
+	uri := parseURIArg(args)
+
+	if name != "" && id != "" {
+		s.Logger.Panic("Cannot specify both --id and --name.")
+	}
+
+	if name == "" && id == "" {
+		s.Logger.Panic("Must specify either --id or --name.")
+	}
+
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	var secs []secrets.Secret
+	if uri != nil {
+		secs, err = uri.Find(ctx, kpr)
+	} else if id != "" {
+		var sec secrets.Secret
+		sec, err = kpr.GetSecret(ctx, id)
+		secs = []secrets.Secret{sec}
+	} else {
+		secs, err = kpr.GetSecretsByName(ctx, name)
+	}
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	switch {
+	case len(secs) == 0:
+		s.Logger.Panic("No secret found.")
+	case len(secs) > 1 && !one:
+		s.Logger.Panicf("Found %d secrets. Use --one to use the first.", len(secs))
+	}
+
+	var code *otp.Code
+	if otpDigits == 0 && otpPeriod == 0 && otpAlgorithm == "" {
+		code, err = otp.Generate(ctx, kpr, secs[0])
+	} else {
+		code, err = overriddenOTP(secs[0])
+	}
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	switch output {
+	case "code":
+		s.Printer.Print(code.Code)
+	case "json":
+		out, err := json.Marshal(map[string]any{
+			"code":      code.Code,
+			"remaining": int(code.Remaining / time.Second),
+			"period":    int(code.Period / time.Second),
+		})
+		if err != nil {
+			s.Logger.Panic(err)
+		}
+		s.Printer.Print(string(out))
+	case "pretty":
+		if code.Remaining == 0 {
+			s.Printer.Print(code.Code)
+		} else {
+			s.Printer.Printf("%s (%v remaining)", code.Code, code.Remaining)
+		}
+	default:
+		s.Logger.Panicf("Unknown output format %q.", output)
+	}
+}
+
+// overriddenOTP returns the current code for the secret after applying the
+// settings given by flag.
+func overriddenOTP(sec secrets.Secret) (*otp.Code, error) {
+	k, err := otp.FromSecret(sec)
+	if err != nil {
+		return nil, err
+	}
+
+	if otpDigits != 0 {
+		k.Digits = otpDigits
+	}
+
+	if otpPeriod != 0 {
+		k.Period = otpPeriod
+	}
+
+	if otpAlgorithm != "" {
+		k.Algorithm, err = otp.ParseAlgorithm(otpAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := k.Validate(); err != nil {
+		return nil, err
+	}
+
+	return k.Generate(time.Now()), nil
+}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zostay/ghost/cmd/picker"
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/clipboard"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var (
+	pickCmd = &cobra.Command{
+		Use:   "pick [query]",
+		Short: "Pick a secret interactively",
+		Long: `Pick a secret from a list filtered as you type.
+
+Typing fuzzy matches the name, username, URL, and location of each secret.
+The selected secret is previewed with its password and any fields that look
+secret hidden. The keys are:
+
+  up, down, ^P, ^N   select a secret
+  enter              print the secret, or the field named by --field
+  ^Y                 copy the password to the clipboard
+  ^O                 open the URL
+  ^R                 reveal or hide the password and secret fields
+  ^U                 clear the query
+  esc, ^C            quit`,
+		Run: RunPick,
+	}
+
+	pickField    string
+	pickLocation string
+)
+
+func init() {
+	pickCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	pickCmd.Flags().StringVar(&pickLocation, "location", "", "Only pick from secrets in this location")
+	pickCmd.Flags().StringVar(&pickField, "field", "", "The field to print when a secret is picked")
+	pickCmd.Flags().BoolVar(&showPassword, "show-password", false, "Allow the password to be printed with --field=password")
+	pickCmd.Flags().DurationVar(&clipTimeout, "clip-timeout", clipboard.DefaultTimeout, "Clear the clipboard after this long (0 to never clear)")
+}
+
+func RunPick(cmd *cobra.Command, args []string) {
+	if pickField == "password" && !showPassword {
+		s.Logger.Panic("Cannot enable --field=password without --show-password")
+	}
+
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	var secs []secrets.Secret
+	collect := func(sec secrets.Secret) error {
+		secs = append(secs, sec)
+		return nil
+	}
+
+	if pickLocation != "" {
+		err = secrets.ForEachInLocation(ctx, kpr, pickLocation, collect)
+	} else {
+		err = secrets.ForEach(ctx, kpr, collect)
+	}
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	// the picker is drawn on the terminal, leaving standard output for the
+	// picked secret
+	in, out := os.Stdin, os.Stderr
+	if tty, closeTTY := openTerminal(); tty != os.Stderr {
+		defer closeTTY()
+		in, out = tty, tty
+	}
+
+	p := picker.New(secs)
+	for _, r := range strings.Join(args, " ") {
+		p.Type(r)
+	}
+
+	sec, action, err := picker.Pick(p, in, out)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	switch action {
+	case picker.ActionPrint:
+		if pickField == "" {
+			s.PrintSecret(sec, false)
+			return
+		}
+
+		uri := &config.SecretURI{Field: pickField}
+		s.Printer.Print(uri.Value(sec))
+	case picker.ActionCopy:
+		copyToClipboard(ctx, sec.Password(), fmt.Sprintf("the password of %q", sec.Name()), out)
+	case picker.ActionOpen:
+		if err := picker.OpenURL(secrets.UrlString(sec)); err != nil {
+			s.Logger.Panic(err)
+		}
+	}
+}
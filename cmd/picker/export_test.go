@@ -0,0 +1,26 @@
+package picker
+
+import "github.com/zostay/ghost/pkg/secrets"
+
+// These expose the internals of the picker to its tests.
+
+var (
+	FuzzyScore     = fuzzyScore
+	SensitiveField = sensitiveField
+)
+
+// Preview returns the lines describing the selected secret.
+func (p *Picker) Preview() []string {
+	return p.preview(p.Selected())
+}
+
+// ToggleReveal shows or hides the password and sensitive fields, as if ^R
+// were pressed.
+func (p *Picker) ToggleReveal() {
+	p.handle(keyCtrlR)
+}
+
+// Matches returns the secrets matching the query, best match first.
+func (p *Picker) Matches() []secrets.Secret {
+	return p.matches
+}
@@ -0,0 +1,87 @@
+package picker
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyScore reports whether every rune of the pattern appears in the text in
+// order, ignoring case, and gives a score to rank the match. Runes matched one
+// after another or at the start of a word score higher, so that "gh" ranks
+// "GitHub" above "Light House".
+func fuzzyScore(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+
+	pat := []rune(strings.ToLower(pattern))
+	txt := []rune(text)
+
+	score, pi, last := 0, 0, -2
+	for ti, r := range txt {
+		if pi == len(pat) {
+			break
+		}
+
+		if unicode.ToLower(r) != pat[pi] {
+			continue
+		}
+
+		score++
+		if ti == last+1 {
+			score += 3
+		}
+		if wordStart(txt, ti) {
+			score += 2
+		}
+
+		last = ti
+		pi++
+	}
+
+	if pi < len(pat) {
+		return 0, false
+	}
+
+	// shorter texts are a closer match
+	return score*100 - len(txt), true
+}
+
+// wordStart returns true if the rune at i starts a word: it is the first rune,
+// it follows a rune that is not a letter or digit, or it is an upper case
+// letter following a lower case one, like the H in GitHub.
+func wordStart(txt []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	prev := txt[i-1]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+
+	return unicode.IsUpper(txt[i]) && unicode.IsLower(prev)
+}
+
+// matchTerms scores the texts against each space-separated term of the query.
+// Every term must match at least one text. The best score of each term is
+// added up.
+func matchTerms(query string, texts ...string) (int, bool) {
+	total := 0
+	for _, term := range strings.Fields(query) {
+		best, found := 0, false
+		for _, text := range texts {
+			if score, ok := fuzzyScore(term, text); ok && (!found || score > best) {
+				best, found = score, true
+			}
+		}
+
+		if !found {
+			return 0, false
+		}
+
+		total += best
+	}
+
+	return total, true
+}
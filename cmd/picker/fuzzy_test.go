@@ -0,0 +1,85 @@
+package picker_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/cmd/picker"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	t.Parallel()
+
+	_, ok := picker.FuzzyScore("gh", "GitHub")
+	assert.True(t, ok)
+	_, ok = picker.FuzzyScore("hg", "GitHub")
+	assert.False(t, ok, "runes must appear in order")
+	_, ok = picker.FuzzyScore("gx", "GitHub")
+	assert.False(t, ok, "every rune must appear")
+
+	score, ok := picker.FuzzyScore("", "GitHub")
+	assert.True(t, ok)
+	assert.Equal(t, 0, score)
+
+	// each pair is the better match first
+	tests := []struct {
+		pattern       string
+		better, worse string
+		why           string
+	}{
+		{"git", "GitLab", "Digital", "runes in a row at the start of a word"},
+		{"gh", "GitHub", "Light House", "word starts rank above letters later in a word"},
+		{"gh", "gh-bot", "GitHub", "runes in a row rank above word starts apart"},
+		{"lab", "lab", "laboratory", "shorter texts are a closer match"},
+		{"GH", "GitHub", "Light House", "case is ignored"},
+	}
+
+	for _, tc := range tests {
+		better, ok := picker.FuzzyScore(tc.pattern, tc.better)
+		require.True(t, ok, tc.why)
+		worse, ok := picker.FuzzyScore(tc.pattern, tc.worse)
+		require.True(t, ok, tc.why)
+		assert.Greater(t, better, worse, tc.why)
+	}
+}
+
+// matched types the query into a new picker and returns the names of the
+// secrets matched, best match first.
+func matched(secs []secrets.Secret, query string) []string {
+	p := picker.New(secs)
+	for _, r := range query {
+		p.Type(r)
+	}
+
+	names := []string{}
+	for _, sec := range p.Matches() {
+		names = append(names, sec.Name())
+	}
+	return names
+}
+
+func TestPicker_Ranking(t *testing.T) {
+	t.Parallel()
+
+	secs := []secrets.Secret{
+		secrets.NewSecret("Light House", "me", "pw"),
+		secrets.NewSecret("GitHub", "me", "pw"),
+		secrets.NewSecret("Bank", "me", "pw"),
+		secrets.NewSecret("Mail", "gh-bot", "pw", secrets.WithLocation("Work")),
+	}
+
+	assert.Equal(t, []string{"Bank", "GitHub", "Light House", "Mail"},
+		matched(secs, ""), "sorted by name before typing")
+	assert.Equal(t, []string{"Mail", "GitHub", "Light House"}, matched(secs, "gh"))
+
+	// every term must match one of the name, username, URL, or location
+	assert.Equal(t, []string{"Mail"}, matched(secs, "gh work"))
+	assert.Empty(t, matched(secs, "zzz"))
+
+	p := picker.New(secs)
+	p.Type('z')
+	assert.Nil(t, p.Selected())
+}
@@ -0,0 +1,326 @@
+package picker
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// Action is what was asked to be done with the picked secret.
+type Action int
+
+const (
+	// ActionNone means the picker was closed without picking a secret.
+	ActionNone Action = iota
+
+	// ActionPrint prints the picked secret or a field of it.
+	ActionPrint
+
+	// ActionCopy copies the password of the picked secret to the clipboard.
+	ActionCopy
+
+	// ActionOpen opens the URL of the picked secret.
+	ActionOpen
+)
+
+const (
+	hidden = "<hidden>"
+
+	help = "enter print · ^Y copy password · ^O open URL · ^R reveal · esc quit"
+)
+
+// sensitiveWords are the words that mark a field as holding a secret value,
+// which is hidden in the preview like the password is.
+var sensitiveWords = []string{"password", "pass", "pin", "secret", "token", "key", "otp", "totp", "seed", "recovery"}
+
+// sensitiveField returns true if the name of the field suggests it holds a
+// secret value.
+func sensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, w := range sensitiveWords {
+		if strings.Contains(name, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// Picker is a terminal interface for choosing a secret from a list by fuzzy
+// matching the name, username, URL, and location. Passwords and fields that
+// appear to hold secrets are never drawn unless revealed.
+type Picker struct {
+	all     []secrets.Secret
+	matches []secrets.Secret
+
+	query    string
+	selected int
+	offset   int
+	reveal   bool
+}
+
+// New returns a picker for choosing between the given secrets.
+func New(secs []secrets.Secret) *Picker {
+	all := make([]secrets.Secret, len(secs))
+	copy(all, secs)
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Name() != all[j].Name() {
+			return strings.ToLower(all[i].Name()) < strings.ToLower(all[j].Name())
+		}
+		return all[i].Location() < all[j].Location()
+	})
+
+	p := &Picker{all: all}
+	p.filter()
+	return p
+}
+
+// filter finds the secrets matching the query, best match first.
+func (p *Picker) filter() {
+	type scored struct {
+		sec   secrets.Secret
+		score int
+	}
+
+	found := make([]scored, 0, len(p.all))
+	for _, sec := range p.all {
+		score, ok := matchTerms(p.query,
+			sec.Name(), sec.Username(), secrets.UrlString(sec), sec.Location())
+		if ok {
+			found = append(found, scored{sec, score})
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].score > found[j].score
+	})
+
+	p.matches = make([]secrets.Secret, len(found))
+	for i, f := range found {
+		p.matches[i] = f.sec
+	}
+
+	p.selected, p.offset = 0, 0
+}
+
+// Type adds the rune to the query, as if typed.
+func (p *Picker) Type(r rune) {
+	p.handle(key(string(r)))
+}
+
+// Selected returns the selected secret or nil if nothing matches.
+func (p *Picker) Selected() secrets.Secret {
+	if p.selected < len(p.matches) {
+		return p.matches[p.selected]
+	}
+	return nil
+}
+
+// move moves the selection by the given number of secrets.
+func (p *Picker) move(by int) {
+	p.selected += by
+	if p.selected >= len(p.matches) {
+		p.selected = len(p.matches) - 1
+	}
+	if p.selected < 0 {
+		p.selected = 0
+	}
+}
+
+// handle updates the picker for the key. It returns true with the action
+// chosen when picking is done.
+func (p *Picker) handle(k key) (Action, bool) {
+	switch k {
+	case keyEscape, keyCtrlC:
+		return ActionNone, true
+	case keyEnter:
+		return ActionPrint, p.Selected() != nil
+	case keyCtrlY:
+		return ActionCopy, p.Selected() != nil
+	case keyCtrlO:
+		sec := p.Selected()
+		return ActionOpen, sec != nil && sec.Url() != nil
+	case keyCtrlR:
+		p.reveal = !p.reveal
+	case keyUp, keyCtrlP:
+		p.move(-1)
+	case keyDown, keyCtrlN:
+		p.move(1)
+	case keyPageUp:
+		p.move(-10)
+	case keyPageDown:
+		p.move(10)
+	case keyBackspace:
+		if p.query != "" {
+			_, size := utf8.DecodeLastRuneInString(p.query)
+			p.query = p.query[:len(p.query)-size]
+			p.filter()
+		}
+	case keyCtrlU:
+		p.query = ""
+		p.filter()
+	default:
+		if k.isText() {
+			p.query += string(k)
+			p.filter()
+		}
+	}
+
+	return ActionNone, false
+}
+
+// fit cuts the string to the given width, padding it out to that width.
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+
+	rs := []rune(s)
+	if len(rs) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(rs[:width-1]) + "…"
+	}
+
+	return s + strings.Repeat(" ", width-len(rs))
+}
+
+// preview returns the lines describing the secret.
+func (p *Picker) preview(sec secrets.Secret) []string {
+	if sec == nil {
+		return []string{"No matching secrets."}
+	}
+
+	lines := []string{"Name: " + sec.Name()}
+	add := func(label, value string) {
+		if value != "" {
+			lines = append(lines, label+": "+value)
+		}
+	}
+
+	add("Location", sec.Location())
+	add("Username", sec.Username())
+	if sec.Password() != "" {
+		pw := hidden
+		if p.reveal {
+			pw = sec.Password()
+		}
+		lines = append(lines, "Password: "+pw)
+	}
+	add("URL", secrets.UrlString(sec))
+	add("Type", sec.Type())
+	if !sec.LastModified().IsZero() {
+		add("Modified", sec.LastModified().Format(time.RFC3339))
+	}
+
+	names := make([]string, 0, len(sec.Fields()))
+	for name := range sec.Fields() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(names) > 0 {
+		lines = append(lines, "Fields:")
+	}
+	for _, name := range names {
+		value := sec.GetField(name)
+		if sensitiveField(name) && !p.reveal {
+			value = hidden
+		}
+		lines = append(lines, "  "+name+": "+value)
+	}
+
+	return lines
+}
+
+// render draws the picker to the terminal with the given size.
+func (p *Picker) render(w io.Writer, width, height int) {
+	previewHeight := height / 3
+	listHeight := height - previewHeight - 4
+	if listHeight < 1 {
+		listHeight = 1
+	}
+
+	if p.selected < p.offset {
+		p.offset = p.selected
+	}
+	if p.selected >= p.offset+listHeight {
+		p.offset = p.selected - listHeight + 1
+	}
+
+	lines := make([]string, 0, height)
+	lines = append(lines,
+		fit("> "+p.query, width),
+		fit(fmt.Sprintf("  %d/%d", len(p.matches), len(p.all)), width))
+
+	col := (width - 2) / 4
+	for i := p.offset; i < p.offset+listHeight; i++ {
+		if i >= len(p.matches) {
+			lines = append(lines, "")
+			continue
+		}
+
+		sec := p.matches[i]
+		marker := "  "
+		if i == p.selected {
+			marker = "> "
+		}
+
+		lines = append(lines, fit(marker+
+			fit(sec.Name(), col)+
+			fit(sec.Username(), col)+
+			fit(sec.Location(), col)+
+			secrets.UrlString(sec), width))
+	}
+
+	lines = append(lines, strings.Repeat("─", width))
+	pv := p.preview(p.Selected())
+	for i := 0; i < previewHeight; i++ {
+		if i < len(pv) {
+			lines = append(lines, fit(pv[i], width))
+		} else {
+			lines = append(lines, "")
+		}
+	}
+	lines = append(lines, fit(help, width))
+
+	fmt.Fprint(w, "\x1b[H")
+	for i, line := range lines {
+		if i > 0 {
+			fmt.Fprint(w, "\r\n")
+		}
+		fmt.Fprint(w, line, "\x1b[K")
+	}
+	fmt.Fprint(w, "\x1b[J")
+
+	// leave the cursor at the end of the query
+	fmt.Fprintf(w, "\x1b[1;%dH", utf8.RuneCountInString(p.query)+3)
+}
+
+// run draws the picker and handles keys read from in until picking is done.
+func (p *Picker) run(in io.Reader, out io.Writer, size func() (int, int)) (secrets.Secret, Action, error) {
+	buf := make([]byte, 256)
+	for {
+		width, height := size()
+		p.render(out, width, height)
+
+		n, err := in.Read(buf)
+		if err != nil {
+			return nil, ActionNone, err
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			if action, done := p.handle(k); done {
+				if action == ActionNone {
+					return nil, ActionNone, nil
+				}
+				return p.Selected(), action, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package picker_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/cmd/picker"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+func TestSensitiveField(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{
+		"password", "Passphrase", "PIN", "client_secret", "API Token",
+		"ssh-key", "OTP", "totp", "seed", "Recovery Codes",
+	} {
+		assert.True(t, picker.SensitiveField(name), name)
+	}
+
+	for _, name := range []string{"username", "email", "notes", "url", "account"} {
+		assert.False(t, picker.SensitiveField(name), name)
+	}
+}
+
+func TestPicker_Preview(t *testing.T) {
+	t.Parallel()
+
+	sec := secrets.NewSecret("db", "me", "hunter2",
+		secrets.WithLocation("Work"),
+		secrets.WithField("api-token", "tok"),
+		secrets.WithField("notes", "read only"),
+		secrets.WithField("Recovery Codes", "1234"))
+
+	p := picker.New([]secrets.Secret{sec})
+	require.NotNil(t, p.Selected())
+
+	assert.Equal(t, []string{
+		"Name: db",
+		"Location: Work",
+		"Username: me",
+		"Password: <hidden>",
+		"Fields:",
+		"  Recovery Codes: <hidden>",
+		"  api-token: <hidden>",
+		"  notes: read only",
+	}, p.Preview())
+
+	p.ToggleReveal()
+	assert.Equal(t, []string{
+		"Name: db",
+		"Location: Work",
+		"Username: me",
+		"Password: hunter2",
+		"Fields:",
+		"  Recovery Codes: 1234",
+		"  api-token: tok",
+		"  notes: read only",
+	}, p.Preview())
+
+	p.ToggleReveal()
+	assert.Contains(t, p.Preview(), "Password: <hidden>")
+	assert.Contains(t, p.Preview(), "  api-token: <hidden>")
+}
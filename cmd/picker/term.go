@@ -0,0 +1,143 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// key is a key pressed on the terminal. Text is a key holding the single rune
+// typed. Other keys have the names below.
+type key string
+
+const (
+	keyUp        key = "up"
+	keyDown      key = "down"
+	keyPageUp    key = "page-up"
+	keyPageDown  key = "page-down"
+	keyEnter     key = "enter"
+	keyBackspace key = "backspace"
+	keyEscape    key = "escape"
+	keyCtrlC     key = "ctrl-c"
+	keyCtrlN     key = "ctrl-n"
+	keyCtrlO     key = "ctrl-o"
+	keyCtrlP     key = "ctrl-p"
+	keyCtrlR     key = "ctrl-r"
+	keyCtrlU     key = "ctrl-u"
+	keyCtrlY     key = "ctrl-y"
+)
+
+// ctrlKeys maps control characters to keys.
+var ctrlKeys = map[byte]key{
+	3:   keyCtrlC,
+	8:   keyBackspace,
+	10:  keyEnter,
+	13:  keyEnter,
+	14:  keyCtrlN,
+	15:  keyCtrlO,
+	16:  keyCtrlP,
+	18:  keyCtrlR,
+	21:  keyCtrlU,
+	25:  keyCtrlY,
+	127: keyBackspace,
+}
+
+// isText returns true if the key is a typed rune.
+func (k key) isText() bool {
+	r, size := utf8.DecodeRuneInString(string(k))
+	return size == len(k) && r != utf8.RuneError && unicode.IsPrint(r)
+}
+
+// parseKeys splits the bytes read from a terminal in raw mode into keys.
+// Unknown escape sequences and control characters are dropped.
+func parseKeys(b []byte) []key {
+	var keys []key
+	for i := 0; i < len(b); {
+		switch c := b[i]; {
+		case c == 0x1b && i+2 < len(b) && (b[i+1] == '[' || b[i+1] == 'O'):
+			seq := i + 2
+			for seq < len(b) && (b[seq] < 0x40 || b[seq] > 0x7e) {
+				seq++
+			}
+
+			switch string(b[i+2 : min(seq+1, len(b))]) {
+			case "A":
+				keys = append(keys, keyUp)
+			case "B":
+				keys = append(keys, keyDown)
+			case "5~":
+				keys = append(keys, keyPageUp)
+			case "6~":
+				keys = append(keys, keyPageDown)
+			}
+			i = seq + 1
+		case c == 0x1b:
+			keys = append(keys, keyEscape)
+			i++
+		case c < 0x20 || c == 127:
+			if k, isKnown := ctrlKeys[c]; isKnown {
+				keys = append(keys, k)
+			}
+			i++
+		default:
+			r, size := utf8.DecodeRune(b[i:])
+			if r != utf8.RuneError {
+				keys = append(keys, key(string(r)))
+			}
+			i += size
+		}
+	}
+	return keys
+}
+
+// Pick shows the picker on the terminal, reading keys from in and drawing to
+// out, and returns the secret picked and the action chosen. The terminal is
+// restored before returning.
+func Pick(p *Picker, in, out *os.File) (secrets.Secret, Action, error) {
+	inFd := int(in.Fd())
+	if !term.IsTerminal(inFd) {
+		return nil, ActionNone, errors.New("picking a secret requires a terminal")
+	}
+
+	state, err := term.MakeRaw(inFd)
+	if err != nil {
+		return nil, ActionNone, err
+	}
+	defer func() { _ = term.Restore(inFd, state) }()
+
+	// draw on the alternate screen, so nothing is left in the scrollback
+	fmt.Fprint(out, "\x1b[?1049h")
+	defer fmt.Fprint(out, "\x1b[?1049l")
+
+	size := func() (int, int) {
+		width, height, err := term.GetSize(int(out.Fd()))
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	}
+
+	return p.run(in, out, size)
+}
+
+// OpenURL opens the URL with the desktop's default handler.
+func OpenURL(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	return cmd.Start()
+}
@@ -1,17 +1,12 @@
 package cmd
 
 import (
-	"bufio"
-	"crypto/rand"
-	"math/big"
-	"os"
-	"regexp"
-
 	"github.com/spf13/cobra"
-	"github.com/zostay/go-std/generic"
-	"github.com/zostay/go-std/slices"
+	"github.com/spf13/pflag"
 
 	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/generate"
 )
 
 var (
@@ -22,161 +17,138 @@ var (
 		Run:   RunRandomPassword,
 	}
 
-	lc, uc, digits, symbols float32
-	length                  int
-	chbs                    bool
-	dictionary              string
-
-	lcChars     = slices.FromRange[byte]('a', 'z', 1)
-	ucChars     = slices.FromRange[byte]('A', 'Z', 1)
-	symbolChars = []byte{
-		'~', '`', '!', '@', '#', '$', '%', '^', '&', '*', '(', ')', '-', '_', '+', '=',
-		'[', '{', ']', '}', '\\', '|', ';', ':', '\'', '"', ',', '<', '.', '>', '/', '?',
-	}
-	digitChars = slices.FromRange[byte]('0', '9', 1)
+	recipeName string
+	recipe     = generate.DefaultRecipe()
 )
 
 func init() {
-	randomCmd.Flags().Float32VarP(&lc, "lowercase-weight", "l", 0.4, "lowercase letter weight")
-	randomCmd.Flags().Float32VarP(&uc, "uppercase-weight", "u", 0.3, "uppercase letter weight")
-	randomCmd.Flags().Float32VarP(&digits, "digit-weight", "d", 0.2, "numeric digit weight")
-	randomCmd.Flags().Float32VarP(&symbols, "symbol-weight", "s", 0.1, "wymbol character weight")
-	randomCmd.Flags().IntVarP(&length, "length", "n", 20, "length of the password")
-	randomCmd.Flags().BoolVarP(&chbs, "correct-horse-battery-staple", "x", false, "generate a password using the XKCD method")
-	randomCmd.Flags().StringVarP(&dictionary, "dictionary", "D", "/usr/share/dict/words", "dictionary file to use for the XKCD method")
+	addRecipeFlags(randomCmd.Flags(), true)
 }
 
-func RunRandomPassword(*cobra.Command, []string) {
-	if chbs {
-		runCHBS()
-		return
-	}
-
-	totes := lc + uc + digits + symbols
-	lc /= totes
-	uc /= totes
-	digits /= totes
-	symbols /= totes
-
-	lcCount := selectChars(lc, length)
-	ucCount := selectChars(uc, length)
-	digitCount := selectChars(digits, length)
-	symbolCount := selectChars(symbols, length)
-
-	for (lcCount + ucCount + digitCount + symbolCount) > length {
-		switch {
-		case lcCount > generic.Max(ucCount, generic.Max(digitCount, symbolCount)):
-			lcCount--
-		case ucCount > generic.Max(lcCount, generic.Max(digitCount, symbolCount)):
-			ucCount--
-		case digitCount > generic.Max(lcCount, generic.Max(ucCount, symbolCount)):
-			digitCount--
-		default:
-			symbolCount--
+// addRecipeFlags adds the options for generating a secret to the flags. The
+// single letter shorthands are only given when asked for.
+func addRecipeFlags(flags *pflag.FlagSet, shorthands bool) {
+	short := func(shorthand string) string {
+		if shorthands {
+			return shorthand
 		}
+		return ""
 	}
 
-	for (lcCount + ucCount + digitCount + symbolCount) < length {
-		switch {
-		case lcCount > 0 && lcCount < generic.Min(ucCount, generic.Min(digitCount, symbolCount)):
-			lcCount++
-		case ucCount > 0 && ucCount < generic.Min(lcCount, generic.Min(digitCount, symbolCount)):
-			ucCount++
-		case digitCount > 0 && digitCount < generic.Min(lcCount, generic.Min(ucCount, symbolCount)):
-			digitCount++
-		case symbolCount > 0:
-			symbolCount++
-		}
-	}
-
-	pw := sample(lcChars, lcCount)
-	pw = append(pw, sample(ucChars, ucCount)...)
-	pw = append(pw, sample(digitChars, digitCount)...)
-	pw = append(pw, sample(symbolChars, symbolCount)...)
-
-	shuffle(pw)
-
-	s.Printer.Println(string(pw))
+	def := generate.DefaultRecipe()
+	flags.StringVar(&recipeName, "recipe", "", "the name of the recipe in the configuration to start from")
+	flags.Float32VarP(&recipe.LowercaseWeight, "lowercase-weight", short("l"), def.LowercaseWeight, "lowercase letter weight")
+	flags.Float32VarP(&recipe.UppercaseWeight, "uppercase-weight", short("u"), def.UppercaseWeight, "uppercase letter weight")
+	flags.Float32VarP(&recipe.DigitWeight, "digit-weight", short("d"), def.DigitWeight, "numeric digit weight")
+	flags.Float32VarP(&recipe.SymbolWeight, "symbol-weight", short("s"), def.SymbolWeight, "symbol character weight")
+	flags.StringVar(&recipe.Symbols, "symbols", def.Symbols, "the symbol characters to choose from")
+	flags.IntVarP(&recipe.Length, "length", short("n"), def.Length, "length of the password")
+	flags.BoolVarP(&recipe.CorrectHorseBatteryStaple, "correct-horse-battery-staple", short("x"), def.CorrectHorseBatteryStaple, "generate a password using the XKCD method")
+	flags.StringVarP(&recipe.Dictionary, "dictionary", short("D"), def.Dictionary, "dictionary file to use for the XKCD method")
 }
 
-func selectChars(weight float32, length int) int {
-	if weight > 0 {
-		return int(generic.Max(1.0, weight*float32(length)))
-	}
-	return 0
-}
+// generateSecret generates a secret using the recipe named by --recipe or else
+// the recipe configured for the location and type, if any. Recipe options
+// given on the command line take precedence over the recipe.
+func generateSecret(flags *pflag.FlagSet, location, typ string) string {
+	c := config.Instance()
+	r := generate.DefaultRecipe()
 
-var plainWord = regexp.MustCompile(`^\w+$`)
+	name := recipeName
+	if name == "" {
+		name = c.Generate.RecipeName(location, typ)
+	}
 
-func runCHBS() {
-	dr, err := os.Open(dictionary)
-	if err != nil {
-		s.Logger.Panic(err)
-	}
-	defer func() { _ = dr.Close() }()
-
-	longWords := make([]string, 0, 1000)
-	shortWords := make([]string, 0, 1000)
-	dscanner := bufio.NewScanner(dr)
-	for dscanner.Scan() {
-		word := dscanner.Text()
-		if plainWord.MatchString(word) {
-			if len(word) > 6 {
-				longWords = append(longWords, word)
-			} else {
-				shortWords = append(shortWords, word)
-			}
+	if name != "" {
+		rc, hasRecipe := c.Recipes[name]
+		if !hasRecipe {
+			s.Logger.Panicf("No recipe named %q.", name)
 		}
+
+		applyRecipeConfig(&r, rc)
 	}
 
-	shuffle(shortWords)
-	shuffle(longWords)
+	applyRecipeFlags(&r, flags)
 
-	pw := ""
-	for len(pw) < length {
-		if len(pw) > 0 {
-			pw += " "
-		}
-
-		if randomInt(100) < 5 {
-			pw += pick(longWords)
-		} else {
-			pw += pick(shortWords)
-		}
+	pw, err := r.Generate()
+	if err != nil {
+		s.Logger.Panicf("Unable to generate a password: %v", err)
 	}
 
-	s.Printer.Println(pw)
+	return pw
 }
 
-func sample[T any](from []T, count int) []T {
-	out := make([]T, 0, count)
-	for len(out) < count {
-		out = append(out, pick(from))
+// applyRecipeConfig sets the parts of the recipe set by the configured recipe.
+func applyRecipeConfig(r *generate.Recipe, rc config.RecipeConfig) {
+	if rc.LowercaseWeight != nil {
+		r.LowercaseWeight = *rc.LowercaseWeight
+	}
+	if rc.UppercaseWeight != nil {
+		r.UppercaseWeight = *rc.UppercaseWeight
+	}
+	if rc.DigitWeight != nil {
+		r.DigitWeight = *rc.DigitWeight
+	}
+	if rc.SymbolWeight != nil {
+		r.SymbolWeight = *rc.SymbolWeight
+	}
+	if rc.Symbols != "" {
+		r.Symbols = rc.Symbols
+	}
+	if rc.Length != 0 {
+		r.Length = rc.Length
+	}
+	if rc.CorrectHorseBatteryStaple {
+		r.CorrectHorseBatteryStaple = true
+	}
+	if rc.Dictionary != "" {
+		r.Dictionary = rc.Dictionary
 	}
-	return out
 }
 
-func pick[T any](from []T) T {
-	mx := big.NewInt(int64(len(from)))
-	p, err := rand.Int(rand.Reader, mx)
-	if err != nil {
-		s.Logger.Panic(err)
-	}
-	return from[p.Int64()]
+// recipeFlagsChanged returns true if any recipe option is given on the command
+// line.
+func recipeFlagsChanged(flags *pflag.FlagSet) bool {
+	changed := false
+	flags.Visit(func(f *pflag.Flag) {
+		switch f.Name {
+		case "recipe", "lowercase-weight", "uppercase-weight", "digit-weight",
+			"symbol-weight", "symbols", "length", "correct-horse-battery-staple",
+			"dictionary":
+			changed = true
+		}
+	})
+	return changed
 }
 
-func randomInt(mx int) int {
-	p, err := rand.Int(rand.Reader, big.NewInt(int64(mx)))
-	if err != nil {
-		s.Logger.Panic(err)
+// applyRecipeFlags sets the parts of the recipe given on the command line.
+func applyRecipeFlags(r *generate.Recipe, flags *pflag.FlagSet) {
+	if flags.Changed("lowercase-weight") {
+		r.LowercaseWeight = recipe.LowercaseWeight
+	}
+	if flags.Changed("uppercase-weight") {
+		r.UppercaseWeight = recipe.UppercaseWeight
+	}
+	if flags.Changed("digit-weight") {
+		r.DigitWeight = recipe.DigitWeight
+	}
+	if flags.Changed("symbol-weight") {
+		r.SymbolWeight = recipe.SymbolWeight
+	}
+	if flags.Changed("symbols") {
+		r.Symbols = recipe.Symbols
+	}
+	if flags.Changed("length") {
+		r.Length = recipe.Length
+	}
+	if flags.Changed("correct-horse-battery-staple") {
+		r.CorrectHorseBatteryStaple = recipe.CorrectHorseBatteryStaple
+	}
+	if flags.Changed("dictionary") {
+		r.Dictionary = recipe.Dictionary
 	}
-	return int(p.Int64())
 }
 
-func shuffle[T any](in []T) []T {
-	for i := range in {
-		j := randomInt(len(in))
-		in[i], in[j] = in[j], in[i]
-	}
-	return in
+func RunRandomPassword(cmd *cobra.Command, _ []string) {
+	s.Printer.Println(generateSecret(cmd.Flags(), "", ""))
 }
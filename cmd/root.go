@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	s "github.com/zostay/ghost/cmd/shared"
@@ -18,15 +22,25 @@ var (
 
 func init() {
 	RootCmd.AddCommand(
+		attachmentCmd,
 		configCmd,
 		deleteCmd,
+		dockerCredentialCmd,
 		enforcePolicyCmd,
 		getCmd,
+		gitCredentialCmd,
 		listCmd,
+		mirrorCmd,
+		otpCmd,
+		pickCmd,
 		randomCmd,
 		serviceCmd,
+		searchCmd,
 		setCmd,
+		sshAgentCmd,
 		syncCmd,
+		trashCmd,
+		uriCmd,
 		versionCmd,
 	)
 
@@ -34,5 +48,11 @@ func init() {
 }
 
 func Execute() {
+	// docker runs its credential helpers as docker-credential-<name>, so ghost
+	// acts as one when linked to that name
+	if strings.HasPrefix(filepath.Base(os.Args[0]), "docker-credential-") {
+		RootCmd.SetArgs(append([]string{dockerCredentialCmd.Name()}, os.Args[1:]...))
+	}
+
 	cobra.CheckErr(RootCmd.Execute())
 }
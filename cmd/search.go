@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var searchCmd = &cobra.Command{
+	Use:   "search [<field><op><value> ...]",
+	Short: "Search for secrets matching conditions",
+	Long: `Search for secrets matching every condition given.
+
+Each condition is a field, an operator, and a value, such as:
+
+  ghost search type=ssh-key location=Work
+  ghost search url.host=github.com
+  ghost search 'name~*prod*' 'modified<2025-01-01'
+
+The operators are = and != for exact matches, ~ and !~ for glob patterns or
+/regular expressions/, and < and > for comparing the modified time. The fields
+are id, name, username, type, location, url, url.scheme, url.host, url.path,
+modified, and the names of any other fields of the secret. The password cannot
+be searched. With no conditions, every secret is listed.`,
+	Run: RunSearch,
+}
+
+func init() {
+	searchCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	searchCmd.Flags().StringSliceVar(&flds, "fields", []string{}, "The fields to display")
+	searchCmd.Flags().BoolVar(&showPassword, "show-password", false, "Show the password in the output")
+}
+
+func RunSearch(cmd *cobra.Command, args []string) {
+	q, err := secrets.ParseQuery(args...)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	secs, err := secrets.Search(ctx, kpr, q)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	for _, sec := range secs {
+		s.PrintSecret(sec, showPassword, flds...)
+	}
+}
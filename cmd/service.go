@@ -15,4 +15,5 @@ func init() {
 	serviceCmd.AddCommand(service.StartCmd)
 	serviceCmd.AddCommand(service.StopCmd)
 	serviceCmd.AddCommand(service.StatusCmd)
+	serviceCmd.AddCommand(service.FlushCacheCmd)
 }
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/keeper"
+)
+
+var (
+	FlushCacheCmd = &cobra.Command{
+		Use:   "flush-cache",
+		Short: "Remove secrets from the caches used by the ghost service",
+		Args:  cobra.NoArgs,
+		Run:   RunFlushCache,
+	}
+
+	flushIds []string
+)
+
+func init() {
+	FlushCacheCmd.Flags().StringSliceVar(&flushIds, "id", []string{}, "the ID of a secret to remove from the caches (all secrets are removed by default)")
+}
+
+func RunFlushCache(cmd *cobra.Command, _ []string) {
+	n, err := keeper.FlushServiceCache(cmd.Context(), flushIds...)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	if n == 0 {
+		s.Logger.Panic("The keeper served by the ghost service does not use a cache.")
+	}
+
+	s.Logger.Printf("Flushed %d cache(s).", n)
+}
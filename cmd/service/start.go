@@ -2,17 +2,28 @@ package service
 
 import (
 	"context"
+	"net"
+	"os"
 	"time"
 
+	"github.com/godbus/dbus/v5"
 	"github.com/spf13/cobra"
 
 	s "github.com/zostay/ghost/cmd/shared"
 	"github.com/zostay/ghost/pkg/config"
 	"github.com/zostay/ghost/pkg/keeper"
 	"github.com/zostay/ghost/pkg/plugin"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/cache"
 	"github.com/zostay/ghost/pkg/secrets/policy"
+	"github.com/zostay/ghost/pkg/secretservice"
+	"github.com/zostay/ghost/pkg/sshagent"
 )
 
+// snapshotSaveDelay is how long to wait after a cache changes before saving
+// its snapshot, so that a burst of changes is saved together.
+const snapshotSaveDelay = 5 * time.Second
+
 var (
 	StartCmd = &cobra.Command{
 		Use:   "start",
@@ -24,6 +35,18 @@ var (
 	enforcePolicies    []string
 	enforcementPeriod  time.Duration
 	keeperService      string
+
+	secretService             bool
+	secretServiceLocation     string
+	secretServiceLocations    []string
+	secretServiceUnlockSecret string
+	secretServiceUnlocked     bool
+
+	sshAgent bool
+
+	warmCache            bool
+	warmCacheConcurrency int
+	warmCacheInterval    time.Duration
 )
 
 func init() {
@@ -31,6 +54,15 @@ func init() {
 	StartCmd.Flags().StringSliceVar(&enforcePolicies, "enforce-policy", []string{}, "enforce the named policies")
 	StartCmd.Flags().DurationVar(&enforcementPeriod, "enforcement-period", 1*time.Minute, "enforce policies every period")
 	StartCmd.Flags().StringVar(&keeperService, "keeper", "", "the name of the keeper service to use (master used by default)")
+	StartCmd.Flags().BoolVar(&secretService, "secret-service", false, "provide the freedesktop.org Secret Service on the D-Bus session bus")
+	StartCmd.Flags().StringVar(&secretServiceLocation, "secret-service-location", secretservice.DefaultLocation, "the location used for the default Secret Service collection")
+	StartCmd.Flags().StringSliceVar(&secretServiceLocations, "secret-service-share", []string{}, "another location to share as a Secret Service collection")
+	StartCmd.Flags().StringVar(&secretServiceUnlockSecret, "secret-service-unlock-secret", "", "a secret URI naming the password that unlocks the Secret Service collections")
+	StartCmd.Flags().BoolVar(&secretServiceUnlocked, "secret-service-unlocked", false, "leave the Secret Service collections unlocked for every application")
+	StartCmd.Flags().BoolVar(&sshAgent, "ssh-agent", false, "provide an SSH agent holding the keys kept in the keeper (see ghost ssh-agent)")
+	StartCmd.Flags().BoolVar(&warmCache, "warm-cache", false, "preload every secret into the caches used by the keeper")
+	StartCmd.Flags().IntVar(&warmCacheConcurrency, "warm-cache-concurrency", cache.DefaultWarmUpConcurrency, "the number of secrets to preload at once")
+	StartCmd.Flags().DurationVar(&warmCacheInterval, "warm-cache-interval", 15*time.Minute, "reload the caches every interval (0 to never reload)")
 }
 
 func RunStartService(cmd *cobra.Command, _ []string) {
@@ -44,6 +76,21 @@ func RunStartService(cmd *cobra.Command, _ []string) {
 		return
 	}
 
+	if warmCacheInterval < 0 {
+		s.Logger.Panic("warm cache interval must not be negative")
+		return
+	}
+
+	if secretService && secretServiceUnlockSecret == "" && !secretServiceUnlocked {
+		s.Logger.Panic("--secret-service requires --secret-service-unlock-secret or --secret-service-unlocked")
+		return
+	}
+
+	if secretServiceUnlockSecret != "" && secretServiceUnlocked {
+		s.Logger.Panic("cannot use --secret-service-unlock-secret and --secret-service-unlocked together")
+		return
+	}
+
 	c := config.Instance()
 	if keeperService == "" {
 		keeperService = c.MasterKeeper
@@ -77,6 +124,27 @@ func RunStartService(cmd *cobra.Command, _ []string) {
 
 	startPolicyEnforcement(ctx, c)
 
+	caches := findCaches(kpr)
+	startSnapshotSavers(ctx, caches)
+	defer saveSnapshots(caches)
+
+	if warmCache {
+		startCacheWarmUp(ctx, caches)
+	}
+
+	if secretService {
+		ss := startSecretService(ctx, kpr)
+		defer func() { _ = ss.Close() }()
+	}
+
+	if sshAgent {
+		l := startSSHAgent(ctx, c, kpr)
+		defer func() {
+			_ = l.Close()
+			_ = os.Remove(sshagent.SocketName())
+		}()
+	}
+
 	err = keeper.StartServer(
 		s.Logger,
 		kpr,
@@ -128,3 +196,215 @@ func enforcePolicyThenWait(
 	}()
 	<-time.After(enforcementPeriod)
 }
+
+func startSecretService(ctx context.Context, kpr secrets.Keeper) *secretservice.Server {
+	opts := []secretservice.Option{
+		secretservice.WithDefaultLocation(secretServiceLocation),
+		secretservice.WithLocations(secretServiceLocations...),
+	}
+
+	if secretServiceUnlocked {
+		opts = append(opts, secretservice.WithAlwaysUnlocked())
+	} else {
+		opts = append(opts, secretservice.WithUnlockPassword(lookupUnlockPassword(ctx)))
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		s.Logger.Panicf("failed to connect to the D-Bus session bus: %v", err)
+	}
+
+	ss := secretservice.NewServer(kpr, opts...)
+	err = ss.Serve(ctx, conn)
+	if err != nil {
+		s.Logger.Panicf("failed to start the Secret Service: %v", err)
+	}
+
+	s.Logger.Printf("providing the Secret Service with the default collection at %q", secretServiceLocation)
+	return ss
+}
+
+func lookupUnlockPassword(ctx context.Context) string {
+	uri, err := config.ParseSecretURI(secretServiceUnlockSecret)
+	if err != nil {
+		s.Logger.Panicf("bad --secret-service-unlock-secret: %v", err)
+	}
+
+	kpr, err := keeper.Build(ctx, uri.Keeper)
+	if err != nil {
+		s.Logger.Panicf("failed to configure keeper %q: %v", uri.Keeper, err)
+	}
+
+	sec, err := uri.FindOne(ctx, kpr)
+	if err != nil {
+		s.Logger.Panicf("failed to find the Secret Service unlock password: %v", err)
+	}
+
+	password := uri.Value(sec)
+	if password == "" {
+		s.Logger.Panic("the Secret Service unlock password is empty")
+	}
+
+	return password
+}
+
+func startSSHAgent(ctx context.Context, c *config.Config, kpr secrets.Keeper) net.Listener {
+	reloadInterval := c.SSHAgent.ReloadInterval
+	if reloadInterval == 0 {
+		reloadInterval = time.Minute
+	}
+
+	opts := []sshagent.Option{
+		sshagent.WithLifetime(uint32(c.SSHAgent.Lifetime / time.Second)),
+		sshagent.WithConfirm(c.SSHAgent.Confirm),
+		sshagent.WithReloadInterval(reloadInterval),
+		sshagent.WithLogger(s.Logger),
+	}
+
+	rules := make([]sshagent.Rule, 0, len(c.SSHAgent.Keys))
+	for i, kc := range c.SSHAgent.Keys {
+		r := sshagent.Rule{
+			Lifetime: uint32(kc.Lifetime / time.Second),
+			Confirm:  kc.Confirm,
+		}
+
+		if len(kc.Match) > 0 {
+			q, err := secrets.ParseQuery(kc.Match...)
+			if err != nil {
+				s.Logger.Panicf("bad match for SSH agent key rule #%d: %v", i+1, err)
+			}
+			r.Query = q
+		}
+
+		if kc.Passphrase != nil {
+			passphrase, err := keeper.ResolveSecretRef(ctx, kc.Passphrase)
+			if err != nil {
+				s.Logger.Panicf("failed to look up the passphrase for SSH agent key rule #%d: %v", i+1, err)
+			}
+
+			var isString bool
+			r.Passphrase, isString = passphrase.(string)
+			if !isString {
+				s.Logger.Panicf("the passphrase for SSH agent key rule #%d is not a string", i+1)
+			}
+		}
+
+		rules = append(rules, r)
+	}
+
+	if len(rules) > 0 {
+		opts = append(opts, sshagent.WithRules(rules...))
+	}
+
+	a := sshagent.New(kpr, opts...)
+	n, err := a.Load(ctx)
+	if err != nil {
+		s.Logger.Printf("failed to load some SSH keys: %v", err)
+	}
+
+	if ss, err := keeper.CheckServer(); err == nil {
+		s.Logger.Panicf("server already running with pid %d", ss.Pid)
+	}
+
+	// the service is not running, so any socket left here is stale
+	sockName := sshagent.SocketName()
+	_ = os.Remove(sockName)
+
+	l, err := net.Listen("unix", sockName)
+	if err != nil {
+		s.Logger.Panicf("failed to listen on unix socket %q: %v", sockName, err)
+	}
+
+	err = os.Chmod(sockName, 0o600)
+	if err != nil {
+		s.Logger.Panicf("failed to restrict access to unix socket %q: %v", sockName, err)
+	}
+
+	go func() {
+		err := a.Serve(l)
+		if err != nil {
+			s.Logger.Printf("SSH agent quit with error: %v", err)
+		}
+	}()
+
+	s.Logger.Printf("providing an SSH agent holding %d keys at %q", n, sockName)
+	return l
+}
+
+// findCaches returns every cache keeper used by the keeper.
+func findCaches(kpr secrets.Keeper) []*cache.Cache {
+	var caches []*cache.Cache
+	_ = secrets.Walk(kpr, func(k secrets.Keeper) error {
+		if c, isCache := k.(*cache.Cache); isCache {
+			caches = append(caches, c)
+		}
+		return nil
+	})
+	return caches
+}
+
+// startSnapshotSavers saves the snapshots of the caches in the background, so
+// that lookups are not held up while the snapshots are written.
+func startSnapshotSavers(ctx context.Context, caches []*cache.Cache) {
+	for _, c := range caches {
+		c.StartSnapshotSaver(ctx, snapshotSaveDelay)
+	}
+}
+
+// saveSnapshots saves any changes left to the snapshots of the caches.
+func saveSnapshots(caches []*cache.Cache) {
+	for i, c := range caches {
+		if err := c.SaveSnapshot(); err != nil {
+			s.Logger.Printf("failed to save the snapshot of cache #%d: %v", i+1, err)
+		}
+	}
+}
+
+func startCacheWarmUp(ctx context.Context, caches []*cache.Cache) {
+	if len(caches) == 0 {
+		s.Logger.Printf("no cache keepers found to warm up")
+		return
+	}
+
+	for i, c := range caches {
+		go warmCacheLoop(ctx, i, c)
+	}
+}
+
+// warmCacheLoop warms up the cache in the background, so the service can start
+// answering right away, and then again every interval, if one is set.
+func warmCacheLoop(
+	ctx context.Context,
+	i int,
+	c *cache.Cache,
+) {
+	warmCacheOnce(ctx, i, c)
+	if warmCacheInterval == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(warmCacheInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			warmCacheOnce(ctx, i, c)
+		}
+	}
+}
+
+func warmCacheOnce(
+	ctx context.Context,
+	i int,
+	c *cache.Cache,
+) {
+	n, err := c.WarmUp(ctx, warmCacheConcurrency)
+	if err != nil {
+		s.Logger.Printf("failed to warm up cache #%d after loading %d secrets: %v", i+1, n, err)
+		return
+	}
+
+	s.Logger.Printf("loaded %d secrets into cache #%d", n, i+1)
+}
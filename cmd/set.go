@@ -14,13 +14,15 @@ import (
 
 var (
 	setCmd = &cobra.Command{
-		Use:   "set",
+		Use:   "set [uri]",
 		Short: "Set a secret",
+		Args:  cobra.MaximumNArgs(1),
 		Run:   RunSet,
 	}
 
 	username, password     string
 	prompt                 bool
+	generatePassword       bool
 	location               string
 	typ                    string
 	moveSecret, copySecret bool
@@ -35,15 +37,22 @@ func init() {
 	setCmd.Flags().StringVar(&username, "username", "", "The new username to set")
 	setCmd.Flags().StringVar(&password, "password", "", "The new password to set")
 	setCmd.Flags().BoolVar(&prompt, "prompt", false, "Prompt for the password")
+	setCmd.Flags().BoolVar(&generatePassword, "generate", false, "Generate a random password")
 	setCmd.Flags().StringVar(&typ, "type", "", "The new type of secret to set")
 	setCmd.Flags().StringVar(&location, "location", "", "The location to give the secret")
 	setCmd.Flags().BoolVar(&moveSecret, "move", false, "Move the secret to a new location")
 	setCmd.Flags().BoolVar(&copySecret, "copy", false, "Copy the secret to a new location")
 	setCmd.Flags().StringVar(&url, "url", "", "The new URL to set")
 	setCmd.Flags().StringToStringVar(&setFlds, "field", map[string]string{}, "The new fields to set")
+	addRecipeFlags(setCmd.Flags(), false)
 }
 
-func RunSet(cmd *cobra.Command, _ []string) {
+func RunSet(cmd *cobra.Command, args []string) {
+	uri := parseURIArg(args)
+	if uri != nil && uri.Field != "" {
+		s.Logger.Panic("Cannot specify a field in the secret URI when setting a secret. Use --field instead.")
+	}
+
 	if name != "" && id != "" {
 		s.Logger.Panic("Cannot specify both --id and --name.")
 	}
@@ -74,6 +83,14 @@ func RunSet(cmd *cobra.Command, _ []string) {
 		s.Logger.Panic("Cannot specify both --password and --prompt.")
 	}
 
+	if generatePassword && (password != "" || prompt) {
+		s.Logger.Panic("Cannot specify --generate with --password or --prompt.")
+	}
+
+	if !generatePassword && recipeFlagsChanged(cmd.Flags()) {
+		s.Logger.Panic("The recipe options may only be used with --generate.")
+	}
+
 	c := config.Instance()
 	if keeperName == "" {
 		keeperName = c.MasterKeeper
@@ -94,7 +111,12 @@ func RunSet(cmd *cobra.Command, _ []string) {
 	}
 
 	var secs []secrets.Secret
-	if id != "" {
+	if uri != nil {
+		secs, err = uri.Find(ctx, kpr)
+		if err != nil {
+			s.Logger.Panic(err)
+		}
+	} else if id != "" {
 		var sec secrets.Secret
 		sec, err = kpr.GetSecret(ctx, id)
 		if err != nil && !errors.Is(err, secrets.ErrNotFound) {
@@ -115,7 +137,14 @@ func RunSet(cmd *cobra.Command, _ []string) {
 		if moveSecret || copySecret {
 			s.Logger.Panicf("The secret is new. No %s allowed.", opVerb)
 		}
-		sec = secrets.NewSecret(name, "", "", secrets.WithLocation(location))
+		newLocation, newUsername := location, ""
+		if uri != nil {
+			newUsername = uri.Username
+			if newLocation == "" {
+				newLocation = uri.Location
+			}
+		}
+		sec = secrets.NewSecret(name, newUsername, "", secrets.WithLocation(newLocation))
 	case 1:
 		sec = secs[0]
 		if location != "" && location != sec.Location() && !moveSecret && !copySecret {
@@ -141,6 +170,14 @@ func RunSet(cmd *cobra.Command, _ []string) {
 	if typ != "" {
 		sec = secrets.SetType(sec, typ)
 	}
+	if generatePassword {
+		genLocation := sec.Location()
+		if moveSecret || copySecret {
+			genLocation = location
+		}
+
+		sec = secrets.SetPassword(sec, generateSecret(cmd.Flags(), genLocation, sec.Type()))
+	}
 	if url != "" {
 		u, err := neturl.Parse(url)
 		if err != nil {
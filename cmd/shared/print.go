@@ -2,6 +2,7 @@ package shared
 
 import (
 	"strings"
+	"time"
 
 	"github.com/zostay/go-std/set"
 	"github.com/zostay/go-std/slices"
@@ -13,6 +14,10 @@ func PrintSecret(sec secrets.Secret, showSecret bool, flds ...string) {
 	fldSet := set.New[string](slices.Map(flds, strings.ToLower)...)
 
 	Printer.Printf("%s:", sec.Name())
+	if since, isStale := secrets.IsStale(sec); isStale {
+		Printer.Printf("  Stale: from offline snapshot taken %v (%v ago)",
+			since.Format(time.RFC3339), time.Since(since).Round(time.Second))
+	}
 	if (fldSet.Len() == 0 || fldSet.Contains("id")) && sec.ID() != "" {
 		Printer.Printf("  ID: %s", sec.ID())
 	}
@@ -48,4 +53,9 @@ func PrintSecret(sec secrets.Secret, showSecret bool, flds ...string) {
 			Printer.Printf("    %s: %s", k, v)
 		}
 	}
+	if fldSet.Len() == 0 || fldSet.Contains("attachments") {
+		if names := secrets.Attachments(sec); len(names) > 0 {
+			Printer.Printf("  Attachments: %s", strings.Join(names, ", "))
+		}
+	}
 }
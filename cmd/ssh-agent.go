@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/sshagent"
+)
+
+var (
+	sshAgentCmd = &cobra.Command{
+		Use:   "ssh-agent",
+		Short: "Print the shell commands for using the SSH agent of the ghost service",
+		Long: `Print the shell commands that point SSH at the agent provided by the ghost
+service when started with --ssh-agent, like so:
+
+    eval "$(ghost ssh-agent)"
+
+The agent holds the keys kept in secrets of type ssh-key. The ssh_agent section
+of the configuration chooses which keys are loaded and how:
+
+    ssh_agent:
+      lifetime: 8h
+      keys:
+      - match: [location=SSH, name~GitHub*]
+        confirm: true
+        passphrase:
+          __SECRET__: ghost://myKeepass/SSH/GitHub%20Passphrase#password
+      - match: [location=SSH]
+
+Each rule gives the query conditions a secret must meet and the first rule
+matching a secret applies to it. Every secret of type ssh-key is loaded if
+there are no rules. The private key is read from the password of the secret or
+else from its attachments.`,
+		Args: cobra.NoArgs,
+		Run:  RunSSHAgent,
+	}
+
+	sshAgentCsh bool
+)
+
+func init() {
+	sshAgentCmd.Flags().BoolVar(&sshAgentCsh, "csh", false, "Print commands for csh rather than the Bourne shell")
+}
+
+func RunSSHAgent(_ *cobra.Command, _ []string) {
+	if _, err := keeper.CheckServer(); err != nil {
+		s.Logger.Panicf("The ghost service is not running: %v", err)
+	}
+
+	sockName := sshagent.SocketName()
+	if _, err := os.Stat(sockName); err != nil {
+		s.Logger.Panic("The ghost service is not providing an SSH agent. Start it with --ssh-agent.")
+	}
+
+	if sshAgentCsh {
+		s.Printer.Printf("setenv SSH_AUTH_SOCK %s;", sockName)
+		return
+	}
+
+	s.Printer.Printf("SSH_AUTH_SOCK=%s; export SSH_AUTH_SOCK;", sockName)
+}
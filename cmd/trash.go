@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/zostay/ghost/cmd/trash"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted secrets kept in the trash",
+}
+
+func init() {
+	trashCmd.AddCommand(
+		trash.EmptyCmd,
+		trash.ListCmd,
+		trash.RestoreCmd,
+	)
+}
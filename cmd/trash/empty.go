@@ -0,0 +1,33 @@
+package trash
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+)
+
+var EmptyCmd = &cobra.Command{
+	Use:   "empty",
+	Short: "Permanently remove the secrets in the trash",
+	Args:  cobra.NoArgs,
+	Run:   RunEmpty,
+}
+
+func init() {
+	EmptyCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+}
+
+func RunEmpty(cmd *cobra.Command, _ []string) {
+	ctx, t := buildTrashable(cmd)
+
+	secs, err := t.ListTrash(ctx)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	if err := t.EmptyTrash(ctx); err != nil {
+		s.Logger.Panic(err)
+	}
+
+	s.Logger.Printf("Removed %d secret(s) from the trash.", len(secs))
+}
@@ -0,0 +1,38 @@
+package trash
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+)
+
+var (
+	ListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the secrets in the trash",
+		Args:  cobra.NoArgs,
+		Run:   RunList,
+	}
+
+	flds         []string
+	showPassword bool
+)
+
+func init() {
+	ListCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	ListCmd.Flags().StringSliceVar(&flds, "fields", []string{}, "The fields to display")
+	ListCmd.Flags().BoolVar(&showPassword, "show-password", false, "Show the password in the output")
+}
+
+func RunList(cmd *cobra.Command, _ []string) {
+	ctx, t := buildTrashable(cmd)
+
+	secs, err := t.ListTrash(ctx)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	for _, sec := range secs {
+		s.PrintSecret(sec, showPassword, flds...)
+	}
+}
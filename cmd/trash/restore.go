@@ -0,0 +1,67 @@
+package trash
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+)
+
+var (
+	RestoreCmd = &cobra.Command{
+		Use:   "restore [id...]",
+		Short: "Restore secrets from the trash to where they were deleted from",
+		Run:   RunRestore,
+	}
+
+	name string
+	one  bool
+)
+
+func init() {
+	RestoreCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	RestoreCmd.Flags().StringVar(&name, "name", "", "The name of the secret to restore")
+	RestoreCmd.Flags().BoolVarP(&one, "one", "1", false, "If multiple secrets with the name are found, restore the first found")
+}
+
+func RunRestore(cmd *cobra.Command, args []string) {
+	if name != "" && len(args) > 0 {
+		s.Logger.Panic("Cannot specify both IDs and --name.")
+	}
+
+	if name == "" && len(args) == 0 {
+		s.Logger.Panic("Must specify either the IDs or --name of the secrets to restore.")
+	}
+
+	ctx, t := buildTrashable(cmd)
+
+	ids := args
+	if name != "" {
+		secs, err := t.ListTrash(ctx)
+		if err != nil {
+			s.Logger.Panic(err)
+		}
+
+		for _, sec := range secs {
+			if sec.Name() == name {
+				ids = append(ids, sec.ID())
+			}
+		}
+
+		switch {
+		case len(ids) == 0:
+			s.Logger.Panic("No secret found in the trash.")
+		case len(ids) > 1 && !one:
+			s.Logger.Panicf("Found %d secrets in the trash. Use --one to restore the first.", len(ids))
+		}
+		ids = ids[:1]
+	}
+
+	for _, id := range ids {
+		sec, err := t.RestoreFromTrash(ctx, id)
+		if err != nil {
+			s.Logger.Panicf("Unable to restore %q: %v", id, err)
+		}
+
+		s.Logger.Printf("Restored %s to %q.", sec.Name(), sec.Location())
+	}
+}
@@ -0,0 +1,44 @@
+package trash
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var keeperName string
+
+// buildTrashable builds the keeper named by --keeper, or the master keeper,
+// and returns it if it has a trash.
+func buildTrashable(cmd *cobra.Command) (context.Context, secrets.Trashable) {
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	t, isTrashable := kpr.(secrets.Trashable)
+	if !isTrashable {
+		s.Logger.Panicf("The keeper named %q has no trash.", keeperName)
+	}
+
+	return ctx, t
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	s "github.com/zostay/ghost/cmd/shared"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var (
+	uriCmd = &cobra.Command{
+		Use:   "uri",
+		Short: "Print the URI of a secret",
+		Args:  cobra.NoArgs,
+		Run:   RunURI,
+	}
+
+	uriField string
+)
+
+func init() {
+	uriCmd.Flags().StringVar(&keeperName, "keeper", "", "The name of the secret keeper to use")
+	uriCmd.Flags().StringVar(&id, "id", "", "The ID of the secret to find")
+	uriCmd.Flags().StringVar(&name, "name", "", "The name of the secret to find")
+	uriCmd.Flags().StringVar(&uriField, "field", "", "The field to name in the URI")
+}
+
+// parseURIArg parses the secret URI given as the only argument, if any. The
+// keeper and name are taken from the URI, so they must not also be given by
+// flag.
+func parseURIArg(args []string) *config.SecretURI {
+	if len(args) == 0 {
+		return nil
+	}
+
+	if keeperName != "" || id != "" || name != "" {
+		s.Logger.Panic("Cannot specify --keeper, --id, or --name with a secret URI.")
+	}
+
+	uri, err := config.ParseSecretURI(args[0])
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	keeperName = uri.Keeper
+	name = uri.Name
+	return uri
+}
+
+func RunURI(cmd *cobra.Command, _ []string) {
+	if name != "" && id != "" {
+		s.Logger.Panic("Cannot specify both --id and --name.")
+	}
+
+	if name == "" && id == "" {
+		s.Logger.Panic("Must specify either --id or --name.")
+	}
+
+	c := config.Instance()
+	if keeperName == "" {
+		keeperName = c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		s.Logger.Panic("No keeper specified.")
+	}
+
+	if _, hasConfig := c.Keepers[keeperName]; !hasConfig {
+		s.Logger.Panicf("No keeper named %q.", keeperName)
+	}
+
+	ctx := keeper.WithBuilder(cmd.Context(), c)
+	kpr, err := keeper.Build(ctx, keeperName)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	var secs []secrets.Secret
+	if id != "" {
+		sec, err := kpr.GetSecret(ctx, id)
+		if err != nil {
+			s.Logger.Panic(err)
+		}
+
+		secs = append(secs, sec)
+		name = sec.Name()
+	}
+
+	// other secrets with the same name decide whether a username is needed
+	named, err := kpr.GetSecretsByName(ctx, name)
+	if err != nil {
+		s.Logger.Panic(err)
+	}
+
+	if id == "" {
+		secs = named
+	}
+
+	if len(secs) == 0 {
+		s.Logger.Panicf("No secret named %q.", name)
+	}
+
+	for _, sec := range secs {
+		uri := config.SecretURIFor(keeperName, sec, named...)
+		uri.Field = uriField
+		s.Printer.Print(uri.String())
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"github.com/zostay/ghost/cmd"
+	_ "github.com/zostay/ghost/pkg/secrets/alias"
 	_ "github.com/zostay/ghost/pkg/secrets/cache"
 	_ "github.com/zostay/ghost/pkg/secrets/http"
 	_ "github.com/zostay/ghost/pkg/secrets/human"
@@ -10,8 +11,10 @@ import (
 	_ "github.com/zostay/ghost/pkg/secrets/lastpass"
 	_ "github.com/zostay/ghost/pkg/secrets/low"
 	_ "github.com/zostay/ghost/pkg/secrets/memory"
+	_ "github.com/zostay/ghost/pkg/secrets/mirror"
 	_ "github.com/zostay/ghost/pkg/secrets/onepassword"
 	_ "github.com/zostay/ghost/pkg/secrets/policy"
+	_ "github.com/zostay/ghost/pkg/secrets/redact"
 	_ "github.com/zostay/ghost/pkg/secrets/router"
 	_ "github.com/zostay/ghost/pkg/secrets/seq"
 )
@@ -0,0 +1,203 @@
+// Package clipboard places secrets on the system clipboard and removes them
+// again after a while.
+package clipboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long a copied secret is left on the clipboard by
+// default.
+const DefaultTimeout = 45 * time.Second
+
+var (
+	// ErrNoClipboard is returned by Detect when no clipboard helper is
+	// available.
+	ErrNoClipboard = errors.New("no clipboard helper found")
+
+	// ErrUnreadable is returned by Paste when the clipboard can be written, but
+	// not read.
+	ErrUnreadable = errors.New("clipboard cannot be read")
+)
+
+// Clipboard is a system clipboard that can be written and, usually, read.
+type Clipboard interface {
+	// Name returns a name for the clipboard, for use in messages.
+	Name() string
+
+	// Copy places the value on the clipboard.
+	Copy(ctx context.Context, value string) error
+
+	// Paste returns the value on the clipboard. It returns ErrUnreadable if
+	// the clipboard cannot be read.
+	Paste(ctx context.Context) (string, error)
+}
+
+// Command is a Clipboard that runs a helper program to copy and another to
+// paste, such as wl-copy and wl-paste or xclip.
+type Command struct {
+	name     string
+	copyCmd  []string
+	pasteCmd []string
+}
+
+var _ Clipboard = &Command{}
+
+// NewCommand returns a Clipboard that copies by running the copy command with
+// the value on standard input and pastes by reading the standard output of the
+// paste command.
+func NewCommand(name string, copyCmd, pasteCmd []string) *Command {
+	return &Command{name, copyCmd, pasteCmd}
+}
+
+// Name returns the name of the clipboard helper.
+func (c *Command) Name() string {
+	return c.name
+}
+
+// Copy runs the copy command with the value on standard input.
+func (c *Command) Copy(ctx context.Context, value string) error {
+	cmd := exec.CommandContext(ctx, c.copyCmd[0], c.copyCmd[1:]...) //nolint:gosec // the helpers are fixed
+	cmd.Stdin = strings.NewReader(value)
+
+	// output is left unconnected since some helpers leave a process behind to
+	// hold the selection, which would keep a pipe open
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed to copy: %w", c.name, err)
+	}
+	return nil
+}
+
+// Paste returns the standard output of the paste command.
+func (c *Command) Paste(ctx context.Context) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.pasteCmd[0], c.pasteCmd[1:]...) //nolint:gosec // the helpers are fixed
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed to paste: %w", c.name, err)
+	}
+	return out.String(), nil
+}
+
+// OSC52 is a Clipboard that asks the terminal to set the clipboard with the
+// OSC 52 escape sequence. This works in many terminals, even over SSH, but the
+// clipboard cannot be read back.
+type OSC52 struct {
+	out io.Writer
+}
+
+var _ Clipboard = &OSC52{}
+
+// NewOSC52 returns a Clipboard that writes OSC 52 escape sequences to the given
+// terminal.
+func NewOSC52(out io.Writer) *OSC52 {
+	return &OSC52{out}
+}
+
+// Name returns "osc52".
+func (o *OSC52) Name() string {
+	return "osc52"
+}
+
+// Copy writes the escape sequence to set the clipboard to the value.
+func (o *OSC52) Copy(_ context.Context, value string) error {
+	_, err := fmt.Fprintf(o.out, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(value)))
+	return err
+}
+
+// Paste always returns ErrUnreadable.
+func (o *OSC52) Paste(context.Context) (string, error) {
+	return "", ErrUnreadable
+}
+
+// helpers are the clipboard helpers tried by Detect, in order. The env names
+// an environment variable that must be set for the helper to be useful.
+var helpers = []struct {
+	env   string
+	goos  string
+	copy  []string
+	paste []string
+}{
+	{"WAYLAND_DISPLAY", "", []string{"wl-copy"}, []string{"wl-paste", "--no-newline"}},
+	{"DISPLAY", "", []string{"xclip", "-selection", "clipboard"}, []string{"xclip", "-selection", "clipboard", "-o"}},
+	{"DISPLAY", "", []string{"xsel", "--clipboard", "--input"}, []string{"xsel", "--clipboard", "--output"}},
+	{"", "darwin", []string{"pbcopy"}, []string{"pbpaste"}},
+}
+
+// Detect returns a Clipboard for the first helper program found that works in
+// this environment: wl-copy under Wayland, xclip or xsel under X11, or pbcopy
+// on macOS. It returns ErrNoClipboard if there is none.
+func Detect() (Clipboard, error) {
+	for _, h := range helpers {
+		if h.env != "" && os.Getenv(h.env) == "" {
+			continue
+		}
+
+		if h.goos != "" && h.goos != runtime.GOOS {
+			continue
+		}
+
+		if _, err := exec.LookPath(h.copy[0]); err != nil {
+			continue
+		}
+
+		if _, err := exec.LookPath(h.paste[0]); err != nil {
+			continue
+		}
+
+		return NewCommand(h.copy[0], h.copy, h.paste), nil
+	}
+
+	return nil, ErrNoClipboard
+}
+
+// DetectOrTerminal returns the Clipboard found by Detect or, if there is none,
+// an OSC52 clipboard writing to the given terminal.
+func DetectOrTerminal(term io.Writer) Clipboard {
+	if cb, err := Detect(); err == nil {
+		return cb
+	}
+	return NewOSC52(term)
+}
+
+// Clear empties the clipboard, provided it still holds the value. If the
+// clipboard cannot be read, it is emptied regardless. It returns true if the
+// clipboard was emptied.
+func Clear(ctx context.Context, cb Clipboard, value string) (bool, error) {
+	current, err := cb.Paste(ctx)
+	if err != nil && !errors.Is(err, ErrUnreadable) {
+		return false, err
+	}
+
+	if err == nil && current != value {
+		return false, nil
+	}
+
+	if err := cb.Copy(ctx, ""); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// ClearAfter waits for the timeout and then calls Clear. It returns early
+// without clearing if the context is canceled first.
+func ClearAfter(ctx context.Context, cb Clipboard, value string, timeout time.Duration) (bool, error) {
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-time.After(timeout):
+	}
+
+	return Clear(ctx, cb, value)
+}
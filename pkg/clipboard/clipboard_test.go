@@ -0,0 +1,121 @@
+package clipboard_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/clipboard"
+)
+
+// newFileClipboard returns a clipboard helper that keeps the clipboard in a
+// file.
+func newFileClipboard(t *testing.T) clipboard.Clipboard {
+	t.Helper()
+
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("requires /bin/sh")
+	}
+
+	file := filepath.Join(t.TempDir(), "clipboard")
+	require.NoError(t, os.WriteFile(file, []byte{}, 0o600))
+
+	return clipboard.NewCommand("file",
+		[]string{"/bin/sh", "-c", "cat > " + file},
+		[]string{"/bin/sh", "-c", "cat " + file})
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cb := newFileClipboard(t)
+	assert.Equal(t, "file", cb.Name())
+
+	require.NoError(t, cb.Copy(ctx, "secret"))
+
+	got, err := cb.Paste(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got)
+}
+
+func TestClear(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cb := newFileClipboard(t)
+
+	require.NoError(t, cb.Copy(ctx, "secret"))
+
+	cleared, err := clipboard.Clear(ctx, cb, "secret")
+	require.NoError(t, err)
+	assert.True(t, cleared)
+
+	got, err := cb.Paste(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", got)
+
+	// something else copied since is left alone
+	require.NoError(t, cb.Copy(ctx, "other"))
+
+	cleared, err = clipboard.Clear(ctx, cb, "secret")
+	require.NoError(t, err)
+	assert.False(t, cleared)
+
+	got, err = cb.Paste(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "other", got)
+}
+
+func TestClearAfter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cb := newFileClipboard(t)
+
+	require.NoError(t, cb.Copy(ctx, "secret"))
+
+	cleared, err := clipboard.ClearAfter(ctx, cb, "secret", 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.True(t, cleared)
+
+	require.NoError(t, cb.Copy(ctx, "secret"))
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	cleared, err = clipboard.ClearAfter(canceled, cb, "secret", time.Hour)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, cleared)
+
+	got, err := cb.Paste(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got)
+}
+
+func TestOSC52(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	buf := &bytes.Buffer{}
+	cb := clipboard.NewOSC52(buf)
+
+	require.NoError(t, cb.Copy(ctx, "secret"))
+	assert.Equal(t, "\x1b]52;c;c2VjcmV0\a", buf.String())
+
+	_, err := cb.Paste(ctx)
+	assert.ErrorIs(t, err, clipboard.ErrUnreadable)
+
+	// an unreadable clipboard is cleared regardless
+	buf.Reset()
+	cleared, err := clipboard.Clear(ctx, cb, "secret")
+	require.NoError(t, err)
+	assert.True(t, cleared)
+	assert.Equal(t, "\x1b]52;c;\a", buf.String())
+}
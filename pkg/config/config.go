@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +21,84 @@ type KeeperConfig map[string]any
 type Config struct {
 	MasterKeeper string                  `yaml:"master"`
 	Keepers      map[string]KeeperConfig `yaml:"keepers"`
+	Docker       DockerConfig            `yaml:"docker,omitempty"`
+	SSHAgent     SSHAgentConfig          `yaml:"ssh_agent,omitempty"`
+	Recipes      map[string]RecipeConfig `yaml:"recipes,omitempty"`
+	Generate     GenerateConfig          `yaml:"generate,omitempty"`
+}
+
+// DockerConfig configures where the docker credential helper keeps registry
+// credentials. Docker runs the helper without options, so they are set here.
+type DockerConfig struct {
+	Keeper   string `yaml:"keeper,omitempty"`
+	Location string `yaml:"location,omitempty"`
+}
+
+// SSHAgentConfig configures the keys the SSH agent of the ghost service loads
+// from the keeper of the service.
+type SSHAgentConfig struct {
+	// Lifetime is how long keys are kept once loaded, unless a rule says
+	// otherwise. Keys are kept until removed if it is not set.
+	Lifetime time.Duration `yaml:"lifetime,omitempty"`
+	// Confirm requires that every use of every key be confirmed.
+	Confirm bool `yaml:"confirm,omitempty"`
+	// ReloadInterval is how often the keys may be reloaded from the keeper
+	// when a client lists them. It is one minute if not set. The keys are
+	// never reloaded if it is negative.
+	ReloadInterval time.Duration `yaml:"reload_interval,omitempty"`
+	// Keys are the rules choosing which secrets of type ssh-key are loaded.
+	// Every one is loaded if there are none.
+	Keys []SSHKeyConfig `yaml:"keys,omitempty"`
+}
+
+// SSHKeyConfig is a rule choosing secrets to load into the SSH agent. The first
+// rule matching a secret applies to it.
+type SSHKeyConfig struct {
+	// Match are the query conditions a secret must meet, such as
+	// location=SSH or name~github*.
+	Match []string `yaml:"match,omitempty"`
+	// Lifetime is how long the keys are kept once loaded.
+	Lifetime time.Duration `yaml:"lifetime,omitempty"`
+	// Confirm requires that every use of the keys be confirmed.
+	Confirm bool `yaml:"confirm,omitempty"`
+	// Passphrase decrypts the keys. It is usually a secret reference.
+	Passphrase any `yaml:"passphrase,omitempty"`
+}
+
+// RecipeConfig is a named recipe for generating secrets. Anything not set is
+// left as the default of the generator.
+type RecipeConfig struct {
+	LowercaseWeight           *float32 `yaml:"lowercase_weight,omitempty"`
+	UppercaseWeight           *float32 `yaml:"uppercase_weight,omitempty"`
+	DigitWeight               *float32 `yaml:"digit_weight,omitempty"`
+	SymbolWeight              *float32 `yaml:"symbol_weight,omitempty"`
+	Symbols                   string   `yaml:"symbols,omitempty"`
+	Length                    int      `yaml:"length,omitempty"`
+	CorrectHorseBatteryStaple bool     `yaml:"correct_horse_battery_staple,omitempty"`
+	Dictionary                string   `yaml:"dictionary,omitempty"`
+}
+
+// GenerateConfig chooses the recipe used to generate a secret when none is
+// named. A recipe for the location of the secret is preferred to one for its
+// type, which is preferred to the default.
+type GenerateConfig struct {
+	Default   string            `yaml:"default,omitempty"`
+	Locations map[string]string `yaml:"locations,omitempty"`
+	Types     map[string]string `yaml:"types,omitempty"`
+}
+
+// RecipeName returns the name of the recipe for generating a secret at the
+// location with the type. It returns an empty string if there is none.
+func (g GenerateConfig) RecipeName(location, typ string) string {
+	if name := g.Locations[location]; location != "" && name != "" {
+		return name
+	}
+
+	if name := g.Types[typ]; typ != "" && name != "" {
+		return name
+	}
+
+	return g.Default
 }
 
 // configPath locates the configuration file.
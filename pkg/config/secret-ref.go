@@ -4,6 +4,10 @@ package config
 // keeper config.
 const SecretRefKey = "__SECRET__"
 
+// SecretPlaceholder is the value given in place of a secret reference when
+// configuration is validated without looking up secrets.
+const SecretPlaceholder = "<secret-placeholder>"
+
 // SecretRef is a reference to a secret in another keeper.
 type SecretRef struct {
 	KeeperName string `mapstructure:"keeper"`
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// URIScheme is the URL scheme used by secret URIs.
+const URIScheme = "ghost"
+
+// SecretURI is the canonical address of a secret, written as:
+//
+//	ghost://<keeper>/<location>/<name>?username=<username>#<field>
+//
+// Only the name is required. An empty keeper means the default keeper, or the
+// same keeper when the URI is stored inside a secret. The location may contain
+// slashes, since the last path segment is always the name. A slash inside the
+// name or a location segment must be escaped as %2F. The username helps pick
+// between secrets of the same name. An empty field means the password.
+type SecretURI struct {
+	Keeper   string
+	Location string
+	Name     string
+	Username string
+	Field    string
+}
+
+// IsSecretURI returns true if the value looks like a secret URI.
+func IsSecretURI(value string) bool {
+	return strings.HasPrefix(value, URIScheme+"://")
+}
+
+// ParseSecretURI parses a secret URI.
+func ParseSecretURI(value string) (*SecretURI, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed secret URI %q: %w", value, err)
+	}
+
+	if u.Scheme != URIScheme {
+		return nil, fmt.Errorf("malformed secret URI %q: scheme is not %q", value, URIScheme)
+	}
+
+	segs := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/")
+	for i, seg := range segs {
+		segs[i], err = url.PathUnescape(seg)
+		if err != nil {
+			return nil, fmt.Errorf("malformed secret URI %q: %w", value, err)
+		}
+	}
+
+	uri := &SecretURI{
+		Keeper:   u.Host,
+		Location: strings.Join(segs[:len(segs)-1], "/"),
+		Name:     segs[len(segs)-1],
+		Username: u.Query().Get("username"),
+		Field:    u.Fragment,
+	}
+
+	if uri.Name == "" {
+		return nil, fmt.Errorf("malformed secret URI %q: secret name is empty", value)
+	}
+
+	return uri, nil
+}
+
+// String returns the secret URI in its canonical form.
+func (u *SecretURI) String() string {
+	segs := []string{u.Name}
+	if u.Location != "" {
+		segs = append(strings.Split(u.Location, "/"), u.Name)
+	}
+
+	escSegs := make([]string, len(segs))
+	for i, seg := range segs {
+		escSegs[i] = url.PathEscape(seg)
+	}
+
+	su := url.URL{
+		Scheme:   URIScheme,
+		Host:     u.Keeper,
+		Path:     "/" + strings.Join(segs, "/"),
+		RawPath:  "/" + strings.Join(escSegs, "/"),
+		Fragment: u.Field,
+	}
+
+	if u.Username != "" {
+		su.RawQuery = url.Values{"username": {u.Username}}.Encode()
+	}
+
+	return su.String()
+}
+
+// Matches returns true if the secret has the name of the URI and, if set, its
+// location and username.
+func (u *SecretURI) Matches(sec secrets.Secret) bool {
+	return sec.Name() == u.Name &&
+		(u.Location == "" || sec.Location() == u.Location) &&
+		(u.Username == "" || sec.Username() == u.Username)
+}
+
+// URIFinder is implemented by keepers able to find the secrets matching a
+// secret URI themselves, such as the client of the secret keeper service.
+type URIFinder interface {
+	// GetSecretsByURI returns the secrets matching the secret URI.
+	GetSecretsByURI(ctx context.Context, uri string) ([]secrets.Secret, error)
+}
+
+// Find returns the secrets in the keeper that match the URI. If only a name is
+// given and no secret has that name, the name is tried as a secret ID. If the
+// keeper is a URIFinder, the keeper finds the secrets instead, given the URI
+// without its keeper, as the keeper has already been chosen.
+func (u *SecretURI) Find(ctx context.Context, kpr secrets.Keeper) ([]secrets.Secret, error) {
+	if finder, isFinder := kpr.(URIFinder); isFinder {
+		inKeeper := *u
+		inKeeper.Keeper = ""
+		return finder.GetSecretsByURI(ctx, inKeeper.String())
+	}
+
+	secs, err := kpr.GetSecretsByName(ctx, u.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]secrets.Secret, 0, len(secs))
+	for _, sec := range secs {
+		if u.Matches(sec) {
+			found = append(found, sec)
+		}
+	}
+
+	if len(found) > 0 || u.Location != "" || u.Username != "" {
+		return found, nil
+	}
+
+	sec, err := kpr.GetSecret(ctx, u.Name)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return found, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []secrets.Secret{sec}, nil
+}
+
+// FindOne returns the one secret in the keeper that matches the URI. It
+// returns secrets.ErrNotFound if there is none and an error if there are more
+// than one.
+func (u *SecretURI) FindOne(ctx context.Context, kpr secrets.Keeper) (secrets.Secret, error) {
+	secs, err := u.Find(ctx, kpr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(secs) {
+	case 0:
+		return nil, secrets.ErrNotFound
+	case 1:
+		return secs[0], nil
+	default:
+		return nil, fmt.Errorf("secret URI %s matches %d secrets", u, len(secs))
+	}
+}
+
+// Value returns the value of the field of the secret named by the URI. This is
+// the password if the field is empty. The standard fields, such as username,
+// url, or id, are returned by name. Any other name is looked up in the fields
+// of the secret.
+func (u *SecretURI) Value(sec secrets.Secret) string {
+	switch u.Field {
+	case "", "password":
+		return sec.Password()
+	case "id":
+		return sec.ID()
+	case "name":
+		return sec.Name()
+	case "username":
+		return sec.Username()
+	case "type":
+		return sec.Type()
+	case "url":
+		return secrets.UrlString(sec)
+	case "location":
+		return sec.Location()
+	default:
+		return sec.GetField(u.Field)
+	}
+}
+
+// SecretURIFor returns the URI of the secret in the named keeper. The username
+// is included only if it is needed to tell the secret apart from others with
+// the same name and location, which are given in others.
+func SecretURIFor(keeperName string, sec secrets.Secret, others ...secrets.Secret) *SecretURI {
+	uri := &SecretURI{
+		Keeper:   keeperName,
+		Location: sec.Location(),
+		Name:     sec.Name(),
+	}
+
+	for _, other := range others {
+		if other.ID() != sec.ID() && uri.Matches(other) {
+			uri.Username = sec.Username()
+			break
+		}
+	}
+
+	return uri
+}
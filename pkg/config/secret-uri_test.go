@@ -0,0 +1,242 @@
+package config_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+)
+
+func TestParseSecretURI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value string
+		uri   *config.SecretURI
+		err   string
+	}{
+		{
+			value: "ghost:///db",
+			uri:   &config.SecretURI{Name: "db"},
+		},
+		{
+			value: "ghost://work/Team/Prod/db?username=me#api-key",
+			uri: &config.SecretURI{
+				Keeper:   "work",
+				Location: "Team/Prod",
+				Name:     "db",
+				Username: "me",
+				Field:    "api-key",
+			},
+		},
+		{
+			value: "ghost://work/Team%2FProd/My%20db%2Fprimary?username=me%40example.com#api%20key",
+			uri: &config.SecretURI{
+				Keeper:   "work",
+				Location: "Team/Prod",
+				Name:     "My db/primary",
+				Username: "me@example.com",
+				Field:    "api key",
+			},
+		},
+		{value: "https://work/db", err: `scheme is not "ghost"`},
+		{value: "ghost://work/", err: "secret name is empty"},
+		{value: "ghost://work/Team/", err: "secret name is empty"},
+		{value: "ghost://work/Team/a%zzb", err: "malformed secret URI"},
+	}
+
+	for _, tc := range tests {
+		uri, err := config.ParseSecretURI(tc.value)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, tc.value)
+			continue
+		}
+
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.uri, uri, tc.value)
+	}
+}
+
+func TestSecretURI_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		uri   config.SecretURI
+		value string
+	}{
+		{
+			uri:   config.SecretURI{Name: "db"},
+			value: "ghost:///db",
+		},
+		{
+			uri: config.SecretURI{
+				Keeper:   "work",
+				Location: "Team/Prod",
+				Name:     "db",
+				Username: "me",
+				Field:    "api-key",
+			},
+			value: "ghost://work/Team/Prod/db?username=me#api-key",
+		},
+		{
+			uri: config.SecretURI{
+				Keeper:   "work",
+				Location: "Team",
+				Name:     "My db/primary",
+				Username: "me@example.com",
+				Field:    "api key",
+			},
+			value: "ghost://work/Team/My%20db%2Fprimary?username=me%40example.com#api%20key",
+		},
+		{
+			uri:   config.SecretURI{Name: "100% db?#"},
+			value: "ghost:///100%25%20db%3F%23",
+		},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.value, tc.uri.String())
+
+		// every URI survives the round trip
+		uri, err := config.ParseSecretURI(tc.uri.String())
+		require.NoError(t, err, tc.value)
+		assert.Equal(t, tc.uri, *uri, tc.value)
+	}
+}
+
+func TestSecretURI_Matches(t *testing.T) {
+	t.Parallel()
+
+	sec := secrets.NewSecret("db", "me", "secret",
+		secrets.WithLocation("Team/Prod"))
+
+	tests := []struct {
+		uri     config.SecretURI
+		matches bool
+	}{
+		{config.SecretURI{Name: "db"}, true},
+		{config.SecretURI{Name: "db", Location: "Team/Prod"}, true},
+		{config.SecretURI{Name: "db", Location: "Team/Prod", Username: "me"}, true},
+		{config.SecretURI{Name: "db", Username: "me", Field: "api-key"}, true},
+		{config.SecretURI{Name: "web"}, false},
+		{config.SecretURI{Name: "db", Location: "Team"}, false},
+		{config.SecretURI{Name: "db", Username: "you"}, false},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.matches, tc.uri.Matches(sec), tc.uri.String())
+	}
+}
+
+func TestSecretURIFor(t *testing.T) {
+	t.Parallel()
+
+	mine := secrets.NewSecret("db", "me", "mine",
+		secrets.WithID("1"), secrets.WithLocation("Team"))
+	yours := secrets.NewSecret("db", "you", "yours",
+		secrets.WithID("2"), secrets.WithLocation("Team"))
+	elsewhere := secrets.NewSecret("db", "you", "elsewhere",
+		secrets.WithID("3"), secrets.WithLocation("Home"))
+
+	assert.Equal(t, "ghost://work/Team/db",
+		config.SecretURIFor("work", mine).String())
+	assert.Equal(t, "ghost://work/Team/db",
+		config.SecretURIFor("work", mine, mine, elsewhere).String())
+	assert.Equal(t, "ghost://work/Team/db?username=me",
+		config.SecretURIFor("work", mine, mine, yours, elsewhere).String())
+	assert.Equal(t, "ghost:///Home/db",
+		config.SecretURIFor("", elsewhere, mine, yours, elsewhere).String())
+}
+
+func TestSecretURI_Find(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	var ids []string
+	for _, sec := range []secrets.Secret{
+		secrets.NewSecret("db", "me", "mine", secrets.WithLocation("Team")),
+		secrets.NewSecret("db", "you", "yours", secrets.WithLocation("Team")),
+		secrets.NewSecret("db", "me", "home", secrets.WithLocation("Home")),
+	} {
+		sec, err := kpr.SetSecret(ctx, sec)
+		require.NoError(t, err)
+		ids = append(ids, sec.ID())
+	}
+
+	find := func(value string) []string {
+		uri, err := config.ParseSecretURI(value)
+		require.NoError(t, err)
+
+		secs, err := uri.Find(ctx, kpr)
+		require.NoError(t, err)
+
+		passwords := make([]string, len(secs))
+		for i, sec := range secs {
+			passwords[i] = sec.Password()
+		}
+		return passwords
+	}
+
+	assert.ElementsMatch(t, []string{"mine", "yours", "home"}, find("ghost:///db"))
+	assert.ElementsMatch(t, []string{"mine", "yours"}, find("ghost:///Team/db"))
+	assert.ElementsMatch(t, []string{"mine", "home"}, find("ghost:///db?username=me"))
+	assert.Equal(t, []string{"mine"}, find("ghost:///Team/db?username=me"))
+	assert.Empty(t, find("ghost:///Work/db"))
+
+	// a name matching no secret is tried as an ID
+	assert.Equal(t, []string{"home"}, find("ghost:///"+ids[2]))
+	assert.Empty(t, find("ghost:///Home/"+ids[2]))
+
+	uri, err := config.ParseSecretURI("ghost:///Team/db")
+	require.NoError(t, err)
+
+	_, err = uri.FindOne(ctx, kpr)
+	assert.ErrorContains(t, err, "matches 2 secrets")
+
+	uri.Username = "me"
+	sec, err := uri.FindOne(ctx, kpr)
+	require.NoError(t, err)
+	assert.Equal(t, "mine", sec.Password())
+
+	uri.Username = "them"
+	_, err = uri.FindOne(ctx, kpr)
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+// uriFinder is a keeper that finds secrets by URI itself.
+type uriFinder struct {
+	secrets.Keeper
+	uris []string
+}
+
+func (f *uriFinder) GetSecretsByURI(_ context.Context, uri string) ([]secrets.Secret, error) {
+	f.uris = append(f.uris, uri)
+	return []secrets.Secret{secrets.NewSecret("db", "me", "found")}, nil
+}
+
+func TestSecretURI_FindByURI(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	finder := &uriFinder{Keeper: kpr}
+	uri, err := config.ParseSecretURI("ghost://service/Team/db?username=me#api-key")
+	require.NoError(t, err)
+
+	sec, err := uri.FindOne(ctx, finder)
+	require.NoError(t, err)
+	assert.Equal(t, "found", sec.Password())
+
+	// the keeper has already been chosen, so it is left out
+	assert.Equal(t, []string{"ghost:///Team/db?username=me#api-key"}, finder.uris)
+}
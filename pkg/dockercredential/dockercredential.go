@@ -0,0 +1,245 @@
+// Package dockercredential keeps the registry credentials of docker and other
+// OCI tools in a secret keeper, speaking the protocol of the
+// docker-credential-helpers project.
+//
+// The tool runs the helper with an action of store, get, erase, or list.
+// Credentials are passed as JSON objects with ServerURL, Username, and Secret
+// keys. The get and erase actions are given only the server URL on standard
+// input. Errors are reported by writing the message to standard output and
+// exiting with a non-zero status.
+package dockercredential
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// ErrNotFound is returned when there are no credentials for the server. Its
+// message is the one docker looks for to tell a missing credential from other
+// errors.
+var ErrNotFound = errors.New("credentials not found in native keychain")
+
+// ErrUnknownAction is returned by Run for an action it does not know.
+var ErrUnknownAction = errors.New("unknown credential helper action")
+
+// Credentials are the credentials for a registry.
+type Credentials struct {
+	ServerURL string `json:"ServerURL"`
+	Username  string `json:"Username"`
+	Secret    string `json:"Secret"`
+}
+
+// Helper keeps credentials in a location of a secret keeper. Each credential
+// is a secret with the URL of the registry server.
+type Helper struct {
+	kpr      secrets.Keeper
+	location string
+}
+
+// NewHelper returns a helper keeping credentials at the location of the
+// keeper.
+func NewHelper(kpr secrets.Keeper, location string) *Helper {
+	return &Helper{kpr, location}
+}
+
+// ServerURL parses the server URL given by docker, which may be a bare host
+// name, as a URL. A bare host name is taken to use https.
+func ServerURL(serverURL string) (*url.URL, error) {
+	serverURL = strings.TrimSpace(serverURL)
+	if serverURL == "" {
+		return nil, errors.New("no server URL given")
+	}
+
+	if !strings.Contains(serverURL, "://") {
+		serverURL = "https://" + serverURL
+	}
+
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("malformed server URL %q: %w", serverURL, err)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("server URL %q has no host", serverURL)
+	}
+
+	return u, nil
+}
+
+// sameServer returns true if both URLs are for the same server. The hosts and
+// ports must be the same, as must the paths, ignoring slashes at either end.
+// The schemes are not compared, as docker may give the same registry with or
+// without one.
+func sameServer(a, b *url.URL) bool {
+	return strings.EqualFold(a.Hostname(), b.Hostname()) &&
+		a.Port() == b.Port() &&
+		strings.Trim(a.Path, "/") == strings.Trim(b.Path, "/")
+}
+
+// find returns the secrets at the location of the helper for the server.
+func (h *Helper) find(ctx context.Context, serverURL string) ([]secrets.Secret, error) {
+	want, err := ServerURL(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []secrets.Secret
+	err = secrets.ForEachInLocation(ctx, h.kpr, h.location, func(sec secrets.Secret) error {
+		if u := sec.Url(); u != nil && sameServer(u, want) {
+			found = append(found, sec)
+		}
+		return nil
+	})
+
+	return found, err
+}
+
+// Store saves the credentials, replacing any already kept for the server.
+func (h *Helper) Store(ctx context.Context, creds *Credentials) error {
+	u, err := ServerURL(creds.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	found, err := h.find(ctx, creds.ServerURL)
+	if err != nil {
+		return err
+	}
+
+	if len(found) > 0 {
+		sec := secrets.NewSingleFromSecret(found[0])
+		sec.SetUsername(creds.Username)
+		sec.SetPassword(creds.Secret)
+		_, err := h.kpr.SetSecret(ctx, sec)
+		return err
+	}
+
+	sec := secrets.NewSecret(creds.ServerURL, creds.Username, creds.Secret,
+		secrets.WithUrl(u),
+		secrets.WithLocation(h.location))
+	_, err = h.kpr.SetSecret(ctx, sec)
+	return err
+}
+
+// Get returns the credentials for the server. It returns ErrNotFound if there
+// are none.
+func (h *Helper) Get(ctx context.Context, serverURL string) (*Credentials, error) {
+	found, err := h.find(ctx, serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(found) == 0 {
+		return nil, ErrNotFound
+	}
+
+	best := found[0]
+	for _, sec := range found[1:] {
+		if sec.LastModified().After(best.LastModified()) {
+			best = sec
+		}
+	}
+
+	return &Credentials{
+		ServerURL: serverURL,
+		Username:  best.Username(),
+		Secret:    best.Password(),
+	}, nil
+}
+
+// Erase deletes the credentials for the server. It returns ErrNotFound if
+// there are none.
+func (h *Helper) Erase(ctx context.Context, serverURL string) error {
+	found, err := h.find(ctx, serverURL)
+	if err != nil {
+		return err
+	}
+
+	if len(found) == 0 {
+		return ErrNotFound
+	}
+
+	for _, sec := range found {
+		if err := h.kpr.DeleteSecret(ctx, sec.ID()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// List returns the username for each server with credentials.
+func (h *Helper) List(ctx context.Context) (map[string]string, error) {
+	list := map[string]string{}
+	err := secrets.ForEachInLocation(ctx, h.kpr, h.location, func(sec secrets.Secret) error {
+		if u := sec.Url(); u != nil && u.Host != "" {
+			list[u.String()] = sec.Username()
+		}
+		return nil
+	})
+
+	return list, err
+}
+
+// Run performs the action, reading its input from in and writing its output
+// to out as the credential helper protocol requires.
+func (h *Helper) Run(ctx context.Context, action string, in io.Reader, out io.Writer) error {
+	switch action {
+	case "store":
+		creds := &Credentials{}
+		if err := json.NewDecoder(in).Decode(creds); err != nil {
+			return fmt.Errorf("malformed credentials: %w", err)
+		}
+		return h.Store(ctx, creds)
+	case "get":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+
+		creds, err := h.Get(ctx, serverURL)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(out).Encode(creds)
+	case "erase":
+		serverURL, err := readServerURL(in)
+		if err != nil {
+			return err
+		}
+
+		return h.Erase(ctx, serverURL)
+	case "list":
+		list, err := h.List(ctx)
+		if err != nil {
+			return err
+		}
+
+		return json.NewEncoder(out).Encode(list)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownAction, action)
+	}
+}
+
+// readServerURL reads the server URL given on input to get and erase.
+func readServerURL(in io.Reader) (string, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return "", err
+	}
+
+	serverURL := strings.TrimSpace(string(data))
+	if serverURL == "" {
+		return "", errors.New("no server URL given")
+	}
+
+	return serverURL, nil
+}
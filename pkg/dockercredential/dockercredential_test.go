@@ -0,0 +1,118 @@
+package dockercredential_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/dockercredential"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+)
+
+// run runs the action of the helper with the input and returns the output.
+func run(t *testing.T, h *dockercredential.Helper, action, in string) (string, error) {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	err := h.Run(context.Background(), action, strings.NewReader(in), out)
+	return out.String(), err
+}
+
+func TestServerURL(t *testing.T) {
+	t.Parallel()
+
+	u, err := dockercredential.ServerURL("registry.example.com:5000")
+	require.NoError(t, err)
+	assert.Equal(t, "https://registry.example.com:5000", u.String())
+
+	u, err = dockercredential.ServerURL("https://index.docker.io/v1/")
+	require.NoError(t, err)
+	assert.Equal(t, "index.docker.io", u.Host)
+
+	_, err = dockercredential.ServerURL("")
+	assert.Error(t, err)
+}
+
+func TestHelper(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	// a secret for the same host outside the location is not used
+	other, err := kpr.SetSecret(ctx, secrets.NewSecret("Registry Login", "bob", "hunter2",
+		secrets.WithLocation("Work"),
+		secrets.WithUrl(mustURL(t, "https://registry.example.com"))))
+	require.NoError(t, err)
+
+	h := dockercredential.NewHelper(kpr, "Docker")
+
+	_, err = run(t, h, "get", "registry.example.com\n")
+	assert.ErrorIs(t, err, dockercredential.ErrNotFound)
+
+	_, err = run(t, h, "store",
+		`{"ServerURL":"registry.example.com","Username":"alice","Secret":"s3cret"}`)
+	require.NoError(t, err)
+
+	_, err = run(t, h, "store",
+		`{"ServerURL":"https://index.docker.io/v1/","Username":"carol","Secret":"d0cker"}`)
+	require.NoError(t, err)
+
+	out, err := run(t, h, "get", "https://registry.example.com")
+	require.NoError(t, err)
+	var creds dockercredential.Credentials
+	require.NoError(t, json.Unmarshal([]byte(out), &creds))
+	assert.Equal(t, dockercredential.Credentials{
+		ServerURL: "https://registry.example.com",
+		Username:  "alice",
+		Secret:    "s3cret",
+	}, creds)
+
+	_, err = run(t, h, "store",
+		`{"ServerURL":"registry.example.com","Username":"alice","Secret":"n3w"}`)
+	require.NoError(t, err)
+
+	secs, err := kpr.GetSecretsByName(ctx, "registry.example.com")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, "Docker", secs[0].Location())
+	assert.Equal(t, "n3w", secs[0].Password())
+
+	out, err = run(t, h, "list", "")
+	require.NoError(t, err)
+	var list map[string]string
+	require.NoError(t, json.Unmarshal([]byte(out), &list))
+	assert.Equal(t, map[string]string{
+		"https://registry.example.com": "alice",
+		"https://index.docker.io/v1/":  "carol",
+	}, list)
+
+	_, err = run(t, h, "erase", "registry.example.com")
+	require.NoError(t, err)
+
+	_, err = run(t, h, "erase", "registry.example.com")
+	assert.ErrorIs(t, err, dockercredential.ErrNotFound)
+
+	_, err = kpr.GetSecret(ctx, other.ID())
+	assert.NoError(t, err)
+
+	_, err = run(t, h, "frobnicate", "")
+	assert.ErrorIs(t, err, dockercredential.ErrUnknownAction)
+}
+
+// mustURL parses the URL.
+func mustURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u
+}
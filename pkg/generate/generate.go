@@ -0,0 +1,266 @@
+// Package generate generates random secrets, either passwords drawn from a
+// weighted mix of lowercase letters, uppercase letters, digits, and symbols or
+// passphrases of dictionary words in the manner of XKCD's "correct horse
+// battery staple". A Recipe describes what to generate.
+package generate
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"regexp"
+
+	"github.com/zostay/go-std/generic"
+	"github.com/zostay/go-std/slices"
+)
+
+// DefaultSymbols are the symbol characters used when a recipe names none.
+const DefaultSymbols = "~`!@#$%^&*()-_+=[{]}\\|;:'\",<.>/?"
+
+// DefaultDictionary is the dictionary file used when a recipe names none.
+const DefaultDictionary = "/usr/share/dict/words"
+
+// Recipe describes the secret to generate.
+type Recipe struct {
+	// LowercaseWeight is the share of lowercase letters in a password.
+	LowercaseWeight float32
+	// UppercaseWeight is the share of uppercase letters in a password.
+	UppercaseWeight float32
+	// DigitWeight is the share of digits in a password.
+	DigitWeight float32
+	// SymbolWeight is the share of symbols in a password.
+	SymbolWeight float32
+	// Symbols are the symbol characters to choose from. DefaultSymbols are
+	// used if empty.
+	Symbols string
+	// Length is the length of a password or the least length of a passphrase.
+	Length int
+	// CorrectHorseBatteryStaple generates a passphrase of dictionary words
+	// instead of a password.
+	CorrectHorseBatteryStaple bool
+	// Dictionary is the file of words for passphrases, one per line.
+	// DefaultDictionary is used if empty.
+	Dictionary string
+}
+
+// DefaultRecipe returns the recipe for a password of 20 characters, mostly
+// letters, with a few digits and symbols.
+func DefaultRecipe() Recipe {
+	return Recipe{
+		LowercaseWeight: 0.4,
+		UppercaseWeight: 0.3,
+		DigitWeight:     0.2,
+		SymbolWeight:    0.1,
+		Symbols:         DefaultSymbols,
+		Length:          20,
+		Dictionary:      DefaultDictionary,
+	}
+}
+
+var (
+	lcChars    = slices.FromRange[byte]('a', 'z', 1)
+	ucChars    = slices.FromRange[byte]('A', 'Z', 1)
+	digitChars = slices.FromRange[byte]('0', '9', 1)
+)
+
+// Generate generates a secret following the recipe.
+func (r Recipe) Generate() (string, error) {
+	if r.Length <= 0 {
+		return "", errors.New("length must be greater than zero")
+	}
+
+	if r.CorrectHorseBatteryStaple {
+		return r.passphrase()
+	}
+
+	return r.password()
+}
+
+// password generates a password of random characters mixed by the weights of
+// the recipe.
+func (r Recipe) password() (string, error) {
+	lc, uc, digits, symbols := r.LowercaseWeight, r.UppercaseWeight, r.DigitWeight, r.SymbolWeight
+	if lc < 0 || uc < 0 || digits < 0 || symbols < 0 {
+		return "", errors.New("weights must not be negative")
+	}
+
+	totes := lc + uc + digits + symbols
+	if totes == 0 {
+		return "", errors.New("at least one weight must be greater than zero")
+	}
+
+	symbolChars := []byte(r.Symbols)
+	if len(symbolChars) == 0 {
+		symbolChars = []byte(DefaultSymbols)
+	}
+
+	lc /= totes
+	uc /= totes
+	digits /= totes
+	symbols /= totes
+
+	counts := []int{
+		selectChars(lc, r.Length),
+		selectChars(uc, r.Length),
+		selectChars(digits, r.Length),
+		selectChars(symbols, r.Length),
+	}
+
+	sum := func() int { return counts[0] + counts[1] + counts[2] + counts[3] }
+
+	// take away from whichever kind is most used, symbols first on a tie
+	for sum() > r.Length {
+		most := len(counts) - 1
+		for i := most - 1; i >= 0; i-- {
+			if counts[i] > counts[most] {
+				most = i
+			}
+		}
+		counts[most]--
+	}
+
+	// make up the difference with whichever chosen kind is least used
+	for sum() < r.Length {
+		least := -1
+		for i, count := range counts {
+			if count > 0 && (least < 0 || count < counts[least]) {
+				least = i
+			}
+		}
+		counts[least]++
+	}
+
+	pw, err := sample(lcChars, counts[0])
+	if err != nil {
+		return "", err
+	}
+
+	for i, chars := range [][]byte{ucChars, digitChars, symbolChars} {
+		more, err := sample(chars, counts[i+1])
+		if err != nil {
+			return "", err
+		}
+		pw = append(pw, more...)
+	}
+
+	if err := shuffle(pw); err != nil {
+		return "", err
+	}
+
+	return string(pw), nil
+}
+
+func selectChars(weight float32, length int) int {
+	if weight > 0 {
+		return int(generic.Max(1.0, weight*float32(length)))
+	}
+	return 0
+}
+
+var plainWord = regexp.MustCompile(`^\w+$`)
+
+// passphrase generates a passphrase of words from the dictionary of the
+// recipe, mostly short words, at least as long as the length of the recipe.
+func (r Recipe) passphrase() (string, error) {
+	dictionary := r.Dictionary
+	if dictionary == "" {
+		dictionary = DefaultDictionary
+	}
+
+	dr, err := os.Open(dictionary)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = dr.Close() }()
+
+	longWords := make([]string, 0, 1000)
+	shortWords := make([]string, 0, 1000)
+	dscanner := bufio.NewScanner(dr)
+	for dscanner.Scan() {
+		word := dscanner.Text()
+		if plainWord.MatchString(word) {
+			if len(word) > 6 {
+				longWords = append(longWords, word)
+			} else {
+				shortWords = append(shortWords, word)
+			}
+		}
+	}
+
+	if err := dscanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(longWords) == 0 && len(shortWords) == 0 {
+		return "", fmt.Errorf("dictionary %q has no words", dictionary)
+	}
+
+	pw := ""
+	for len(pw) < r.Length {
+		if len(pw) > 0 {
+			pw += " "
+		}
+
+		n, err := randomInt(100)
+		if err != nil {
+			return "", err
+		}
+
+		words := shortWords
+		if (n < 5 && len(longWords) > 0) || len(shortWords) == 0 {
+			words = longWords
+		}
+
+		word, err := pick(words)
+		if err != nil {
+			return "", err
+		}
+
+		pw += word
+	}
+
+	return pw, nil
+}
+
+func sample[T any](from []T, count int) ([]T, error) {
+	out := make([]T, 0, count)
+	for len(out) < count {
+		p, err := pick(from)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func pick[T any](from []T) (T, error) {
+	p, err := randomInt(len(from))
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return from[p], nil
+}
+
+func randomInt(mx int) (int, error) {
+	p, err := rand.Int(rand.Reader, big.NewInt(int64(mx)))
+	if err != nil {
+		return 0, err
+	}
+	return int(p.Int64()), nil
+}
+
+func shuffle[T any](in []T) error {
+	for i := range in {
+		j, err := randomInt(len(in))
+		if err != nil {
+			return err
+		}
+		in[i], in[j] = in[j], in[i]
+	}
+	return nil
+}
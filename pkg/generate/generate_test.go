@@ -0,0 +1,110 @@
+package generate_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/generate"
+)
+
+// countKinds counts the lowercase letters, uppercase letters, digits, and
+// other characters of the password.
+func countKinds(pw string) (lc, uc, digits, symbols int) {
+	for _, c := range pw {
+		switch {
+		case unicode.IsLower(c):
+			lc++
+		case unicode.IsUpper(c):
+			uc++
+		case unicode.IsDigit(c):
+			digits++
+		default:
+			symbols++
+		}
+	}
+	return
+}
+
+func TestRecipe_Generate(t *testing.T) {
+	t.Parallel()
+
+	pw, err := generate.DefaultRecipe().Generate()
+	require.NoError(t, err)
+	assert.Len(t, pw, 20)
+	lc, uc, digits, symbols := countKinds(pw)
+	assert.Equal(t, []int{8, 6, 4, 2}, []int{lc, uc, digits, symbols})
+
+	r := generate.DefaultRecipe()
+	r.SymbolWeight = 0
+	r.Length = 16
+	pw, err = r.Generate()
+	require.NoError(t, err)
+	assert.Len(t, pw, 16)
+	lc, uc, digits, symbols = countKinds(pw)
+	assert.Zero(t, symbols)
+	assert.Equal(t, 16, lc+uc+digits)
+
+	r = generate.DefaultRecipe()
+	r.Length = 2
+	pw, err = r.Generate()
+	require.NoError(t, err)
+	assert.Len(t, pw, 2)
+
+	r = generate.DefaultRecipe()
+	r.Symbols = "#"
+	r.SymbolWeight = 1
+	r.LowercaseWeight = 0
+	r.UppercaseWeight = 0
+	r.DigitWeight = 0
+	pw, err = r.Generate()
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("#", 20), pw)
+}
+
+func TestRecipe_Generate_Errors(t *testing.T) {
+	t.Parallel()
+
+	r := generate.DefaultRecipe()
+	r.Length = 0
+	_, err := r.Generate()
+	assert.Error(t, err)
+
+	r = generate.Recipe{Length: 10}
+	_, err = r.Generate()
+	assert.Error(t, err)
+
+	r = generate.DefaultRecipe()
+	r.DigitWeight = -1
+	_, err = r.Generate()
+	assert.Error(t, err)
+
+	r = generate.DefaultRecipe()
+	r.CorrectHorseBatteryStaple = true
+	r.Dictionary = filepath.Join(t.TempDir(), "missing")
+	_, err = r.Generate()
+	assert.Error(t, err)
+}
+
+func TestRecipe_Generate_CorrectHorseBatteryStaple(t *testing.T) {
+	t.Parallel()
+
+	dict := filepath.Join(t.TempDir(), "words")
+	require.NoError(t, os.WriteFile(dict, []byte("correct\nhorse\nbattery\nstaple\nit's\n"), 0o600))
+
+	r := generate.DefaultRecipe()
+	r.CorrectHorseBatteryStaple = true
+	r.Dictionary = dict
+	r.Length = 25
+	pw, err := r.Generate()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(pw), 25)
+	for _, word := range strings.Split(pw, " ") {
+		assert.Contains(t, []string{"correct", "horse", "battery", "staple"}, word)
+	}
+}
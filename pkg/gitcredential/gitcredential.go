@@ -0,0 +1,181 @@
+// Package gitcredential reads and writes the credentials exchanged by git with
+// a credential helper and matches them to secrets.
+//
+// Git describes a credential as lines of key=value pairs ended by a blank line
+// or the end of input. Only the protocol, host, path, username, password, and
+// url keys are used here. Any other key is ignored.
+package gitcredential
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// ErrBadValue is returned by Write when a value cannot be written because it
+// holds a newline or NUL.
+var ErrBadValue = errors.New("credential value holds a newline or NUL")
+
+// Credential is a credential as described by git.
+type Credential struct {
+	Protocol string
+	Host     string
+	Path     string
+	Username string
+	Password string
+}
+
+// Read reads a credential from git.
+func Read(r io.Reader) (*Credential, error) {
+	c := &Credential{}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			break
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		if !hasValue {
+			return nil, fmt.Errorf("malformed credential line %q", line)
+		}
+
+		switch key {
+		case "protocol":
+			c.Protocol = value
+		case "host":
+			c.Host = value
+		case "path":
+			c.Path = value
+		case "username":
+			c.Username = value
+		case "password":
+			c.Password = value
+		case "url":
+			if err := c.setURL(value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// setURL sets the parts of the credential given in the URL.
+func (c *Credential) setURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("malformed credential url %q: %w", value, err)
+	}
+
+	c.Protocol = u.Scheme
+	c.Host = u.Host
+	c.Path = strings.TrimPrefix(u.Path, "/")
+	if u.User != nil {
+		c.Username = u.User.Username()
+		if pw, hasPassword := u.User.Password(); hasPassword {
+			c.Password = pw
+		}
+	}
+
+	return nil
+}
+
+// Write writes the non-empty parts of the credential for git.
+func (c *Credential) Write(w io.Writer) error {
+	for _, kv := range [][2]string{
+		{"protocol", c.Protocol},
+		{"host", c.Host},
+		{"path", c.Path},
+		{"username", c.Username},
+		{"password", c.Password},
+	} {
+		if kv[1] == "" {
+			continue
+		}
+
+		if strings.ContainsAny(kv[1], "\n\x00") {
+			return fmt.Errorf("%w: %s", ErrBadValue, kv[0])
+		}
+
+		if _, err := fmt.Fprintf(w, "%s=%s\n", kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// URL returns the URL of the credential, without the username or password.
+func (c *Credential) URL() *url.URL {
+	u := &url.URL{
+		Scheme: c.Protocol,
+		Host:   c.Host,
+	}
+
+	if c.Path != "" {
+		u.Path = "/" + c.Path
+	}
+
+	return u
+}
+
+// Matches returns true if the secret is for this credential. The host and
+// port of the URL of the secret must be the same as the credential. The
+// protocol, path, and username must be the same if both the credential and
+// the secret have them.
+func (c *Credential) Matches(sec secrets.Secret) bool {
+	u := sec.Url()
+	if u == nil || c.Host == "" {
+		return false
+	}
+
+	want := c.URL()
+	if !strings.EqualFold(u.Hostname(), want.Hostname()) || u.Port() != want.Port() {
+		return false
+	}
+
+	if c.Protocol != "" && u.Scheme != "" && !strings.EqualFold(u.Scheme, c.Protocol) {
+		return false
+	}
+
+	path := strings.Trim(u.Path, "/")
+	if c.Path != "" && path != "" && path != strings.Trim(c.Path, "/") {
+		return false
+	}
+
+	return c.Username == "" || sec.Username() == c.Username
+}
+
+// Best returns the secret that matches the credential most closely, preferring
+// those with the same path and then those most recently modified. It returns
+// nil if none match.
+func (c *Credential) Best(secs []secrets.Secret) secrets.Secret {
+	var best secrets.Secret
+	bestPath := false
+	for _, sec := range secs {
+		if !c.Matches(sec) {
+			continue
+		}
+
+		samePath := c.Path != "" && strings.Trim(sec.Url().Path, "/") == strings.Trim(c.Path, "/")
+		switch {
+		case best == nil,
+			samePath && !bestPath,
+			samePath == bestPath && sec.LastModified().After(best.LastModified()):
+			best, bestPath = sec, samePath
+		}
+	}
+
+	return best
+}
@@ -111,6 +111,25 @@ func Exists(ctx context.Context, name string) bool {
 	return builder.c.Keepers[name] != nil
 }
 
+// ResolveSecretRef looks up the value of a secret reference found elsewhere in
+// the configuration than a keeper. A map holding a __SECRET__ key is replaced
+// by the value of the secret it refers to. Any other value is returned as is.
+func ResolveSecretRef(ctx context.Context, v any) (any, error) {
+	builder, isBuilder := ctx.Value(builderKey{}).(*builderContext)
+	if !isBuilder {
+		return nil, errors.New("unable to find the secret keeper factory in context")
+	}
+
+	switch m := v.(type) {
+	case config.KeeperConfig:
+		return builder.resolveSecretRefsInMap(m, true)
+	case map[string]any:
+		return builder.resolveSecretRefsInMap(m, true)
+	}
+
+	return v, nil
+}
+
 // DecodePartial works the same as Decode, but does not resolve secret
 // references.
 func DecodePartial(ctx context.Context, name string) (any, error) {
@@ -261,6 +280,47 @@ func (mb *builderContext) resolveSecretRefsInKeeperConfig(
 	return nil, errors.New("unable to resolve secret references in keeper config")
 }
 
+// resolveSecretURI looks up the value of a secret reference written as a
+// secret URI. If the URI names no keeper, the master keeper is used.
+func (mb *builderContext) resolveSecretURI(
+	value string,
+	lookup bool,
+) (any, error) {
+	uri, err := config.ParseSecretURI(value)
+	if err != nil {
+		return nil, err
+	}
+
+	keeperName := uri.Keeper
+	if keeperName == "" {
+		keeperName = mb.c.MasterKeeper
+	}
+
+	if keeperName == "" {
+		return nil, fmt.Errorf("malformed secret reference %s: keeper is empty and there is no master keeper", uri)
+	}
+
+	if mb.c.Keepers[keeperName] == nil {
+		return nil, fmt.Errorf("malformed secret reference %s: keeper %q does not exist", uri, keeperName)
+	}
+
+	if !lookup {
+		return config.SecretPlaceholder, nil
+	}
+
+	kpr, err := mb.Build(keeperName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform lookup with keeper %q: %w", keeperName, err)
+	}
+
+	sec, err := uri.FindOne(mb, kpr)
+	if err != nil {
+		return nil, fmt.Errorf("unable to perform secret lookup for %s: %w", uri, err)
+	}
+
+	return uri.Value(sec), nil
+}
+
 func (mb *builderContext) resolveSecretRefsInMap(
 	kc config.KeeperConfig,
 	lookup bool,
@@ -268,6 +328,10 @@ func (mb *builderContext) resolveSecretRefsInMap(
 	cp := make(config.KeeperConfig, len(kc))
 	for k, v := range kc {
 		if k == config.SecretRefKey {
+			if uri, isURI := v.(string); isURI {
+				return mb.resolveSecretURI(uri, lookup)
+			}
+
 			var ref config.SecretRef
 			err := mpDecode(v, &ref)
 			if err != nil {
@@ -291,7 +355,7 @@ func (mb *builderContext) resolveSecretRefsInMap(
 			}
 
 			if !lookup {
-				return "<secret-placeholder>", nil
+				return config.SecretPlaceholder, nil
 			}
 
 			kpr, err := mb.Build(ref.KeeperName)
@@ -1,8 +1,12 @@
 package keeper
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/ncruces/zenity"
 	"golang.org/x/term"
@@ -43,3 +47,40 @@ func getTermPassword(_, _, prompt, _ string) (string, error) {
 
 	return string(x), nil
 }
+
+// Confirm is a tool that makes it easier to display a dialog asking the user
+// to confirm an action. It returns true only if the user agrees.
+func Confirm(title, desc string) (bool, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		return getTermConfirm(title, desc)
+	}
+
+	return getGUIConfirm(title, desc)
+}
+
+func getGUIConfirm(title, desc string) (bool, error) {
+	err := zenity.Question(desc,
+		zenity.Title(title),
+		zenity.OKLabel("Allow"),
+		zenity.CancelLabel("Deny"),
+	)
+
+	if errors.Is(err, zenity.ErrCanceled) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func getTermConfirm(_, desc string) (bool, error) {
+	fmt.Print(desc + " [y/N] ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
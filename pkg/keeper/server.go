@@ -13,6 +13,7 @@ import (
 	"syscall"
 	"time"
 
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/zostay/ghost/pkg/config"
@@ -204,6 +205,23 @@ func CheckServer() (*ServiceStatus, error) {
 	return &ss, nil
 }
 
+// FlushServiceCache asks the running service to remove the identified secrets
+// from every cache it uses. If no IDs are given, the caches are emptied. It
+// returns the number of caches that were flushed.
+func FlushServiceCache(ctx context.Context, ids ...string) (int, error) {
+	client, err := http.BuildServiceClient()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrGRPCClient, err)
+	}
+
+	res, err := client.FlushCache(ctx, &http.FlushCacheRequest{Ids: ids})
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrServiceError, err)
+	}
+
+	return int(res.GetCaches()), nil
+}
+
 // RecoverService performs the work to clean up the system to make it possible to
 // restart after a crash.
 func RecoverService() error {
@@ -267,3 +285,43 @@ func RecoverService() error {
 	// in any way.
 	return err
 }
+
+// CopyToServiceClipboard asks the running service to place the value on the
+// clipboard and to clear it again after the timeout. Since the service outlives
+// the command, the clipboard is cleared even after the command exits. It
+// returns the name of the clipboard used.
+func CopyToServiceClipboard(ctx context.Context, value string, timeout time.Duration) (string, error) {
+	client, err := http.BuildServiceClient()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrGRPCClient, err)
+	}
+
+	res, err := client.CopyToClipboard(ctx, &http.CopyToClipboardRequest{
+		Value:   value,
+		Timeout: durationpb.New(timeout),
+	})
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrServiceError, err)
+	}
+
+	return res.GetClipboard(), nil
+}
+
+// ServiceKeeper returns a client for the keeper served by the running service
+// if the service is running and serves the named keeper, so that the keeper
+// can be used without unlocking it again. An empty name matches whichever
+// keeper the service serves. It returns false if the keeper must be built
+// instead.
+func ServiceKeeper(name string) (secrets.Keeper, bool) {
+	ss, err := CheckServer()
+	if err != nil || (name != "" && ss.Keeper != name) {
+		return nil, false
+	}
+
+	client, err := http.BuildServiceClient()
+	if err != nil {
+		return nil, false
+	}
+
+	return http.NewClient(client), true
+}
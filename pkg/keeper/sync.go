@@ -116,7 +116,7 @@ func (s *Sync) AddSecret(
 	}
 
 	sk := makeKey(sec)
-	if similar, similarExists := s.index[sk]; !similarExists {
+	if similar, similarExists := s.index[sk]; similarExists {
 		if o.ignoreDuplicates {
 			if sec.LastModified().After(similar.lastModified) {
 				return s.addToIndex(ctx, sec)
@@ -240,7 +240,7 @@ func (s *Sync) CopyTo(
 		}
 
 		if syncSec == nil {
-			syncSec = secrets.NewSecret("", "", "")
+			syncSec = secrets.NewSecret("", "", "", secrets.WithLocation(sk.location))
 		}
 
 		origSec, err := s.gatherer.GetSecret(ctx, lk.id)
@@ -279,6 +279,12 @@ func (s *Sync) CopyTo(
 			secrets.SetField(syncSec, fldName, fldVal)
 		}
 
+		for _, attName := range secrets.Attachments(origSec) {
+			if data, hasAttachment := secrets.GetAttachment(origSec, attName); hasAttachment {
+				syncSec = secrets.SetAttachment(syncSec, attName, data)
+			}
+		}
+
 		// select the secret to overwrite when overwriting
 		if o.overwriteMatching {
 			var best secrets.Secret
@@ -0,0 +1,103 @@
+package keeper_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+)
+
+// syncSecret returns a secret for syncing modified at the given hour.
+func syncSecret(name, location, password string, hour int) secrets.Secret {
+	return secrets.NewSecret(name, "user", password,
+		secrets.WithLocation(location),
+		secrets.WithLastModified(time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)))
+}
+
+func TestSync_AddSecret(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	s, err := keeper.NewSync()
+	require.NoError(t, err)
+
+	require.NoError(t, s.AddSecret(ctx, syncSecret("db", "Work", "work", 1)))
+	require.NoError(t, s.AddSecret(ctx, syncSecret("db", "Personal", "personal", 1)))
+	require.NoError(t, s.AddSecret(ctx, syncSecret("web", "Work", "web", 1)))
+
+	err = s.AddSecret(ctx, syncSecret("db", "Work", "again", 2))
+	assert.ErrorIs(t, err, keeper.ErrDuplicate)
+
+	// with duplicates ignored, the newest secret is kept
+	require.NoError(t, s.AddSecret(ctx, syncSecret("db", "Work", "newer", 3),
+		keeper.WithIgnoredDuplicates()))
+	require.NoError(t, s.AddSecret(ctx, syncSecret("db", "Work", "older", 0),
+		keeper.WithIgnoredDuplicates()))
+
+	to, err := memory.New()
+	require.NoError(t, err)
+	require.NoError(t, s.CopyTo(ctx, to))
+
+	secs, err := to.GetSecretsByName(ctx, "db")
+	require.NoError(t, err)
+
+	passwords := map[string]string{}
+	for _, sec := range secs {
+		passwords[sec.Location()] = sec.Password()
+	}
+	assert.Equal(t, map[string]string{
+		"Work":     "newer",
+		"Personal": "personal",
+	}, passwords)
+}
+
+func TestSync_CopyTo(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	from, err := memory.New()
+	require.NoError(t, err)
+
+	for _, sec := range []secrets.Secret{
+		syncSecret("db", "Work", "work", 1),
+		syncSecret("db", "Personal", "personal", 1),
+		syncSecret("web", "Work/Team", "web", 1),
+	} {
+		_, err := from.SetSecret(ctx, sec)
+		require.NoError(t, err)
+	}
+
+	s, err := keeper.NewSync()
+	require.NoError(t, err)
+	require.NoError(t, s.AddSecretKeeper(ctx, from))
+
+	to, err := memory.New()
+	require.NoError(t, err)
+	require.NoError(t, s.CopyTo(ctx, to))
+
+	locs, err := to.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Work", "Personal", "Work/Team"}, locs)
+
+	for _, loc := range locs {
+		fromIds, err := from.ListSecrets(ctx, loc)
+		require.NoError(t, err)
+
+		toIds, err := to.ListSecrets(ctx, loc)
+		require.NoError(t, err)
+		require.Len(t, toIds, len(fromIds), loc)
+
+		for _, id := range toIds {
+			sec, err := to.GetSecret(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, loc, sec.Location())
+			assert.Equal(t, "user", sec.Username())
+		}
+	}
+}
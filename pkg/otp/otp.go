@@ -0,0 +1,328 @@
+// Package otp computes one-time passwords from the seeds stored in secrets, as
+// described by RFC 4226 (HOTP) and RFC 6238 (TOTP).
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" //nolint:gosec // SHA1 is what the RFC requires
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Scheme is the URL scheme of a key URI, as used in the QR codes given to
+	// authenticator apps.
+	Scheme = "otpauth"
+
+	// DefaultDigits is the number of digits in a code when not configured.
+	DefaultDigits = 6
+
+	// DefaultPeriod is how long a TOTP code lasts when not configured.
+	DefaultPeriod = 30 * time.Second
+
+	// MaxDigits is the most digits a code may have.
+	MaxDigits = 10
+)
+
+// ErrNoSeed is returned when a secret has no OTP seed.
+var ErrNoSeed = errors.New("secret has no OTP seed")
+
+// Type is the kind of one-time password.
+type Type string
+
+const (
+	TOTP Type = "totp" // time-based (RFC 6238)
+	HOTP Type = "hotp" // counter-based (RFC 4226)
+)
+
+// Algorithm is the HMAC hash algorithm used to compute codes.
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+// ParseAlgorithm parses the name of an algorithm. Besides the names of the
+// constants, names like sha-256 and HMAC-SHA-256 are accepted.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	n := strings.ToUpper(name)
+	n = strings.TrimPrefix(n, "HMAC-")
+	n = strings.ReplaceAll(n, "-", "")
+
+	switch a := Algorithm(n); a {
+	case SHA1, SHA256, SHA512:
+		return a, nil
+	case "":
+		return SHA1, nil
+	default:
+		return "", fmt.Errorf("unknown OTP algorithm %q", name)
+	}
+}
+
+// hash returns the constructor of the hash of the algorithm.
+func (a Algorithm) hash() func() hash.Hash {
+	switch a {
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// Key holds the seed and settings needed to compute one-time passwords.
+type Key struct {
+	Type      Type
+	Seed      []byte
+	Algorithm Algorithm
+	Digits    int
+	Period    time.Duration // for TOTP
+	Counter   uint64        // for HOTP
+
+	Issuer  string
+	Account string
+}
+
+// NewKey returns a TOTP key with the default settings for the given base32
+// seed.
+func NewKey(seed string) (*Key, error) {
+	s, err := DecodeBase32(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		Type:      TOTP,
+		Seed:      s,
+		Algorithm: SHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}, nil
+}
+
+// DecodeBase32 decodes a seed written in base32, as authenticator apps expect.
+// Case, spaces, and padding are ignored.
+func DecodeBase32(seed string) ([]byte, error) {
+	s := strings.ToUpper(strings.ReplaceAll(seed, " ", ""))
+	s = strings.TrimRight(s, "=")
+
+	b, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("OTP seed is not valid base32: %w", err)
+	}
+
+	if len(b) == 0 {
+		return nil, errors.New("OTP seed is empty")
+	}
+
+	return b, nil
+}
+
+// ParseURL parses a key URI, like:
+//
+//	otpauth://totp/Example:alice@example.com?secret=JBSWY3DPEHPK3PXP&issuer=Example
+//
+// The algorithm, digits, period, and counter parameters are honored.
+func ParseURL(value string) (*Key, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("malformed OTP URL: %w", err)
+	}
+
+	if u.Scheme != Scheme {
+		return nil, fmt.Errorf("malformed OTP URL: scheme is not %q", Scheme)
+	}
+
+	q := u.Query()
+	k, err := NewKey(q.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	k.Type = Type(strings.ToLower(u.Host))
+	if k.Type != TOTP && k.Type != HOTP {
+		return nil, fmt.Errorf("malformed OTP URL: unknown type %q", u.Host)
+	}
+
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, hasIssuer := strings.Cut(label, ":"); hasIssuer {
+		k.Issuer, k.Account = issuer, strings.TrimSpace(account)
+	} else {
+		k.Account = label
+	}
+
+	if issuer := q.Get("issuer"); issuer != "" {
+		k.Issuer = issuer
+	}
+
+	k.Algorithm, err = ParseAlgorithm(q.Get("algorithm"))
+	if err != nil {
+		return nil, err
+	}
+
+	if v := q.Get("digits"); v != "" {
+		k.Digits, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("malformed OTP URL: digits: %w", err)
+		}
+	}
+
+	if v := q.Get("period"); v != "" {
+		secs, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("malformed OTP URL: period: %w", err)
+		}
+		k.Period = time.Duration(secs) * time.Second
+	}
+
+	if v := q.Get("counter"); v != "" {
+		k.Counter, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed OTP URL: counter: %w", err)
+		}
+	}
+
+	return k, k.Validate()
+}
+
+// Validate returns an error if the key cannot be used to compute codes.
+func (k *Key) Validate() error {
+	if len(k.Seed) == 0 {
+		return errors.New("OTP seed is empty")
+	}
+
+	if k.Digits < 1 || k.Digits > MaxDigits {
+		return fmt.Errorf("OTP digits must be between 1 and %d, not %d", MaxDigits, k.Digits)
+	}
+
+	if k.Type == TOTP && k.Period < time.Second {
+		return fmt.Errorf("OTP period must be at least 1s, not %v", k.Period)
+	}
+
+	if _, err := ParseAlgorithm(string(k.Algorithm)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// URL returns the key URI of the key.
+func (k *Key) URL() string {
+	q := url.Values{}
+	q.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(k.Seed))
+	if k.Issuer != "" {
+		q.Set("issuer", k.Issuer)
+	}
+	q.Set("algorithm", string(k.Algorithm))
+	q.Set("digits", strconv.Itoa(k.Digits))
+	if k.Type == HOTP {
+		q.Set("counter", strconv.FormatUint(k.Counter, 10))
+	} else {
+		q.Set("period", strconv.Itoa(int(k.Period/time.Second)))
+	}
+
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	u := url.URL{
+		Scheme:   Scheme,
+		Host:     string(k.Type),
+		Path:     "/" + label,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+// HOTP returns the code for the given counter, as described in RFC 4226.
+func (k *Key) HOTP(counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(k.Algorithm.hash(), k.Seed)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	bin := uint64(binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", k.Digits, bin%mod)
+}
+
+// TOTP returns the code for the given time, as described in RFC 6238.
+func (k *Key) TOTP(t time.Time) string {
+	return k.HOTP(uint64(t.Unix()) / uint64(k.Period/time.Second))
+}
+
+// Code returns the code of the key for the given time. For a HOTP key, the
+// time is ignored and the code for the stored counter is returned.
+func (k *Key) Code(t time.Time) string {
+	if k.Type == HOTP {
+		return k.HOTP(k.Counter)
+	}
+	return k.TOTP(t)
+}
+
+// Remaining returns how long the TOTP code for the given time remains valid.
+// It returns zero for a HOTP key.
+func (k *Key) Remaining(t time.Time) time.Duration {
+	if k.Type == HOTP {
+		return 0
+	}
+
+	period := int64(k.Period / time.Second)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// Code is a one-time password and how long it remains valid.
+type Code struct {
+	Code      string
+	Remaining time.Duration // zero for HOTP
+	Period    time.Duration // zero for HOTP
+}
+
+// Generate returns the code of the key for the given time along with how long
+// it remains valid.
+func (k *Key) Generate(t time.Time) *Code {
+	c := &Code{Code: k.Code(t)}
+	if k.Type == TOTP {
+		c.Remaining = k.Remaining(t)
+		c.Period = k.Period
+	}
+	return c
+}
+
+// decodeSeed decodes a seed given in a KeePass TimeOtp-Secret field of the
+// given encoding.
+func decodeSeed(enc, value string) ([]byte, error) {
+	switch enc {
+	case "Base32":
+		return DecodeBase32(value)
+	case "Base64":
+		return base64.StdEncoding.DecodeString(value)
+	case "Hex":
+		return hex.DecodeString(value)
+	default:
+		return []byte(value), nil
+	}
+}
@@ -0,0 +1,196 @@
+package otp_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/otp"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+)
+
+func TestKey_HOTP(t *testing.T) {
+	t.Parallel()
+
+	// test vectors from RFC 4226, appendix D
+	k := &otp.Key{
+		Type:      otp.HOTP,
+		Seed:      []byte("12345678901234567890"),
+		Algorithm: otp.SHA1,
+		Digits:    6,
+	}
+
+	expect := []string{
+		"755224", "287082", "359152", "969429", "338314",
+		"254676", "287922", "162583", "399871", "520489",
+	}
+	for i, code := range expect {
+		assert.Equal(t, code, k.HOTP(uint64(i)), "counter %d", i)
+	}
+}
+
+func TestKey_TOTP(t *testing.T) {
+	t.Parallel()
+
+	// test vectors from RFC 6238, appendix B
+	keys := map[otp.Algorithm]*otp.Key{
+		otp.SHA1:   {Seed: []byte("12345678901234567890")},
+		otp.SHA256: {Seed: []byte("12345678901234567890123456789012")},
+		otp.SHA512: {Seed: []byte("1234567890123456789012345678901234567890123456789012345678901234")},
+	}
+
+	for alg, k := range keys {
+		k.Type = otp.TOTP
+		k.Algorithm = alg
+		k.Digits = 8
+		k.Period = 30 * time.Second
+		require.NoError(t, k.Validate())
+	}
+
+	tests := []struct {
+		time   int64
+		sha1   string
+		sha256 string
+		sha512 string
+	}{
+		{59, "94287082", "46119246", "90693936"},
+		{1111111109, "07081804", "68084774", "25091201"},
+		{1111111111, "14050471", "67062674", "99943326"},
+		{1234567890, "89005924", "91819424", "93441116"},
+		{2000000000, "69279037", "90698825", "38618901"},
+		{20000000000, "65353130", "77737706", "47863826"},
+	}
+
+	for _, tt := range tests {
+		at := time.Unix(tt.time, 0)
+		assert.Equal(t, tt.sha1, keys[otp.SHA1].Code(at), "SHA1 at %d", tt.time)
+		assert.Equal(t, tt.sha256, keys[otp.SHA256].Code(at), "SHA256 at %d", tt.time)
+		assert.Equal(t, tt.sha512, keys[otp.SHA512].Code(at), "SHA512 at %d", tt.time)
+	}
+
+	assert.Equal(t, 1*time.Second, keys[otp.SHA1].Remaining(time.Unix(59, 0)))
+	assert.Equal(t, 30*time.Second, keys[otp.SHA1].Remaining(time.Unix(60, 0)))
+}
+
+func TestParseURL(t *testing.T) {
+	t.Parallel()
+
+	k, err := otp.ParseURL("otpauth://totp/ACME%20Co:john@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60")
+	require.NoError(t, err)
+	assert.Equal(t, otp.TOTP, k.Type)
+	assert.Equal(t, []byte("12345678901234567890"), k.Seed)
+	assert.Equal(t, otp.SHA256, k.Algorithm)
+	assert.Equal(t, 8, k.Digits)
+	assert.Equal(t, 60*time.Second, k.Period)
+	assert.Equal(t, "ACME Co", k.Issuer)
+	assert.Equal(t, "john@example.com", k.Account)
+
+	again, err := otp.ParseURL(k.URL())
+	require.NoError(t, err)
+	assert.Equal(t, k, again)
+
+	k, err = otp.ParseURL("otpauth://hotp/alice?secret=gezdgnbvgy3tqojqgezdgnbvgy3tqojq&counter=3")
+	require.NoError(t, err)
+	assert.Equal(t, otp.HOTP, k.Type)
+	assert.Equal(t, "969429", k.Code(time.Now()))
+
+	for _, bad := range []string{
+		"https://example.com/?secret=GEZDGNBV",
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=not!base32",
+		"otpauth://votp/alice?secret=GEZDGNBV",
+		"otpauth://totp/alice?secret=GEZDGNBV&digits=11",
+		"otpauth://totp/alice?secret=GEZDGNBV&period=0",
+		"otpauth://totp/alice?secret=GEZDGNBV&algorithm=MD5",
+	} {
+		_, err := otp.ParseURL(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFromSecret(t *testing.T) {
+	t.Parallel()
+
+	const seed = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	at := time.Unix(59, 0)
+
+	tests := []struct {
+		name string
+		opts []secrets.SingleOption
+		code string
+	}{
+		{"otp field", []secrets.SingleOption{secrets.WithField("otp", "otpauth://totp/x?secret="+seed+"&digits=8")}, "94287082"},
+		{"bare seed", []secrets.SingleOption{secrets.WithField("OTP", strings.ToLower(seed))}, "287082"},
+		{"TOTP Seed", []secrets.SingleOption{
+			secrets.WithField("TOTP Seed", seed),
+			secrets.WithField("TOTP Settings", "30;8"),
+		}, "94287082"},
+		{"KeePass", []secrets.SingleOption{
+			secrets.WithField("TimeOtp-Secret", "12345678901234567890"),
+			secrets.WithField("TimeOtp-Length", "8"),
+			secrets.WithField("TimeOtp-Algorithm", "HMAC-SHA-1"),
+		}, "94287082"},
+		{"url", []secrets.SingleOption{secrets.WithUrl(mustParse(t, "otpauth://totp/x?secret="+seed))}, "287082"},
+		{"other field", []secrets.SingleOption{secrets.WithField("2fa", "otpauth://totp/x?secret="+seed)}, "287082"},
+	}
+
+	for _, tt := range tests {
+		sec := secrets.NewSecret("test", "user", "pass", tt.opts...)
+		k, err := otp.FromSecret(sec)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.code, k.Code(at), tt.name)
+	}
+
+	_, err := otp.FromSecret(secrets.NewSecret("test", "user", "pass"))
+	assert.ErrorIs(t, err, otp.ErrNoSeed)
+}
+
+func TestFuncMap(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	sec, err := m.SetSecret(ctx, secrets.NewSecret("github", "me", "pass",
+		secrets.WithField("otp", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")))
+	require.NoError(t, err)
+
+	k, err := otp.FromSecret(sec)
+	require.NoError(t, err)
+
+	tmpl, err := template.New("test").
+		Funcs(otp.FuncMap(ctx, m)).
+		Parse(`{{ otp . }} {{ otp "ghost:///github" }} {{ if le (otpRemains .) 30 }}ok{{ end }}`)
+	require.NoError(t, err)
+
+	// the code changes if the period ends during the test
+	out := &strings.Builder{}
+	before := k.Code(time.Now())
+	require.NoError(t, tmpl.Execute(out, sec))
+	after := k.Code(time.Now())
+
+	codes := strings.Fields(out.String())
+	require.Len(t, codes, 3)
+	assert.Contains(t, []string{before, after}, codes[0])
+	assert.Contains(t, []string{before, after}, codes[1])
+	assert.Equal(t, "ok", codes[2])
+
+	tmpl, err = template.New("test").Funcs(otp.FuncMap(ctx, m)).Parse(`{{ otp "ghost://work/github" }}`)
+	require.NoError(t, err)
+	assert.Error(t, tmpl.Execute(out, nil))
+}
+
+func mustParse(t *testing.T, u string) *url.URL {
+	t.Helper()
+	pu, err := url.Parse(u)
+	require.NoError(t, err)
+	return pu
+}
@@ -0,0 +1,164 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// SeedFields are the names of the fields searched for a seed, in order. Names
+// are matched without regard to case. The value may be a key URI or a base32
+// seed.
+var SeedFields = []string{"otp", "totp", "TOTP Seed", "otpauth"}
+
+// Generator is implemented by a Keeper that can compute the current code for
+// a secret itself, such as the client of the ghost service, so that the seed
+// need not be read.
+type Generator interface {
+	// GetOTP returns the current code for the secret with the given ID.
+	GetOTP(ctx context.Context, id string) (*Code, error)
+}
+
+// Generate returns the current code for the secret. If the keeper implements
+// Generator, the code is left to the keeper. Otherwise, it is computed from the
+// key found by FromSecret.
+func Generate(ctx context.Context, kpr secrets.Keeper, sec secrets.Secret) (*Code, error) {
+	if g, isGenerator := kpr.(Generator); isGenerator {
+		return g.GetOTP(ctx, sec.ID())
+	}
+
+	k, err := FromSecret(sec)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Generate(time.Now()), nil
+}
+
+// field returns the value of the named field of the secret, matching the name
+// without regard to case.
+func field(sec secrets.Secret, name string) (string, bool) {
+	for k, v := range sec.Fields() {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// FromSecret returns the key stored in the secret. The seed is found in the
+// first of these places:
+//
+//   - a field named in SeedFields,
+//   - the TimeOtp-Secret fields KeePass uses,
+//   - the URL of the secret, if it is a key URI, or
+//   - any other field holding a key URI.
+//
+// A bare base32 seed uses the default settings, except that the TOTP Settings
+// field KeePassXC writes next to a TOTP Seed field, like "30;6", is honored.
+// It returns ErrNoSeed if none is found.
+func FromSecret(sec secrets.Secret) (*Key, error) {
+	for _, name := range SeedFields {
+		if v, has := field(sec, name); has && v != "" {
+			return fromSeedField(sec, v)
+		}
+	}
+
+	if k, err := fromKeePass(sec); k != nil || err != nil {
+		return k, err
+	}
+
+	if u := sec.Url(); u != nil && u.Scheme == Scheme {
+		return ParseURL(u.String())
+	}
+
+	for _, v := range sec.Fields() {
+		if strings.HasPrefix(v, Scheme+"://") {
+			return ParseURL(v)
+		}
+	}
+
+	return nil, ErrNoSeed
+}
+
+// fromSeedField returns the key for the value of a seed field.
+func fromSeedField(sec secrets.Secret, value string) (*Key, error) {
+	if strings.HasPrefix(value, Scheme+"://") {
+		return ParseURL(value)
+	}
+
+	k, err := NewKey(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings, has := field(sec, "TOTP Settings"); has {
+		period, digits, _ := strings.Cut(settings, ";")
+		if secs, err := strconv.Atoi(period); err == nil {
+			k.Period = time.Duration(secs) * time.Second
+		}
+
+		if n, err := strconv.Atoi(digits); err == nil {
+			k.Digits = n
+		}
+	}
+
+	return k, k.Validate()
+}
+
+// fromKeePass returns the key stored in the TimeOtp fields KeePass uses, or nil
+// if there are none.
+func fromKeePass(sec secrets.Secret) (*Key, error) {
+	for _, enc := range []string{"Base32", "Base64", "Hex", ""} {
+		name := "TimeOtp-Secret"
+		if enc != "" {
+			name += "-" + enc
+		}
+
+		v, has := field(sec, name)
+		if !has || v == "" {
+			continue
+		}
+
+		seed, err := decodeSeed(enc, v)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+
+		k := &Key{
+			Type:      TOTP,
+			Seed:      seed,
+			Algorithm: SHA1,
+			Digits:    DefaultDigits,
+			Period:    DefaultPeriod,
+		}
+
+		if v, has := field(sec, "TimeOtp-Length"); has {
+			if k.Digits, err = strconv.Atoi(v); err != nil {
+				return nil, fmt.Errorf("field %q: %w", "TimeOtp-Length", err)
+			}
+		}
+
+		if v, has := field(sec, "TimeOtp-Period"); has {
+			secs, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", "TimeOtp-Period", err)
+			}
+			k.Period = time.Duration(secs) * time.Second
+		}
+
+		if v, has := field(sec, "TimeOtp-Algorithm"); has {
+			if k.Algorithm, err = ParseAlgorithm(v); err != nil {
+				return nil, err
+			}
+		}
+
+		return k, k.Validate()
+	}
+
+	return nil, nil
+}
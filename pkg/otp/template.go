@@ -0,0 +1,65 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// FuncMap returns the template functions for working with one-time passwords,
+// for use with text/template or html/template. These are:
+//
+//	otp        the current code for a secret
+//	otpRemains the seconds the current code for a secret remains valid
+//
+// Each takes either a secrets.Secret or a secret URI, which is looked up in the
+// given keeper. The keeper of the URI must be empty, as only that keeper is
+// searched.
+func FuncMap(ctx context.Context, kpr secrets.Keeper) template.FuncMap {
+	key := func(v any) (*Key, error) {
+		var sec secrets.Secret
+		switch v := v.(type) {
+		case secrets.Secret:
+			sec = v
+		case string:
+			uri, err := config.ParseSecretURI(v)
+			if err != nil {
+				return nil, err
+			}
+
+			if uri.Keeper != "" {
+				return nil, fmt.Errorf("secret URI %s must not name a keeper", uri)
+			}
+
+			sec, err = uri.FindOne(ctx, kpr)
+			if err != nil {
+				return nil, fmt.Errorf("secret URI %s: %w", uri, err)
+			}
+		default:
+			return nil, fmt.Errorf("otp expects a secret or secret URI, not %T", v)
+		}
+
+		return FromSecret(sec)
+	}
+
+	return template.FuncMap{
+		"otp": func(v any) (string, error) {
+			k, err := key(v)
+			if err != nil {
+				return "", err
+			}
+			return k.Code(time.Now()), nil
+		},
+		"otpRemains": func(v any) (int, error) {
+			k, err := key(v)
+			if err != nil {
+				return 0, err
+			}
+			return int(k.Remaining(time.Now()) / time.Second), nil
+		},
+	}
+}
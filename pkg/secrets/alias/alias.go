@@ -0,0 +1,289 @@
+package alias
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// DefaultMaxDepth is the number of references that may be followed to resolve
+// a single value when no maximum depth is set.
+const DefaultMaxDepth = 8
+
+var (
+	// ErrCycle is returned when following references leads back to a
+	// reference already being resolved.
+	ErrCycle = errors.New("secret reference cycle")
+
+	// ErrMaxDepth is returned when more references must be followed to
+	// resolve a value than the maximum depth permits.
+	ErrMaxDepth = errors.New("secret reference too deep")
+)
+
+// Resolver returns the keeper with the given name.
+type Resolver func(ctx context.Context, name string) (secrets.Keeper, error)
+
+// Alias is a Keeper that wraps another Keeper and resolves secret references
+// found in the password, username, and fields of the secrets read from it. A
+// value that is a secret URI, such as ghost://keeper/name#field, is replaced
+// with the value of the referenced field of the referenced secret. This allows
+// one credential to be shared by several secrets.
+//
+// References are resolved when secrets are read. Writes are passed through to
+// the wrapped Keeper, so the references themselves are stored. When a secret
+// read from this keeper is written back, the references are kept in place of
+// the values resolved from them that have not changed.
+type Alias struct {
+	secrets.Keeper
+
+	resolver Resolver
+	maxDepth int
+
+	lock    sync.Mutex
+	keepers map[string]secrets.Keeper
+}
+
+var (
+	_ secrets.Keeper  = &Alias{}
+	_ secrets.Wrapper = &Alias{}
+)
+
+// Option is used to customize the alias keeper during construction.
+type Option func(*Alias)
+
+// WithResolver sets the function used to find the keepers named in
+// references. Without a resolver, only references to the same keeper, which
+// leave the keeper empty, may be resolved.
+func WithResolver(resolver Resolver) Option {
+	return func(a *Alias) {
+		a.resolver = resolver
+	}
+}
+
+// WithMaxDepth sets the number of references that may be followed to resolve a
+// single value. The default is DefaultMaxDepth.
+func WithMaxDepth(depth int) Option {
+	return func(a *Alias) {
+		a.maxDepth = depth
+	}
+}
+
+// New creates a new alias keeper that wraps the given keeper.
+func New(kpr secrets.Keeper, opts ...Option) *Alias {
+	a := &Alias{
+		Keeper:   kpr,
+		maxDepth: DefaultMaxDepth,
+		keepers:  map[string]secrets.Keeper{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// Unwrap returns the wrapped keeper.
+func (a *Alias) Unwrap() []secrets.Keeper {
+	return []secrets.Keeper{a.Keeper}
+}
+
+// chainKey is the context key holding the references being resolved.
+type chainKey struct{}
+
+// chain returns the references being resolved.
+func chain(ctx context.Context) []string {
+	refs, _ := ctx.Value(chainKey{}).([]string)
+	return refs
+}
+
+// withRef returns a context adding the reference to those being resolved. It
+// fails if the reference is already being resolved or the chain of references
+// is too long.
+func (a *Alias) withRef(ctx context.Context, ref *config.SecretURI) (context.Context, error) {
+	refs := chain(ctx)
+	key := ref.String()
+	for _, r := range refs {
+		if r == key {
+			return nil, fmt.Errorf("%w: %s", ErrCycle, key)
+		}
+	}
+
+	if len(refs) >= a.maxDepth {
+		return nil, fmt.Errorf("%w: more than %d references followed to reach %s", ErrMaxDepth, a.maxDepth, key)
+	}
+
+	next := make([]string, len(refs), len(refs)+1)
+	copy(next, refs)
+	return context.WithValue(ctx, chainKey{}, append(next, key)), nil
+}
+
+// keeper returns the named keeper or this keeper, if the name is empty.
+func (a *Alias) keeper(ctx context.Context, name string) (secrets.Keeper, error) {
+	if name == "" {
+		return a, nil
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if kpr, isBuilt := a.keepers[name]; isBuilt {
+		return kpr, nil
+	}
+
+	if a.resolver == nil {
+		return nil, fmt.Errorf("unable to find keeper %q for secret reference", name)
+	}
+
+	kpr, err := a.resolver(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find keeper %q for secret reference: %w", name, err)
+	}
+
+	a.keepers[name] = kpr
+	return kpr, nil
+}
+
+// resolveValue returns the value unchanged, unless it is a reference, in which
+// case the referenced value is returned.
+func (a *Alias) resolveValue(ctx context.Context, value string) (string, error) {
+	if !config.IsSecretURI(value) {
+		return value, nil
+	}
+
+	ref, err := config.ParseSecretURI(value)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, err = a.withRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	kpr, err := a.keeper(ctx, ref.Keeper)
+	if err != nil {
+		return "", err
+	}
+
+	// only the referenced value is resolved, not the whole referenced secret,
+	// so an alias keeper is searched without resolving its references
+	target := a
+	if ta, isAlias := kpr.(*Alias); isAlias {
+		target, kpr = ta, ta.Keeper
+	}
+
+	sec, err := ref.FindOne(ctx, kpr)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve secret reference %s: %w", ref, err)
+	}
+
+	return target.resolveValue(ctx, ref.Value(sec))
+}
+
+// resolveSecret returns a copy of the secret with every reference resolved.
+// Everything else, including the attachments, is copied as is.
+func (a *Alias) resolveSecret(ctx context.Context, sec secrets.Secret) (secrets.Secret, error) {
+	username, err := a.resolveValue(ctx, sec.Username())
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := a.resolveValue(ctx, sec.Password())
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []secrets.SingleOption{
+		secrets.WithUsername(username),
+		secrets.WithPassword(password),
+	}
+
+	for k, v := range sec.Fields() {
+		v, err := a.resolveValue(ctx, v)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, secrets.WithField(k, v))
+	}
+
+	return secrets.NewSingleFromSecret(sec, opts...), nil
+}
+
+// GetSecret returns the secret from the wrapped keeper with its references
+// resolved.
+func (a *Alias) GetSecret(ctx context.Context, id string) (secrets.Secret, error) {
+	sec, err := a.Keeper.GetSecret(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.resolveSecret(ctx, sec)
+}
+
+// GetSecretsByName returns the secrets with the given name from the wrapped
+// keeper with their references resolved.
+func (a *Alias) GetSecretsByName(ctx context.Context, name string) ([]secrets.Secret, error) {
+	secs, err := a.Keeper.GetSecretsByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := make([]secrets.Secret, len(secs))
+	for i, sec := range secs {
+		resolved[i], err = a.resolveSecret(ctx, sec)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// keepRef returns the stored value if it is a reference that resolves to the
+// value given. Otherwise, the value given is returned.
+func (a *Alias) keepRef(ctx context.Context, stored, value string) string {
+	if stored == value || !config.IsSecretURI(stored) {
+		return value
+	}
+
+	resolved, err := a.resolveValue(ctx, stored)
+	if err != nil || resolved != value {
+		return value
+	}
+
+	return stored
+}
+
+// SetSecret writes the secret to the wrapped keeper. If the secret is already
+// stored, any username, password, or field stored as a reference that resolves
+// to the value given is left as the reference, so that changing one value of a
+// secret read from this keeper does not replace the others with their resolved
+// values.
+func (a *Alias) SetSecret(ctx context.Context, sec secrets.Secret) (secrets.Secret, error) {
+	if sec.ID() == "" {
+		return a.Keeper.SetSecret(ctx, sec)
+	}
+
+	stored, err := a.Keeper.GetSecret(ctx, sec.ID())
+	if errors.Is(err, secrets.ErrNotFound) {
+		return a.Keeper.SetSecret(ctx, sec)
+	} else if err != nil {
+		return nil, err
+	}
+
+	opts := []secrets.SingleOption{
+		secrets.WithUsername(a.keepRef(ctx, stored.Username(), sec.Username())),
+		secrets.WithPassword(a.keepRef(ctx, stored.Password(), sec.Password())),
+	}
+
+	for k, v := range sec.Fields() {
+		opts = append(opts, secrets.WithField(k, a.keepRef(ctx, stored.GetField(k), v)))
+	}
+
+	return a.Keeper.SetSecret(ctx, secrets.NewSingleFromSecret(sec, opts...))
+}
@@ -0,0 +1,236 @@
+package alias_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/alias"
+	"github.com/zostay/ghost/pkg/secrets/keepertest"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+)
+
+func TestAlias(t *testing.T) { //nolint:tparallel // it is parallel, you dolt
+	t.Parallel()
+
+	factory := func() (secrets.Keeper, error) {
+		m, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+		return alias.New(m), nil
+	}
+
+	ts := keepertest.New(factory)
+	ts.Run(t)
+}
+
+func TestAlias_GetSecret(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = m.SetSecret(ctx, secrets.NewSecret("db", "admin", "hunter2",
+		secrets.WithLocation("Shared"),
+		secrets.WithField("host", "db.example.com"),
+		secrets.WithField("port", "5432")))
+	require.NoError(t, err)
+
+	app, err := m.SetSecret(ctx, secrets.NewSecret("app", "ghost:///Shared/db#username", "ghost:///db",
+		secrets.WithField("host", "ghost:///db#host"),
+		secrets.WithField("port", "ghost:///Shared/db?username=admin#port"),
+		secrets.WithField("note", "plain"),
+		secrets.WithAttachment("cert.pem", []byte("certificate"))))
+	require.NoError(t, err)
+
+	a := alias.New(m)
+
+	got, err := a.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+	assert.Equal(t, app.ID(), got.ID())
+	assert.Equal(t, "admin", got.Username())
+	assert.Equal(t, "hunter2", got.Password())
+	assert.Equal(t, map[string]string{
+		"host": "db.example.com",
+		"port": "5432",
+		"note": "plain",
+	}, got.Fields())
+
+	data, hasAttachment := secrets.GetAttachment(got, "cert.pem")
+	assert.True(t, hasAttachment)
+	assert.Equal(t, []byte("certificate"), data)
+
+	// the stored secret keeps the references
+	raw, err := m.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "ghost:///db", raw.Password())
+	assert.Equal(t, "ghost:///db#host", raw.GetField("host"))
+
+	secs, err := a.GetSecretsByName(ctx, "app")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, "hunter2", secs[0].Password())
+}
+
+func TestAlias_SetSecret(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = m.SetSecret(ctx, secrets.NewSecret("db", "admin", "hunter2",
+		secrets.WithField("host", "db.example.com")))
+	require.NoError(t, err)
+
+	app, err := m.SetSecret(ctx, secrets.NewSecret("app", "ghost:///db#username", "ghost:///db",
+		secrets.WithField("host", "ghost:///db#host"),
+		secrets.WithField("port", "ghost:///db#host"),
+		secrets.WithField("note", "plain")))
+	require.NoError(t, err)
+
+	a := alias.New(m)
+
+	// read, change one field, and write back, as ghost set does
+	got, err := a.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+
+	_, err = a.SetSecret(ctx, secrets.NewSingleFromSecret(got,
+		secrets.WithField("note", "changed"),
+		secrets.WithField("port", "5432")))
+	require.NoError(t, err)
+
+	raw, err := m.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "ghost:///db#username", raw.Username(), "unchanged values keep the reference")
+	assert.Equal(t, "ghost:///db", raw.Password())
+	assert.Equal(t, map[string]string{
+		"host": "ghost:///db#host",
+		"port": "5432",
+		"note": "changed",
+	}, raw.Fields(), "changed values replace the reference")
+
+	got, err = a.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", got.Password())
+
+	// the references follow the secret they refer to
+	db, err := m.GetSecretsByName(ctx, "db")
+	require.NoError(t, err)
+	require.Len(t, db, 1)
+	_, err = m.SetSecret(ctx, secrets.NewSingleFromSecret(db[0], secrets.WithPassword("changed")))
+	require.NoError(t, err)
+
+	got, err = a.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "changed", got.Password())
+	assert.Equal(t, "5432", got.GetField("port"))
+}
+
+func TestAlias_Resolver(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	work, err := memory.New()
+	require.NoError(t, err)
+
+	_, err = work.SetSecret(ctx, secrets.NewSecret("db", "admin", "hunter2"))
+	require.NoError(t, err)
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	app, err := m.SetSecret(ctx, secrets.NewSecret("app", "app", "ghost://work/db"))
+	require.NoError(t, err)
+
+	lost, err := m.SetSecret(ctx, secrets.NewSecret("lost", "app", "ghost://home/db"))
+	require.NoError(t, err)
+
+	a := alias.New(m, alias.WithResolver(
+		func(_ context.Context, name string) (secrets.Keeper, error) {
+			if name == "work" {
+				return work, nil
+			}
+			return nil, fmt.Errorf("no keeper named %q", name)
+		}))
+
+	got, err := a.GetSecret(ctx, app.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", got.Password())
+
+	_, err = a.GetSecret(ctx, lost.ID())
+	assert.Error(t, err)
+
+	// without a resolver, only the same keeper may be referenced
+	_, err = alias.New(m).GetSecret(ctx, app.ID())
+	assert.Error(t, err)
+}
+
+func TestAlias_Cycle(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	one, err := m.SetSecret(ctx, secrets.NewSecret("one", "user", "ghost:///two"))
+	require.NoError(t, err)
+
+	_, err = m.SetSecret(ctx, secrets.NewSecret("two", "user", "ghost:///one"))
+	require.NoError(t, err)
+
+	// a cycle elsewhere in a referenced secret does not matter
+	three, err := m.SetSecret(ctx, secrets.NewSecret("three", "ghost:///one", "secret"))
+	require.NoError(t, err)
+
+	four, err := m.SetSecret(ctx, secrets.NewSecret("four", "user", "ghost:///three"))
+	require.NoError(t, err)
+
+	a := alias.New(m)
+
+	_, err = a.GetSecret(ctx, one.ID())
+	assert.ErrorIs(t, err, alias.ErrCycle)
+
+	_, err = a.GetSecret(ctx, three.ID())
+	assert.ErrorIs(t, err, alias.ErrCycle)
+
+	got, err := a.GetSecret(ctx, four.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got.Password())
+}
+
+func TestAlias_MaxDepth(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = m.SetSecret(ctx, secrets.NewSecret("s0", "user", "secret"))
+	require.NoError(t, err)
+
+	var last secrets.Secret
+	for i := 1; i <= 3; i++ {
+		last, err = m.SetSecret(ctx, secrets.NewSecret(
+			fmt.Sprintf("s%d", i), "user", fmt.Sprintf("ghost:///s%d", i-1)))
+		require.NoError(t, err)
+	}
+
+	got, err := alias.New(m, alias.WithMaxDepth(3)).GetSecret(ctx, last.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "secret", got.Password())
+
+	_, err = alias.New(m, alias.WithMaxDepth(2)).GetSecret(ctx, last.ID())
+	assert.ErrorIs(t, err, alias.ErrMaxDepth)
+}
@@ -0,0 +1,128 @@
+package alias
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/plugin"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// ConfigType is the type name for the alias keeper.
+const ConfigType = "alias"
+
+// Config is the configuration for the alias keeper.
+type Config struct {
+	// Keeper is the name of the keeper whose secret references are resolved.
+	Keeper string `mapstructure:"keeper" yaml:"keeper"`
+
+	// MaxDepth is the number of references that may be followed to resolve a
+	// single value. The default is 8.
+	MaxDepth int `mapstructure:"max_depth" yaml:"max_depth,omitempty"`
+}
+
+// Builder creates a new alias keeper from the given configuration.
+func Builder(ctx context.Context, c any) (secrets.Keeper, error) {
+	cfg, isAlias := c.(*Config)
+	if !isAlias {
+		return nil, plugin.ErrConfig
+	}
+
+	kpr, err := keeper.Build(ctx, cfg.Keeper)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load keeper to alias %q: %w", cfg.Keeper, err)
+	}
+
+	opts := []Option{
+		WithResolver(func(_ context.Context, name string) (secrets.Keeper, error) {
+			return keeper.Build(ctx, name)
+		}),
+	}
+
+	if cfg.MaxDepth > 0 {
+		opts = append(opts, WithMaxDepth(cfg.MaxDepth))
+	}
+
+	return New(kpr, opts...), nil
+}
+
+// Validate checks that the configuration is correct for the alias keeper. It
+// will check that the wrapped keeper exists and that the maximum depth is not
+// negative.
+func Validate(ctx context.Context, c any) error {
+	cfg, isAlias := c.(*Config)
+	if !isAlias {
+		return plugin.ErrConfig
+	}
+
+	errs := plugin.NewValidationError()
+
+	if !keeper.Exists(ctx, cfg.Keeper) {
+		errs.Append(fmt.Errorf("alias keeper %q does not exist", cfg.Keeper))
+	}
+
+	if cfg.MaxDepth < 0 {
+		errs.Append(fmt.Errorf("alias max depth must not be negative"))
+	}
+
+	return errs.Return()
+}
+
+// Print is the config printer for the alias keeper.
+func Print(c any, w io.Writer) error {
+	cfg, isAlias := c.(*Config)
+	if !isAlias {
+		return plugin.ErrConfig
+	}
+
+	maxDepth := cfg.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultMaxDepth
+	}
+
+	fmt.Fprintln(w, "alias keeper:", cfg.Keeper)
+	fmt.Fprintln(w, "max depth:", maxDepth)
+	return nil
+}
+
+func init() {
+	var (
+		keeperName string
+		maxDepth   int
+	)
+
+	cmd := plugin.CmdConfig{
+		Short: "Configure an alias keeper that resolves secret references in another keeper",
+		Run: func(_ string, _ map[string]any) (config.KeeperConfig, error) {
+			kc := config.KeeperConfig{
+				"type":   ConfigType,
+				"keeper": keeperName,
+			}
+
+			if maxDepth > 0 {
+				kc["max_depth"] = maxDepth
+			}
+
+			return kc, nil
+		},
+		FlagInit: func(flags *pflag.FlagSet) error {
+			flags.StringVar(&keeperName, "keeper", "", "the name of the keeper to resolve references in")
+			flags.IntVar(&maxDepth, "max-depth", 0, fmt.Sprintf("the number of references that may be followed to resolve a value (default %d)", DefaultMaxDepth))
+
+			if err := cobra.MarkFlagRequired(flags, "keeper"); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	plugin.Register(ConfigType, reflect.TypeOf(Config{}), Builder, Validate, Print, cmd)
+}
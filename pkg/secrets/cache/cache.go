@@ -1,59 +1,461 @@
 package cache
 
 import (
+	"container/list"
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"sync"
 	"time"
 
+	"github.com/zostay/fssafe"
+
 	"github.com/zostay/ghost/pkg/secrets"
 	"github.com/zostay/ghost/pkg/secrets/memory"
 )
 
+// entry tracks a single secret held in the cache.
+type entry struct {
+	cacheId string        // the ID of the secret in the memory keeper
+	expires time.Time     // the time the entry goes stale, zero for never
+	elem    *list.Element // the position of the entry in the LRU list
+	dirty   bool          // true if the entry has writes not yet written back
+}
+
+// listEntry tracks a list of locations or secret IDs held in the cache.
+type listEntry struct {
+	names   []string  // the location names or secret IDs
+	expires time.Time // the time the list goes stale, zero for never
+}
+
 // Cache is a secret keeper that wraps another secret keeper and caches
-// secrets in memory. Writing to it directly is not permitted.
+// secrets in memory.
+//
+// By default, secrets are cached forever and the number of secrets cached is
+// unlimited. Options may be given to New to set a time-to-live on entries, to
+// limit the number of entries (evicting the least recently used first), and to
+// remember for a short while that a secret was not found.
+//
+// By default, writing to the cache directly is not permitted. The
+// WithWriteThrough and WithWriteBack options enable writes. In write-through
+// mode, writes are passed on to the wrapped keeper immediately. In write-back
+// mode, writes are applied to the cache and queued to be written to the
+// wrapped keeper later. Secrets with writes still queued are never expired or
+// evicted. New secrets are given a temporary ID until they have been written,
+// but the temporary ID continues to work afterward.
 type Cache struct {
 	secrets.Keeper // the secret keeper to cache
 	*memory.Memory // the memory keeper used to store cached secrets
 
-	origToCacheId map[string]string
+	lock sync.Mutex
+
+	entries       map[string]*entry
 	cacheToOrigId map[string]string
+	lru           *list.List
+	notFound      map[string]time.Time
+	locations     *listEntry
+	secretLists   map[string]*listEntry
+
+	touchOnRead bool          // update last modified on GetSecret* calls
+	ttl         time.Duration // how long an entry is fresh, 0 for forever
+	maxEntries  int           // maximum number of entries, 0 for unlimited
+	negativeTTL time.Duration // how long to remember not found, 0 to not
+
+	writeMode        WriteMode           // how writes are handled
+	queue            []*pendingOp        // writes waiting to be written back
+	queueStore       fssafe.LoaderSaver  // where the queue is saved, or nil
+	queueSealer      *sealer             // encrypts the saved queue
+	deleted          map[string]struct{} // deletes not yet written back
+	renamed          map[string]string   // pending IDs to their final IDs
+	retryInterval    time.Duration       // first delay after a failed write
+	maxRetryInterval time.Duration       // longest delay after a failed write
+	maxAttempts      int                 // attempts before a write is dropped
+	kick             chan struct{}       // wakes the write-back goroutine
+	flushLock        sync.Mutex          // held while writing back
+
+	snapshot      *snapshot     // the offline copy of the cache, or nil
+	snapshotDirty bool          // the snapshot has changes not yet saved
+	snapshotKick  chan struct{} // wakes the snapshot goroutine, or nil
+	snapshotLock  sync.Mutex    // held while saving the snapshot
+
+	logger *log.Logger // reports failures in the background
+}
+
+var (
+	_ secrets.Keeper  = &Cache{}
+	_ secrets.Wrapper = &Cache{}
+)
 
-	touchOnRead bool // update last modified on GetSecret* calls
+// Option is used to customize the cache during construction.
+type Option func(*Cache)
+
+// WithTTL sets the time-to-live for each entry in the cache. Once an entry is
+// older than this, the secret will be fetched from the wrapped keeper again.
+// A zero duration means entries never expire.
+func WithTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.ttl = ttl
+	}
+}
+
+// WithMaxEntries limits the number of secrets held in the cache. When the limit
+// is exceeded, the least recently used entry is evicted. A zero limit means the
+// cache is unlimited.
+func WithMaxEntries(n int) Option {
+	return func(c *Cache) {
+		c.maxEntries = n
+	}
+}
+
+// WithNegativeTTL causes the cache to remember that a secret was not found in
+// the wrapped keeper for the given duration. During that time, GetSecret will
+// return secrets.ErrNotFound without asking the wrapped keeper. A zero duration
+// disables negative caching.
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithLogger sets the logger used to report failures that cannot be returned,
+// such as a failure to save the snapshot. The standard logger is used by
+// default.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *Cache) {
+		c.logger = logger
+	}
 }
 
 // New creates a new caching secret keeper. The keeper will cache
 // secrets in memory and will wrap the given secret keeper. The
 // touchOnRead flag will cause the last modified date of secrets to
 // be updated on GetSecret* calls.
-func New(k secrets.Keeper, touchOnRead bool) (*Cache, error) {
+func New(k secrets.Keeper, touchOnRead bool, opts ...Option) (*Cache, error) {
 	mem, err := memory.New()
 	if err != nil {
 		return nil, err
 	}
 
-	return &Cache{
+	c := &Cache{
 		Memory: mem,
 		Keeper: k,
 
-		origToCacheId: map[string]string{},
+		entries:       map[string]*entry{},
 		cacheToOrigId: map[string]string{},
+		lru:           list.New(),
+		notFound:      map[string]time.Time{},
+		secretLists:   map[string]*listEntry{},
+		deleted:       map[string]struct{}{},
+		renamed:       map[string]string{},
+		kick:          make(chan struct{}, 1),
+
+		touchOnRead:      touchOnRead,
+		logger:           log.Default(),
+		retryInterval:    DefaultRetryInterval,
+		maxRetryInterval: DefaultMaxRetryInterval,
+		maxAttempts:      DefaultMaxAttempts,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.snapshot != nil {
+		if err := c.snapshot.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.writeMode == WriteBack {
+		if err := c.loadQueue(context.Background()); err != nil {
+			return nil, fmt.Errorf("unable to load cache write queue: %w", err)
+		}
+	}
 
-		touchOnRead: touchOnRead,
-	}, nil
+	return c, nil
 }
 
-var _ secrets.Keeper = &Cache{}
+// Unwrap returns the wrapped secret keeper.
+func (c *Cache) Unwrap() []secrets.Keeper {
+	return []secrets.Keeper{c.Keeper}
+}
 
-// ListLocations returns the list of locations in the wrapped secret keeper.
+// newListEntry returns a list entry that expires after the TTL.
+func (c *Cache) newListEntry(names []string) *listEntry {
+	le := &listEntry{names: names}
+	if c.ttl > 0 {
+		le.expires = time.Now().Add(c.ttl)
+	}
+	return le
+}
+
+// fresh returns true if the list entry exists and has not expired.
+func (le *listEntry) fresh(now time.Time) bool {
+	return le != nil && (le.expires.IsZero() || now.Before(le.expires))
+}
+
+// invalidateLists forgets all cached lists. The lock must be held.
+func (c *Cache) invalidateLists() {
+	c.locations = nil
+	c.secretLists = map[string]*listEntry{}
+}
+
+// ListLocations returns the list of locations in the wrapped secret keeper on
+// first call. Subsequent calls will return the cached list until it expires.
 func (c *Cache) ListLocations(ctx context.Context) ([]string, error) {
-	return c.Keeper.ListLocations(ctx)
+	c.lock.Lock()
+	if c.locations.fresh(time.Now()) {
+		defer c.lock.Unlock()
+		return c.overlayLocations(ctx, c.locations.names), nil
+	}
+	c.lock.Unlock()
+
+	locs, err := c.Keeper.ListLocations(ctx)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err != nil {
+		locs, err = c.locationsFromSnapshot(err)
+		if err != nil {
+			return nil, err
+		}
+		return c.overlayLocations(ctx, locs), nil
+	}
+
+	c.locations = c.newListEntry(locs)
+	c.recordLocations(locs)
+	c.saveSnapshot()
+
+	return c.overlayLocations(ctx, locs), nil
 }
 
-// ListSecrets returns the list of secrets in the wrapped secret keeper.
+// ListSecrets returns the list of secrets in the given location of the wrapped
+// secret keeper on first call. Subsequent calls will return the cached list
+// until it expires.
 func (c *Cache) ListSecrets(ctx context.Context, loc string) ([]string, error) {
-	return c.Keeper.ListSecrets(ctx, loc)
+	c.lock.Lock()
+	if le := c.secretLists[loc]; le.fresh(time.Now()) {
+		defer c.lock.Unlock()
+		return c.overlaySecrets(ctx, loc, le.names), nil
+	}
+	c.lock.Unlock()
+
+	ids, err := c.Keeper.ListSecrets(ctx, loc)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err != nil {
+		ids, err = c.secretListFromSnapshot(loc, err)
+		if err != nil {
+			return nil, err
+		}
+		return c.overlaySecrets(ctx, loc, ids), nil
+	}
+
+	c.secretLists[loc] = c.newListEntry(ids)
+	c.recordSecretList(loc, ids)
+	c.saveSnapshot()
+
+	return c.overlaySecrets(ctx, loc, ids), nil
+}
+
+// overlayLocations returns a copy of the list of locations with the locations
+// of secrets with writes not yet written back added. The lock must be held.
+func (c *Cache) overlayLocations(ctx context.Context, locs []string) []string {
+	newLocs := make([]string, len(locs))
+	copy(newLocs, locs)
+
+	seen := make(map[string]struct{}, len(locs))
+	for _, loc := range locs {
+		seen[loc] = struct{}{}
+	}
+
+	for _, e := range c.entries {
+		if !e.dirty {
+			continue
+		}
+
+		sec, err := c.Memory.GetSecret(ctx, e.cacheId)
+		if err != nil {
+			continue
+		}
+
+		if _, isSeen := seen[sec.Location()]; !isSeen {
+			seen[sec.Location()] = struct{}{}
+			newLocs = append(newLocs, sec.Location())
+		}
+	}
+
+	return newLocs
+}
+
+// overlaySecrets returns a copy of the list of secret IDs in the location,
+// adjusted for writes not yet written back: deleted secrets are removed and
+// secrets set, copied, or moved are added to or removed from the list as
+// needed. The lock must be held.
+func (c *Cache) overlaySecrets(ctx context.Context, loc string, ids []string) []string {
+	newIds := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if _, isDeleted := c.deleted[id]; isDeleted {
+			continue
+		}
+
+		if e, isCached := c.entries[id]; isCached && e.dirty {
+			// handled below
+			continue
+		}
+
+		seen[id] = struct{}{}
+		newIds = append(newIds, id)
+	}
+
+	for id, e := range c.entries {
+		if _, isSeen := seen[id]; isSeen || !e.dirty {
+			continue
+		}
+
+		sec, err := c.Memory.GetSecret(ctx, e.cacheId)
+		if err != nil || sec.Location() != loc {
+			continue
+		}
+
+		newIds = append(newIds, id)
+	}
+
+	return newIds
+}
+
+// Invalidate removes the secret with the given ID from the cache, if present.
+// The next request for the secret will be passed to the wrapped keeper. A
+// secret with writes not yet written back is kept.
+func (c *Cache) Invalidate(ctx context.Context, id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	id = c.resolveID(id)
+	if e, isCached := c.entries[id]; isCached && e.dirty {
+		return
+	}
+
+	c.invalidate(ctx, id)
+}
+
+// Flush removes every secret from the cache. Secrets with writes that have
+// not yet been written back are kept.
+func (c *Cache) Flush(ctx context.Context) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for id, e := range c.entries {
+		if !e.dirty {
+			c.invalidate(ctx, id)
+		}
+	}
+
+	c.notFound = map[string]time.Time{}
+	c.invalidateLists()
 }
 
+// Len returns the number of secrets held in the cache.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.entries)
+}
+
+// invalidate removes the entry for the given ID. The lock must be held.
+func (c *Cache) invalidate(ctx context.Context, id string) {
+	delete(c.notFound, id)
+
+	e, isCached := c.entries[id]
+	if !isCached {
+		return
+	}
+
+	_ = c.Memory.DeleteSecret(ctx, e.cacheId)
+	c.lru.Remove(e.elem)
+	delete(c.entries, id)
+	delete(c.cacheToOrigId, e.cacheId)
+}
+
+// expired returns true if the entry has outlived the TTL.
+func (e *entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// lookup returns the fresh cache entry for the given ID or nil. Stale entries
+// are removed. The lock must be held.
+func (c *Cache) lookup(ctx context.Context, id string) *entry {
+	e, isCached := c.entries[id]
+	if !isCached {
+		return nil
+	}
+
+	if !e.dirty && e.expired(time.Now()) {
+		c.invalidate(ctx, id)
+		return nil
+	}
+
+	c.lru.MoveToFront(e.elem)
+	return e
+}
+
+// knownMissing returns true if the ID was recently found to be missing. The
+// lock must be held.
+func (c *Cache) knownMissing(id string) bool {
+	if _, isDeleted := c.deleted[id]; isDeleted {
+		return true
+	}
+
+	expires, isMissing := c.notFound[id]
+	if !isMissing {
+		return false
+	}
+
+	if time.Now().After(expires) {
+		delete(c.notFound, id)
+		return false
+	}
+
+	return true
+}
+
+// rememberMissing records that the ID was not found. The lock must be held.
+func (c *Cache) rememberMissing(id string) {
+	if c.negativeTTL <= 0 {
+		return
+	}
+
+	c.notFound[id] = time.Now().Add(c.negativeTTL)
+}
+
+// evict removes the least recently used entries until the cache is within its
+// size limit. Entries with writes not yet written back are skipped. The lock
+// must be held.
+func (c *Cache) evict(ctx context.Context) {
+	if c.maxEntries <= 0 {
+		return
+	}
+
+	oldest := c.lru.Back()
+	for len(c.entries) > c.maxEntries && oldest != nil {
+		id := oldest.Value.(string)
+		oldest = oldest.Prev()
+
+		if !c.entries[id].dirty {
+			c.invalidate(ctx, id)
+		}
+	}
+}
+
+// touchSecret stores the secret in the cache, updating the last modified
+// date. If the secret is not yet cached, cacheId should be empty and a new
+// entry will be created. The lock must be held.
 func (c *Cache) touchSecret(
 	ctx context.Context,
 	sec secrets.Secret,
@@ -67,124 +469,421 @@ func (c *Cache) touchSecret(
 	if err != nil {
 		return sec, nil
 	}
+
 	if cacheId == "" {
-		cacheId = cacheSec.ID()
+		c.addEntry(ctx, id, cacheSec.ID(), false)
 	}
-	c.origToCacheId[id] = cacheId
-	c.cacheToOrigId[cacheId] = id
+
 	return secrets.NewSingleFromSecret(cacheSec, secrets.WithID(id)), nil
 }
 
+// addEntry records a newly cached secret and evicts entries if the cache has
+// grown too large. The lock must be held.
+func (c *Cache) addEntry(ctx context.Context, id, cacheId string, dirty bool) {
+	if old, isCached := c.entries[id]; isCached {
+		_ = c.Memory.DeleteSecret(ctx, old.cacheId)
+		c.lru.Remove(old.elem)
+		delete(c.cacheToOrigId, old.cacheId)
+	}
+
+	e := &entry{
+		cacheId: cacheId,
+		elem:    c.lru.PushFront(id),
+		dirty:   dirty,
+	}
+
+	if c.ttl > 0 && !dirty {
+		e.expires = time.Now().Add(c.ttl)
+	}
+
+	c.entries[id] = e
+	c.cacheToOrigId[cacheId] = id
+	delete(c.notFound, id)
+
+	c.evict(ctx)
+}
+
+// resolveID returns the final ID for a temporary ID handed out in write-back
+// mode that has since been written to the wrapped keeper. Any other ID is
+// returned as is. The lock must be held.
+func (c *Cache) resolveID(id string) string {
+	if newId, isRenamed := c.renamed[id]; isRenamed {
+		return newId
+	}
+	return id
+}
+
 // GetSecret returns the secret with the given ID from the wrapped secret keeper
-// on first call. Subsequent calls will return the cached secret. If the
-// touchOnRead flag is set, the last modified date of the secret will be updated
-// on each call.
+// on first call. Subsequent calls will return the cached secret until the entry
+// expires or is evicted. If the touchOnRead flag is set, the last modified date
+// of the secret will be updated on each call.
 func (c *Cache) GetSecret(ctx context.Context, id string) (secrets.Secret, error) {
-	if cacheId, isCached := c.origToCacheId[id]; isCached {
-		sec, _ := c.Memory.GetSecret(ctx, cacheId)
+	c.lock.Lock()
+	id = c.resolveID(id)
+	if e := c.lookup(ctx, id); e != nil {
+		sec, _ := c.Memory.GetSecret(ctx, e.cacheId)
 		if sec != nil {
+			defer c.lock.Unlock()
 			if c.touchOnRead {
-				return c.touchSecret(ctx, sec, cacheId, id)
+				return c.touchSecret(ctx, sec, e.cacheId, id)
 			}
 
 			return secrets.NewSingleFromSecret(sec, secrets.WithID(id)), nil
 		}
 	}
 
+	if c.knownMissing(id) {
+		c.lock.Unlock()
+		return nil, secrets.ErrNotFound
+	}
+	c.lock.Unlock()
+
 	sec, err := c.Keeper.GetSecret(ctx, id)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	if err != nil {
-		return nil, err
+		if errors.Is(err, secrets.ErrNotFound) {
+			c.rememberMissing(id)
+			c.forgetSecret(id)
+			c.saveSnapshot()
+		}
+		return c.fromSnapshot(id, err)
 	}
 
+	// a write may have been made while the lock was released
+	if _, isDeleted := c.deleted[id]; isDeleted {
+		return nil, secrets.ErrNotFound
+	}
+	if e, isCached := c.entries[id]; isCached && e.dirty {
+		cachedSec, err := c.Memory.GetSecret(ctx, e.cacheId)
+		if err != nil {
+			return nil, err
+		}
+		return secrets.NewSingleFromSecret(cachedSec, secrets.WithID(id)), nil
+	}
+
+	c.recordSecret(sec)
+	c.saveSnapshot()
+
 	return c.touchSecret(ctx, sec, "", id)
 }
 
-func (c *Cache) touchSecretsFromCache(ctx context.Context, cachedSecs []secrets.Secret) ([]secrets.Secret, error) {
+// freshFromCache returns the cached secrets with their original IDs, dropping
+// any that have expired. The second value returns false if any of the secrets
+// had expired. The lock must be held.
+func (c *Cache) freshFromCache(ctx context.Context, cachedSecs []secrets.Secret) ([]secrets.Secret, bool, error) {
 	var (
-		err     error
-		newSecs = make([]secrets.Secret, len(cachedSecs))
+		allFresh = true
+		newSecs  = make([]secrets.Secret, 0, len(cachedSecs))
 	)
 
-	for i, sec := range cachedSecs {
+	for _, sec := range cachedSecs {
 		id := c.cacheToOrigId[sec.ID()]
-		fixedSec := secrets.NewSingleFromSecret(sec, secrets.WithID(id))
-		newSecs[i], err = c.touchSecret(ctx, fixedSec, sec.ID(), id)
-		if err != nil {
-			return nil, err
+		if c.lookup(ctx, id) == nil {
+			allFresh = false
+			continue
 		}
-	}
 
-	return newSecs, nil
-}
+		var fixedSec secrets.Secret = secrets.NewSingleFromSecret(sec, secrets.WithID(id))
+		if c.touchOnRead {
+			var err error
+			fixedSec, err = c.touchSecret(ctx, fixedSec, sec.ID(), id)
+			if err != nil {
+				return nil, false, err
+			}
+		}
 
-func (c *Cache) rewriteCachedIds(cachedSecs []secrets.Secret) []secrets.Secret {
-	origSecs := make([]secrets.Secret, len(cachedSecs))
-	for i, sec := range cachedSecs {
-		id := c.cacheToOrigId[sec.ID()]
-		origSecs[i] = secrets.NewSingleFromSecret(sec, secrets.WithID(id))
+		newSecs = append(newSecs, fixedSec)
 	}
-	return origSecs
+
+	return newSecs, allFresh, nil
 }
 
-func (c *Cache) touchSecretsFromOrig(ctx context.Context, secs []secrets.Secret) ([]secrets.Secret, error) {
+// touchSecretsFromOrig caches the secrets fetched from the wrapped keeper,
+// replacing any existing entries for them. Secrets with writes not yet written
+// back are returned from the cache instead, including new secrets with the
+// given name. The lock must be held.
+func (c *Cache) touchSecretsFromOrig(
+	ctx context.Context,
+	name string,
+	secs []secrets.Secret,
+) ([]secrets.Secret, error) {
+	newSecs := make([]secrets.Secret, 0, len(secs))
 	for _, sec := range secs {
+		if _, isDeleted := c.deleted[sec.ID()]; isDeleted {
+			continue
+		}
+
+		if e, isCached := c.entries[sec.ID()]; isCached && e.dirty {
+			continue
+		}
+
 		_, err := c.touchSecret(ctx, sec, "", sec.ID())
 		if err != nil {
 			return nil, err
 		}
+
+		c.recordSecret(sec)
+		newSecs = append(newSecs, sec)
+	}
+
+	c.saveSnapshot()
+
+	cachedSecs, _ := c.Memory.GetSecretsByName(ctx, name)
+	for _, sec := range cachedSecs {
+		id := c.cacheToOrigId[sec.ID()]
+		if e, isCached := c.entries[id]; isCached && e.dirty {
+			newSecs = append(newSecs, secrets.NewSingleFromSecret(sec, secrets.WithID(id)))
+		}
 	}
 
-	return secs, nil
+	return newSecs, nil
 }
 
 // GetSecretsByName returns the list of secrets with the given name from
 // the wrapped secret keeper on first call. Subsequent calls will return the
-// cached list of secrets. If the touchOnRead flag is set, the last modified
-// date of the secrets will be updated on each call.
+// cached list of secrets until any of them expire or are evicted. If the
+// touchOnRead flag is set, the last modified date of the secrets will be
+// updated on each call.
 func (c *Cache) GetSecretsByName(ctx context.Context, name string) ([]secrets.Secret, error) {
+	c.lock.Lock()
 	secs, _ := c.Memory.GetSecretsByName(ctx, name)
 	if len(secs) > 0 {
-		if c.touchOnRead {
-			return c.touchSecretsFromCache(ctx, secs)
+		fresh, allFresh, err := c.freshFromCache(ctx, secs)
+		if err != nil {
+			c.lock.Unlock()
+			return nil, err
 		}
 
-		return c.rewriteCachedIds(secs), nil
+		if allFresh {
+			c.lock.Unlock()
+			return fresh, nil
+		}
 	}
+	c.lock.Unlock()
 
 	secs, err := c.Keeper.GetSecretsByName(ctx, name)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	if err != nil {
-		return nil, err
+		return c.fromSnapshotByName(name, err)
 	}
 
-	return c.touchSecretsFromOrig(ctx, secs)
+	return c.touchSecretsFromOrig(ctx, name, secs)
 }
 
-// SetSecret cannot be used and always fails with an error.
-func (c *Cache) SetSecret(context.Context, secrets.Secret) (secrets.Secret, error) {
-	return nil, errors.New("caching secret keeper does not allow direct writes")
+// SetSecret saves the secret. In read-only mode, this always fails with
+// ErrReadOnly. In write-through mode, the secret is saved to the wrapped keeper
+// and the result is cached. In write-back mode, the secret is cached and the
+// write is queued. A new secret is given a temporary ID in that case.
+func (c *Cache) SetSecret(ctx context.Context, sec secrets.Secret) (secrets.Secret, error) {
+	switch c.writeMode {
+	case WriteThrough:
+		newSec, err := c.Keeper.SetSecret(ctx, sec)
+		if err != nil {
+			return nil, err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.invalidateLists()
+
+		if sec.ID() != "" && sec.ID() != newSec.ID() {
+			c.invalidate(ctx, sec.ID())
+			c.forgetSecret(sec.ID())
+		}
+
+		c.recordSecret(newSec)
+		c.saveSnapshot()
+
+		_, err = c.touchSecret(ctx, newSec, "", newSec.ID())
+		return newSec, err
+
+	case WriteBack:
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		id := sec.ID()
+		if id == "" {
+			id = makePendingID()
+		} else {
+			id = c.resolveID(id)
+		}
+
+		newSec := secrets.NewSingleFromSecret(sec, secrets.WithID(id))
+		err := c.enqueue(&pendingOp{
+			Kind:   opSet,
+			ID:     id,
+			Secret: memory.SecretMap(newSec),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return c.storeDirty(ctx, newSec, id)
+
+	default:
+		return nil, ErrReadOnly
+	}
 }
 
-// CopySecret cannot be used and always fails with an error.
-func (c *Cache) CopySecret(context.Context, string, string) (secrets.Secret, error) {
-	return nil, errors.New("caching secret keeper does not allow direct writes")
+// CopySecret copies the secret to a new location. In read-only mode, this
+// always fails with ErrReadOnly. In write-through mode, the secret is copied in
+// the wrapped keeper and the copy is cached. In write-back mode, the copy is
+// cached with a temporary ID and the copy is queued.
+func (c *Cache) CopySecret(ctx context.Context, id, location string) (secrets.Secret, error) {
+	switch c.writeMode {
+	case WriteThrough:
+		newSec, err := c.Keeper.CopySecret(ctx, id, location)
+		if err != nil {
+			return nil, err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.invalidateLists()
+
+		c.recordSecret(newSec)
+		c.saveSnapshot()
+
+		_, err = c.touchSecret(ctx, newSec, "", newSec.ID())
+		return newSec, err
+
+	case WriteBack:
+		sec, err := c.GetSecret(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		newId := makePendingID()
+		err = c.enqueue(&pendingOp{
+			Kind:     opCopy,
+			ID:       c.resolveID(id),
+			ResultID: newId,
+			Location: location,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		newSec := secrets.NewSingleFromSecret(sec,
+			secrets.WithID(newId),
+			secrets.WithLocation(location))
+		return c.storeDirty(ctx, newSec, newId)
+
+	default:
+		return nil, ErrReadOnly
+	}
 }
 
-// MoveSecret cannot be used and always fails with an error.
-func (c *Cache) MoveSecret(context.Context, string, string) (secrets.Secret, error) {
-	return nil, errors.New("caching secret keeper does not allow direct writes")
+// MoveSecret moves the secret to a new location. In read-only mode, this
+// always fails with ErrReadOnly. In write-through mode, the secret is moved in
+// the wrapped keeper and the result is cached. In write-back mode, the moved
+// secret is cached and the move is queued.
+func (c *Cache) MoveSecret(ctx context.Context, id, location string) (secrets.Secret, error) {
+	switch c.writeMode {
+	case WriteThrough:
+		newSec, err := c.Keeper.MoveSecret(ctx, id, location)
+		if err != nil {
+			return nil, err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.invalidateLists()
+
+		if newSec.ID() != id {
+			c.invalidate(ctx, id)
+			c.forgetSecret(id)
+		}
+
+		c.recordSecret(newSec)
+		c.saveSnapshot()
+
+		_, err = c.touchSecret(ctx, newSec, "", newSec.ID())
+		return newSec, err
+
+	case WriteBack:
+		sec, err := c.GetSecret(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		id = c.resolveID(id)
+		err = c.enqueue(&pendingOp{
+			Kind:     opMove,
+			ID:       id,
+			Location: location,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		newSec := secrets.NewSingleFromSecret(sec,
+			secrets.WithID(id),
+			secrets.WithLocation(location))
+		return c.storeDirty(ctx, newSec, id)
+
+	default:
+		return nil, ErrReadOnly
+	}
 }
 
-// DeleteSecret deletes the secret with the given ID from the cache only. This
-// does not delete the secret from the wrapped secret keeper.
+// DeleteSecret deletes the secret with the given ID. In read-only mode, the
+// secret is removed from the cache only and is not deleted from the wrapped
+// secret keeper. In write-through mode, the secret is deleted from the wrapped
+// keeper as well. In write-back mode, the secret is removed from the cache and
+// the delete is queued.
 func (c *Cache) DeleteSecret(ctx context.Context, id string) error {
-	if cacheId, isCached := c.origToCacheId[id]; isCached {
-		err := c.Memory.DeleteSecret(ctx, cacheId)
+	switch c.writeMode {
+	case WriteThrough:
+		if err := c.Keeper.DeleteSecret(ctx, id); err != nil {
+			return err
+		}
+
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		c.invalidateLists()
+
+		c.invalidate(ctx, id)
+		c.forgetSecret(id)
+		c.saveSnapshot()
+
+		return nil
+
+	case WriteBack:
+		c.lock.Lock()
+		defer c.lock.Unlock()
+
+		id = c.resolveID(id)
+		err := c.enqueue(&pendingOp{
+			Kind: opDelete,
+			ID:   id,
+		})
 		if err != nil {
 			return err
 		}
 
-		delete(c.origToCacheId, id)
-		delete(c.cacheToOrigId, cacheId)
+		c.invalidate(ctx, id)
+		c.deleted[id] = struct{}{}
+		return nil
+
+	default:
+		c.Invalidate(ctx, id)
+		return nil
 	}
-	return nil
 }
@@ -1,12 +1,25 @@
 package cache_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/zostay/fssafe"
 
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
 	"github.com/zostay/ghost/pkg/secrets"
 	"github.com/zostay/ghost/pkg/secrets/cache"
 	"github.com/zostay/ghost/pkg/secrets/keepertest"
@@ -132,3 +145,971 @@ func TestCache_DeleteSecret(t *testing.T) {
 	assert.Equal(t, s1.Name(), s3.Name())
 	assert.Equal(t, s1.Password(), s3.Password())
 }
+
+func TestCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	c, err := cache.New(m, false, cache.WithTTL(20*time.Millisecond))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+
+	s2, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s2.Password())
+
+	_, err = m.SetSecret(ctx, secrets.SetPassword(s1, "two"))
+	require.NoError(t, err)
+
+	s3, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s3.Password(), "still cached")
+
+	time.Sleep(40 * time.Millisecond)
+
+	s4, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "two", s4.Password(), "expired and fetched again")
+
+	s5s, err := c.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, s5s, 1)
+	assert.Equal(t, "two", s5s[0].Password())
+}
+
+func TestCache_MaxEntries(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	c, err := cache.New(m, false, cache.WithMaxEntries(2))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	ids := make([]string, 3)
+	for i := range ids {
+		sec, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "test"))
+		require.NoError(t, err)
+		ids[i] = sec.ID()
+	}
+
+	_, err = c.GetSecret(ctx, ids[0])
+	require.NoError(t, err)
+	_, err = c.GetSecret(ctx, ids[1])
+	require.NoError(t, err)
+
+	// touch the first so the second is the least recently used
+	_, err = c.GetSecret(ctx, ids[0])
+	require.NoError(t, err)
+
+	_, err = c.GetSecret(ctx, ids[2])
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Len())
+
+	for _, id := range ids {
+		err = m.DeleteSecret(ctx, id)
+		require.NoError(t, err)
+	}
+
+	_, err = c.GetSecret(ctx, ids[0])
+	assert.NoError(t, err, "recently used is still cached")
+
+	_, err = c.GetSecret(ctx, ids[1])
+	assert.ErrorIs(t, err, secrets.ErrNotFound, "least recently used was evicted")
+
+	_, err = c.GetSecret(ctx, ids[2])
+	assert.NoError(t, err, "newest is still cached")
+}
+
+// countingKeeper counts the calls to GetSecret and the list methods made on
+// the wrapped keeper.
+type countingKeeper struct {
+	*memory.Memory
+	gets  int
+	lists int
+}
+
+func (k *countingKeeper) ListLocations(ctx context.Context) ([]string, error) {
+	k.lists++
+	return k.Memory.ListLocations(ctx)
+}
+
+func (k *countingKeeper) ListSecrets(ctx context.Context, loc string) ([]string, error) {
+	k.lists++
+	return k.Memory.ListSecrets(ctx, loc)
+}
+
+func (k *countingKeeper) GetSecret(ctx context.Context, id string) (secrets.Secret, error) {
+	k.gets++
+	return k.Memory.GetSecret(ctx, id)
+}
+
+func TestCache_NegativeTTL(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &countingKeeper{Memory: m}
+	c, err := cache.New(k, false, cache.WithNegativeTTL(time.Hour))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = c.GetSecret(ctx, "missing")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+	assert.Equal(t, 1, k.gets)
+
+	_, err = c.GetSecret(ctx, "missing")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+	assert.Equal(t, 1, k.gets, "not found is remembered")
+
+	c.Invalidate(ctx, "missing")
+
+	_, err = c.GetSecret(ctx, "missing")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+	assert.Equal(t, 2, k.gets, "invalidate forgets not found")
+}
+
+func TestCache_Flush(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	c, err := cache.New(m, false)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+
+	_, err = c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.Len())
+
+	_, err = m.SetSecret(ctx, secrets.SetPassword(s1, "two"))
+	require.NoError(t, err)
+
+	c.Flush(ctx)
+	assert.Equal(t, 0, c.Len())
+
+	s2, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "two", s2.Password())
+}
+
+func TestCache_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	c, err := cache.New(m, false)
+	require.NoError(t, err)
+
+	_, err = c.SetSecret(context.Background(), secrets.NewSecret("test", "test", "test"))
+	assert.ErrorIs(t, err, cache.ErrReadOnly)
+}
+
+func TestCache_WriteThrough(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &countingKeeper{Memory: m}
+	c, err := cache.New(k, false, cache.WithWriteThrough())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := c.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+	require.NotEmpty(t, s1.ID())
+
+	s2, err := m.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s2.Password())
+
+	s3, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s3.Password())
+	assert.Equal(t, 0, k.gets, "the write was cached")
+
+	s4, err := c.MoveSecret(ctx, s1.ID(), "elsewhere")
+	require.NoError(t, err)
+	assert.Equal(t, "elsewhere", s4.Location())
+
+	err = c.DeleteSecret(ctx, s1.ID())
+	require.NoError(t, err)
+
+	_, err = m.GetSecret(ctx, s1.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+// flakyKeeper fails every write while failing is set, with errFlaky unless
+// failWith is set.
+type flakyKeeper struct {
+	*memory.Memory
+	failing  bool
+	failWith error
+}
+
+var errFlaky = errors.New("rate limited")
+
+func (k *flakyKeeper) fail() error {
+	if k.failWith != nil {
+		return k.failWith
+	}
+	return errFlaky
+}
+
+func (k *flakyKeeper) SetSecret(ctx context.Context, sec secrets.Secret) (secrets.Secret, error) {
+	if k.failing {
+		return nil, k.fail()
+	}
+	return k.Memory.SetSecret(ctx, sec)
+}
+
+func (k *flakyKeeper) DeleteSecret(ctx context.Context, id string) error {
+	if k.failing {
+		return k.fail()
+	}
+	return k.Memory.DeleteSecret(ctx, id)
+}
+
+func TestCache_WriteBack(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &flakyKeeper{Memory: m, failing: true}
+	c, err := cache.New(k, false,
+		cache.WithWriteBack(nil, nil),
+		cache.WithRetryBackoff(time.Millisecond, time.Millisecond))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := c.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+	require.NotEmpty(t, s1.ID())
+	assert.Equal(t, 1, c.PendingWrites())
+
+	s2, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s2.Password())
+
+	secs, err := c.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, s1.ID(), secs[0].ID())
+
+	locs, err := m.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, locs, "nothing written yet")
+
+	err = c.FlushWrites(ctx)
+	assert.ErrorIs(t, err, errFlaky)
+	assert.Equal(t, 1, c.PendingWrites())
+
+	k.failing = false
+	err = c.FlushWrites(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.PendingWrites())
+
+	origs, err := m.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, origs, 1)
+	assert.Equal(t, "one", origs[0].Password())
+
+	s3, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err, "the temporary ID still works")
+	assert.Equal(t, origs[0].ID(), s3.ID())
+
+	err = c.DeleteSecret(ctx, s1.ID())
+	require.NoError(t, err)
+
+	_, err = c.GetSecret(ctx, origs[0].ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	c.StartWriteBack(ctx)
+	assert.Eventually(t, func() bool {
+		return c.PendingWrites() == 0
+	}, time.Second, time.Millisecond)
+
+	_, err = m.GetSecret(ctx, origs[0].ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+func TestCache_WriteBackMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	k := &flakyKeeper{Memory: m, failing: true}
+	c, err := cache.New(k, false,
+		cache.WithWriteBack(nil, nil),
+		cache.WithMaxAttempts(2),
+		cache.WithLogger(log.New(&logs, "", 0)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := c.SetSecret(ctx, secrets.NewSecret("one", "test", "one"))
+	require.NoError(t, err)
+	_, err = c.SetSecret(ctx, secrets.NewSecret("two", "test", "two"))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, c.FlushWrites(ctx), errFlaky)
+	assert.Equal(t, 2, c.PendingWrites())
+	assert.Empty(t, logs.String())
+
+	// the first write is dropped, so the second is no longer held up by it
+	assert.ErrorIs(t, c.FlushWrites(ctx), errFlaky)
+	assert.Equal(t, 1, c.PendingWrites())
+	assert.Contains(t, logs.String(), "giving up on set of secret")
+	assert.Contains(t, logs.String(), "after 2 attempts: rate limited")
+
+	_, err = c.GetSecret(ctx, s1.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound, "the dropped write is forgotten")
+
+	k.failing = false
+	require.NoError(t, c.FlushWrites(ctx))
+	assert.Equal(t, 0, c.PendingWrites())
+
+	secs, err := m.GetSecretsByName(ctx, "two")
+	require.NoError(t, err)
+	assert.Len(t, secs, 1)
+
+	// a keeper that cannot be reached is waited for however long it takes
+	k.failing, k.failWith = true, errOffline
+	_, err = c.SetSecret(ctx, secrets.NewSecret("three", "test", "three"))
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		assert.ErrorIs(t, c.FlushWrites(ctx), errOffline)
+	}
+	assert.Equal(t, 1, c.PendingWrites())
+}
+
+func TestCache_WriteBackResent(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	orig, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "old",
+		secrets.WithLocation("here")))
+	require.NoError(t, err)
+
+	// a queue saved after the secret was sent, but before it was written back
+	queuePath := filepath.Join(t.TempDir(), "queue.yaml")
+	require.NoError(t, os.WriteFile(queuePath, []byte(`version: "1"
+ops:
+  - kind: set
+    id: pending-01J00000000000000000000000
+    sent: true
+    secret:
+      Name: test
+      Username: test
+      Password: new
+      Location: here
+`), 0o600))
+
+	ls := fssafe.NewFileSystemLoaderSaver(queuePath)
+	c, err := cache.New(m, false, cache.WithWriteBack(ls, []byte("secret")))
+	require.NoError(t, err)
+	require.NoError(t, c.FlushWrites(ctx))
+
+	secs, err := m.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 1, "the secret sent before is not sent again")
+	assert.Equal(t, orig.ID(), secs[0].ID())
+	assert.Equal(t, "new", secs[0].Password())
+}
+
+func TestCache_WriteBackPersisted(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &flakyKeeper{Memory: m, failing: true}
+	ls := fssafe.NewTestingLoaderSaver()
+
+	c1, err := cache.New(k, false, cache.WithWriteBack(ls, []byte("secret")))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := c1.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+
+	k.failing = false
+	c2, err := cache.New(k, false, cache.WithWriteBack(ls, []byte("secret")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, c2.PendingWrites())
+
+	s2, err := c2.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s2.Password())
+
+	err = c2.FlushWrites(ctx)
+	require.NoError(t, err)
+
+	origs, err := m.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, origs, 1)
+	assert.Equal(t, "one", origs[0].Password())
+}
+
+func TestBuilder_WriteBackQueue(t *testing.T) {
+	t.Parallel()
+
+	queuePath := filepath.Join(t.TempDir(), "queue.yaml")
+
+	c := config.New()
+	c.Keepers["mem"] = config.KeeperConfig{"type": memory.ConfigType}
+	c.Keepers["wb"] = config.KeeperConfig{
+		"type":                cache.ConfigType,
+		"keeper":              "mem",
+		"write_mode":          "write-back",
+		"snapshot_passphrase": "secret",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ctx = keeper.WithBuilder(ctx, c)
+
+	// the queue would be lost on exit without somewhere to save it
+	_, err := keeper.Build(ctx, "wb")
+	assert.Error(t, err)
+
+	c.Keepers["wb"]["queue_path"] = queuePath
+	kpr, err := keeper.Build(ctx, "wb")
+	require.NoError(t, err)
+
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+
+	fi, err := os.Stat(queuePath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	queue, err := os.ReadFile(queuePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(queue), "one", "the queue is encrypted")
+
+	// another cache using the same queue writes through instead
+	kpr2, err := keeper.Build(ctx, "wb")
+	require.NoError(t, err)
+
+	sec, err := kpr2.SetSecret(ctx, secrets.NewSecret("test", "test", "two"))
+	require.NoError(t, err)
+	assert.NotContains(t, sec.ID(), "pending-", "written to the wrapped keeper at once")
+
+	secs, err := kpr2.(*cache.Cache).Unwrap()[0].GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, "two", secs[0].Password())
+}
+
+func TestCache_WriteBackEncrypted(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &flakyKeeper{Memory: m, failing: true}
+	ls := fssafe.NewTestingLoaderSaver()
+
+	_, err = cache.New(k, false, cache.WithWriteBack(ls, nil))
+	assert.ErrorIs(t, err, cache.ErrQueuePassphrase)
+
+	c, err := cache.New(k, false, cache.WithWriteBack(ls, []byte("secret")))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = c.SetSecret(ctx, secrets.NewSecret("test", "test", "hunter2",
+		secrets.WithField("pin", "1234")))
+	require.NoError(t, err)
+
+	require.NotEmpty(t, ls.Buffers())
+	queue := ls.Buffers()[len(ls.Buffers())-1].String()
+	assert.NotContains(t, queue, "hunter2")
+	assert.NotContains(t, queue, "1234")
+
+	_, err = cache.New(k, false, cache.WithWriteBack(ls, []byte("wrong")))
+	assert.ErrorIs(t, err, cache.ErrQueueDecrypt)
+}
+
+func TestCache_WriteBackPlainTextQueue(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	// a queue saved by an earlier version of ghost
+	queuePath := filepath.Join(t.TempDir(), "queue.yaml")
+	require.NoError(t, os.WriteFile(queuePath, []byte(`version: "1"
+ops:
+  - kind: set
+    id: pending-01J00000000000000000000000
+    secret:
+      Name: test
+      Username: test
+      Password: hunter2
+`), 0o600))
+
+	ls := fssafe.NewFileSystemLoaderSaver(queuePath)
+	c, err := cache.New(m, false, cache.WithWriteBack(ls, []byte("secret")))
+	require.NoError(t, err)
+	assert.Equal(t, 1, c.PendingWrites())
+
+	queue, err := os.ReadFile(queuePath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(queue), "hunter2", "the queue is saved again encrypted")
+
+	ctx := context.Background()
+	require.NoError(t, c.FlushWrites(ctx))
+
+	origs, err := m.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, origs, 1)
+	assert.Equal(t, "hunter2", origs[0].Password())
+}
+
+func TestCache_ListSecrets(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &countingKeeper{Memory: m}
+	c, err := cache.New(k, false, cache.WithWriteThrough())
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("one", "test", "test",
+		secrets.WithLocation("here")))
+	require.NoError(t, err)
+
+	locs, err := c.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"here"}, locs)
+
+	locs, err = c.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"here"}, locs)
+	assert.Equal(t, 1, k.lists, "locations are cached")
+
+	ids, err := c.ListSecrets(ctx, "here")
+	require.NoError(t, err)
+	assert.Equal(t, []string{s1.ID()}, ids)
+
+	ids, err = c.ListSecrets(ctx, "here")
+	require.NoError(t, err)
+	assert.Equal(t, []string{s1.ID()}, ids)
+	assert.Equal(t, 2, k.lists, "secrets are cached")
+
+	s2, err := c.SetSecret(ctx, secrets.NewSecret("two", "test", "test",
+		secrets.WithLocation("here")))
+	require.NoError(t, err)
+
+	ids, err = c.ListSecrets(ctx, "here")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{s1.ID(), s2.ID()}, ids, "writes clear the cached lists")
+}
+
+func TestCache_ListSecretsWriteBack(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	c, err := cache.New(m, false, cache.WithWriteBack(nil, nil))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("one", "test", "test",
+		secrets.WithLocation("here")))
+	require.NoError(t, err)
+
+	s2, err := c.SetSecret(ctx, secrets.NewSecret("two", "test", "test",
+		secrets.WithLocation("there")))
+	require.NoError(t, err)
+
+	locs, err := c.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"here", "there"}, locs)
+
+	ids, err := c.ListSecrets(ctx, "there")
+	require.NoError(t, err)
+	assert.Equal(t, []string{s2.ID()}, ids)
+
+	err = c.DeleteSecret(ctx, s1.ID())
+	require.NoError(t, err)
+
+	ids, err = c.ListSecrets(ctx, "here")
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestCache_WarmUp(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	k := &countingKeeper{Memory: m}
+	c, err := cache.New(k, false)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	var ids []string
+	for _, loc := range []string{"a", "b"} {
+		for _, name := range []string{"one", "two", "three"} {
+			sec, err := m.SetSecret(ctx, secrets.NewSecret(name, "test", "test",
+				secrets.WithLocation(loc)))
+			require.NoError(t, err)
+			ids = append(ids, sec.ID())
+		}
+	}
+
+	n, err := c.WarmUp(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
+	assert.Equal(t, 6, c.Len())
+	assert.Equal(t, 6, k.gets)
+	assert.Equal(t, 3, k.lists)
+
+	for _, id := range ids {
+		_, err := c.GetSecret(ctx, id)
+		require.NoError(t, err)
+	}
+
+	_, err = c.ListSecrets(ctx, "a")
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, k.gets, "no gets after warm up")
+	assert.Equal(t, 3, k.lists, "no lists after warm up")
+}
+
+// offlineKeeper fails every read with a network error.
+type offlineKeeper struct {
+	*memory.Memory
+}
+
+var errOffline = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+func (k *offlineKeeper) ListLocations(context.Context) ([]string, error) {
+	return nil, errOffline
+}
+
+func (k *offlineKeeper) GetSecret(context.Context, string) (secrets.Secret, error) {
+	return nil, errOffline
+}
+
+func (k *offlineKeeper) GetSecretsByName(context.Context, string) ([]secrets.Secret, error) {
+	return nil, errOffline
+}
+
+func TestCache_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ls := fssafe.NewTestingLoaderSaver()
+	passphrase := []byte("secret")
+
+	c1, err := cache.New(m, false, cache.WithSnapshot(ls, passphrase))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "one",
+		secrets.WithLocation("here"),
+		secrets.WithAttachment("key.pem", []byte("private key"))))
+	require.NoError(t, err)
+
+	n, err := c1.WarmUp(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	s2, err := c1.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	_, isStale := secrets.IsStale(s2)
+	assert.False(t, isStale)
+
+	require.NotEmpty(t, ls.Buffers())
+	assert.NotContains(t, ls.Buffers()[len(ls.Buffers())-1].String(), "one",
+		"the snapshot is encrypted")
+
+	c2, err := cache.New(&offlineKeeper{m}, false, cache.WithSnapshot(ls, passphrase))
+	require.NoError(t, err)
+
+	s3, err := c2.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", s3.Password())
+	assert.Equal(t, "here", s3.Location())
+	data, hasAttachment := secrets.GetAttachment(s3, "key.pem")
+	assert.True(t, hasAttachment)
+	assert.Equal(t, []byte("private key"), data)
+	since, isStale := secrets.IsStale(s3)
+	assert.True(t, isStale)
+	assert.WithinDuration(t, time.Now(), since, time.Minute)
+
+	secs, err := c2.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, s1.ID(), secs[0].ID())
+
+	locs, err := c2.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"here"}, locs)
+
+	_, err = c2.GetSecret(ctx, "missing")
+	assert.ErrorIs(t, err, errOffline)
+
+	_, err = cache.New(m, false, cache.WithSnapshot(ls, []byte("wrong")))
+	assert.ErrorIs(t, err, cache.ErrSnapshotDecrypt)
+}
+
+// countingSaver counts the snapshots saved with it and may fail every save.
+type countingSaver struct {
+	fssafe.LoaderSaver
+
+	mu    sync.Mutex
+	saved int
+	fail  bool
+}
+
+func (s *countingSaver) Saver() (io.WriteCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.fail {
+		return nil, errors.New("disk is full")
+	}
+
+	w, err := s.LoaderSaver.Saver()
+	if err != nil {
+		return nil, err
+	}
+	return &countingWriter{w, s}, nil
+}
+
+func (s *countingSaver) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saved
+}
+
+// countingWriter counts the save when it is finished.
+type countingWriter struct {
+	io.WriteCloser
+	s *countingSaver
+}
+
+func (w *countingWriter) Close() error {
+	err := w.WriteCloser.Close()
+
+	w.s.mu.Lock()
+	w.s.saved++
+	w.s.mu.Unlock()
+
+	return err
+}
+
+func TestCache_SnapshotSaver(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	var ids []string
+	for _, pw := range []string{"one", "two", "three"} {
+		sec, err := m.SetSecret(ctx, secrets.NewSecret(pw, "test", pw))
+		require.NoError(t, err)
+		ids = append(ids, sec.ID())
+	}
+
+	ls := &countingSaver{LoaderSaver: fssafe.NewTestingLoaderSaver()}
+	c, err := cache.New(m, false, cache.WithSnapshot(ls, []byte("secret")))
+	require.NoError(t, err)
+
+	// without the saver, every change is saved at once
+	_, err = c.GetSecret(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, 1, ls.count())
+
+	saverCtx, cancel := context.WithCancel(ctx)
+	c.StartSnapshotSaver(saverCtx, 50*time.Millisecond)
+
+	// a burst of changes is saved together, later
+	_, err = c.GetSecret(ctx, ids[1])
+	require.NoError(t, err)
+	_, err = c.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, ls.count())
+
+	assert.Eventually(t, func() bool { return ls.count() == 2 },
+		time.Second, 10*time.Millisecond)
+
+	// nothing is saved without a change
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, ls.count())
+
+	// changes left are saved when the saver stops
+	_, err = c.GetSecret(ctx, ids[2])
+	require.NoError(t, err)
+	cancel()
+	assert.Eventually(t, func() bool { return ls.count() == 3 },
+		time.Second, 10*time.Millisecond)
+
+	offline, err := cache.New(&offlineKeeper{m}, false,
+		cache.WithSnapshot(ls, []byte("secret")))
+	require.NoError(t, err)
+	for i, id := range ids {
+		sec, err := offline.GetSecret(ctx, id)
+		require.NoError(t, err, "secret %d is in the snapshot", i+1)
+		assert.Equal(t, id, sec.ID())
+	}
+}
+
+func TestCache_SnapshotFailure(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	sec, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	ls := &countingSaver{LoaderSaver: fssafe.NewTestingLoaderSaver(), fail: true}
+	c, err := cache.New(m, false,
+		cache.WithSnapshot(ls, []byte("secret")),
+		cache.WithLogger(log.New(&buf, "", 0)))
+	require.NoError(t, err)
+
+	// the read succeeds, but the failed save is logged
+	got, err := c.GetSecret(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "one", got.Password())
+	assert.Contains(t, buf.String(), "unable to save cache snapshot: disk is full")
+
+	// the failed save is tried again
+	_, err = c.WarmUp(ctx, 1)
+	assert.ErrorContains(t, err, "disk is full")
+
+	ls.mu.Lock()
+	ls.fail = false
+	ls.mu.Unlock()
+	require.NoError(t, c.SaveSnapshot())
+	assert.Equal(t, 1, ls.count())
+
+	require.NoError(t, c.SaveSnapshot())
+	assert.Equal(t, 1, ls.count(), "nothing left to save")
+}
+
+func TestCache_Trash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	m, err := memory.New(memory.WithTrash())
+	require.NoError(t, err)
+
+	c, err := cache.New(m, false, cache.WithWriteThrough(), cache.WithNegativeTTL(time.Hour))
+	require.NoError(t, err)
+
+	s1, err := c.SetSecret(ctx, secrets.NewSecret("test", "test", "test"))
+	require.NoError(t, err)
+
+	require.NoError(t, c.DeleteSecret(ctx, s1.ID()))
+	_, err = c.GetSecret(ctx, s1.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	trash, err := c.ListTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, s1.ID(), trash[0].ID())
+
+	// restoring forgets the secret was missing
+	_, err = c.RestoreFromTrash(ctx, s1.ID())
+	require.NoError(t, err)
+	s2, err := c.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "test", s2.Password())
+
+	plain, err := memory.New()
+	require.NoError(t, err)
+	c, err = cache.New(plain, false)
+	require.NoError(t, err)
+	_, err = c.ListTrash(ctx)
+	assert.ErrorIs(t, err, secrets.ErrNoTrash)
+}
+
+func TestCache_TrashWriteMode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	m, err := memory.New(memory.WithTrash())
+	require.NoError(t, err)
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("test", "test", "test"))
+	require.NoError(t, err)
+	require.NoError(t, m.DeleteSecret(ctx, s1.ID()))
+
+	c, err := cache.New(m, false)
+	require.NoError(t, err)
+
+	_, err = c.RestoreFromTrash(ctx, s1.ID())
+	assert.ErrorIs(t, err, cache.ErrReadOnly)
+	assert.ErrorIs(t, c.EmptyTrash(ctx), cache.ErrReadOnly)
+
+	trash, err := m.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Len(t, trash, 1, "read-only cache leaves the trash alone")
+
+	c, err = cache.New(m, false, cache.WithWriteBack(nil, nil))
+	require.NoError(t, err)
+
+	_, err = c.RestoreFromTrash(ctx, s1.ID())
+	require.NoError(t, err)
+
+	// the queued delete is written before the trash is emptied
+	require.NoError(t, c.DeleteSecret(ctx, s1.ID()))
+	assert.Equal(t, 1, c.PendingWrites())
+	require.NoError(t, c.EmptyTrash(ctx))
+	assert.Equal(t, 0, c.PendingWrites())
+
+	trash, err = m.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+	_, err = m.GetSecret(ctx, s1.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
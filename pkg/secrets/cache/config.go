@@ -2,12 +2,20 @@ package cache
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
+	"time"
 
+	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+	"github.com/zalando/go-keyring"
+	"github.com/zostay/fssafe"
 
 	"github.com/zostay/ghost/pkg/config"
 	"github.com/zostay/ghost/pkg/keeper"
@@ -26,6 +34,78 @@ type Config struct {
 	// TouchOnRead will cause the last modified date of secrets to be updated
 	// on GetSecret* calls.
 	TouchOnRead bool `mapstructure:"touch_on_read"`
+
+	// TTL is how long a cached secret is used before it is fetched from the
+	// wrapped keeper again. Zero means forever.
+	TTL time.Duration `mapstructure:"ttl"`
+
+	// MaxEntries limits the number of secrets cached. The least recently used
+	// secrets are evicted first. Zero means unlimited.
+	MaxEntries int `mapstructure:"max_entries"`
+
+	// NegativeTTL is how long to remember that a secret was not found in the
+	// wrapped keeper. Zero means not found is never cached.
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+
+	// WriteMode is one of "read-only", "write-through", or "write-back". The
+	// default is "read-only".
+	WriteMode string `mapstructure:"write_mode"`
+
+	// QueuePath is the file to save queued writes to in write-back mode. It is
+	// required in that mode, since otherwise writes still queued when ghost
+	// exits would be lost. The file is encrypted like the snapshot.
+	QueuePath string `mapstructure:"queue_path"`
+
+	// RetryInterval is the delay before retrying a failed queued write. It
+	// doubles after each failure. The default is 1s.
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+
+	// MaxRetryInterval is the longest delay between retries of a failed
+	// queued write. The default is 5m.
+	MaxRetryInterval time.Duration `mapstructure:"max_retry_interval"`
+
+	// MaxAttempts is the number of times a queued write is attempted before
+	// it is dropped, unless the wrapped keeper cannot be reached. The default
+	// is 10. A negative number means writes are never dropped.
+	MaxAttempts int `mapstructure:"max_attempts"`
+
+	// SnapshotPath is the file to keep an encrypted snapshot of the cache in.
+	// The snapshot is used when the wrapped keeper cannot be reached.
+	SnapshotPath string `mapstructure:"snapshot_path"`
+
+	// SnapshotPassphrase is the passphrase to encrypt the snapshot and the
+	// write queue with. This may be a secret reference. If not set, a random
+	// key is generated for each file and kept in the system keyring.
+	SnapshotPassphrase string `mapstructure:"snapshot_passphrase"`
+}
+
+// snapshotKeyringService is the system keyring service used to store the key
+// for a cache snapshot or write queue when no passphrase is configured.
+const snapshotKeyringService = "ghost-cache-snapshot"
+
+// fileKey returns the key to use to encrypt the snapshot or write queue at the
+// given path. It is kept in the system keyring and generated the first time.
+func fileKey(path string) ([]byte, error) {
+	key, err := keyring.Get(snapshotKeyringService, path)
+	if err == nil {
+		return []byte(key), nil
+	}
+
+	if !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("unable to get cache key for %q from system keyring: %w", path, err)
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	key = base64.StdEncoding.EncodeToString(raw)
+	if err := keyring.Set(snapshotKeyringService, path, key); err != nil {
+		return nil, fmt.Errorf("unable to save cache key for %q to system keyring: %w", path, err)
+	}
+
+	return []byte(key), nil
 }
 
 // Builder creates a new cache keeper from the given configuration.
@@ -40,11 +120,104 @@ func Builder(ctx context.Context, c any) (secrets.Keeper, error) {
 		return nil, fmt.Errorf("unable to load keeper to cache %q: %w", cfg.Keeper, err)
 	}
 
-	return New(kpr, cfg.TouchOnRead)
+	writeMode, err := ParseWriteMode(cfg.WriteMode)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []Option{
+		WithTTL(cfg.TTL),
+		WithMaxEntries(cfg.MaxEntries),
+		WithNegativeTTL(cfg.NegativeTTL),
+	}
+
+	switch writeMode {
+	case WriteThrough:
+		opts = append(opts, WithWriteThrough())
+	case WriteBack:
+		var (
+			ls         fssafe.LoaderSaver
+			passphrase = []byte(cfg.SnapshotPassphrase)
+		)
+		if cfg.QueuePath != "" {
+			path, err := homedir.Expand(os.ExpandEnv(cfg.QueuePath))
+			if err != nil {
+				return nil, err
+			}
+
+			queue := newPrivateFile(path)
+			err = queue.lock()
+			if errors.Is(err, errQueueLocked) {
+				// another ghost process, usually the service, is writing the
+				// queue back, so write through rather than send its writes
+				// again
+				opts = append(opts, WithWriteThrough())
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("unable to lock cache write queue: %w", err)
+			}
+			ls = queue
+
+			if len(passphrase) == 0 {
+				passphrase, err = fileKey(path)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		retryInterval := DefaultRetryInterval
+		if cfg.RetryInterval > 0 {
+			retryInterval = cfg.RetryInterval
+		}
+
+		maxRetryInterval := DefaultMaxRetryInterval
+		if cfg.MaxRetryInterval > 0 {
+			maxRetryInterval = cfg.MaxRetryInterval
+		}
+
+		maxAttempts := DefaultMaxAttempts
+		if cfg.MaxAttempts != 0 {
+			maxAttempts = max(cfg.MaxAttempts, 0)
+		}
+
+		opts = append(opts,
+			WithWriteBack(ls, passphrase),
+			WithRetryBackoff(retryInterval, maxRetryInterval),
+			WithMaxAttempts(maxAttempts))
+	}
+
+	if cfg.SnapshotPath != "" {
+		path, err := homedir.Expand(os.ExpandEnv(cfg.SnapshotPath))
+		if err != nil {
+			return nil, err
+		}
+
+		passphrase := []byte(cfg.SnapshotPassphrase)
+		if len(passphrase) == 0 {
+			passphrase, err = fileKey(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		opts = append(opts,
+			WithSnapshot(fssafe.NewFileSystemLoaderSaver(path), passphrase))
+	}
+
+	cache, err := New(kpr, cfg.TouchOnRead, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.StartWriteBack(ctx)
+
+	return cache, nil
 }
 
 // Validate checks that the configuration is correct for the cache keeper.
-// It will check that the wrapped keeper to cache exists.
+// It will check that the wrapped keeper to cache exists, that the limits
+// are not negative, and that the write mode settings make sense.
 func Validate(ctx context.Context, c any) error {
 	cfg, isCache := c.(*Config)
 	if !isCache {
@@ -57,7 +230,44 @@ func Validate(ctx context.Context, c any) error {
 		errs.Append(fmt.Errorf("cache keeper %q does not exist", cfg.Keeper))
 	}
 
-	return nil
+	if cfg.TTL < 0 {
+		errs.Append(fmt.Errorf("cache ttl %v must not be negative", cfg.TTL))
+	}
+
+	if cfg.MaxEntries < 0 {
+		errs.Append(fmt.Errorf("cache max entries %d must not be negative", cfg.MaxEntries))
+	}
+
+	if cfg.NegativeTTL < 0 {
+		errs.Append(fmt.Errorf("cache negative ttl %v must not be negative", cfg.NegativeTTL))
+	}
+
+	writeMode, err := ParseWriteMode(cfg.WriteMode)
+	if err != nil {
+		errs.Append(err)
+	}
+
+	if writeMode != WriteBack {
+		if cfg.QueuePath != "" {
+			errs.Append(fmt.Errorf("cache queue path is set, but write mode is not write-back"))
+		}
+	} else if cfg.QueuePath == "" {
+		errs.Append(fmt.Errorf("cache write mode is write-back, but queue path is not set"))
+	}
+
+	if cfg.RetryInterval < 0 {
+		errs.Append(fmt.Errorf("cache retry interval %v must not be negative", cfg.RetryInterval))
+	}
+
+	if cfg.MaxRetryInterval < 0 {
+		errs.Append(fmt.Errorf("cache max retry interval %v must not be negative", cfg.MaxRetryInterval))
+	}
+
+	if cfg.SnapshotPassphrase != "" && cfg.SnapshotPath == "" && cfg.QueuePath == "" {
+		errs.Append(fmt.Errorf("cache snapshot passphrase is set, but neither snapshot path nor queue path is"))
+	}
+
+	return errs.Return()
 }
 
 // Print is the config printer for the cache keeper.
@@ -69,6 +279,40 @@ func Print(c any, w io.Writer) error {
 
 	fmt.Fprintln(w, "cache keeper:", cfg.Keeper)
 	fmt.Fprintln(w, "update cache time on read:", cfg.TouchOnRead)
+	if cfg.TTL > 0 {
+		fmt.Fprintln(w, "ttl:", cfg.TTL)
+	}
+	if cfg.MaxEntries > 0 {
+		fmt.Fprintln(w, "max entries:", cfg.MaxEntries)
+	}
+	if cfg.NegativeTTL > 0 {
+		fmt.Fprintln(w, "negative ttl:", cfg.NegativeTTL)
+	}
+	if cfg.WriteMode != "" {
+		fmt.Fprintln(w, "write mode:", cfg.WriteMode)
+	}
+	if cfg.QueuePath != "" {
+		fmt.Fprintln(w, "queue path:", cfg.QueuePath)
+	}
+	if cfg.RetryInterval > 0 {
+		fmt.Fprintln(w, "retry interval:", cfg.RetryInterval)
+	}
+	if cfg.MaxRetryInterval > 0 {
+		fmt.Fprintln(w, "max retry interval:", cfg.MaxRetryInterval)
+	}
+	if cfg.MaxAttempts != 0 {
+		fmt.Fprintln(w, "max attempts:", cfg.MaxAttempts)
+	}
+	if cfg.SnapshotPath != "" {
+		fmt.Fprintln(w, "snapshot path:", cfg.SnapshotPath)
+	}
+	if cfg.SnapshotPath != "" || cfg.QueuePath != "" {
+		passphrase := "<system keyring>"
+		if cfg.SnapshotPassphrase != "" {
+			passphrase = "<hidden>"
+		}
+		fmt.Fprintln(w, "snapshot passphrase:", passphrase)
+	}
 	return nil
 }
 
@@ -76,20 +320,70 @@ func init() {
 	var (
 		keeperName  string
 		touchOnRead bool
+		ttl         time.Duration
+		maxEntries  int
+		negativeTTL time.Duration
+
+		writeMode        string
+		queuePath        string
+		retryInterval    time.Duration
+		maxRetryInterval time.Duration
+		maxAttempts      int
+
+		snapshotPath string
 	)
 
 	cmd := plugin.CmdConfig{
 		Short: "Configure a caching keeper that wraps another keeper",
+		Fields: map[string]string{
+			"snapshot-passphrase": "The passphrase to encrypt the snapshot and write queue with",
+		},
 		Run: func(keeperName string, fields map[string]any) (config.KeeperConfig, error) {
-			return config.KeeperConfig{
+			kc := config.KeeperConfig{
 				"type":          ConfigType,
 				"keeper":        keeperName,
 				"touch_on_read": touchOnRead,
-			}, nil
+				"ttl":           ttl,
+				"max_entries":   maxEntries,
+				"negative_ttl":  negativeTTL,
+			}
+
+			if writeMode != "" {
+				kc["write_mode"] = writeMode
+			}
+			if queuePath != "" {
+				kc["queue_path"] = queuePath
+			}
+			if retryInterval > 0 {
+				kc["retry_interval"] = retryInterval
+			}
+			if maxRetryInterval > 0 {
+				kc["max_retry_interval"] = maxRetryInterval
+			}
+			if maxAttempts != 0 {
+				kc["max_attempts"] = maxAttempts
+			}
+			if snapshotPath != "" {
+				kc["snapshot_path"] = snapshotPath
+			}
+			if passphrase, ok := fields["snapshot-passphrase"]; ok {
+				kc["snapshot_passphrase"] = passphrase
+			}
+
+			return kc, nil
 		},
 		FlagInit: func(flags *pflag.FlagSet) error {
 			flags.StringVar(&keeperName, "keeper", "", "the name of the keeper to cache")
 			flags.BoolVar(&touchOnRead, "touch-on-read", false, "update the last modified date of secrets on read")
+			flags.DurationVar(&ttl, "ttl", 0, "how long to keep a cached secret before fetching it again (0 is forever)")
+			flags.IntVar(&maxEntries, "max-entries", 0, "the maximum number of secrets to cache (0 is unlimited)")
+			flags.DurationVar(&negativeTTL, "negative-ttl", 0, "how long to remember that a secret was not found (0 is never)")
+			flags.StringVar(&writeMode, "write-mode", "", "how to handle writes: read-only, write-through, or write-back")
+			flags.StringVar(&queuePath, "queue-path", "", "the file to save queued writes to (required in write-back mode)")
+			flags.DurationVar(&retryInterval, "retry-interval", 0, "the delay before retrying a failed queued write (default 1s)")
+			flags.DurationVar(&maxRetryInterval, "max-retry-interval", 0, "the longest delay between retries of a failed queued write (default 5m)")
+			flags.IntVar(&maxAttempts, "max-attempts", 0, "the number of attempts before a failed queued write is dropped (default 10, negative for never)")
+			flags.StringVar(&snapshotPath, "snapshot-path", "", "the file to keep an encrypted snapshot of the cache in for offline use")
 
 			if err := cobra.MarkFlagRequired(flags, "keeper"); err != nil {
 				return err
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const sealSaltSize = 16
+
+// sealer encrypts the files kept by the cache with AES-GCM, using a key derived
+// from a passphrase with argon2id. A sealed file is the magic, the salt, the
+// nonce, and then the ciphertext.
+type sealer struct {
+	magic      []byte
+	passphrase []byte
+	salt       []byte
+	aead       cipher.AEAD
+	errDecrypt error // wrapped by every failure to open a sealed file
+}
+
+// newSealer returns a sealer for files beginning with the magic.
+func newSealer(magic, passphrase []byte, errDecrypt error) *sealer {
+	return &sealer{
+		magic:      magic,
+		passphrase: passphrase,
+		errDecrypt: errDecrypt,
+	}
+}
+
+// initCipher derives the encryption key from the passphrase and salt.
+func (s *sealer) initCipher() error {
+	key := argon2.IDKey(s.passphrase, s.salt, 1, 64*1024, 4, 32)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	s.aead, err = cipher.NewGCM(block)
+	return err
+}
+
+// newSalt chooses a new salt for a file not yet saved.
+func (s *sealer) newSalt() error {
+	s.salt = make([]byte, sealSaltSize)
+	if _, err := rand.Read(s.salt); err != nil {
+		return err
+	}
+	return s.initCipher()
+}
+
+// sealed returns true if the raw file begins with the magic.
+func (s *sealer) sealed(raw []byte) bool {
+	return bytes.HasPrefix(raw, s.magic)
+}
+
+// open decrypts the raw file. The salt of the file is kept for sealing it
+// again.
+func (s *sealer) open(raw []byte) ([]byte, error) {
+	if !s.sealed(raw) {
+		return nil, fmt.Errorf("%w: unknown file format", s.errDecrypt)
+	}
+	raw = raw[len(s.magic):]
+
+	if len(raw) < sealSaltSize {
+		return nil, fmt.Errorf("%w: file is truncated", s.errDecrypt)
+	}
+	s.salt, raw = raw[:sealSaltSize], raw[sealSaltSize:]
+
+	if err := s.initCipher(); err != nil {
+		return nil, err
+	}
+
+	nonceSize := s.aead.NonceSize()
+	if len(raw) < nonceSize {
+		return nil, fmt.Errorf("%w: file is truncated", s.errDecrypt)
+	}
+
+	plain, err := s.aead.Open(nil, raw[:nonceSize], raw[nonceSize:], s.magic)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", s.errDecrypt, err)
+	}
+
+	return plain, nil
+}
+
+// seal encrypts the plain text as a file.
+func (s *sealer) seal(plain []byte) ([]byte, error) {
+	if s.aead == nil {
+		if err := s.newSalt(); err != nil {
+			return nil, err
+		}
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := make([]byte, 0, len(s.magic)+len(s.salt)+len(nonce)+len(plain)+s.aead.Overhead())
+	sealed = append(sealed, s.magic...)
+	sealed = append(sealed, s.salt...)
+	sealed = append(sealed, nonce...)
+	return s.aead.Seal(sealed, nonce, plain, s.magic), nil
+}
@@ -0,0 +1,449 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/zostay/fssafe"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// snapshotMagic begins every snapshot file.
+var snapshotMagic = []byte("ghost-cache-snapshot-1\n")
+
+// ErrSnapshotDecrypt is returned when the snapshot cannot be decrypted, most
+// likely because the passphrase has changed.
+var ErrSnapshotDecrypt = errors.New("unable to decrypt cache snapshot")
+
+// WithSnapshot keeps an encrypted snapshot of every secret and list fetched
+// from the wrapped keeper. The snapshot is saved with ls and loaded when the
+// cache is created. The encryption key is derived from the passphrase. When
+// the wrapped keeper cannot be reached due to a network error, the snapshot is
+// used instead. Secrets returned from the snapshot are marked with
+// secrets.NewStaleSecret.
+func WithSnapshot(ls fssafe.LoaderSaver, passphrase []byte) Option {
+	return func(c *Cache) {
+		c.snapshot = &snapshot{
+			ls:     ls,
+			sealer: newSealer(snapshotMagic, passphrase, ErrSnapshotDecrypt),
+			data:   newSnapshotData(),
+		}
+	}
+}
+
+// snapshotSecret is the form of a secret held in a snapshot.
+type snapshotSecret struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Username     string            `json:"username"`
+	Password     string            `json:"password"`
+	Type         string            `json:"type"`
+	Fields       map[string]string `json:"fields"`
+	LastModified time.Time         `json:"last_modified"`
+	Url          string            `json:"url"`
+	Location     string            `json:"location"`
+	Attachments  map[string][]byte `json:"attachments,omitempty"`
+
+	// Taken is when this copy was fetched from the wrapped keeper.
+	Taken time.Time `json:"taken"`
+}
+
+// snapshotList is a list of locations or secret IDs held in a snapshot.
+type snapshotList struct {
+	Names []string  `json:"names"`
+	Taken time.Time `json:"taken"`
+}
+
+// snapshotData is the decrypted content of a snapshot.
+type snapshotData struct {
+	Locations *snapshotList              `json:"locations"`
+	Lists     map[string]*snapshotList   `json:"lists"`
+	Secrets   map[string]*snapshotSecret `json:"secrets"`
+}
+
+// snapshot is the persistent, encrypted copy of the cache.
+type snapshot struct {
+	*sealer
+	ls   fssafe.LoaderSaver
+	data *snapshotData
+}
+
+func newSnapshotData() *snapshotData {
+	return &snapshotData{
+		Lists:   map[string]*snapshotList{},
+		Secrets: map[string]*snapshotSecret{},
+	}
+}
+
+// toSnapshotSecret converts the secret for storage in the snapshot.
+func toSnapshotSecret(sec secrets.Secret, taken time.Time) *snapshotSecret {
+	flds := make(map[string]string, len(sec.Fields()))
+	for k, v := range sec.Fields() {
+		flds[k] = v
+	}
+
+	var atts map[string][]byte
+	for _, name := range secrets.Attachments(sec) {
+		if data, hasAttachment := secrets.GetAttachment(sec, name); hasAttachment {
+			if atts == nil {
+				atts = map[string][]byte{}
+			}
+			atts[name] = data
+		}
+	}
+
+	return &snapshotSecret{
+		ID:           sec.ID(),
+		Name:         sec.Name(),
+		Username:     sec.Username(),
+		Password:     sec.Password(),
+		Type:         sec.Type(),
+		Fields:       flds,
+		LastModified: sec.LastModified(),
+		Url:          secrets.UrlString(sec),
+		Location:     sec.Location(),
+		Attachments:  atts,
+		Taken:        taken,
+	}
+}
+
+// secret returns the snapshot copy as a secret marked as stale.
+func (ss *snapshotSecret) secret() secrets.Secret {
+	opts := []secrets.SingleOption{
+		secrets.WithID(ss.ID),
+		secrets.WithType(ss.Type),
+		secrets.WithLastModified(ss.LastModified),
+		secrets.WithLocation(ss.Location),
+	}
+
+	if ss.Url != "" {
+		if u, err := url.Parse(ss.Url); err == nil {
+			opts = append(opts, secrets.WithUrl(u))
+		}
+	}
+
+	for k, v := range ss.Fields {
+		opts = append(opts, secrets.WithField(k, v))
+	}
+
+	for name, data := range ss.Attachments {
+		opts = append(opts, secrets.WithAttachment(name, data))
+	}
+
+	return secrets.NewStaleSecret(
+		secrets.NewSecret(ss.Name, ss.Username, ss.Password, opts...),
+		ss.Taken)
+}
+
+// load reads and decrypts the snapshot. If there is no snapshot yet, a new
+// salt is chosen for the first save.
+func (s *snapshot) load() error {
+	r, err := s.ls.Loader()
+	if err != nil {
+		// no snapshot saved yet
+		return s.newSalt()
+	}
+	defer func() { _ = r.Close() }()
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	plain, err := s.open(raw)
+	if err != nil {
+		return err
+	}
+
+	data := newSnapshotData()
+	if err := json.Unmarshal(plain, data); err != nil {
+		return err
+	}
+
+	s.data = data
+	return nil
+}
+
+// save encrypts and writes the encoded snapshot.
+func (s *snapshot) save(plain []byte) error {
+	sealed, err := s.seal(plain)
+	if err != nil {
+		return err
+	}
+
+	w, err := s.ls.Saver()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	_, err = w.Write(sealed)
+	return err
+}
+
+// isNetworkError returns true if the error indicates that the wrapped keeper
+// could not be reached.
+func isNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ENETUNREACH) ||
+		errors.Is(err, syscall.EHOSTUNREACH)
+}
+
+// recordSecret records the secret in the snapshot, but does not save it. The
+// lock must be held.
+func (c *Cache) recordSecret(sec secrets.Secret) {
+	if c.snapshot == nil {
+		return
+	}
+
+	c.snapshot.data.Secrets[sec.ID()] = toSnapshotSecret(sec, time.Now())
+	c.snapshotDirty = true
+}
+
+// forgetSecret removes the secret from the snapshot, but does not save it.
+// The lock must be held.
+func (c *Cache) forgetSecret(id string) {
+	if c.snapshot == nil {
+		return
+	}
+
+	delete(c.snapshot.data.Secrets, id)
+	c.snapshotDirty = true
+}
+
+// recordLocations records the list of locations in the snapshot, but does
+// not save it. The lock must be held.
+func (c *Cache) recordLocations(locs []string) {
+	if c.snapshot == nil {
+		return
+	}
+
+	c.snapshot.data.Locations = &snapshotList{Names: locs, Taken: time.Now()}
+	c.snapshotDirty = true
+}
+
+// recordSecretList records the list of secrets in the location in the
+// snapshot, but does not save it. The lock must be held.
+func (c *Cache) recordSecretList(loc string, ids []string) {
+	if c.snapshot == nil {
+		return
+	}
+
+	c.snapshot.data.Lists[loc] = &snapshotList{Names: ids, Taken: time.Now()}
+	c.snapshotDirty = true
+}
+
+// saveSnapshot saves the snapshot, if one is kept, and logs any failure. Once
+// StartSnapshotSaver has been called, the save is left to its goroutine
+// instead. The lock must be held.
+func (c *Cache) saveSnapshot() {
+	if c.snapshot == nil {
+		return
+	}
+
+	c.snapshotDirty = true
+	if c.snapshotKick != nil {
+		select {
+		case c.snapshotKick <- struct{}{}:
+		default:
+		}
+		return
+	}
+
+	if err := c.writeSnapshot(); err != nil {
+		c.logger.Print(err)
+	}
+}
+
+// encodeSnapshot returns the snapshot encoded for saving, or nil if it has no
+// changes to save. The lock must be held.
+func (c *Cache) encodeSnapshot() ([]byte, error) {
+	if c.snapshot == nil || !c.snapshotDirty {
+		return nil, nil
+	}
+
+	plain, err := json.Marshal(c.snapshot.data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to save cache snapshot: %w", err)
+	}
+
+	c.snapshotDirty = false
+	return plain, nil
+}
+
+// writeSnapshot saves the changes to the snapshot not yet saved. The lock must
+// be held.
+func (c *Cache) writeSnapshot() error {
+	plain, err := c.encodeSnapshot()
+	if err != nil || plain == nil {
+		return err
+	}
+
+	if err := c.snapshot.save(plain); err != nil {
+		c.snapshotDirty = true
+		return fmt.Errorf("unable to save cache snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// SaveSnapshot saves the changes to the snapshot not yet saved. It does
+// nothing if no snapshot is kept.
+func (c *Cache) SaveSnapshot() error {
+	c.snapshotLock.Lock()
+	defer c.snapshotLock.Unlock()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.writeSnapshot()
+}
+
+// StartSnapshotSaver starts a goroutine that saves the snapshot, so that the
+// snapshot is no longer encrypted and written while the cache is locked. Once
+// a change is made, the goroutine waits for the delay before saving, so that
+// a burst of changes is saved together. When the context is canceled, the
+// goroutine saves any changes left and the cache goes back to saving each
+// change at once. Failures are logged. It does nothing if no snapshot is kept.
+func (c *Cache) StartSnapshotSaver(ctx context.Context, delay time.Duration) {
+	if c.snapshot == nil {
+		return
+	}
+
+	kick := make(chan struct{}, 1)
+	c.lock.Lock()
+	c.snapshotKick = kick
+	c.lock.Unlock()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				c.snapshotLock.Lock()
+				c.lock.Lock()
+				c.snapshotKick = nil
+				if err := c.writeSnapshot(); err != nil {
+					c.logger.Print(err)
+				}
+				c.lock.Unlock()
+				c.snapshotLock.Unlock()
+				return
+			case <-kick:
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+			case <-timer.C:
+			}
+			timer.Stop()
+
+			if ctx.Err() == nil {
+				c.flushSnapshot()
+			}
+		}
+	}()
+}
+
+// flushSnapshot saves the changes to the snapshot not yet saved, holding the
+// lock only while encoding them.
+func (c *Cache) flushSnapshot() {
+	c.snapshotLock.Lock()
+	defer c.snapshotLock.Unlock()
+
+	c.lock.Lock()
+	plain, err := c.encodeSnapshot()
+	c.lock.Unlock()
+
+	if err == nil && plain != nil {
+		if err = c.snapshot.save(plain); err != nil {
+			c.lock.Lock()
+			c.snapshotDirty = true
+			c.lock.Unlock()
+			err = fmt.Errorf("unable to save cache snapshot: %w", err)
+		}
+	}
+
+	if err != nil {
+		c.logger.Print(err)
+	}
+}
+
+// fromSnapshot returns the stale copy of the secret held in the snapshot, if
+// the error is a network error and the snapshot holds the secret. Otherwise,
+// it returns the error. The lock must be held.
+func (c *Cache) fromSnapshot(id string, err error) (secrets.Secret, error) {
+	if c.snapshot == nil || !isNetworkError(err) {
+		return nil, err
+	}
+
+	ss, isSaved := c.snapshot.data.Secrets[id]
+	if !isSaved {
+		return nil, err
+	}
+
+	return ss.secret(), nil
+}
+
+// fromSnapshotByName returns the stale copies of the secrets with the given
+// name held in the snapshot, if the error is a network error. Otherwise, it
+// returns the error. The lock must be held.
+func (c *Cache) fromSnapshotByName(name string, err error) ([]secrets.Secret, error) {
+	if c.snapshot == nil || !isNetworkError(err) {
+		return nil, err
+	}
+
+	var secs []secrets.Secret
+	for id, ss := range c.snapshot.data.Secrets {
+		if _, isDeleted := c.deleted[id]; isDeleted {
+			continue
+		}
+
+		if ss.Name == name {
+			secs = append(secs, ss.secret())
+		}
+	}
+
+	return secs, nil
+}
+
+// locationsFromSnapshot returns the list of locations held in the snapshot, if
+// the error is a network error and the snapshot holds the list. Otherwise, it
+// returns the error. The lock must be held.
+func (c *Cache) locationsFromSnapshot(err error) ([]string, error) {
+	if c.snapshot == nil || !isNetworkError(err) || c.snapshot.data.Locations == nil {
+		return nil, err
+	}
+
+	return c.snapshot.data.Locations.Names, nil
+}
+
+// secretListFromSnapshot returns the list of secrets in the location held in
+// the snapshot, if the error is a network error and the snapshot holds the
+// list. Otherwise, it returns the error. The lock must be held.
+func (c *Cache) secretListFromSnapshot(loc string, err error) ([]string, error) {
+	if c.snapshot == nil || !isNetworkError(err) {
+		return nil, err
+	}
+
+	sl, isSaved := c.snapshot.data.Lists[loc]
+	if !isSaved {
+		return nil, err
+	}
+
+	return sl.Names, nil
+}
@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var _ secrets.Trashable = &Cache{}
+
+// trashable returns the wrapped keeper as a secrets.Trashable or returns
+// secrets.ErrNoTrash.
+func (c *Cache) trashable() (secrets.Trashable, error) {
+	t, isTrashable := c.Keeper.(secrets.Trashable)
+	if !isTrashable {
+		return nil, secrets.ErrNoTrash
+	}
+	return t, nil
+}
+
+// ListTrash returns the secrets in the trash of the wrapped keeper. Secrets in
+// the trash are never cached.
+func (c *Cache) ListTrash(ctx context.Context) ([]secrets.Secret, error) {
+	t, err := c.trashable()
+	if err != nil {
+		return nil, err
+	}
+	return t.ListTrash(ctx)
+}
+
+// trashWrite prepares to change the trash of the wrapped keeper. In read-only
+// mode, this always fails with ErrReadOnly. In write-back mode, the queued
+// writes are flushed first so that the change to the trash does not run ahead
+// of a queued delete.
+func (c *Cache) trashWrite(ctx context.Context) (secrets.Trashable, error) {
+	t, err := c.trashable()
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.writeMode {
+	case WriteThrough:
+		return t, nil
+	case WriteBack:
+		if err := c.FlushWrites(ctx); err != nil {
+			return nil, err
+		}
+		return t, nil
+	default:
+		return nil, ErrReadOnly
+	}
+}
+
+// RestoreFromTrash restores the secret from the trash of the wrapped keeper and
+// forgets that the secret was missing. In read-only mode, this always fails
+// with ErrReadOnly. In write-back mode, the queued writes are flushed first.
+func (c *Cache) RestoreFromTrash(ctx context.Context, id string) (secrets.Secret, error) {
+	t, err := c.trashWrite(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sec, err := t.RestoreFromTrash(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.invalidateLists()
+	c.invalidate(ctx, id)
+
+	return sec, nil
+}
+
+// EmptyTrash empties the trash of the wrapped keeper. In read-only mode, this
+// always fails with ErrReadOnly. In write-back mode, the queued writes are
+// flushed first.
+func (c *Cache) EmptyTrash(ctx context.Context) error {
+	t, err := c.trashWrite(ctx)
+	if err != nil {
+		return err
+	}
+	return t.EmptyTrash(ctx)
+}
@@ -0,0 +1,132 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// DefaultWarmUpConcurrency is the number of secrets fetched at once by WarmUp
+// when no concurrency is given.
+const DefaultWarmUpConcurrency = 4
+
+// WarmUp loads every location, every list of secrets, and every secret from
+// the wrapped keeper into the cache and its snapshot, if it keeps one. At most
+// concurrency secrets are fetched at once. Anything already cached is fetched
+// again and its expiration reset, so this may also be used to refresh the
+// cache. Secrets with writes not yet written back are left alone.
+//
+// It returns the number of secrets loaded. A failure to load a secret does not
+// stop the warm-up. All such errors are returned together.
+func (c *Cache) WarmUp(ctx context.Context, concurrency int) (int, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultWarmUpConcurrency
+	}
+
+	locs, err := c.Keeper.ListLocations(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("unable to list locations: %w", err)
+	}
+
+	c.lock.Lock()
+	c.locations = c.newListEntry(locs)
+	c.recordLocations(locs)
+	c.lock.Unlock()
+
+	var (
+		ids     []string
+		errs    []error
+		seenLoc = make(map[string]struct{}, len(locs))
+	)
+	for _, loc := range locs {
+		// some keepers list a location more than once
+		if _, isSeen := seenLoc[loc]; isSeen {
+			continue
+		}
+		seenLoc[loc] = struct{}{}
+
+		locIds, err := c.Keeper.ListSecrets(ctx, loc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to list secrets in location %q: %w", loc, err))
+			continue
+		}
+
+		c.lock.Lock()
+		c.secretLists[loc] = c.newListEntry(locIds)
+		c.recordSecretList(loc, locIds)
+		c.lock.Unlock()
+
+		ids = append(ids, locIds...)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		errLock sync.Mutex
+		loaded  int
+		sem     = make(chan struct{}, concurrency)
+	)
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(id string) {
+			defer func() { <-sem; wg.Done() }()
+
+			err := c.refreshSecret(ctx, id)
+
+			errLock.Lock()
+			defer errLock.Unlock()
+
+			switch {
+			case err == nil:
+				loaded++
+			case !errors.Is(err, secrets.ErrNotFound):
+				errs = append(errs, fmt.Errorf("unable to load secret %q: %w", id, err))
+			}
+		}(id)
+	}
+
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		errs = append(errs, ctx.Err())
+	}
+
+	if err := c.SaveSnapshot(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return loaded, errors.Join(errs...)
+}
+
+// refreshSecret fetches the secret from the wrapped keeper and replaces any
+// cached copy unless the secret has writes not yet written back.
+func (c *Cache) refreshSecret(ctx context.Context, id string) error {
+	sec, err := c.Keeper.GetSecret(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, isDeleted := c.deleted[id]; isDeleted {
+		return nil
+	}
+
+	if e, isCached := c.entries[id]; isCached && e.dirty {
+		return nil
+	}
+
+	c.recordSecret(sec)
+
+	_, err = c.touchSecret(ctx, sec, "", id)
+	return err
+}
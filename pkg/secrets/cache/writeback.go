@@ -0,0 +1,749 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/zostay/fssafe"
+	"gopkg.in/yaml.v3"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+)
+
+// WriteMode determines how the cache handles writes.
+type WriteMode int
+
+const (
+	// ReadOnly refuses all writes. DeleteSecret only removes the secret from
+	// the cache. This is the default.
+	ReadOnly WriteMode = iota
+
+	// WriteThrough passes every write on to the wrapped keeper immediately and
+	// caches the result.
+	WriteThrough
+
+	// WriteBack applies every write to the cache immediately and queues it to
+	// be written to the wrapped keeper in the background.
+	WriteBack
+)
+
+const (
+	// DefaultRetryInterval is the initial delay before retrying a queued
+	// write that failed.
+	DefaultRetryInterval = time.Second
+
+	// DefaultMaxRetryInterval is the longest delay between retries of a
+	// queued write that keeps failing.
+	DefaultMaxRetryInterval = 5 * time.Minute
+
+	// DefaultMaxAttempts is the number of times a queued write is attempted
+	// before it is dropped, unless it fails because the wrapped keeper cannot
+	// be reached.
+	DefaultMaxAttempts = 10
+
+	// pendingPrefix is used for the IDs of new secrets that have not yet been
+	// written to the wrapped keeper.
+	pendingPrefix = "pending-"
+)
+
+// ErrReadOnly is returned by the write methods of a cache in ReadOnly mode.
+var ErrReadOnly = errors.New("caching secret keeper does not allow direct writes")
+
+// ErrUnsupportedQueueVersion is returned when the persisted write queue was
+// written by a newer version of ghost.
+var ErrUnsupportedQueueVersion = errors.New("unsupported write queue version")
+
+// ErrQueueDecrypt is returned when the persisted write queue cannot be
+// decrypted, most likely because the passphrase has changed.
+var ErrQueueDecrypt = errors.New("unable to decrypt cache write queue")
+
+// ErrQueuePassphrase is returned when the write queue is to be saved without a
+// passphrase to encrypt it with.
+var ErrQueuePassphrase = errors.New("a passphrase is required to save the cache write queue")
+
+// errQueueLocked is returned when another process holds the lock on the saved
+// write queue.
+var errQueueLocked = errors.New("cache write queue is locked by another process")
+
+// queueMagic begins every persisted write queue.
+var queueMagic = []byte("ghost-cache-queue-1\n")
+
+// String returns the configuration name of the write mode.
+func (m WriteMode) String() string {
+	switch m {
+	case ReadOnly:
+		return "read-only"
+	case WriteThrough:
+		return "write-through"
+	case WriteBack:
+		return "write-back"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseWriteMode returns the write mode with the given configuration name. The
+// empty string is treated as ReadOnly.
+func ParseWriteMode(s string) (WriteMode, error) {
+	switch s {
+	case "", "read-only":
+		return ReadOnly, nil
+	case "write-through":
+		return WriteThrough, nil
+	case "write-back":
+		return WriteBack, nil
+	default:
+		return ReadOnly, fmt.Errorf("unknown cache write mode %q", s)
+	}
+}
+
+// WithWriteThrough causes writes to the cache to be passed on to the wrapped
+// keeper immediately.
+func WithWriteThrough() Option {
+	return func(c *Cache) {
+		c.writeMode = WriteThrough
+	}
+}
+
+// WithWriteBack causes writes to be queued and written to the wrapped keeper
+// later by FlushWrites or the goroutine started by StartWriteBack. If ls is not
+// nil, the queue is saved with it after every change and any queue saved
+// previously is loaded when the cache is created. Without it, writes still
+// queued when the program exits are lost.
+//
+// The saved queue holds the pending secrets, so it is encrypted the same way as
+// the snapshot with a key derived from the passphrase. New fails with
+// ErrQueuePassphrase if ls is set without a passphrase.
+func WithWriteBack(ls fssafe.LoaderSaver, passphrase []byte) Option {
+	return func(c *Cache) {
+		c.writeMode = WriteBack
+		c.queueStore = ls
+		c.queueSealer = newSealer(queueMagic, passphrase, ErrQueueDecrypt)
+	}
+}
+
+// WithMaxAttempts sets the number of times a queued write is attempted before
+// it is dropped and the failure logged, so that a write that can never succeed
+// does not hold up the writes queued after it. Writes failing because the
+// wrapped keeper cannot be reached are retried until they succeed. Zero means
+// writes are never dropped.
+func WithMaxAttempts(n int) Option {
+	return func(c *Cache) {
+		c.maxAttempts = n
+	}
+}
+
+// WithRetryBackoff sets the delay before a failed queued write is retried. The
+// delay starts at initial and doubles on each failure up to max.
+func WithRetryBackoff(initial, max time.Duration) Option {
+	return func(c *Cache) {
+		c.retryInterval = initial
+		c.maxRetryInterval = max
+	}
+}
+
+// opKind names the operation of a queued write.
+type opKind string
+
+const (
+	opSet    opKind = "set"
+	opCopy   opKind = "copy"
+	opMove   opKind = "move"
+	opDelete opKind = "delete"
+)
+
+// pendingOp is a write waiting to be sent to the wrapped keeper.
+type pendingOp struct {
+	Kind opKind `yaml:"kind"`
+
+	// ID is the secret written, copied, moved, or deleted.
+	ID string `yaml:"id"`
+
+	// ResultID is the ID handed back to the caller for the new secret created
+	// by a copy.
+	ResultID string `yaml:"result_id,omitempty"`
+
+	// Location is the target location of a copy or move.
+	Location string `yaml:"location,omitempty"`
+
+	// Secret is the secret to set.
+	Secret map[string]string `yaml:"secret,omitempty"`
+
+	// Sent is set before the operation is first sent to the wrapped keeper.
+	// When sending a new secret or a copy again, the secret sent before may
+	// already exist.
+	Sent bool `yaml:"sent,omitempty"`
+
+	Attempts    int       `yaml:"attempts,omitempty"`
+	NextAttempt time.Time `yaml:"next_attempt,omitempty"`
+}
+
+// writeQueue is the persisted form of the write-back queue.
+type writeQueue struct {
+	Version string       `yaml:"version"`
+	Ops     []*pendingOp `yaml:"ops"`
+}
+
+// makePendingID returns a new ID for a secret not yet in the wrapped keeper.
+func makePendingID() string {
+	return pendingPrefix + ulid.Make().String()
+}
+
+// resultID returns the ID of the secret produced by the operation, if any.
+func (op *pendingOp) resultID() string {
+	switch op.Kind {
+	case opCopy:
+		return op.ResultID
+	case opDelete:
+		return ""
+	default:
+		return op.ID
+	}
+}
+
+// references returns true if the operation refers to the given ID.
+func (op *pendingOp) references(id string) bool {
+	return op.ID == id || op.ResultID == id
+}
+
+// loadQueue loads the saved write queue, if there is one. A queue saved in
+// plain text by an earlier version of ghost is loaded and saved again
+// encrypted.
+func (c *Cache) loadQueue(ctx context.Context) error {
+	if c.queueStore == nil {
+		return nil
+	}
+
+	if len(c.queueSealer.passphrase) == 0 {
+		return ErrQueuePassphrase
+	}
+
+	r, err := c.queueStore.Loader()
+	if err != nil {
+		// no saved queue yet
+		return nil
+	}
+	defer func() { _ = r.Close() }()
+
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	yamlQueue, plainText := raw, !c.queueSealer.sealed(raw)
+	if !plainText {
+		yamlQueue, err = c.queueSealer.open(raw)
+		if err != nil {
+			return err
+		}
+	}
+
+	var q writeQueue
+	err = yaml.Unmarshal(yamlQueue, &q)
+	if err != nil {
+		return err
+	}
+
+	version, err := strconv.Atoi(q.Version)
+	if err != nil {
+		version = 1
+	}
+
+	if version != 1 {
+		return ErrUnsupportedQueueVersion
+	}
+
+	c.queue = q.Ops
+
+	// restore what we can of the cache so reads see the pending writes
+	for _, op := range c.queue {
+		switch op.Kind {
+		case opSet:
+			_, err := c.storeDirty(ctx, memory.MapSecret(op.Secret), op.ID)
+			if err != nil {
+				return err
+			}
+		case opDelete:
+			c.deleted[op.ID] = struct{}{}
+		}
+	}
+
+	if plainText {
+		return c.saveQueue()
+	}
+
+	return nil
+}
+
+// privateFile is a fssafe.LoaderSaver for a file only its owner may read or
+// write. Like fssafe.NewFileSystemLoaderSaver, each save is written to a new
+// file that replaces the old one when closed, but no backup is kept.
+type privateFile struct {
+	path     string
+	lockFile *os.File // held open while the file is locked
+}
+
+var _ fssafe.LoaderSaver = &privateFile{}
+
+// newPrivateFile returns a loader-saver for the private file at the path.
+func newPrivateFile(path string) *privateFile {
+	return &privateFile{path: path}
+}
+
+// lock takes an exclusive lock on the file, held until the process exits, so
+// that no other process loads and writes back the same writes. The lock is
+// taken on a separate file, since the file itself is replaced on every save.
+// It returns errQueueLocked if another process holds the lock.
+func (p *privateFile) lock() error {
+	f, err := os.OpenFile(p.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if err := tryLock(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	p.lockFile = f
+	return nil
+}
+
+// Loader opens the file for reading.
+func (p *privateFile) Loader() (io.ReadCloser, error) {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Saver opens a new file for writing that replaces the file when closed.
+func (p *privateFile) Saver() (io.WriteCloser, error) {
+	f, err := os.OpenFile(p.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	// a file left behind earlier may have been created with other permissions
+	if err := f.Chmod(0o600); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return &privateWriter{f, p.path}, nil
+}
+
+// LoaderFunc returns Loader as a fssafe.Loader.
+func (p *privateFile) LoaderFunc() fssafe.Loader {
+	return p.Loader
+}
+
+// SaverFunc returns Saver as a fssafe.Saver.
+func (p *privateFile) SaverFunc() fssafe.Saver {
+	return p.Saver
+}
+
+// privateWriter writes a new private file and moves it into place on close.
+type privateWriter struct {
+	*os.File
+	path string
+}
+
+// Close closes the new file and replaces the old file with it.
+func (w *privateWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(w.path+".new", w.path)
+}
+
+// saveQueue saves the write queue. The lock must be held.
+func (c *Cache) saveQueue() error {
+	if c.queueStore == nil {
+		return nil
+	}
+
+	yamlQueue, err := yaml.Marshal(&writeQueue{
+		Version: "1",
+		Ops:     c.queue,
+	})
+	if err != nil {
+		return err
+	}
+
+	sealed, err := c.queueSealer.seal(yamlQueue)
+	if err != nil {
+		return err
+	}
+
+	w, err := c.queueStore.Saver()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = w.Close() }()
+
+	_, err = w.Write(sealed)
+	return err
+}
+
+// enqueue adds the operation to the write queue, saves the queue, and wakes
+// the background writer. The lock must be held.
+func (c *Cache) enqueue(op *pendingOp) error {
+	c.queue = append(c.queue, op)
+	if err := c.saveQueue(); err != nil {
+		c.queue = c.queue[:len(c.queue)-1]
+		return fmt.Errorf("unable to save cache write queue: %w", err)
+	}
+
+	select {
+	case c.kick <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// storeDirty stores the secret in the cache under the given ID and marks it
+// as having unwritten changes, so it will not expire or be evicted until it
+// has been written to the wrapped keeper. The lock must be held.
+func (c *Cache) storeDirty(ctx context.Context, sec secrets.Secret, id string) (secrets.Secret, error) {
+	delete(c.deleted, id)
+
+	cacheId := ""
+	if e, isCached := c.entries[id]; isCached {
+		cacheId = e.cacheId
+	}
+
+	updSec := secrets.NewSingleFromSecret(sec, secrets.WithID(cacheId))
+	cacheSec, err := c.Memory.SetSecret(ctx, updSec)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheId == "" {
+		c.addEntry(ctx, id, cacheSec.ID(), true)
+	} else {
+		e := c.entries[id]
+		e.dirty = true
+		e.expires = time.Time{}
+	}
+
+	return secrets.NewSingleFromSecret(cacheSec, secrets.WithID(id)), nil
+}
+
+// backoff returns the delay before the next attempt of an operation that has
+// failed the given number of times.
+func (c *Cache) backoff(attempts int) time.Duration {
+	delay := c.retryInterval
+	for i := 1; i < attempts && delay < c.maxRetryInterval; i++ {
+		delay *= 2
+	}
+
+	if delay > c.maxRetryInterval {
+		delay = c.maxRetryInterval
+	}
+
+	return delay
+}
+
+// remap renames a pending ID to the ID assigned by the wrapped keeper. The lock
+// must be held.
+func (c *Cache) remap(oldId, newId string) {
+	if e, isCached := c.entries[oldId]; isCached {
+		delete(c.entries, oldId)
+		e.elem.Value = newId
+		c.entries[newId] = e
+		c.cacheToOrigId[e.cacheId] = newId
+	}
+
+	if _, isDeleted := c.deleted[oldId]; isDeleted {
+		delete(c.deleted, oldId)
+		c.deleted[newId] = struct{}{}
+	}
+
+	for _, op := range c.queue {
+		if op.ID == oldId {
+			op.ID = newId
+		}
+		if op.ResultID == oldId {
+			op.ResultID = newId
+		}
+	}
+
+	if strings.HasPrefix(oldId, pendingPrefix) {
+		c.renamed[oldId] = newId
+	}
+}
+
+// queued returns true if a queued write refers to the ID. The lock must be
+// held.
+func (c *Cache) queued(id string) bool {
+	for _, op := range c.queue {
+		if op.references(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// settle clears the dirty flag of the secret, or the deleted mark, once no
+// queued writes refer to it anymore. The lock must be held.
+func (c *Cache) settle(id string) {
+	if c.queued(id) {
+		return
+	}
+
+	delete(c.deleted, id)
+
+	if e, isCached := c.entries[id]; isCached && e.dirty {
+		e.dirty = false
+		if c.ttl > 0 {
+			e.expires = time.Now().Add(c.ttl)
+		}
+	}
+}
+
+// findSent returns the secret in the wrapped keeper with the name, username,
+// and location, if there is one. It is used to find a new secret or copy that
+// was sent before, but not removed from the queue, such as when ghost exits
+// before the queue is saved.
+func (c *Cache) findSent(ctx context.Context, name, username, location string) (secrets.Secret, error) {
+	secs, err := c.Keeper.GetSecretsByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sec := range secs {
+		if sec.Username() == username && sec.Location() == location {
+			return sec, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// applyOp performs the queued operation against the wrapped keeper.
+func (c *Cache) applyOp(ctx context.Context, op *pendingOp) (secrets.Secret, error) {
+	switch op.Kind {
+	case opSet:
+		sec := memory.MapSecret(op.Secret)
+		id := op.ID
+		if strings.HasPrefix(id, pendingPrefix) {
+			id = ""
+			if op.Sent {
+				sent, err := c.findSent(ctx, sec.Name(), sec.Username(), sec.Location())
+				if err != nil {
+					return nil, err
+				}
+				if sent != nil {
+					id = sent.ID()
+				}
+			}
+		}
+		return c.Keeper.SetSecret(ctx, secrets.NewSingleFromSecret(sec, secrets.WithID(id)))
+	case opCopy:
+		if op.Sent {
+			orig, err := c.Keeper.GetSecret(ctx, op.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			sent, err := c.findSent(ctx, orig.Name(), orig.Username(), op.Location)
+			if err != nil || sent != nil {
+				return sent, err
+			}
+		}
+		return c.Keeper.CopySecret(ctx, op.ID, op.Location)
+	case opMove:
+		return c.Keeper.MoveSecret(ctx, op.ID, op.Location)
+	case opDelete:
+		return nil, c.Keeper.DeleteSecret(ctx, op.ID)
+	default:
+		return nil, fmt.Errorf("unknown queued cache write %q", op.Kind)
+	}
+}
+
+// FlushWrites writes every queued write to the wrapped keeper in order,
+// ignoring any retry delay. It stops at the first write that fails and returns
+// the error. The failed write remains queued and will be retried, unless it
+// has failed too many times, in which case it is dropped and the failure is
+// logged. Writes that fail because the secret no longer exists are dropped.
+func (c *Cache) FlushWrites(ctx context.Context) error {
+	return c.flushWrites(ctx, true)
+}
+
+// flushWrites writes queued writes to the wrapped keeper in order. Unless
+// force is set, it stops at the first write that is waiting out its retry
+// delay.
+func (c *Cache) flushWrites(ctx context.Context, force bool) error {
+	c.flushLock.Lock()
+	defer c.flushLock.Unlock()
+
+	for {
+		c.lock.Lock()
+		if len(c.queue) == 0 {
+			c.lock.Unlock()
+			return nil
+		}
+
+		op := c.queue[0]
+		if !force && time.Now().Before(op.NextAttempt) {
+			c.lock.Unlock()
+			return nil
+		}
+
+		if !op.Sent && (op.Kind == opSet || op.Kind == opCopy) {
+			op.Sent = true
+			if err := c.saveQueue(); err != nil {
+				op.Sent = false
+				c.lock.Unlock()
+				return fmt.Errorf("unable to save cache write queue: %w", err)
+			}
+		}
+		c.lock.Unlock()
+
+		res, err := c.applyOp(ctx, op)
+
+		c.lock.Lock()
+		if err != nil && !(errors.Is(err, secrets.ErrNotFound) && op.Kind != opSet) {
+			op.Attempts++
+			if c.maxAttempts > 0 && op.Attempts >= c.maxAttempts && !isNetworkError(err) {
+				c.dropOp(ctx, op, err)
+				continue
+			}
+
+			op.NextAttempt = time.Now().Add(c.backoff(op.Attempts))
+			_ = c.saveQueue()
+			c.lock.Unlock()
+			return fmt.Errorf("unable to write %s of secret %q to cached keeper: %w", op.Kind, op.ID, err)
+		}
+
+		c.queue = c.queue[1:]
+
+		id := op.resultID()
+		switch {
+		case err != nil:
+			// the secret is gone, so the cached result is bogus
+			c.invalidate(ctx, id)
+		case res != nil && res.ID() != id:
+			c.remap(id, res.ID())
+			id = res.ID()
+		}
+
+		if res != nil {
+			c.recordSecret(res)
+		}
+
+		if op.Kind == opDelete {
+			id = op.ID
+			c.forgetSecret(id)
+		}
+		c.settle(id)
+		c.invalidateLists()
+		c.saveSnapshot()
+
+		err = c.saveQueue()
+		c.lock.Unlock()
+		if err != nil {
+			return fmt.Errorf("unable to save cache write queue: %w", err)
+		}
+	}
+}
+
+// dropOp removes the operation at the head of the queue after it has failed
+// too many times, logs the failure, and forgets what the cache holds for it, so
+// that the secret is read from the wrapped keeper again. The lock must be held
+// and is released.
+func (c *Cache) dropOp(ctx context.Context, op *pendingOp, err error) {
+	defer c.lock.Unlock()
+
+	c.queue = c.queue[1:]
+	c.logger.Printf("giving up on %s of secret %q to cached keeper after %d attempts: %v",
+		op.Kind, op.ID, op.Attempts, err)
+
+	for _, id := range []string{op.ID, op.ResultID} {
+		if id == "" || c.queued(id) {
+			continue
+		}
+		c.settle(id)
+		c.invalidate(ctx, id)
+	}
+	c.invalidateLists()
+
+	if err := c.saveQueue(); err != nil {
+		c.logger.Printf("unable to save cache write queue: %v", err)
+	}
+}
+
+// PendingWrites returns the number of writes waiting to be written to the
+// wrapped keeper.
+func (c *Cache) PendingWrites() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.queue)
+}
+
+// nextAttempt returns the time of the next queued write attempt and true, or
+// false if the queue is empty.
+func (c *Cache) nextAttempt() (time.Time, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(c.queue) == 0 {
+		return time.Time{}, false
+	}
+
+	return c.queue[0].NextAttempt, true
+}
+
+// StartWriteBack starts a goroutine that writes queued writes to the wrapped
+// keeper as they arrive, retrying failed writes with exponential backoff. It
+// runs until the context is canceled. It does nothing unless the cache is in
+// WriteBack mode.
+//
+// Only one cache should write back a saved queue. The cache keeper
+// configuration locks the queue file, so a second ghost process using the same
+// queue writes through instead.
+func (c *Cache) StartWriteBack(ctx context.Context) {
+	if c.writeMode != WriteBack {
+		return
+	}
+
+	go func() {
+		for {
+			_ = c.flushWrites(ctx, false)
+
+			var timer *time.Timer
+			var wait <-chan time.Time
+			if next, pending := c.nextAttempt(); pending {
+				timer = time.NewTimer(time.Until(next))
+				wait = timer.C
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-c.kick:
+			case <-wait:
+			}
+
+			if timer != nil {
+				timer.Stop()
+			}
+
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"io"
 
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/otp"
 	"github.com/zostay/ghost/pkg/secrets"
 )
 
@@ -14,7 +16,11 @@ type Client struct {
 	client KeeperClient
 }
 
-var _ secrets.Keeper = &Client{}
+var (
+	_ secrets.Keeper   = &Client{}
+	_ otp.Generator    = &Client{}
+	_ config.URIFinder = &Client{}
+)
 
 // NewClient creates a new client for the secret keeper service.
 func NewClient(client KeeperClient) *Client {
@@ -102,6 +108,30 @@ func (c *Client) GetSecretsByName(ctx context.Context, name string) ([]secrets.S
 	}
 }
 
+// GetSecretsByURI retrieves the list of secrets matching the given secret URI
+// from the secret keeper service.
+func (c *Client) GetSecretsByURI(ctx context.Context, uri string) ([]secrets.Secret, error) {
+	rawSecs, err := c.client.GetSecretsByURI(ctx, &GetSecretsByURIRequest{
+		Uri: uri,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var secs []secrets.Secret
+	for {
+		sec, err := rawSecs.Recv()
+		if errors.Is(err, io.EOF) {
+			return secs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		secs = append(secs, NewSecretWrapper(sec))
+	}
+}
+
 // SetSecret stores the given secret in the secret keeper service.
 func (c *Client) SetSecret(ctx context.Context, secret secrets.Secret) (secrets.Secret, error) {
 	sec, err := c.client.SetSecret(ctx, FromSecret(secret))
@@ -152,3 +182,20 @@ func (c *Client) DeleteSecret(ctx context.Context, id string) error {
 
 	return nil
 }
+
+// GetOTP asks the secret keeper service for the current one-time password of
+// the secret with the given ID.
+func (c *Client) GetOTP(ctx context.Context, id string) (*otp.Code, error) {
+	res, err := c.client.GetOTP(ctx, &GetOTPRequest{
+		Id: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &otp.Code{
+		Code:      res.GetCode(),
+		Remaining: res.GetRemaining().AsDuration(),
+		Period:    res.GetPeriod().AsDuration(),
+	}, nil
+}
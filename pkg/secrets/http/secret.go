@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/url"
+	"sort"
 	"time"
 
 	"google.golang.org/protobuf/types/known/timestamppb"
@@ -15,7 +16,10 @@ type SecretWrapper struct {
 	*Secret
 }
 
-var _ secrets.Secret = &SecretWrapper{}
+var (
+	_ secrets.Stale           = &SecretWrapper{}
+	_ secrets.WithAttachments = &SecretWrapper{}
+)
 
 // NewSecretWrapper creates a new secret wrapper for the given protobuf Secret
 // message.
@@ -27,7 +31,7 @@ func NewSecretWrapper(s *Secret) *SecretWrapper {
 
 // FromSecret creates a new protobuf Secret message from the given secret.
 func FromSecret(s secrets.Secret) *Secret {
-	return &Secret{
+	sec := &Secret{
 		Id:           s.ID(),
 		Name:         s.Name(),
 		Username:     s.Username(),
@@ -38,6 +42,21 @@ func FromSecret(s secrets.Secret) *Secret {
 		Location:     s.Location(),
 		LastModified: timestamppb.New(s.LastModified()),
 	}
+
+	for _, name := range secrets.Attachments(s) {
+		if data, hasAttachment := secrets.GetAttachment(s, name); hasAttachment {
+			if sec.Attachments == nil {
+				sec.Attachments = map[string][]byte{}
+			}
+			sec.Attachments[name] = data
+		}
+	}
+
+	if since, isStale := secrets.IsStale(s); isStale {
+		sec.StaleSince = timestamppb.New(since)
+	}
+
+	return sec
 }
 
 func (s *SecretWrapper) init() {
@@ -135,6 +154,15 @@ func (s *SecretWrapper) LastModified() time.Time {
 	return s.GetLastModified().AsTime()
 }
 
+// StaleSince returns the time of the offline snapshot the secret came from, or
+// the zero time if the secret is not stale.
+func (s *SecretWrapper) StaleSince() time.Time {
+	if s.GetStaleSince() == nil {
+		return time.Time{}
+	}
+	return s.GetStaleSince().AsTime()
+}
+
 // Url returns the URL of the secret.
 func (s *SecretWrapper) Url() *url.URL {
 	u, _ := url.Parse(s.GetUrl())
@@ -151,3 +179,36 @@ func (s *SecretWrapper) SetUrl(url *url.URL) {
 func (s *SecretWrapper) Location() string {
 	return s.GetLocation()
 }
+
+// Attachments returns the names of the attachments of the secret in sorted
+// order.
+func (s *SecretWrapper) Attachments() []string {
+	names := make([]string, 0, len(s.GetAttachments()))
+	for name := range s.GetAttachments() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetAttachment returns the content of the named attachment.
+func (s *SecretWrapper) GetAttachment(name string) ([]byte, bool) {
+	data, hasAttachment := s.GetAttachments()[name]
+	return data, hasAttachment
+}
+
+// SetAttachment adds or replaces the named attachment.
+func (s *SecretWrapper) SetAttachment(name string, data []byte) {
+	s.init()
+	if s.Secret.Attachments == nil {
+		s.Secret.Attachments = map[string][]byte{name: data}
+		return
+	}
+	s.Secret.Attachments[name] = data
+}
+
+// DeleteAttachment deletes the named attachment.
+func (s *SecretWrapper) DeleteAttachment(name string) {
+	s.init()
+	delete(s.Secret.Attachments, name)
+}
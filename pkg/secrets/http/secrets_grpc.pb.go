@@ -24,11 +24,15 @@ const (
 	Keeper_ListSecrets_FullMethodName      = "/ghost.secrets.Keeper/ListSecrets"
 	Keeper_GetSecretsByName_FullMethodName = "/ghost.secrets.Keeper/GetSecretsByName"
 	Keeper_GetSecret_FullMethodName        = "/ghost.secrets.Keeper/GetSecret"
+	Keeper_GetSecretsByURI_FullMethodName  = "/ghost.secrets.Keeper/GetSecretsByURI"
 	Keeper_SetSecret_FullMethodName        = "/ghost.secrets.Keeper/SetSecret"
 	Keeper_CopySecret_FullMethodName       = "/ghost.secrets.Keeper/CopySecret"
 	Keeper_MoveSecret_FullMethodName       = "/ghost.secrets.Keeper/MoveSecret"
 	Keeper_DeleteSecret_FullMethodName     = "/ghost.secrets.Keeper/DeleteSecret"
 	Keeper_GetServiceInfo_FullMethodName   = "/ghost.secrets.Keeper/GetServiceInfo"
+	Keeper_FlushCache_FullMethodName       = "/ghost.secrets.Keeper/FlushCache"
+	Keeper_CopyToClipboard_FullMethodName  = "/ghost.secrets.Keeper/CopyToClipboard"
+	Keeper_GetOTP_FullMethodName           = "/ghost.secrets.Keeper/GetOTP"
 )
 
 // KeeperClient is the client API for Keeper service.
@@ -43,6 +47,8 @@ type KeeperClient interface {
 	GetSecretsByName(ctx context.Context, in *GetSecretsByNameRequest, opts ...grpc.CallOption) (Keeper_GetSecretsByNameClient, error)
 	// GetSecret gets a secret by its ID.
 	GetSecret(ctx context.Context, in *GetSecretRequest, opts ...grpc.CallOption) (*Secret, error)
+	// GetSecretsByURI retrieves the secrets matching a secret URI.
+	GetSecretsByURI(ctx context.Context, in *GetSecretsByURIRequest, opts ...grpc.CallOption) (Keeper_GetSecretsByURIClient, error)
 	// SetSecret sets a secret.
 	SetSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*Secret, error)
 	// CopySecret copies a secret to a new location.
@@ -53,6 +59,14 @@ type KeeperClient interface {
 	DeleteSecret(ctx context.Context, in *DeleteSecretRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	// GetServiceInfo returns information about the service.
 	GetServiceInfo(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ServiceInfo, error)
+	// FlushCache removes secrets from the caches used by the service.
+	FlushCache(ctx context.Context, in *FlushCacheRequest, opts ...grpc.CallOption) (*FlushCacheResponse, error)
+	// CopyToClipboard places a value on the clipboard of the service and clears
+	// it after a timeout.
+	CopyToClipboard(ctx context.Context, in *CopyToClipboardRequest, opts ...grpc.CallOption) (*CopyToClipboardResponse, error)
+	// GetOTP computes the current one-time password from the seed stored in a
+	// secret, so the seed need not leave the service.
+	GetOTP(ctx context.Context, in *GetOTPRequest, opts ...grpc.CallOption) (*OTP, error)
 }
 
 type keeperClient struct {
@@ -168,6 +182,38 @@ func (c *keeperClient) GetSecret(ctx context.Context, in *GetSecretRequest, opts
 	return out, nil
 }
 
+func (c *keeperClient) GetSecretsByURI(ctx context.Context, in *GetSecretsByURIRequest, opts ...grpc.CallOption) (Keeper_GetSecretsByURIClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Keeper_ServiceDesc.Streams[3], Keeper_GetSecretsByURI_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &keeperGetSecretsByURIClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Keeper_GetSecretsByURIClient interface {
+	Recv() (*Secret, error)
+	grpc.ClientStream
+}
+
+type keeperGetSecretsByURIClient struct {
+	grpc.ClientStream
+}
+
+func (x *keeperGetSecretsByURIClient) Recv() (*Secret, error) {
+	m := new(Secret)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *keeperClient) SetSecret(ctx context.Context, in *Secret, opts ...grpc.CallOption) (*Secret, error) {
 	out := new(Secret)
 	err := c.cc.Invoke(ctx, Keeper_SetSecret_FullMethodName, in, out, opts...)
@@ -213,6 +259,33 @@ func (c *keeperClient) GetServiceInfo(ctx context.Context, in *emptypb.Empty, op
 	return out, nil
 }
 
+func (c *keeperClient) FlushCache(ctx context.Context, in *FlushCacheRequest, opts ...grpc.CallOption) (*FlushCacheResponse, error) {
+	out := new(FlushCacheResponse)
+	err := c.cc.Invoke(ctx, Keeper_FlushCache_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) CopyToClipboard(ctx context.Context, in *CopyToClipboardRequest, opts ...grpc.CallOption) (*CopyToClipboardResponse, error) {
+	out := new(CopyToClipboardResponse)
+	err := c.cc.Invoke(ctx, Keeper_CopyToClipboard_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *keeperClient) GetOTP(ctx context.Context, in *GetOTPRequest, opts ...grpc.CallOption) (*OTP, error) {
+	out := new(OTP)
+	err := c.cc.Invoke(ctx, Keeper_GetOTP_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // KeeperServer is the server API for Keeper service.
 // All implementations must embed UnimplementedKeeperServer
 // for forward compatibility
@@ -225,6 +298,8 @@ type KeeperServer interface {
 	GetSecretsByName(*GetSecretsByNameRequest, Keeper_GetSecretsByNameServer) error
 	// GetSecret gets a secret by its ID.
 	GetSecret(context.Context, *GetSecretRequest) (*Secret, error)
+	// GetSecretsByURI retrieves the secrets matching a secret URI.
+	GetSecretsByURI(*GetSecretsByURIRequest, Keeper_GetSecretsByURIServer) error
 	// SetSecret sets a secret.
 	SetSecret(context.Context, *Secret) (*Secret, error)
 	// CopySecret copies a secret to a new location.
@@ -235,6 +310,14 @@ type KeeperServer interface {
 	DeleteSecret(context.Context, *DeleteSecretRequest) (*emptypb.Empty, error)
 	// GetServiceInfo returns information about the service.
 	GetServiceInfo(context.Context, *emptypb.Empty) (*ServiceInfo, error)
+	// FlushCache removes secrets from the caches used by the service.
+	FlushCache(context.Context, *FlushCacheRequest) (*FlushCacheResponse, error)
+	// CopyToClipboard places a value on the clipboard of the service and clears
+	// it after a timeout.
+	CopyToClipboard(context.Context, *CopyToClipboardRequest) (*CopyToClipboardResponse, error)
+	// GetOTP computes the current one-time password from the seed stored in a
+	// secret, so the seed need not leave the service.
+	GetOTP(context.Context, *GetOTPRequest) (*OTP, error)
 	mustEmbedUnimplementedKeeperServer()
 }
 
@@ -254,6 +337,9 @@ func (UnimplementedKeeperServer) GetSecretsByName(*GetSecretsByNameRequest, Keep
 func (UnimplementedKeeperServer) GetSecret(context.Context, *GetSecretRequest) (*Secret, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetSecret not implemented")
 }
+func (UnimplementedKeeperServer) GetSecretsByURI(*GetSecretsByURIRequest, Keeper_GetSecretsByURIServer) error {
+	return status.Errorf(codes.Unimplemented, "method GetSecretsByURI not implemented")
+}
 func (UnimplementedKeeperServer) SetSecret(context.Context, *Secret) (*Secret, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetSecret not implemented")
 }
@@ -269,6 +355,15 @@ func (UnimplementedKeeperServer) DeleteSecret(context.Context, *DeleteSecretRequ
 func (UnimplementedKeeperServer) GetServiceInfo(context.Context, *emptypb.Empty) (*ServiceInfo, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetServiceInfo not implemented")
 }
+func (UnimplementedKeeperServer) FlushCache(context.Context, *FlushCacheRequest) (*FlushCacheResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FlushCache not implemented")
+}
+func (UnimplementedKeeperServer) CopyToClipboard(context.Context, *CopyToClipboardRequest) (*CopyToClipboardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CopyToClipboard not implemented")
+}
+func (UnimplementedKeeperServer) GetOTP(context.Context, *GetOTPRequest) (*OTP, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOTP not implemented")
+}
 func (UnimplementedKeeperServer) mustEmbedUnimplementedKeeperServer() {}
 
 // UnsafeKeeperServer may be embedded to opt out of forward compatibility for this service.
@@ -363,6 +458,27 @@ func _Keeper_GetSecret_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Keeper_GetSecretsByURI_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetSecretsByURIRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KeeperServer).GetSecretsByURI(m, &keeperGetSecretsByURIServer{stream})
+}
+
+type Keeper_GetSecretsByURIServer interface {
+	Send(*Secret) error
+	grpc.ServerStream
+}
+
+type keeperGetSecretsByURIServer struct {
+	grpc.ServerStream
+}
+
+func (x *keeperGetSecretsByURIServer) Send(m *Secret) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 func _Keeper_SetSecret_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Secret)
 	if err := dec(in); err != nil {
@@ -453,6 +569,60 @@ func _Keeper_GetServiceInfo_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Keeper_FlushCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FlushCacheRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).FlushCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_FlushCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).FlushCache(ctx, req.(*FlushCacheRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_CopyToClipboard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CopyToClipboardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).CopyToClipboard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_CopyToClipboard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).CopyToClipboard(ctx, req.(*CopyToClipboardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Keeper_GetOTP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOTPRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KeeperServer).GetOTP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Keeper_GetOTP_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KeeperServer).GetOTP(ctx, req.(*GetOTPRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Keeper_ServiceDesc is the grpc.ServiceDesc for Keeper service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -484,6 +654,18 @@ var Keeper_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetServiceInfo",
 			Handler:    _Keeper_GetServiceInfo_Handler,
 		},
+		{
+			MethodName: "FlushCache",
+			Handler:    _Keeper_FlushCache_Handler,
+		},
+		{
+			MethodName: "CopyToClipboard",
+			Handler:    _Keeper_CopyToClipboard_Handler,
+		},
+		{
+			MethodName: "GetOTP",
+			Handler:    _Keeper_GetOTP_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -501,6 +683,11 @@ var Keeper_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _Keeper_GetSecretsByName_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "GetSecretsByURI",
+			Handler:       _Keeper_GetSecretsByURI_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "secrets.proto",
 }
@@ -2,14 +2,25 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
 	"google.golang.org/protobuf/types/known/durationpb"
 
+	"github.com/zostay/ghost/pkg/clipboard"
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/otp"
 	"github.com/zostay/ghost/pkg/secrets"
 )
 
+// cacheFlusher is implemented by caching secret keepers, such as cache.Cache,
+// that can be asked to forget the secrets they hold.
+type cacheFlusher interface {
+	Invalidate(ctx context.Context, id string)
+	Flush(ctx context.Context)
+}
+
 // Server gives a secret keeper a gRPC server interface.
 type Server struct {
 	UnimplementedKeeperServer
@@ -116,6 +127,37 @@ func (s *Server) GetSecret(
 	return FromSecret(sec), nil
 }
 
+// GetSecretsByURI finds the secrets matching a secret URI in the secret keeper.
+// The keeper named in the URI must be empty or match the name of the keeper
+// served.
+func (s *Server) GetSecretsByURI(
+	req *GetSecretsByURIRequest,
+	stream Keeper_GetSecretsByURIServer,
+) error {
+	uri, err := config.ParseSecretURI(req.GetUri())
+	if err != nil {
+		return err
+	}
+
+	if uri.Keeper != "" && uri.Keeper != s.name {
+		return fmt.Errorf("secret URI %s names keeper %q, but the service serves keeper %q", uri, uri.Keeper, s.name)
+	}
+
+	secs, err := uri.Find(stream.Context(), s.Keeper)
+	if err != nil {
+		return err
+	}
+
+	for _, sec := range secs {
+		err := stream.Send(FromSecret(sec))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // SetSecret maps the SetSecret secret keeper call to the gRPC interface.
 func (s *Server) SetSecret(
 	ctx context.Context,
@@ -179,3 +221,84 @@ func (s *Server) GetServiceInfo(
 		EnforcedPolicies:  s.enforcedPolicies,
 	}, nil
 }
+
+// FlushCache locates every cache used by the served secret keeper and removes
+// the identified secrets from them. If no IDs are given, every cache is
+// emptied.
+func (s *Server) FlushCache(
+	ctx context.Context,
+	req *FlushCacheRequest,
+) (*FlushCacheResponse, error) {
+	var caches int32
+	err := secrets.Walk(s.Keeper, func(k secrets.Keeper) error {
+		c, isCache := k.(cacheFlusher)
+		if !isCache {
+			return nil
+		}
+
+		caches++
+		if len(req.GetIds()) == 0 {
+			c.Flush(ctx)
+			return nil
+		}
+
+		for _, id := range req.GetIds() {
+			c.Invalidate(ctx, id)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &FlushCacheResponse{Caches: caches}, nil
+}
+
+// CopyToClipboard places the value on the clipboard found by clipboard.Detect.
+// The clipboard is cleared once the timeout passes, provided it still holds the
+// value. A timeout of zero leaves the value on the clipboard.
+func (s *Server) CopyToClipboard(
+	ctx context.Context,
+	req *CopyToClipboardRequest,
+) (*CopyToClipboardResponse, error) {
+	cb, err := clipboard.Detect()
+	if err != nil {
+		return nil, fmt.Errorf("service cannot copy to the clipboard: %w", err)
+	}
+
+	if err := cb.Copy(ctx, req.GetValue()); err != nil {
+		return nil, err
+	}
+
+	if timeout := req.GetTimeout().AsDuration(); timeout > 0 {
+		go func() {
+			_, _ = clipboard.ClearAfter(context.Background(), cb, req.GetValue(), timeout)
+		}()
+	}
+
+	return &CopyToClipboardResponse{Clipboard: cb.Name()}, nil
+}
+
+// GetOTP computes the current one-time password from the seed stored in the
+// secret with the given ID.
+func (s *Server) GetOTP(
+	ctx context.Context,
+	req *GetOTPRequest,
+) (*OTP, error) {
+	sec, err := s.Keeper.GetSecret(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	code, err := otp.Generate(ctx, s.Keeper, sec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTP{
+		Code:      code.Code,
+		Remaining: durationpb.New(code.Remaining),
+		Period:    durationpb.New(code.Period),
+	}, nil
+}
@@ -2,6 +2,7 @@ package secrets
 
 import (
 	"net/url"
+	"sort"
 	"time"
 )
 
@@ -83,6 +84,52 @@ type SettableUrl interface {
 	SetUrl(*url.URL)
 }
 
+// WithAttachments is the interface for a secret that carries named binary
+// attachments, such as private keys, certificates, or other files.
+type WithAttachments interface {
+	// Attachments returns the names of the attachments in sorted order.
+	Attachments() []string
+
+	// GetAttachment returns the content of the named attachment and whether
+	// the attachment exists.
+	GetAttachment(string) ([]byte, bool)
+
+	// SetAttachment adds or replaces the named attachment.
+	SetAttachment(string, []byte)
+
+	// DeleteAttachment removes the named attachment.
+	DeleteAttachment(string)
+}
+
+// Attachments is a helper that returns the names of the attachments of the
+// secret. It returns nil if the secret does not implement WithAttachments.
+func Attachments(sec Secret) []string {
+	if wa, hasAttachments := sec.(WithAttachments); hasAttachments {
+		return wa.Attachments()
+	}
+	return nil
+}
+
+// GetAttachment is a helper that returns the content of the named attachment of
+// the secret. It returns false if the secret does not implement WithAttachments
+// or has no such attachment.
+func GetAttachment(sec Secret, name string) ([]byte, bool) {
+	if wa, hasAttachments := sec.(WithAttachments); hasAttachments {
+		return wa.GetAttachment(name)
+	}
+	return nil, false
+}
+
+// sortedKeys returns the keys of an attachment map in sorted order.
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // UrlString is a helper that returns the string for a URL. If the URL is set,
 // it returns the value returned by calling the String method on it. If not, it
 // returns an empty string.
@@ -0,0 +1,165 @@
+package keepass_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	gokeepasslib "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets/keepass"
+)
+
+// benchmarkSizes are the database sizes the benchmarks are run against. The
+// time per lookup should not grow with the size of the database.
+var benchmarkSizes = []int{500, 1000, 5000}
+
+// benchmarkGroups is the number of groups the entries are spread across.
+const benchmarkGroups = 50
+
+// writeBenchmarkDatabase writes a database holding n entries spread across
+// benchmarkGroups groups and returns its path and the IDs and names of the
+// entries. The database is built directly, as saving it once per entry would
+// take far longer than the benchmarks themselves.
+func writeBenchmarkDatabase(b *testing.B, n int) (string, []string, []string) {
+	b.Helper()
+
+	db := gokeepasslib.NewDatabase(gokeepasslib.WithDatabaseKDBXVersion3())
+	db.Credentials = gokeepasslib.NewPasswordCredentials("testing123")
+
+	root := &db.Content.Root.Groups[0]
+	for i := 0; i < benchmarkGroups; i++ {
+		g := gokeepasslib.NewGroup()
+		g.Name = fmt.Sprintf("Group%02d", i)
+		root.Groups = append(root.Groups, g)
+	}
+
+	ids := make([]string, 0, n)
+	names := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("secret%05d", i)
+
+		e := gokeepasslib.NewEntry()
+		e.Values = append(e.Values,
+			gokeepasslib.ValueData{Key: "Title", Value: gokeepasslib.V{Content: name}},
+			gokeepasslib.ValueData{Key: "UserName", Value: gokeepasslib.V{Content: "user"}},
+			gokeepasslib.ValueData{Key: "Password", Value: gokeepasslib.V{
+				Content:   "password",
+				Protected: w.NewBoolWrapper(true),
+			}},
+		)
+
+		g := &root.Groups[i%benchmarkGroups]
+		g.Entries = append(g.Entries, e)
+
+		id, err := e.UUID.MarshalText()
+		require.NoError(b, err)
+		ids = append(ids, string(id))
+		names = append(names, name)
+	}
+
+	require.NoError(b, db.LockProtectedEntries())
+
+	path := filepath.Join(b.TempDir(), "bench.kdbx")
+	f, err := os.Create(path)
+	require.NoError(b, err)
+	require.NoError(b, gokeepasslib.NewEncoder(f).Encode(db))
+	require.NoError(b, f.Close())
+
+	return path, ids, names
+}
+
+// openBenchmarkDatabase opens a new database of n entries for a benchmark.
+func openBenchmarkDatabase(b *testing.B, n int) (*keepass.Keepass, []string, []string) {
+	b.Helper()
+
+	path, ids, names := writeBenchmarkDatabase(b, n)
+	k, err := keepass.NewKeepass(path, "testing123")
+	require.NoError(b, err)
+
+	return k, ids, names
+}
+
+func BenchmarkKeepass_GetSecret(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			k, ids, _ := openBenchmarkDatabase(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := k.GetSecret(ctx, ids[i%n]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkKeepass_GetSecretsByName(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			k, _, names := openBenchmarkDatabase(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				secs, err := k.GetSecretsByName(ctx, names[i%n])
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(secs) != 1 {
+					b.Fatalf("found %d secrets", len(secs))
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkKeepass_ListSecrets(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			k, _, _ := openBenchmarkDatabase(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				loc := fmt.Sprintf("Group%02d", i%benchmarkGroups)
+				if _, err := k.ListSecrets(ctx, loc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkSync_AddSecretKeeper gathers every secret in the database for a
+// sync. The time taken per entry should not grow with the size of the
+// database.
+func BenchmarkSync_AddSecretKeeper(b *testing.B) {
+	ctx := context.Background()
+	for _, n := range benchmarkSizes {
+		b.Run(fmt.Sprintf("entries=%d", n), func(b *testing.B) {
+			k, _, _ := openBenchmarkDatabase(b, n)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s, err := keeper.NewSync()
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				if err := s.AddSecretKeeper(ctx, k); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*n), "ns/entry")
+		})
+	}
+}
@@ -2,12 +2,14 @@ package keepass
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
 
 	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/pflag"
 
 	"github.com/zostay/ghost/pkg/config"
 	"github.com/zostay/ghost/pkg/plugin"
@@ -23,6 +25,21 @@ type Config struct {
 	Path string `mapstructure:"path" yaml:"path"`
 	// Master is the master password to use to unlock the Keepass database.
 	Master string `mapstructure:"master_password" yaml:"master_password"`
+	// KeyFile is the path to the key file used to unlock the Keepass database.
+	// When both this and a master password are set, both are required.
+	KeyFile string `mapstructure:"key_file" yaml:"key_file,omitempty"`
+	// KeyData is the contents of the key file used to unlock the Keepass
+	// database, usually set with a secret reference. It is used in place of
+	// KeyFile.
+	KeyData string `mapstructure:"key_data" yaml:"key_data,omitempty"`
+	// GenerateKeyFile generates the key file when creating a new database if
+	// the key file does not exist.
+	GenerateKeyFile bool `mapstructure:"generate_key_file" yaml:"generate_key_file,omitempty"`
+	// KDBXVersion is the KDBX format version, 3 or 4, of a new database.
+	KDBXVersion int `mapstructure:"kdbx_version" yaml:"kdbx_version,omitempty"`
+	// KDF is the key derivation function, aes-kdf or argon2d, of a new
+	// database. Argon2id is not supported.
+	KDF string `mapstructure:"kdf" yaml:"kdf,omitempty"`
 }
 
 // Builder builds a new Keepass secret keeper.
@@ -37,7 +54,96 @@ func Builder(_ context.Context, c any) (secrets.Keeper, error) {
 		return nil, err
 	}
 
-	return NewKeepass(path, cfg.Master)
+	var opts []Option
+	if cfg.KeyFile != "" {
+		keyFile, err := homedir.Expand(os.ExpandEnv(cfg.KeyFile))
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithKeyFile(keyFile))
+	}
+
+	if cfg.KeyData != "" {
+		opts = append(opts, WithKeyData([]byte(cfg.KeyData)))
+	}
+
+	if cfg.GenerateKeyFile {
+		opts = append(opts, WithGeneratedKeyFile())
+	}
+
+	if cfg.KDBXVersion != 0 {
+		opts = append(opts, WithKDBXVersion(cfg.KDBXVersion))
+	}
+
+	if cfg.KDF != "" {
+		kdf, err := ParseKDF(cfg.KDF)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithKDF(kdf))
+	}
+
+	return NewKeepass(path, cfg.Master, opts...)
+}
+
+// Validate checks that the configuration is correct for the Keepass secret
+// keeper. It checks that the database can be unlocked with a master password,
+// a key file, or both, that the key file exists or may be generated, and that
+// the format chosen for a new database is supported.
+func Validate(_ context.Context, c any) error {
+	cfg, isKeepass := c.(*Config)
+	if !isKeepass {
+		return plugin.ErrConfig
+	}
+
+	errs := plugin.NewValidationError()
+
+	if cfg.Path == "" {
+		errs.Append(errors.New("keepass path is required"))
+	}
+
+	if cfg.Master == "" && cfg.KeyFile == "" && cfg.KeyData == "" {
+		errs.Append(errors.New("keepass requires a master password, a key file, or both"))
+	}
+
+	if cfg.KeyFile != "" && cfg.KeyData != "" {
+		errs.Append(errors.New("keepass key file and key data may not both be set"))
+	}
+
+	if cfg.KeyFile != "" && cfg.KeyFile != config.SecretPlaceholder && !cfg.GenerateKeyFile {
+		keyFile, err := homedir.Expand(os.ExpandEnv(cfg.KeyFile))
+		if err != nil {
+			errs.Append(fmt.Errorf("keepass key file: %w", err))
+		} else if _, err := os.Stat(keyFile); err != nil {
+			errs.Append(fmt.Errorf("keepass key file: %w", err))
+		}
+	}
+
+	if cfg.GenerateKeyFile && (cfg.KeyFile == "" || cfg.KeyFile == config.SecretPlaceholder) {
+		errs.Append(errors.New("keepass generate key file requires a key file path"))
+	}
+
+	kdf := KDFAES
+	if cfg.KDF != "" {
+		var err error
+		kdf, err = ParseKDF(cfg.KDF)
+		if err != nil {
+			errs.Append(err)
+			kdf = KDFAES
+		}
+	}
+
+	switch cfg.KDBXVersion {
+	case 0, 3:
+		if kdf != KDFAES {
+			errs.Append(fmt.Errorf("keepass KDBX version 3 only supports the %s KDF", KDFAES))
+		}
+	case 4:
+	default:
+		errs.Append(fmt.Errorf("keepass KDBX version must be 3 or 4, not %d", cfg.KDBXVersion))
+	}
+
+	return errs.Return()
 }
 
 // Print prints the configuration for the Keepass secret keeper.
@@ -53,15 +159,39 @@ func Print(c any, w io.Writer) error {
 		masterVal = "<hidden>"
 	}
 	fmt.Fprintln(w, "master:", masterVal)
+	if cfg.KeyFile != "" {
+		keyFile := cfg.KeyFile
+		if keyFile == config.SecretPlaceholder {
+			keyFile = "<hidden>"
+		}
+		fmt.Fprintln(w, "key file:", keyFile)
+	}
+	if cfg.KeyData != "" {
+		fmt.Fprintln(w, "key data: <hidden>")
+	}
+	if cfg.KDBXVersion != 0 {
+		fmt.Fprintln(w, "kdbx version:", cfg.KDBXVersion)
+	}
+	if cfg.KDF != "" {
+		fmt.Fprintln(w, "kdf:", cfg.KDF)
+	}
 	return nil
 }
 
 func init() {
+	var (
+		generateKeyFile bool
+		kdbxVersion     int
+		kdf             string
+	)
+
 	cmd := plugin.CmdConfig{
 		Short: "Configure a Keepass secret keeper",
 		Fields: map[string]string{
 			"path":            "Path to the Keepass database",
 			"master-password": "The master password to use to unlock the Keepass database",
+			"key-file":        "The path to the key file to use to unlock the Keepass database",
+			"key-data":        "The contents of the key file to use to unlock the Keepass database, in place of key-file",
 		},
 		Run: func(keeperName string, fields map[string]any) (config.KeeperConfig, error) {
 			kc := config.KeeperConfig{
@@ -76,8 +206,34 @@ func init() {
 				kc["master_password"] = master
 			}
 
+			if keyFile, ok := fields["key-file"]; ok {
+				kc["key_file"] = keyFile
+			}
+
+			if keyData, ok := fields["key-data"]; ok {
+				kc["key_data"] = keyData
+			}
+
+			if generateKeyFile {
+				kc["generate_key_file"] = true
+			}
+
+			if kdbxVersion != 0 {
+				kc["kdbx_version"] = kdbxVersion
+			}
+
+			if kdf != "" {
+				kc["kdf"] = kdf
+			}
+
 			return kc, nil
 		},
+		FlagInit: func(flags *pflag.FlagSet) error {
+			flags.BoolVar(&generateKeyFile, "generate-key-file", false, "generate the key file when creating a new database")
+			flags.IntVar(&kdbxVersion, "kdbx-version", 0, "the KDBX version, 3 or 4, of a new database (default 3)")
+			flags.StringVar(&kdf, "kdf", "", "the key derivation function, aes-kdf or argon2d, of a new database (argon2id is not supported)")
+			return nil
+		},
 	}
-	plugin.Register(ConfigType, reflect.TypeOf(Config{}), Builder, nil, Print, cmd)
+	plugin.Register(ConfigType, reflect.TypeOf(Config{}), Builder, Validate, Print, cmd)
 }
@@ -0,0 +1,132 @@
+package keepass
+
+import (
+	keepass "github.com/tobischo/gokeepasslib/v3"
+	"github.com/zostay/go-std/slices"
+)
+
+// indexedEntry locates an entry in the database.
+type indexedEntry struct {
+	e     *keepass.Entry
+	dir   string
+	title string
+}
+
+// index provides lookups of entries by UUID and title and of groups by
+// location and UUID without walking the database. It holds pointers into the
+// entry and group slices of the database, so it must be updated whenever an
+// entry or group is added to or removed from a slice, as that may move the
+// others in the same slice.
+type index struct {
+	entries   map[keepass.UUID]indexedEntry
+	titles    map[string][]keepass.UUID
+	groups    map[string][]*keepass.Group
+	groupDirs map[keepass.UUID]string
+}
+
+// newIndex builds the index of the database.
+func newIndex(db *keepass.Database) *index {
+	x := &index{
+		entries:   map[keepass.UUID]indexedEntry{},
+		titles:    map[string][]keepass.UUID{},
+		groups:    map[string][]*keepass.Group{},
+		groupDirs: map[keepass.UUID]string{},
+	}
+
+	x.indexGroups(db)
+
+	kw := walk(db, true)
+	for kw.Next() {
+		x.indexEntry(kw.Dir(), kw.Entry())
+	}
+
+	return x
+}
+
+// indexGroups rebuilds the index of the groups after a group has been added,
+// which may move the other groups in the same slice. Entries are not moved
+// when their group is, so the index of the entries is kept.
+func (x *index) indexGroups(db *keepass.Database) {
+	x.groups = map[string][]*keepass.Group{}
+	x.groupDirs = map[keepass.UUID]string{}
+
+	kw := walk(db, false)
+	for kw.Next() {
+		x.groups[kw.Dir()] = append(x.groups[kw.Dir()], kw.Group())
+		x.groupDirs[kw.Group().UUID] = kw.Dir()
+	}
+}
+
+// indexEntries updates the index after the entries of a group have been added
+// to, removed from, or changed.
+func (x *index) indexEntries(dir string, g *keepass.Group) {
+	for i := range g.Entries {
+		x.indexEntry(dir, &g.Entries[i])
+	}
+}
+
+// indexEntry adds the entry to the index or updates it.
+func (x *index) indexEntry(dir string, e *keepass.Entry) {
+	title := e.GetTitle()
+	old, isIndexed := x.entries[e.UUID]
+	if isIndexed && old.title != title {
+		x.unindexTitle(old.title, e.UUID)
+	}
+
+	if !isIndexed || old.title != title {
+		x.titles[title] = append(x.titles[title], e.UUID)
+	}
+
+	x.entries[e.UUID] = indexedEntry{e, dir, title}
+}
+
+// unindexEntry removes the entry with the given UUID from the index.
+func (x *index) unindexEntry(uuid keepass.UUID) {
+	if old, isIndexed := x.entries[uuid]; isIndexed {
+		x.unindexTitle(old.title, uuid)
+		delete(x.entries, uuid)
+	}
+}
+
+// unindexTitle removes the UUID from the entries with the given title.
+func (x *index) unindexTitle(title string, uuid keepass.UUID) {
+	uuids := x.titles[title]
+	for i, u := range uuids {
+		if u.Compare(uuid) {
+			uuids = slices.Delete(uuids, i)
+			break
+		}
+	}
+
+	if len(uuids) == 0 {
+		delete(x.titles, title)
+		return
+	}
+
+	x.titles[title] = uuids
+}
+
+// group returns the first group at the given location or nil.
+func (x *index) group(dir string) *keepass.Group {
+	if gs := x.groups[dir]; len(gs) > 0 {
+		return gs[0]
+	}
+	return nil
+}
+
+// groupByUUID returns the group with the given UUID and its location, or nil
+// if there is no such group.
+func (x *index) groupByUUID(uuid keepass.UUID) (*keepass.Group, string) {
+	dir, hasGroup := x.groupDirs[uuid]
+	if !hasGroup {
+		return nil, ""
+	}
+
+	for _, g := range x.groups[dir] {
+		if g.UUID.Compare(uuid) {
+			return g, dir
+		}
+	}
+
+	return nil, ""
+}
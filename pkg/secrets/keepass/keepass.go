@@ -3,7 +3,12 @@ package keepass
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
 	"strings"
+	"sync"
 
 	keepass "github.com/tobischo/gokeepasslib/v3"
 	"github.com/zostay/go-std/slices"
@@ -14,30 +19,78 @@ import (
 )
 
 // Keepass is a Keeper with access to a Keepass password database.
+//
+// Before each read, the database is reloaded if another program, such as
+// KeePassXC, has changed the file. Before each write, changes made by another
+// program are merged with the changes being saved, entry by entry. If both
+// changed the same entry, nothing is saved and ErrConflict is returned.
+//
+// The protected values of the loaded database are kept unlocked and are only
+// locked while the database is written, so that entries may be read, moved, and
+// removed without unlocking every value in the database each time.
+//
+// A Keepass is safe for concurrent use.
 type Keepass struct {
 	fssafe.LoaderSaver
-	db *keepass.Database // the loaded db struct
+	mu  sync.RWMutex      // guards the fields below
+	db  *keepass.Database // the loaded db struct, kept unlocked
+	idx *index            // lookups into the loaded db
+
+	path  string                      // path to the db file, if any
+	state fileState                   // state of the file when last read or written
+	base  map[keepass.UUID]entryState // state of the entries when last read or written
 }
 
 var _ secrets.Keeper = &Keepass{}
 
 // NewKeepassNoVerify creates a new Keepass Keeper and returns it. It does not
-// attempt to read the database or verify it is set up correctly.
-func NewKeepassNoVerify(path, master string) (*Keepass, error) {
-	db := keepass.NewDatabase()
-	db.Credentials = keepass.NewPasswordCredentials(master)
+// attempt to read the database or verify it is set up correctly. The database
+// is unlocked with the master password, the key file given by WithKeyFile or
+// WithKeyData, or both.
+func NewKeepassNoVerify(path, master string, opts ...Option) (*Keepass, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	db, err := o.newDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	db.Credentials, err = o.credentials(master)
+	if err != nil {
+		return nil, err
+	}
 
 	ls := fssafe.NewFileSystemLoaderSaver(path)
-	k := Keepass{ls, db}
+	k := Keepass{
+		LoaderSaver: ls,
+		db:          db,
+		idx:         newIndex(db),
+		path:        path,
+	}
 
 	return &k, nil
 }
 
 // NewKeepass creates a new Keepass Keeper and returns it. If no database exists
-// yet, it will create an empty one. It returns an error if there's a problem
-// reading the Keepass database.
-func NewKeepass(path, master string) (*Keepass, error) {
-	k, err := NewKeepassNoVerify(path, master)
+// yet, it will create an empty one using the format chosen with WithKDBXVersion
+// and WithKDF, first generating the key file if WithGeneratedKeyFile is given.
+// It returns an error if there's a problem reading the Keepass database.
+func NewKeepass(path, master string, opts ...Option) (*Keepass, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.generateKeyFile && o.keyFile != "" && !fileExists(path) && !fileExists(o.keyFile) {
+		if err := GenerateKeyFile(o.keyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	k, err := NewKeepassNoVerify(path, master, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +108,12 @@ func NewKeepass(path, master string) (*Keepass, error) {
 	return k, nil
 }
 
+// fileExists returns true if a file exists at the path.
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 // ensureExists attempts to create an empty Keepass database if there's an error
 // attempting to load. Returns an error if the save fails.
 func (k *Keepass) ensureExists() error {
@@ -69,63 +128,117 @@ func (k *Keepass) ensureExists() error {
 	return nil
 }
 
-// reload loads the databsae from disk.
+// reload loads the database from disk. The database is decoded fresh and only
+// replaces the loaded database if it is read successfully.
 func (k *Keepass) reload() error {
-	dfr, err := k.Loader()
+	db := keepass.NewDatabase()
+	db.Credentials = k.db.Credentials
+
+	st, err := k.decode(db)
 	if err != nil {
 		return err
 	}
 
-	d := keepass.NewDecoder(dfr)
-	err = d.Decode(k.db)
+	k.db = db
+	k.state = st
+	k.idx = newIndex(k.db)
+	k.base = entryStates(k.db)
+	return nil
+}
+
+// decode reads the database from disk into db and returns the state of the
+// file read. The protected values of db are unlocked.
+func (k *Keepass) decode(db *keepass.Database) (fileState, error) {
+	dfr, err := k.Loader()
 	if err != nil {
-		return err
+		return fileState{}, err
+	}
+
+	r, h := hashingReader(dfr)
+	err = decodeDatabase(r, db)
+	if err != nil {
+		_ = dfr.Close()
+		return fileState{}, err
 	}
 
 	err = dfr.Close()
 	if err != nil {
-		return err
+		return fileState{}, err
 	}
 
-	return nil
+	err = db.UnlockProtectedEntries()
+	if err != nil {
+		return fileState{}, err
+	}
+
+	return k.fileStateOf(h)
+}
+
+// ErrCorrupt is returned when the database file cannot be decoded.
+var ErrCorrupt = errors.New("keepass database is corrupt")
+
+// decodeDatabase decodes the database read from r into db. The decoder panics
+// on some corrupt files, so a panic is returned as an error.
+func decodeDatabase(r io.Reader, db *keepass.Database) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: %v", ErrCorrupt, p)
+		}
+	}()
+
+	return keepass.NewDecoder(r).Decode(db)
 }
 
 // ListLocations gets all the group names from the Keepass database. Groups are
 // hierarchical in the Keepass database. Each location is returned as path fully
 // qualified path.
 func (k *Keepass) ListLocations(ctx context.Context) ([]string, error) {
-	kw := k.Walker(false)
+	if err := k.rlock(); err != nil {
+		return nil, err
+	}
+	defer k.mu.RUnlock()
+
+	trashDir := k.trashDir()
+	kw := walk(k.db, false)
 	var locations []string
 	for kw.Next() {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
+			if inTrash(trashDir, kw.Dir()) {
+				continue
+			}
 			locations = append(locations, kw.Dir())
 		}
 	}
 	return locations, nil
 }
 
-// ListSecrets gets the names of all secrets in the named location.
+// ListSecrets gets the IDs of all secrets in the named location. The location
+// is the full path of a group, as returned by ListLocations.
 func (k *Keepass) ListSecrets(
 	ctx context.Context,
 	folder string,
 ) ([]string, error) {
-	kw := k.Walker(true)
+	if err := k.rlock(); err != nil {
+		return nil, err
+	}
+	defer k.mu.RUnlock()
+
+	if inTrash(k.trashDir(), folder) {
+		return nil, nil
+	}
+
 	var secs []string
-	for kw.Next() {
+	for _, g := range k.idx.groups[folder] {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			e := kw.Entry()
-			g := kw.Group()
-			if g.Name != folder {
-				continue
+			for _, e := range g.Entries {
+				secs = append(secs, makeID(e.UUID))
 			}
-
-			secs = append(secs, makeID(e.UUID))
 		}
 	}
 
@@ -136,27 +249,46 @@ func (k *Keepass) ListSecrets(
 func (k *Keepass) GetSecret(
 	ctx context.Context,
 	id string,
+) (secrets.Secret, error) {
+	if err := k.rlock(); err != nil {
+		return nil, err
+	}
+	defer k.mu.RUnlock()
+
+	return k.getSecret(ctx, id)
+}
+
+// rlock reloads the database if another program has changed it on disk and
+// then takes the read lock, which the caller must release. The read lock is not
+// held if an error is returned.
+func (k *Keepass) rlock() error {
+	k.mu.Lock()
+	err := k.refresh()
+	k.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	k.mu.RLock()
+	return nil
+}
+
+// getSecret retrieves the identified secret from the loaded database.
+func (k *Keepass) getSecret(
+	_ context.Context,
+	id string,
 ) (secrets.Secret, error) {
 	uuid, err := makeUUID(id)
 	if err != nil {
 		return nil, err
 	}
 
-	kw := k.Walker(true)
-	for kw.Next() {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-			e := kw.Entry()
-			dir := kw.Dir()
-
-			if e.UUID.Compare(uuid) {
-				return newSecret(k.db, e, dir), nil
-			}
-		}
+	ie, isIndexed := k.idx.entries[uuid]
+	if !isIndexed || inTrash(k.trashDir(), ie.dir) {
+		return nil, secrets.ErrNotFound
 	}
-	return nil, secrets.ErrNotFound
+
+	return newSecret(k.db, ie.e, ie.dir), nil
 }
 
 // GetSecretsByName retrieves all secrets with the given name from the Keepass
@@ -165,18 +297,21 @@ func (k *Keepass) GetSecretsByName(
 	ctx context.Context,
 	name string,
 ) ([]secrets.Secret, error) {
+	if err := k.rlock(); err != nil {
+		return nil, err
+	}
+	defer k.mu.RUnlock()
+
 	var secs []secrets.Secret
-	kw := k.Walker(true)
-	for kw.Next() {
+	trashDir := k.trashDir()
+	for _, uuid := range k.idx.titles[name] {
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		default:
-			e := kw.Entry()
-			dir := kw.Dir()
-
-			if e.GetTitle() == name {
-				secs = append(secs, newSecret(k.db, e, dir))
+			ie := k.idx.entries[uuid]
+			if !inTrash(trashDir, ie.dir) {
+				secs = append(secs, newSecret(k.db, ie.e, ie.dir))
 			}
 		}
 	}
@@ -186,13 +321,7 @@ func (k *Keepass) GetSecretsByName(
 
 // getGroup retrieves the named group or returns nil.
 func (k *Keepass) getGroup(groupName string) *keepass.Group {
-	kw := k.Walker(false)
-	for kw.Next() {
-		if kw.Dir() == groupName {
-			return kw.Group()
-		}
-	}
-	return nil
+	return k.idx.group(groupName)
 }
 
 func getGroup(grp *keepass.Group, groupName string) *keepass.Group {
@@ -206,16 +335,57 @@ func getGroup(grp *keepass.Group, groupName string) *keepass.Group {
 }
 
 func createGroup(grp *keepass.Group, groupName string) *keepass.Group {
-	newGrp := keepass.Group{Name: groupName}
+	newGrp := keepass.NewGroup()
+	newGrp.Name = groupName
 	grp.Groups = append(grp.Groups, newGrp)
 	return &grp.Groups[len(grp.Groups)-1]
 }
 
 // ensureGroupExists creates a group with the given groupName if it does not yet
-// exist.
-func (k *Keepass) ensureGroupExists(groupPath string) *keepass.Group {
+// exist. It returns the group and its location.
+func (k *Keepass) ensureGroupExists(groupPath string) (*keepass.Group, string) {
+	dir := path.Join(strings.Split(groupPath, "/")...)
+	if g := k.idx.group(dir); g != nil {
+		return g, dir
+	}
+
+	g := ensureGroup(k.db, dir)
+	k.idx.indexGroups(k.db)
+	return g, dir
+}
+
+// takeEntry removes the entry with the given UUID from its group and returns
+// it. It returns false if there is no such entry.
+func (k *Keepass) takeEntry(uuid keepass.UUID) (keepass.Entry, bool) {
+	ie, isIndexed := k.idx.entries[uuid]
+	if !isIndexed {
+		return keepass.Entry{}, false
+	}
+
+	for _, g := range k.idx.groups[ie.dir] {
+		for i := range g.Entries {
+			if &g.Entries[i] != ie.e {
+				continue
+			}
+
+			e := ie.e.Clone()
+			e.UUID = ie.e.UUID
+			g.Entries = slices.Delete(g.Entries, i)
+
+			k.idx.unindexEntry(uuid)
+			k.idx.indexEntries(ie.dir, g)
+			return e, true
+		}
+	}
+
+	return keepass.Entry{}, false
+}
+
+// ensureGroup creates the group at the given path in the database if it does
+// not yet exist.
+func ensureGroup(db *keepass.Database, groupPath string) *keepass.Group {
 	groupNames := strings.Split(groupPath, "/")
-	grp := &k.db.Content.Root.Groups[0]
+	grp := &db.Content.Root.Groups[0]
 	for _, groupName := range groupNames {
 		if groupName == "" {
 			continue
@@ -237,14 +407,15 @@ func (k *Keepass) SetSecret(
 	ctx context.Context,
 	secret secrets.Secret,
 ) (secrets.Secret, error) {
-	g := k.ensureGroupExists(secret.Location())
+	k.mu.Lock()
+	defer k.mu.Unlock()
 
 	var (
 		newSec *Secret
 		isNew  bool
 	)
 
-	foundSecret, err := k.GetSecret(ctx, secret.ID())
+	foundSecret, err := k.getSecret(ctx, secret.ID())
 	if err != nil {
 		if errors.Is(err, secrets.ErrNotFound) {
 			isNew = true
@@ -257,24 +428,15 @@ func (k *Keepass) SetSecret(
 		newSec.applyChanges(secret)
 	}
 
-	err = k.db.UnlockProtectedEntries()
-	if err != nil {
-		return nil, err
-	}
+	touch(newSec.e)
 
+	g, dir := k.ensureGroupExists(secret.Location())
 	if isNew {
 		g.Entries = append(g.Entries, *newSec.e)
-	} else {
-		for i, ge := range g.Entries {
-			if ge.UUID.Compare(newSec.e.UUID) {
-				g.Entries[i] = *newSec.e
-			}
-		}
-	}
-
-	err = k.db.LockProtectedEntries()
-	if err != nil {
-		return nil, err
+		k.idx.indexEntries(dir, g)
+	} else if ie := k.idx.entries[newSec.e.UUID]; ie.dir == dir {
+		*ie.e = *newSec.e
+		k.idx.indexEntry(dir, ie.e)
 	}
 
 	err = k.save()
@@ -282,7 +444,9 @@ func (k *Keepass) SetSecret(
 		return nil, err
 	}
 
-	return newSec, nil
+	// saving renumbers the binaries of the database, so the references held
+	// by newSec may no longer be correct
+	return k.getSecret(ctx, newSec.ID())
 }
 
 // performCopy copies the secret into a new location.
@@ -290,6 +454,7 @@ func (k *Keepass) performCopy(
 	_ context.Context,
 	newSec *Secret,
 	g *keepass.Group,
+	dir string,
 ) {
 	preExisting := false
 	for i, ge := range g.Entries {
@@ -302,6 +467,8 @@ func (k *Keepass) performCopy(
 	if !preExisting {
 		g.Entries = append(g.Entries, *newSec.e)
 	}
+
+	k.idx.indexEntries(dir, g)
 }
 
 // CopySecret copies the secret into an additional group in the Keepass
@@ -310,14 +477,18 @@ func (k *Keepass) CopySecret(
 	ctx context.Context,
 	id, grp string,
 ) (secrets.Secret, error) {
-	g := k.ensureGroupExists(grp)
-	secret, err := k.GetSecret(ctx, id)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	secret, err := k.getSecret(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	newSec := fromSecret(k.db, secret, false)
+	touch(newSec.e)
 
-	k.performCopy(ctx, newSec, g)
+	g, dir := k.ensureGroupExists(grp)
+	k.performCopy(ctx, newSec, g, dir)
 
 	err = k.save()
 	if err != nil {
@@ -332,26 +503,21 @@ func (k *Keepass) MoveSecret(
 	ctx context.Context,
 	id, grp string,
 ) (secrets.Secret, error) {
-	secret, err := k.GetSecret(ctx, id)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	secret, err := k.getSecret(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	oldGrp := k.getGroup(secret.Location())
-	newGrp := k.ensureGroupExists(grp)
-
 	oldUUID, _ := makeUUID(secret.ID())
 	newSec := fromSecret(k.db, secret, false)
+	touch(newSec.e)
 
-	k.performCopy(ctx, newSec, newGrp)
-
-	if oldGrp != nil {
-		for i, ge := range oldGrp.Entries {
-			if ge.UUID.Compare(oldUUID) {
-				oldGrp.Entries = slices.Delete(oldGrp.Entries, i)
-			}
-		}
-	}
+	g, dir := k.ensureGroupExists(grp)
+	k.performCopy(ctx, newSec, g, dir)
+	k.takeEntry(oldUUID)
 
 	err = k.save()
 	if err != nil {
@@ -361,34 +527,63 @@ func (k *Keepass) MoveSecret(
 	return newSec, nil
 }
 
-// DeleteSecret removes the secret from the Keepass database.
+// DeleteSecret moves the secret to the recycle bin of the Keepass database if
+// the recycle bin is enabled. Otherwise, or if the secret is already in the
+// recycle bin, the secret is removed permanently.
 func (k *Keepass) DeleteSecret(
 	_ context.Context,
 	id string,
 ) error {
-	kw := k.Walker(false)
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
 	uuid, _ := makeUUID(id)
-	for kw.Next() {
-		g := kw.Group()
-		for i, ge := range g.Entries {
-			if ge.UUID.Compare(uuid) {
-				g.Entries = slices.Delete(g.Entries, i)
-				return k.save()
-			}
-		}
+	ie, isIndexed := k.idx.entries[uuid]
+	if !isIndexed {
+		return nil
 	}
-	return nil
+
+	trashDir := k.trashDir()
+	if inTrash(trashDir, ie.dir) || !k.trashEntry(ie.dir, uuid) {
+		k.takeEntry(uuid)
+	}
+
+	return k.save()
 }
 
-// save sends changes made to the Keepass database to disk.
+// save sends changes made to the Keepass database to disk. If another program
+// has changed the database on disk, the changes are merged first.
 func (k *Keepass) save() error {
+	isChanged, err := k.changedOnDisk()
+	if err != nil {
+		return err
+	}
+
+	if isChanged {
+		err = k.merge()
+		if err != nil {
+			return err
+		}
+	}
+
 	cfw, err := k.Saver()
 	if err != nil {
 		return err
 	}
 
-	e := keepass.NewEncoder(cfw)
+	// the encoder expects the protected values to be locked, in the order in
+	// which they are written, and leaves them locked
+	err = k.db.LockProtectedEntries()
+	if err != nil {
+		return err
+	}
+
+	wr, h := hashingWriter(cfw)
+	e := keepass.NewEncoder(wr)
 	err = e.Encode(k.db)
+	if unlockErr := k.db.UnlockProtectedEntries(); err == nil {
+		err = unlockErr
+	}
 	if err != nil {
 		return err
 	}
@@ -398,5 +593,11 @@ func (k *Keepass) save() error {
 		return err
 	}
 
+	k.state, err = k.fileStateOf(h)
+	if err != nil {
+		return err
+	}
+
+	k.base = entryStates(k.db)
 	return nil
 }
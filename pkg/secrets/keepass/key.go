@@ -0,0 +1,203 @@
+package keepass
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	keepass "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+)
+
+// KDF names the key derivation function used to protect a new database.
+type KDF string
+
+const (
+	// KDFAES is the AES-KDF, the only choice for KDBX 3.1 databases.
+	KDFAES KDF = "aes-kdf"
+
+	// KDFArgon2d is Argon2d, the default for KDBX 4 databases.
+	KDFArgon2d KDF = "argon2d"
+)
+
+// aesRounds is the number of AES-KDF rounds used by new KDBX 4 databases,
+// matching the KeePass default.
+const aesRounds = 60000
+
+// ErrUnsupportedKDF is returned when a KDF that cannot be used to create a
+// database is requested.
+var ErrUnsupportedKDF = errors.New("unsupported key derivation function")
+
+// ParseKDF parses the name of a key derivation function. Argon2id is rejected,
+// as gokeepasslib only supports Argon2d.
+func ParseKDF(name string) (KDF, error) {
+	switch strings.ToLower(name) {
+	case "aes", "aes-kdf", "aeskdf":
+		return KDFAES, nil
+	case "argon2", "argon2d":
+		return KDFArgon2d, nil
+	case "argon2id":
+		return "", fmt.Errorf("%w %q: only argon2d is supported", ErrUnsupportedKDF, name)
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnsupportedKDF, name)
+	}
+}
+
+// Option modifies how a Keepass database is opened or created.
+type Option func(*options)
+
+type options struct {
+	keyFile         string
+	keyData         []byte
+	generateKeyFile bool
+	kdbxVersion     int
+	kdf             KDF
+}
+
+// WithKeyFile adds the key file at the given path to the composite key. If a
+// master password is also given, both are required to open the database.
+func WithKeyFile(path string) Option {
+	return func(o *options) {
+		o.keyFile = path
+	}
+}
+
+// WithKeyData adds the key file with the given contents to the composite key.
+// This is used in place of WithKeyFile when the key file is kept in another
+// secret keeper.
+func WithKeyData(data []byte) Option {
+	return func(o *options) {
+		o.keyData = data
+	}
+}
+
+// WithGeneratedKeyFile causes a new key file to be generated at the path given
+// by WithKeyFile if the database and key file do not exist yet.
+func WithGeneratedKeyFile() Option {
+	return func(o *options) {
+		o.generateKeyFile = true
+	}
+}
+
+// WithKDBXVersion selects the major version of the KDBX format, 3 or 4, used
+// when a new database is created. The default is 3.
+func WithKDBXVersion(v int) Option {
+	return func(o *options) {
+		o.kdbxVersion = v
+	}
+}
+
+// WithKDF selects the key derivation function used when a new database is
+// created. KDBX 3.1 databases only support KDFAES. KDBX 4 databases use
+// KDFArgon2d by default.
+func WithKDF(kdf KDF) Option {
+	return func(o *options) {
+		o.kdf = kdf
+	}
+}
+
+// credentials builds the composite key from the master password and the key
+// file or key data. An empty master password is left out of the composite key
+// if a key file is given.
+func (o *options) credentials(master string) (*keepass.DBCredentials, error) {
+	switch {
+	case o.keyData != nil && master != "":
+		return keepass.NewPasswordAndKeyDataCredentials(master, o.keyData)
+	case o.keyData != nil:
+		return keepass.NewKeyDataCredentials(o.keyData)
+	case o.keyFile != "" && master != "":
+		return keepass.NewPasswordAndKeyCredentials(master, o.keyFile)
+	case o.keyFile != "":
+		return keepass.NewKeyCredentials(o.keyFile)
+	default:
+		return keepass.NewPasswordCredentials(master), nil
+	}
+}
+
+// newDatabase returns an empty database with the format and KDF chosen. The
+// recycle bin is enabled, as KeePass does for new databases.
+func (o *options) newDatabase() (*keepass.Database, error) {
+	db, err := o.newEmptyDatabase()
+	if err != nil {
+		return nil, err
+	}
+
+	db.Content.Meta.RecycleBinEnabled = w.NewBoolWrapper(true)
+	return db, nil
+}
+
+// newEmptyDatabase returns an empty database with the format and KDF chosen.
+func (o *options) newEmptyDatabase() (*keepass.Database, error) {
+	switch o.kdbxVersion {
+	case 0, 3:
+		if o.kdf != "" && o.kdf != KDFAES {
+			return nil, fmt.Errorf("%w %q: KDBX 3.1 only supports %s", ErrUnsupportedKDF, o.kdf, KDFAES)
+		}
+
+		return keepass.NewDatabase(keepass.WithDatabaseKDBXVersion3()), nil
+	case 4:
+		db := keepass.NewDatabase(keepass.WithDatabaseKDBXVersion4())
+		switch o.kdf {
+		case "", KDFArgon2d:
+		case KDFAES:
+			kdf := db.Header.FileHeaders.KdfParameters
+			kdf.UUID = keepass.KdfAES4
+			kdf.Rounds = aesRounds
+			kdf.Parallelism, kdf.Memory, kdf.Iterations, kdf.Version = 0, 0, 0, 0
+		default:
+			return nil, fmt.Errorf("%w %q", ErrUnsupportedKDF, o.kdf)
+		}
+		return db, nil
+	default:
+		return nil, fmt.Errorf("unsupported KDBX version %d", o.kdbxVersion)
+	}
+}
+
+// GenerateKeyFile writes a new KeePass 2.0 XML key file holding 32 random
+// bytes to the given path. It will not overwrite an existing file.
+func GenerateKeyFile(path string) error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(key)
+	data := fmt.Sprintf("%X", key)
+
+	lines := make([]string, 0, 2)
+	for i := 0; i < len(data); i += 32 {
+		groups := make([]string, 0, 4)
+		for j := i; j < i+32; j += 8 {
+			groups = append(groups, data[j:j+8])
+		}
+		lines = append(lines, "\t\t\t"+strings.Join(groups, " "))
+	}
+
+	xml := fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<KeyFile>
+	<Meta>
+		<Version>2.0</Version>
+	</Meta>
+	<Key>
+		<Data Hash="%X">
+%s
+		</Data>
+	</Key>
+</KeyFile>
+`, hash[:4], strings.Join(lines, "\n"))
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(xml); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
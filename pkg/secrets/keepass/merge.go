@@ -0,0 +1,366 @@
+package keepass
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"time"
+
+	keepass "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+	"github.com/zostay/go-std/slices"
+)
+
+// ErrConflict is returned when a change cannot be saved because the same entry
+// was also changed by another program since the database was last read.
+var ErrConflict = errors.New("keepass entry was also changed by another program")
+
+// fileState records the state of the database file when it was last read or
+// written, so that changes made by other programs can be detected.
+type fileState struct {
+	info os.FileInfo
+	hash [sha256.Size]byte
+}
+
+// entryState is the state of an entry used to decide whether it has been
+// changed since the database was last read or written. The last modification
+// time is only kept to the second, so a digest of the content is kept as well
+// to catch changes made within the same second.
+type entryState struct {
+	dir      string
+	modified time.Time
+	digest   [sha256.Size]byte
+}
+
+// sameContent returns true if both states have the same content and location.
+func (s entryState) sameContent(o entryState) bool {
+	return s.dir == o.dir && s.digest == o.digest
+}
+
+// equal returns true if both states are the same.
+func (s entryState) equal(o entryState) bool {
+	return s.sameContent(o) && s.modified.Equal(o.modified)
+}
+
+// entryStates returns the state of every entry in the database, including
+// those in the recycle bin, by UUID.
+func entryStates(db *keepass.Database) map[keepass.UUID]entryState {
+	states := map[keepass.UUID]entryState{}
+	kw := walk(db, true)
+	for kw.Next() {
+		e := kw.Entry()
+
+		var modified time.Time
+		if e.Times.LastModificationTime != nil {
+			modified = e.Times.LastModificationTime.Time
+		}
+
+		states[e.UUID] = entryState{kw.Dir(), modified, entryDigest(db, e)}
+	}
+	return states
+}
+
+// entryDigest returns a digest of the values and attachments of the entry.
+func entryDigest(db *keepass.Database, e *keepass.Entry) [sha256.Size]byte {
+	h := sha256.New()
+	for _, v := range e.Values {
+		_, _ = fmt.Fprintf(h, "%q=%q\n", v.Key, v.Value.Content)
+	}
+
+	for _, br := range e.Binaries {
+		_, _ = fmt.Fprintf(h, "%q:", br.Name)
+		if b := db.FindBinary(br.Value.ID); b != nil {
+			data, _ := b.GetContentBytes()
+			_, _ = h.Write(data)
+		}
+		_, _ = h.Write([]byte("\n"))
+	}
+
+	var sum [sha256.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return sum
+}
+
+// changed returns true if the entry with the given UUID differs between the
+// two sets of entry states, including when it is present in only one of them.
+func changed(a, b map[keepass.UUID]entryState, uuid keepass.UUID) bool {
+	as, inA := a[uuid]
+	bs, inB := b[uuid]
+	if inA != inB {
+		return true
+	}
+	return inA && !as.equal(bs)
+}
+
+// sameChange returns true if the entry with the given UUID has the same
+// content and location in both sets of entry states, or is missing from both.
+func sameChange(a, b map[keepass.UUID]entryState, uuid keepass.UUID) bool {
+	as, inA := a[uuid]
+	bs, inB := b[uuid]
+	if inA != inB {
+		return false
+	}
+	return !inA || as.sameContent(bs)
+}
+
+// touch updates the last modification time of the entry to now.
+func touch(e *keepass.Entry) {
+	now := w.Now()
+	if e.Times.CreationTime == nil {
+		e.Times.CreationTime = &now
+	}
+	e.Times.LastModificationTime = &now
+	e.Times.LastAccessTime = &now
+}
+
+// hashingReader returns a reader that hashes everything read from r.
+func hashingReader(r io.Reader) (io.Reader, hash.Hash) {
+	h := sha256.New()
+	return io.TeeReader(r, h), h
+}
+
+// hashingWriter returns a writer that hashes everything written to wr.
+func hashingWriter(wr io.Writer) (io.Writer, hash.Hash) {
+	h := sha256.New()
+	return io.MultiWriter(wr, h), h
+}
+
+// fileStateOf returns the state of the database file given the hash of its
+// content. It returns a state without file information if the
+// database is not kept in a file.
+func (k *Keepass) fileStateOf(h hash.Hash) (fileState, error) {
+	var st fileState
+	copy(st.hash[:], h.Sum(nil))
+
+	if k.path == "" {
+		return st, nil
+	}
+
+	fi, err := os.Stat(k.path)
+	if err != nil {
+		return st, err
+	}
+
+	st.info = fi
+	return st, nil
+}
+
+// changedOnDisk returns true if another program has written to the database
+// file since it was last read or written. The file, modification time, and size
+// are checked first and the content is hashed only when they differ, so that
+// touching the file is not mistaken for a change. Comparing the file catches
+// saves made within the resolution of the modification time by programs that,
+// like ghost and KeePassXC, save by replacing the file.
+func (k *Keepass) changedOnDisk() (bool, error) {
+	if k.path == "" {
+		return false, nil
+	}
+
+	fi, err := os.Stat(k.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if last := k.state.info; last != nil && os.SameFile(fi, last) &&
+		fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		return false, err
+	}
+
+	if sha256.Sum256(data) != k.state.hash {
+		return true, nil
+	}
+
+	k.state.info = fi
+	return false, nil
+}
+
+// refresh reloads the database if another program has changed it on disk.
+func (k *Keepass) refresh() error {
+	isChanged, err := k.changedOnDisk()
+	if err != nil || !isChanged {
+		return err
+	}
+
+	return k.reload()
+}
+
+// merge reloads the database from disk after another program has changed it
+// and applies the changes made here since it was last read or written,
+// entry by entry. An entry has been changed if it was added, removed, moved,
+// or its last modification time differs. If the same entry was changed here
+// and by the other program, and not in the same way, the changes made here are
+// dropped, the database is left as found on disk, and ErrConflict is returned.
+func (k *Keepass) merge() error {
+	theirs := keepass.NewDatabase()
+	theirs.Credentials = k.db.Credentials
+
+	st, err := k.decode(theirs)
+	if err != nil {
+		return err
+	}
+
+	ours := k.db
+	oursNow := entryStates(ours)
+	theirsNow := entryStates(theirs)
+
+	uuids := make([]keepass.UUID, 0, len(oursNow))
+	for uuid := range k.base {
+		if _, inOurs := oursNow[uuid]; !inOurs {
+			uuids = append(uuids, uuid)
+		}
+	}
+	for uuid := range oursNow {
+		uuids = append(uuids, uuid)
+	}
+
+	var changes []keepass.UUID
+	for _, uuid := range uuids {
+		if !changed(k.base, oursNow, uuid) {
+			continue
+		}
+
+		if changed(k.base, theirsNow, uuid) && !sameChange(oursNow, theirsNow, uuid) {
+			*k.db = *theirs
+			k.idx = newIndex(k.db)
+			k.state = st
+			k.base = theirsNow
+			return fmt.Errorf("%w: %s", ErrConflict, entryName(ours, theirs, uuid))
+		}
+
+		changes = append(changes, uuid)
+	}
+
+	for _, uuid := range changes {
+		removeEntry(theirs, uuid)
+
+		if e, dir := findEntry(ours, uuid); e != nil {
+			g := ensureGroup(theirs, dir)
+			g.Entries = append(g.Entries, copyEntry(ours, theirs, e))
+		}
+	}
+
+	adoptRecycleBin(ours, theirs)
+
+	*k.db = *theirs
+	k.idx = newIndex(k.db)
+	return nil
+}
+
+// entryName describes the entry with the given UUID by its title and ID for
+// use in error messages.
+func entryName(ours, theirs *keepass.Database, uuid keepass.UUID) string {
+	e, _ := findEntry(ours, uuid)
+	if e == nil {
+		e, _ = findEntry(theirs, uuid)
+	}
+
+	if e == nil {
+		return makeID(uuid)
+	}
+
+	return fmt.Sprintf("%q (%s)", e.GetTitle(), makeID(uuid))
+}
+
+// findEntry returns the entry with the given UUID and its location, or nil if
+// there is no such entry.
+func findEntry(db *keepass.Database, uuid keepass.UUID) (*keepass.Entry, string) {
+	kw := walk(db, true)
+	for kw.Next() {
+		if kw.Entry().UUID.Compare(uuid) {
+			return kw.Entry(), kw.Dir()
+		}
+	}
+	return nil, ""
+}
+
+// removeEntry removes the entry with the given UUID from the database, if it
+// is there.
+func removeEntry(db *keepass.Database, uuid keepass.UUID) {
+	kw := walk(db, false)
+	for kw.Next() {
+		g := kw.Group()
+		for i, ge := range g.Entries {
+			if ge.UUID.Compare(uuid) {
+				g.Entries = slices.Delete(g.Entries, i)
+				return
+			}
+		}
+	}
+}
+
+// copyEntry returns a copy of an entry in one database for use in another,
+// adding the binaries the entry and its history refer to to the other
+// database.
+func copyEntry(from, to *keepass.Database, e *keepass.Entry) keepass.Entry {
+	cp := e.Clone()
+	cp.UUID = e.UUID
+
+	copyBinaries(from, to, cp.Binaries)
+	for i := range cp.Histories {
+		for j := range cp.Histories[i].Entries {
+			he := &cp.Histories[i].Entries[j]
+			he.Binaries = append([]keepass.BinaryReference(nil), he.Binaries...)
+			copyBinaries(from, to, he.Binaries)
+		}
+	}
+
+	return cp
+}
+
+// copyBinaries adds the binaries referenced from one database to the other and
+// points the references at the copies.
+func copyBinaries(from, to *keepass.Database, refs []keepass.BinaryReference) {
+	for i, ref := range refs {
+		b := from.FindBinary(ref.Value.ID)
+		if b == nil {
+			continue
+		}
+
+		data, err := b.GetContentBytes()
+		if err != nil {
+			continue
+		}
+
+		refs[i].Value.ID = to.AddBinary(data).ID
+	}
+}
+
+// adoptRecycleBin makes the group used as the recycle bin in one database the
+// recycle bin of the other, if the other has none. This happens when the
+// recycle bin was created by the changes being merged.
+func adoptRecycleBin(from, to *keepass.Database) {
+	if !to.Content.Meta.RecycleBinUUID.Compare(keepass.UUID{}) {
+		if g, _ := findGroup(to, to.Content.Meta.RecycleBinUUID); g != nil {
+			return
+		}
+	}
+
+	if from.Content.Meta.RecycleBinUUID.Compare(keepass.UUID{}) {
+		return
+	}
+
+	_, dir := findGroup(from, from.Content.Meta.RecycleBinUUID)
+	if dir == "" {
+		return
+	}
+
+	kw := walk(to, false)
+	for kw.Next() {
+		if kw.Dir() == dir {
+			to.Content.Meta.RecycleBinUUID = kw.Group().UUID
+			to.Content.Meta.RecycleBinChanged = from.Content.Meta.RecycleBinChanged
+			return
+		}
+	}
+}
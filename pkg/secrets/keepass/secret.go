@@ -1,8 +1,9 @@
 package keepass
 
 import (
-	"fmt"
+	"bytes"
 	"net/url"
+	"sort"
 	"time"
 
 	keepass "github.com/tobischo/gokeepasslib/v3"
@@ -32,6 +33,9 @@ type Secret struct {
 	delFields   set.Set[string]
 	newUrl      *url.URL
 	newLocation *string
+
+	newAttachments map[string][]byte
+	delAttachments set.Set[string]
 }
 
 func newSecret(
@@ -40,11 +44,13 @@ func newSecret(
 	dir string,
 ) *Secret {
 	return &Secret{
-		db:        db,
-		e:         e,
-		dir:       dir,
-		newFields: map[string]string{},
-		delFields: set.New[string](),
+		db:             db,
+		e:              e,
+		dir:            dir,
+		newFields:      map[string]string{},
+		delFields:      set.New[string](),
+		newAttachments: map[string][]byte{},
+		delAttachments: set.New[string](),
 	}
 }
 
@@ -56,11 +62,13 @@ func fromSecret(
 	if eSec, isESec := secret.(*Secret); isESec {
 		cp := eSec.e.Clone()
 		retSec := &Secret{
-			db:        db,
-			e:         &cp,
-			dir:       eSec.dir,
-			newFields: map[string]string{},
-			delFields: set.New[string](),
+			db:             db,
+			e:              &cp,
+			dir:            eSec.dir,
+			newFields:      map[string]string{},
+			delFields:      set.New[string](),
+			newAttachments: map[string][]byte{},
+			delAttachments: set.New[string](),
 		}
 
 		if keepID {
@@ -84,7 +92,9 @@ func fromSecret(
 			UUID:   uuid,
 			Values: make([]keepass.ValueData, 0, len(secret.Fields())+stdKeys.Len()),
 		},
-		dir: secret.Location(),
+		dir:            secret.Location(),
+		newAttachments: map[string][]byte{},
+		delAttachments: set.New[string](),
 	}
 
 	eSec.applyChanges(secret)
@@ -124,6 +134,46 @@ func (s *Secret) applyChanges(secret secrets.Secret) {
 	if secret.Url() != nil {
 		s.setEntryValue(keyURL, secret.Url().String(), false)
 	}
+
+	if eSec, isESec := secret.(*Secret); isESec {
+		for _, name := range eSec.delAttachments.Keys() {
+			s.removeEntryBinary(name)
+		}
+	}
+
+	for _, name := range secrets.Attachments(secret) {
+		if data, hasAttachment := secrets.GetAttachment(secret, name); hasAttachment {
+			s.setEntryBinary(name, data)
+		}
+	}
+}
+
+// setEntryBinary adds the content to the binaries of the database and replaces
+// or adds the reference to it in the entry.
+func (s *Secret) setEntryBinary(name string, data []byte) {
+	if cur, hasBinary := s.entryBinary(name); hasBinary && bytes.Equal(cur, data) {
+		return
+	}
+
+	ref := s.db.AddBinary(data).CreateReference(name)
+	for i, br := range s.e.Binaries {
+		if br.Name == name {
+			s.e.Binaries[i] = ref
+			return
+		}
+	}
+	s.e.Binaries = append(s.e.Binaries, ref)
+}
+
+// removeEntryBinary removes the named reference from the entry. The binary is
+// dropped from the database when it is saved if nothing else refers to it.
+func (s *Secret) removeEntryBinary(name string) {
+	for i, br := range s.e.Binaries {
+		if br.Name == name {
+			s.e.Binaries = append(s.e.Binaries[:i], s.e.Binaries[i+1:]...)
+			return
+		}
+	}
 }
 
 func makeID(id keepass.UUID) string {
@@ -176,31 +226,13 @@ func (s *Secret) SetUsername(username string) {
 	s.set(keyUsername, username)
 }
 
-func (s *Secret) whileUnlocked(run func()) {
-	err := s.db.UnlockProtectedEntries()
-	if err != nil {
-		panic(fmt.Errorf("failed to unlock protected entries: %w", err))
-	}
-	defer func() {
-		err := s.db.LockProtectedEntries()
-		if err != nil {
-			panic(fmt.Errorf("failed to lock protected entries: %w", err))
-		}
-	}()
-	run()
-}
-
 // Password returns the Password of the Keepass entry.
 func (s *Secret) Password() string {
 	if secret, hasNewSecret := s.newFields[keySecret]; hasNewSecret {
 		return secret
 	}
 
-	var secret string
-	s.whileUnlocked(func() {
-		secret = s.e.GetPassword()
-	})
-	return secret
+	return s.e.GetPassword()
 }
 
 // SetPassword sets the Password of the Keepass entry.
@@ -297,3 +329,72 @@ func (s *Secret) Location() string {
 func (s *Secret) SetLocation(loc string) {
 	s.newLocation = &loc
 }
+
+// Attachments returns the names of the binaries attached to the Keepass entry.
+func (s *Secret) Attachments() []string {
+	names := set.New[string]()
+	for _, br := range s.e.Binaries {
+		names.Insert(br.Name)
+	}
+	for name := range s.newAttachments {
+		names.Insert(name)
+	}
+	for _, name := range s.delAttachments.Keys() {
+		names.Delete(name)
+	}
+
+	list := names.Keys()
+	sort.Strings(list)
+	return list
+}
+
+// GetAttachment returns the content of the named binary attached to the
+// Keepass entry.
+func (s *Secret) GetAttachment(name string) ([]byte, bool) {
+	if data, hasNewAttachment := s.newAttachments[name]; hasNewAttachment {
+		return data, true
+	}
+
+	if s.delAttachments.Contains(name) {
+		return nil, false
+	}
+
+	return s.entryBinary(name)
+}
+
+// entryBinary returns the content of the named binary referenced by the entry.
+func (s *Secret) entryBinary(name string) ([]byte, bool) {
+	for _, br := range s.e.Binaries {
+		if br.Name != name {
+			continue
+		}
+
+		b := s.db.FindBinary(br.Value.ID)
+		if b == nil {
+			return nil, false
+		}
+
+		data, err := b.GetContentBytes()
+		if err != nil {
+			return nil, false
+		}
+		return data, true
+	}
+
+	return nil, false
+}
+
+// SetAttachment attaches a binary with the given name to the Keepass entry.
+func (s *Secret) SetAttachment(name string, data []byte) {
+	if s.newAttachments == nil {
+		s.newAttachments = map[string][]byte{}
+	}
+	s.newAttachments[name] = data
+	s.delAttachments.Delete(name)
+}
+
+// DeleteAttachment removes the named binary from the Keepass entry.
+func (s *Secret) DeleteAttachment(name string) {
+	delete(s.newAttachments, name)
+	s.delAttachments.Insert(name)
+}
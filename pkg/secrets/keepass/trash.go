@@ -0,0 +1,217 @@
+package keepass
+
+import (
+	"context"
+	"strings"
+
+	keepass "github.com/tobischo/gokeepasslib/v3"
+	w "github.com/tobischo/gokeepasslib/v3/wrappers"
+	"github.com/zostay/go-std/slices"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+const (
+	// recycleBinName is the name given to the recycle bin group when ghost
+	// creates it, matching the name used by KeePass.
+	recycleBinName = "Recycle Bin"
+
+	// recycleBinIconID is the icon KeePass uses for the recycle bin group.
+	recycleBinIconID = 43
+
+	// keyPreviousLocation is the custom data key used to record the location
+	// of an entry before it was moved to the recycle bin.
+	keyPreviousLocation = "ghost.PreviousLocation"
+)
+
+var _ secrets.Trashable = &Keepass{}
+
+// findGroup returns the group with the given UUID and its location, or nil if
+// there is no such group.
+func findGroup(db *keepass.Database, uuid keepass.UUID) (*keepass.Group, string) {
+	kw := walk(db, false)
+	for kw.Next() {
+		if kw.Group().UUID.Compare(uuid) {
+			return kw.Group(), kw.Dir()
+		}
+	}
+	return nil, ""
+}
+
+// recycleBin returns the recycle bin group and its location, or nil if the
+// recycle bin is disabled or has not been created yet.
+func (k *Keepass) recycleBin() (*keepass.Group, string) {
+	meta := k.db.Content.Meta
+	if !meta.RecycleBinEnabled.Bool || meta.RecycleBinUUID.Compare(keepass.UUID{}) {
+		return nil, ""
+	}
+
+	return k.idx.groupByUUID(meta.RecycleBinUUID)
+}
+
+// ensureRecycleBin returns the recycle bin group, creating it if it does not
+// exist yet. It returns nil if the recycle bin is disabled.
+func (k *Keepass) ensureRecycleBin() *keepass.Group {
+	if !k.db.Content.Meta.RecycleBinEnabled.Bool {
+		return nil
+	}
+
+	if bin, _ := k.recycleBin(); bin != nil {
+		return bin
+	}
+
+	bin := keepass.NewGroup()
+	bin.Name = recycleBinName
+	bin.IconID = recycleBinIconID
+	bin.EnableAutoType = w.NewNullableBoolWrapper(false)
+	bin.EnableSearching = w.NewNullableBoolWrapper(false)
+
+	root := &k.db.Content.Root.Groups[0]
+	root.Groups = append(root.Groups, bin)
+
+	now := w.Now()
+	k.db.Content.Meta.RecycleBinUUID = bin.UUID
+	k.db.Content.Meta.RecycleBinChanged = &now
+
+	k.idx.indexGroups(k.db)
+	return &root.Groups[len(root.Groups)-1]
+}
+
+// inTrash returns true if the location is the recycle bin or a group within it.
+// The location of the recycle bin is given as trashDir, which is empty if there
+// is no recycle bin.
+func inTrash(trashDir, dir string) bool {
+	return trashDir != "" && (dir == trashDir || strings.HasPrefix(dir, trashDir+"/"))
+}
+
+// trashDir returns the location of the recycle bin, or an empty string if there
+// is none.
+func (k *Keepass) trashDir() string {
+	_, dir := k.recycleBin()
+	return dir
+}
+
+// ListTrash returns the secrets in the recycle bin.
+func (k *Keepass) ListTrash(ctx context.Context) ([]secrets.Secret, error) {
+	if err := k.rlock(); err != nil {
+		return nil, err
+	}
+	defer k.mu.RUnlock()
+
+	trashDir := k.trashDir()
+	if trashDir == "" {
+		return nil, nil
+	}
+
+	var secs []secrets.Secret
+	kw := walk(k.db, true)
+	for kw.Next() {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+			if inTrash(trashDir, kw.Dir()) {
+				secs = append(secs, newSecret(k.db, kw.Entry(), kw.Dir()))
+			}
+		}
+	}
+
+	return secs, nil
+}
+
+// RestoreFromTrash moves the identified entry out of the recycle bin and back
+// to the location it was deleted from, creating the group again if needed.
+func (k *Keepass) RestoreFromTrash(
+	ctx context.Context,
+	id string,
+) (secrets.Secret, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	uuid, err := makeUUID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	trashDir := k.trashDir()
+	if trashDir == "" {
+		return nil, secrets.ErrNotFound
+	}
+
+	ie, isIndexed := k.idx.entries[uuid]
+	if !isIndexed || !inTrash(trashDir, ie.dir) {
+		return nil, secrets.ErrNotFound
+	}
+
+	e, _ := k.takeEntry(uuid)
+	dest, dir := k.ensureGroupExists(takeCustomData(&e, keyPreviousLocation))
+
+	now := w.Now()
+	e.Times.LocationChanged = &now
+	dest.Entries = append(dest.Entries, e)
+	k.idx.indexEntries(dir, dest)
+
+	if err := k.save(); err != nil {
+		return nil, err
+	}
+
+	return k.getSecret(ctx, id)
+}
+
+// EmptyTrash permanently removes every entry and group in the recycle bin.
+func (k *Keepass) EmptyTrash(context.Context) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	bin, _ := k.recycleBin()
+	if bin == nil {
+		return nil
+	}
+
+	bin.Entries = nil
+	bin.Groups = nil
+	k.idx = newIndex(k.db)
+
+	return k.save()
+}
+
+// trashEntry moves the entry with the given UUID in the group at the given
+// location to the recycle bin, recording the location so that it may be
+// restored. It returns false if the recycle bin is disabled. It must be called
+// while the database is unlocked.
+func (k *Keepass) trashEntry(dir string, uuid keepass.UUID) bool {
+	bin := k.ensureRecycleBin()
+	if bin == nil {
+		return false
+	}
+
+	e, isTaken := k.takeEntry(uuid)
+	if !isTaken {
+		return true
+	}
+
+	takeCustomData(&e, keyPreviousLocation)
+	e.CustomData = append(e.CustomData, keepass.CustomData{
+		Key:   keyPreviousLocation,
+		Value: dir,
+	})
+
+	now := w.Now()
+	e.Times.LocationChanged = &now
+	bin.Entries = append(bin.Entries, e)
+	k.idx.indexEntries(k.trashDir(), bin)
+
+	return true
+}
+
+// takeCustomData removes the custom data item with the given key from the
+// entry and returns its value.
+func takeCustomData(e *keepass.Entry, key string) string {
+	for i, cd := range e.CustomData {
+		if cd.Key == key {
+			e.CustomData = slices.Delete(e.CustomData, i)
+			return cd.Value
+		}
+	}
+	return ""
+}
@@ -25,11 +25,18 @@ type Walker struct {
 }
 
 // Walker creates an iterator for walking through the Keepass database records.
+// The walker does not lock the database, so it must not be used while the
+// Keepass is being changed.
 func (k *Keepass) Walker(walkEntries bool) *Walker {
+	return walk(k.db, walkEntries)
+}
+
+// walk creates an iterator for walking through the records of the database.
+func walk(db *keepass.Database, walkEntries bool) *Walker {
 	w := &Walker{
 		groups: []groupDir{
 			{
-				group: &k.db.Content.Root.Groups[0],
+				group: &db.Content.Root.Groups[0],
 				dir:   "",
 			},
 		},
@@ -81,8 +88,8 @@ func (w *Walker) nextEntry() bool {
 		w.currentGroup = currentGroupDir.group
 		w.currentDir = currentGroupDir.dir
 
+		w.pushGroups(w.currentGroup.Groups)
 		if len(w.currentGroup.Entries) > 0 {
-			w.pushGroups(w.currentGroup.Groups)
 			w.pushEntries(w.currentGroup.Entries)
 			break
 		}
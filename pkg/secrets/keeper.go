@@ -6,7 +6,8 @@ import (
 )
 
 var (
-	ErrNotFound = errors.New("secret not found") // error returned by a secrets.Keeper when a secret is not found
+	ErrNotFound = errors.New("secret not found")    // error returned by a secrets.Keeper when a secret is not found
+	ErrNoTrash  = errors.New("keeper has no trash") // error returned by a secrets.Trashable that has no trash to use
 )
 
 // Keeper is a tool for storing and retrieving secrets. Locations are treated as
@@ -63,3 +64,21 @@ type Keeper interface {
 	// if the secret was not found.
 	DeleteSecret(ctx context.Context, id string) error
 }
+
+// Trashable is implemented by a Keeper that moves deleted secrets to a trash,
+// from which they may be restored, rather than removing them permanently.
+// Secrets in the trash are not returned by the Keeper methods. A Trashable that
+// has no trash to use, such as one wrapping a keeper without a trash, returns
+// ErrNoTrash.
+type Trashable interface {
+	// ListTrash returns the secrets in the trash.
+	ListTrash(ctx context.Context) ([]Secret, error)
+
+	// RestoreFromTrash moves the identified secret out of the trash and back to
+	// the location it was deleted from. The restored secret is returned. This
+	// should return ErrNotFound if the secret is not in the trash.
+	RestoreFromTrash(ctx context.Context, id string) (Secret, error)
+
+	// EmptyTrash permanently removes every secret in the trash.
+	EmptyTrash(ctx context.Context) error
+}
@@ -2,6 +2,7 @@ package keepertest
 
 import (
 	"context"
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,6 +29,7 @@ func (s *Suite) Run(t *testing.T) {
 	t.Run("SecretKeeperGetMissingTest", s.SecretKeeperGetMissingTest)
 	t.Run("SecretKeeperSetAndGet", s.SecretKeeperSetAndGet)
 	t.Run("SecretKeeperGetPresets", s.SecretKeeperGetPresets)
+	t.Run("SecretKeeperSearch", s.SecretKeeperSearch)
 }
 
 func (s *Suite) RunWithPresets(t *testing.T) {
@@ -113,3 +115,55 @@ func (s *Suite) SecretKeeperSetAndGet(t *testing.T) {
 	assert.Equal(t, "set1", got.Name(), "got secret name still set1")
 	assert.Equal(t, "secret2", got.Password(), "but got secret value changed to secret2")
 }
+
+// SecretKeeperSearch tests that secrets.Search finds secrets in the keeper.
+func (s *Suite) SecretKeeperSearch(t *testing.T) {
+	t.Parallel()
+
+	k, err := s.factory()
+	require.NoError(t, err, "factory returns keeper")
+
+	ctx := context.Background()
+
+	gh, err := url.Parse("https://github.com/login")
+	require.NoError(t, err, "parsing URL doesn't error")
+
+	toSet := []secrets.Secret{
+		secrets.NewSecret("search1", "username1", "secret1",
+			secrets.WithLocation("Work"),
+			secrets.WithType("ssh-key")),
+		secrets.NewSecret("search2", "username2", "secret2",
+			secrets.WithLocation("Work"),
+			secrets.WithUrl(gh)),
+		secrets.NewSecret("search3", "username3", "secret3",
+			secrets.WithLocation("Home"),
+			secrets.WithType("ssh-key"),
+			secrets.WithUrl(gh)),
+	}
+
+	for _, sec := range toSet {
+		_, err := k.SetSecret(ctx, sec)
+		require.NoError(t, err, "setting doesn't error")
+	}
+
+	names := func(terms ...string) []string {
+		q, err := secrets.ParseQuery(terms...)
+		require.NoError(t, err, "parsing query doesn't error")
+
+		secs, err := secrets.Search(ctx, k, q)
+		require.NoError(t, err, "searching doesn't error")
+
+		names := make([]string, len(secs))
+		for i, sec := range secs {
+			names[i] = sec.Name()
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"search1", "search3"}, names("type=ssh-key"), "search by type")
+	assert.ElementsMatch(t, []string{"search1"}, names("type=ssh-key", "location=Work"), "search by type and location")
+	assert.ElementsMatch(t, []string{"search2", "search3"}, names("url.host=github.com"), "search by URL host")
+	assert.ElementsMatch(t, []string{"search2"}, names("name=search2"), "search by name")
+	assert.ElementsMatch(t, []string{"search1", "search2", "search3"}, names("name~search*"), "search by name pattern")
+	assert.Empty(t, names("modified>2999"), "search by modified time")
+}
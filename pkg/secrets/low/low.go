@@ -10,6 +10,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/zostay/fssafe"
+	"github.com/zostay/go-std/set"
 
 	"github.com/zostay/ghost/pkg/secrets"
 )
@@ -25,7 +26,10 @@ type Security struct {
 	fssafe.LoaderSaver
 }
 
-var _ secrets.Keeper = &Security{}
+var (
+	_ secrets.Keeper    = &Security{}
+	_ secrets.Trashable = &Security{}
+)
 
 // NewSecurity creates a new low security secret keeper at the given path.
 func NewSecurity(path string) *Security {
@@ -43,7 +47,7 @@ func NewSecurityCustom(ls fssafe.LoaderSaver) *Security {
 }
 
 func makeID() string {
-	return ulid.MustNew(ulid.Now(), nil).String()
+	return ulid.Make().String()
 }
 
 // loadSecrets loads the secrets from file.
@@ -109,13 +113,13 @@ func (s *Security) ListLocations(context.Context) ([]string, error) {
 		return nil, err
 	}
 
-	ids := make([]string, 0, len(cfg.Secrets))
+	locs := set.New[string]()
 	iter := cfg.iterator()
 	for iter.Next() {
-		ids = append(ids, iter.ID())
+		locs.Insert(iter.Val().Location())
 	}
 
-	return ids, nil
+	return locs.Keys(), nil
 }
 
 // ListSecrets returns all the secrets listed in the low security file for the
@@ -131,7 +135,7 @@ func (s *Security) ListSecrets(
 
 	ids := make([]string, 0, len(cfg.Secrets))
 	iter := cfg.iterator()
-	if iter.Next() {
+	for iter.Next() {
 		secret := iter.Val()
 		if secret.Location() == location {
 			ids = append(ids, iter.ID())
@@ -175,7 +179,7 @@ func (s *Security) GetSecretsByName(
 	for iter.Next() {
 		secret := iter.Val()
 		single := secrets.NewSingleFromSecret(secret)
-		sec := &Secret{Single: *single}
+		sec := &Secret{Single: *single, id: iter.ID()}
 		if secret.Name() == name {
 			secs = append(secs, sec)
 		}
@@ -197,7 +201,9 @@ func (s *Security) SetSecret(
 	single := secrets.NewSingleFromSecret(secret)
 	sec := Secret{Single: *single}
 
-	sec.SetID(makeID())
+	if _, hasSecret := cfg.get(secret.ID()); secret.ID() == "" || !hasSecret {
+		sec.SetID(makeID())
+	}
 	cfg.set(&sec)
 
 	err = s.saveSecrets(cfg)
@@ -208,8 +214,8 @@ func (s *Security) SetSecret(
 	return &sec, nil
 }
 
-// DeleteSecret deletes the secret with the given ID from the low security
-// file.
+// DeleteSecret moves the secret with the given ID to the trash of the low
+// security file.
 func (s *Security) DeleteSecret(
 	_ context.Context,
 	id string,
@@ -219,7 +225,7 @@ func (s *Security) DeleteSecret(
 		return err
 	}
 
-	cfg.delete(id)
+	cfg.trash(id)
 
 	err = s.saveSecrets(cfg)
 	if err != nil {
@@ -284,3 +290,55 @@ func (s *Security) MoveSecret(
 
 	return sec, nil
 }
+
+// ListTrash returns the secrets in the trash of the low security file.
+func (s *Security) ListTrash(context.Context) ([]secrets.Secret, error) {
+	cfg, err := s.loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	secs := make([]secrets.Secret, 0, len(cfg.Trash))
+	for id, sec := range cfg.Trash {
+		sec.SetID(id)
+		secs = append(secs, sec)
+	}
+
+	return secs, nil
+}
+
+// RestoreFromTrash moves the secret with the given ID out of the trash.
+func (s *Security) RestoreFromTrash(
+	_ context.Context,
+	id string,
+) (secrets.Secret, error) {
+	cfg, err := s.loadSecrets()
+	if err != nil {
+		return nil, err
+	}
+
+	sec, inTrash := cfg.restore(id)
+	if !inTrash {
+		return nil, secrets.ErrNotFound
+	}
+
+	err = s.saveSecrets(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return sec, nil
+}
+
+// EmptyTrash permanently removes the secrets in the trash of the low security
+// file.
+func (s *Security) EmptyTrash(context.Context) error {
+	cfg, err := s.loadSecrets()
+	if err != nil {
+		return err
+	}
+
+	cfg.Trash = nil
+
+	return s.saveSecrets(cfg)
+}
@@ -1,8 +1,11 @@
 package low_test
 
 import (
+	"context"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/zostay/fssafe"
 
 	"github.com/zostay/ghost/pkg/secrets"
@@ -20,3 +23,81 @@ func TestLowSecurity(t *testing.T) {
 	ts := keepertest.New(factory)
 	ts.Run(t)
 }
+
+func TestLowSecurity_GetSecretsByName(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	l := low.NewSecurityCustom(fssafe.NewTestingLoaderSaver())
+
+	sec, err := l.SetSecret(ctx, secrets.NewSecret("test", "user", "secret"))
+	require.NoError(t, err)
+
+	secs, err := l.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, sec.ID(), secs[0].ID())
+
+	_, err = l.GetSecret(ctx, "missing")
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+func TestLowSecurity_Attachments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ls := fssafe.NewTestingLoaderSaver()
+	l := low.NewSecurityCustom(ls)
+
+	sec, err := l.SetSecret(ctx, secrets.NewSecret("cert", "me", "",
+		secrets.WithAttachment("client.p12", []byte{0x30, 0x82, 0x00, 0xff})))
+	require.NoError(t, err)
+
+	got, err := low.NewSecurityCustom(ls).GetSecret(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client.p12"}, secrets.Attachments(got))
+
+	data, has := secrets.GetAttachment(got, "client.p12")
+	assert.True(t, has)
+	assert.Equal(t, []byte{0x30, 0x82, 0x00, 0xff}, data)
+}
+
+func TestLowSecurity_Trash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ls := fssafe.NewTestingLoaderSaver()
+	l := low.NewSecurityCustom(ls)
+
+	sec, err := l.SetSecret(ctx, secrets.NewSecret("test", "user", "secret",
+		secrets.WithLocation("Work")))
+	require.NoError(t, err)
+
+	require.NoError(t, l.DeleteSecret(ctx, sec.ID()))
+	_, err = l.GetSecret(ctx, sec.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	l = low.NewSecurityCustom(ls)
+	trash, err := l.ListTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, sec.ID(), trash[0].ID())
+	assert.Equal(t, "test", trash[0].Name())
+
+	got, err := l.RestoreFromTrash(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "Work", got.Location())
+
+	ids, err := l.ListSecrets(ctx, "Work")
+	require.NoError(t, err)
+	assert.Equal(t, []string{sec.ID()}, ids)
+
+	_, err = l.RestoreFromTrash(ctx, sec.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	require.NoError(t, l.DeleteSecret(ctx, sec.ID()))
+	require.NoError(t, l.EmptyTrash(ctx))
+	trash, err = l.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+}
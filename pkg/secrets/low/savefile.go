@@ -5,6 +5,7 @@ import "github.com/zostay/go-std/maps"
 type lowSecurityConfig struct {
 	Version string
 	Secrets map[string]*Secret
+	Trash   map[string]*Secret `yaml:",omitempty"`
 }
 
 func newLowSecurityConfig() *lowSecurityConfig {
@@ -18,7 +19,11 @@ func (c *lowSecurityConfig) get(id string) (*Secret, bool) {
 		return &Secret{}, false
 	}
 
-	sec := c.Secrets[id]
+	sec, hasSecret := c.Secrets[id]
+	if !hasSecret {
+		return &Secret{}, false
+	}
+
 	sec.SetID(id)
 	return sec, true
 }
@@ -39,6 +44,34 @@ func (c *lowSecurityConfig) delete(id string) {
 	delete(c.Secrets, id)
 }
 
+// trash moves the secret to the trash.
+func (c *lowSecurityConfig) trash(id string) {
+	sec, hasSecret := c.get(id)
+	if !hasSecret {
+		return
+	}
+
+	if c.Trash == nil {
+		c.Trash = make(map[string]*Secret, 1)
+	}
+
+	c.Trash[id] = sec
+	c.delete(id)
+}
+
+// restore moves the secret out of the trash.
+func (c *lowSecurityConfig) restore(id string) (*Secret, bool) {
+	sec, inTrash := c.Trash[id]
+	if !inTrash {
+		return nil, false
+	}
+
+	sec.SetID(id)
+	c.set(sec)
+	delete(c.Trash, id)
+	return sec, true
+}
+
 func (c *lowSecurityConfig) iterator() *maps.Iterator[string, *Secret] {
 	return maps.NewIterator(c.Secrets)
 }
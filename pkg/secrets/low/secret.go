@@ -1,7 +1,9 @@
 package low
 
 import (
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"net/url"
 	"strconv"
 	"time"
@@ -42,7 +44,7 @@ func (s *Secret) MarshalYAML() (interface{}, error) {
 		lm = time.Now()
 	}
 
-	return map[string]any{
+	out := map[string]any{
 		"Name":         s.Name(),
 		"Username":     s.Username(),
 		"Password":     s.Password(),
@@ -51,7 +53,18 @@ func (s *Secret) MarshalYAML() (interface{}, error) {
 		"URL":          secrets.UrlString(s),
 		"Fields":       s.Fields(),
 		"LastModified": lm.Unix(),
-	}, nil
+	}
+
+	if names := s.Attachments(); len(names) > 0 {
+		atts := make(map[string]string, len(names))
+		for _, name := range names {
+			data, _ := s.GetAttachment(name)
+			atts[name] = base64.StdEncoding.EncodeToString(data)
+		}
+		out["Attachments"] = atts
+	}
+
+	return out, nil
 }
 
 // UnmarshalYAML unmarshals the secret from YAML.
@@ -75,6 +88,19 @@ func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
 			}
 		}
 
+		if node.Content[i].Value == "Attachments" &&
+			node.Content[i+1].Kind == yaml.MappingNode {
+			aNode := node.Content[i+1]
+			for j := 0; j < len(aNode.Content); j += 2 {
+				key := aNode.Content[j].Value
+				data, err := base64.StdEncoding.DecodeString(aNode.Content[j+1].Value)
+				if err != nil {
+					return fmt.Errorf("attachment %q: %w", key, err)
+				}
+				s.SetAttachment(key, data)
+			}
+		}
+
 		if node.Content[i+1].Kind != yaml.ScalarNode {
 			continue
 		}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"reflect"
 
+	"github.com/spf13/pflag"
+
 	"github.com/zostay/ghost/pkg/config"
 	"github.com/zostay/ghost/pkg/plugin"
 	"github.com/zostay/ghost/pkg/secrets"
@@ -13,23 +15,40 @@ import (
 const ConfigType = "memory"
 
 // Config is the configuration required for the secret store.
-type Config struct{}
+type Config struct {
+	// Trash keeps deleted secrets in a trash from which they may be restored.
+	Trash bool `mapstructure:"trash" yaml:"trash,omitempty"`
+}
 
 // Builder constructs a new internal secret store.
 func Builder(_ context.Context, c any) (secrets.Keeper, error) {
-	_, isInternal := c.(*Config)
+	cfg, isInternal := c.(*Config)
 	if !isInternal {
 		return nil, plugin.ErrConfig
 	}
 
+	if cfg.Trash {
+		return New(WithTrash())
+	}
+
 	return New()
 }
 
 func init() {
+	var trash bool
+
 	cmd := plugin.CmdConfig{
 		Short: "Configure an in-memory, temporary secret keeper",
 		Run: func(keeperName string, fields map[string]any) (config.KeeperConfig, error) {
-			return config.KeeperConfig{"type": ConfigType}, nil
+			kc := config.KeeperConfig{"type": ConfigType}
+			if trash {
+				kc["trash"] = true
+			}
+			return kc, nil
+		},
+		FlagInit: func(flags *pflag.FlagSet) error {
+			flags.BoolVar(&trash, "trash", false, "keep deleted secrets in a trash until it is emptied")
+			return nil
 		},
 	}
 	plugin.Register(ConfigType, reflect.TypeOf(Config{}), Builder, nil, nil, cmd)
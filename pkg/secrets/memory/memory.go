@@ -9,6 +9,7 @@ import (
 	"encoding/gob"
 
 	"github.com/oklog/ulid/v2"
+	"github.com/zostay/go-std/set"
 
 	"github.com/zostay/ghost/pkg/secrets"
 )
@@ -20,12 +21,28 @@ type Memory struct {
 	cipher  cipher.AEAD
 	nonce   []byte
 	secrets map[string][]byte
+	trash   map[string][]byte // deleted secrets, or nil if there is no trash
 }
 
-var _ secrets.Keeper = &Memory{}
+var (
+	_ secrets.Keeper    = &Memory{}
+	_ secrets.Trashable = &Memory{}
+)
+
+// Option is used to customize the memory store during construction.
+type Option func(*Memory)
+
+// WithTrash causes deleted secrets to be kept in a trash, from which they may
+// be restored, until the trash is emptied. Without it, deleted secrets are
+// removed immediately.
+func WithTrash() Option {
+	return func(m *Memory) {
+		m.trash = make(map[string][]byte)
+	}
+}
 
 // New constructs a new secret memory store.
-func New() (*Memory, error) {
+func New(opts ...Option) (*Memory, error) {
 	k := make([]byte, 32)
 	_, err := rand.Read(k)
 	if err != nil {
@@ -54,6 +71,10 @@ func New() (*Memory, error) {
 		secrets: make(map[string][]byte),
 	}
 
+	for _, opt := range opts {
+		opt(i)
+	}
+
 	return i, nil
 }
 
@@ -90,16 +111,16 @@ func (i *Memory) encodeSecret(sec secrets.Secret) ([]byte, error) {
 
 // ListLocations returns a list of all the secret names in the store.
 func (i *Memory) ListLocations(context.Context) ([]string, error) {
-	locs := make([]string, 0, len(i.secrets)>>1)
+	locs := set.New[string]()
 	for _, ct := range i.secrets {
 		sec, err := i.decodeSecret(ct)
 		if err != nil {
 			return nil, err
 		}
 
-		locs = append(locs, sec.Location())
+		locs.Insert(sec.Location())
 	}
-	return locs, nil
+	return locs.Keys(), nil
 }
 
 // ListSecrets returns a list of all the secret IDs at the given location.
@@ -172,7 +193,7 @@ func (i *Memory) CopySecret(ctx context.Context, id string, location string) (se
 		secrets.WithLocation(location),
 		secrets.WithID(ulid.Make().String()))
 
-	es, err := i.encodeSecret(secret)
+	es, err := i.encodeSecret(cp)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +212,7 @@ func (i *Memory) MoveSecret(ctx context.Context, id string, location string) (se
 	mv := secrets.NewSingleFromSecret(secret,
 		secrets.WithLocation(location))
 
-	es, err := i.encodeSecret(secret)
+	es, err := i.encodeSecret(mv)
 	if err != nil {
 		return nil, err
 	}
@@ -200,8 +221,63 @@ func (i *Memory) MoveSecret(ctx context.Context, id string, location string) (se
 	return mv, nil
 }
 
-// DeleteSecret removes the identified secret from the store.
+// DeleteSecret removes the identified secret from the store. If the store was
+// created WithTrash, the secret is moved to the trash.
 func (i *Memory) DeleteSecret(_ context.Context, id string) error {
+	if s, ok := i.secrets[id]; ok && i.trash != nil {
+		i.trash[id] = s
+	}
 	delete(i.secrets, id)
 	return nil
 }
+
+// ListTrash returns the secrets in the trash. It returns secrets.ErrNoTrash if
+// the store was not created WithTrash.
+func (i *Memory) ListTrash(context.Context) ([]secrets.Secret, error) {
+	if i.trash == nil {
+		return nil, secrets.ErrNoTrash
+	}
+
+	secs := make([]secrets.Secret, 0, len(i.trash))
+	for _, ct := range i.trash {
+		sec, err := i.decodeSecret(ct)
+		if err != nil {
+			return nil, err
+		}
+
+		secs = append(secs, sec)
+	}
+	return secs, nil
+}
+
+// RestoreFromTrash moves the identified secret out of the trash and back into
+// the store.
+func (i *Memory) RestoreFromTrash(_ context.Context, id string) (secrets.Secret, error) {
+	if i.trash == nil {
+		return nil, secrets.ErrNoTrash
+	}
+
+	s, ok := i.trash[id]
+	if !ok {
+		return nil, secrets.ErrNotFound
+	}
+
+	sec, err := i.decodeSecret(s)
+	if err != nil {
+		return nil, err
+	}
+
+	i.secrets[id] = s
+	delete(i.trash, id)
+	return sec, nil
+}
+
+// EmptyTrash permanently removes the secrets in the trash.
+func (i *Memory) EmptyTrash(context.Context) error {
+	if i.trash == nil {
+		return secrets.ErrNoTrash
+	}
+
+	i.trash = make(map[string][]byte)
+	return nil
+}
@@ -1,7 +1,12 @@
 package memory_test
 
 import (
+	"context"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/zostay/ghost/pkg/secrets"
 	"github.com/zostay/ghost/pkg/secrets/keepertest"
@@ -18,3 +23,100 @@ func TestMemory(t *testing.T) {
 	ts := keepertest.New(factory)
 	ts.Run(t)
 }
+
+func TestMemory_LastModified(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	lm := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	sec, err := m.SetSecret(ctx, secrets.NewSecret("test", "me", "secret",
+		secrets.WithLastModified(lm)))
+	require.NoError(t, err)
+
+	got, err := m.GetSecret(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.True(t, lm.Equal(got.LastModified()), "last modified %v is kept", got.LastModified())
+}
+
+func TestMemory_Attachments(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	sec, err := m.SetSecret(ctx, secrets.NewSecret("cert", "me", "",
+		secrets.WithField("note", "client cert"),
+		secrets.WithAttachment("client.p12", []byte{0x30, 0x82, 0x00, 0xff})))
+	require.NoError(t, err)
+
+	got, err := m.GetSecret(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client.p12"}, secrets.Attachments(got))
+	assert.Equal(t, map[string]string{"note": "client cert"}, got.Fields())
+
+	data, has := secrets.GetAttachment(got, "client.p12")
+	assert.True(t, has)
+	assert.Equal(t, []byte{0x30, 0x82, 0x00, 0xff}, data)
+
+	// fields are never mistaken for attachments or the standard parts
+	sec, err = m.SetSecret(ctx, secrets.NewSecret("cert", "me", "secret",
+		secrets.WithField("Attachment:note", "aGVsbG8="),
+		secrets.WithField("Password", "field")))
+	require.NoError(t, err)
+
+	got, err = m.GetSecret(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.Empty(t, secrets.Attachments(got))
+	assert.Equal(t, "secret", got.Password())
+	assert.Equal(t, map[string]string{
+		"Attachment:note": "aGVsbG8=",
+		"Password":        "field",
+	}, got.Fields())
+}
+
+func TestMemory_Trash(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, err := memory.New(memory.WithTrash())
+	require.NoError(t, err)
+
+	sec, err := m.SetSecret(ctx, secrets.NewSecret("test", "user", "secret",
+		secrets.WithLocation("Work")))
+	require.NoError(t, err)
+
+	require.NoError(t, m.DeleteSecret(ctx, sec.ID()))
+	_, err = m.GetSecret(ctx, sec.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	trash, err := m.ListTrash(ctx)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, sec.ID(), trash[0].ID())
+
+	got, err := m.RestoreFromTrash(ctx, sec.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "Work", got.Location())
+
+	_, err = m.GetSecret(ctx, sec.ID())
+	assert.NoError(t, err)
+
+	_, err = m.RestoreFromTrash(ctx, sec.ID())
+	assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+	require.NoError(t, m.DeleteSecret(ctx, sec.ID()))
+	require.NoError(t, m.EmptyTrash(ctx))
+	trash, err = m.ListTrash(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, trash)
+
+	// without a trash, deletes are permanent
+	m, err = memory.New()
+	require.NoError(t, err)
+	_, err = m.ListTrash(ctx)
+	assert.ErrorIs(t, err, secrets.ErrNoTrash)
+}
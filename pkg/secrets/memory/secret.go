@@ -1,17 +1,33 @@
 package memory
 
 import (
+	"encoding/base64"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zostay/ghost/pkg/secrets"
 )
 
+const (
+	// fieldPrefix is prepended to the name of each field to make its key in
+	// the secret map, so that no field can be mistaken for a standard key or
+	// an attachment.
+	fieldPrefix = "Field:"
+
+	// attachmentPrefix is prepended to the name of each attachment to make its
+	// key in the secret map. The value is the base64 encoded content.
+	attachmentPrefix = "Attachment:"
+)
+
+// SecretMap returns the secret as a map. The standard parts of the secret are
+// kept under their own names, while the fields and attachments are kept under
+// their names with a prefix.
 func SecretMap(in secrets.Secret) map[string]string {
 	out := make(map[string]string, len(in.Fields())+8)
 	for k, v := range in.Fields() {
-		out[k] = v
+		out[fieldPrefix+k] = v
 	}
 
 	out["ID"] = in.ID()
@@ -23,13 +39,20 @@ func SecretMap(in secrets.Secret) map[string]string {
 	out["Location"] = in.Location()
 	out["Url"] = secrets.UrlString(in)
 
+	for _, name := range secrets.Attachments(in) {
+		if data, hasAttachment := secrets.GetAttachment(in, name); hasAttachment {
+			out[attachmentPrefix+name] = base64.StdEncoding.EncodeToString(data)
+		}
+	}
+
 	return out
 }
 
+// MapSecret returns the secret kept in a map made by SecretMap.
 func MapSecret(in map[string]string) secrets.Secret {
 	var lm time.Time
 	lmInt, err := strconv.ParseInt(in["LastModified"], 10, 64)
-	if err != nil {
+	if err == nil {
 		lm = time.Unix(lmInt, 0)
 	}
 
@@ -58,7 +81,13 @@ func MapSecret(in map[string]string) secrets.Secret {
 		case "Url":
 			opts = append(opts, secrets.WithUrl(u))
 		default:
-			opts = append(opts, secrets.WithField(k, v))
+			if name, isField := strings.CutPrefix(k, fieldPrefix); isField {
+				opts = append(opts, secrets.WithField(name, v))
+			} else if name, isAttachment := strings.CutPrefix(k, attachmentPrefix); isAttachment {
+				if data, err := base64.StdEncoding.DecodeString(v); err == nil {
+					opts = append(opts, secrets.WithAttachment(name, data))
+				}
+			}
 		}
 	}
 
@@ -0,0 +1,168 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/zostay/fssafe"
+	"github.com/zostay/go-std/set"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/plugin"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// ConfigType is the type name for the mirror keeper.
+const ConfigType = "mirror"
+
+// Config is the configuration for the mirror keeper.
+type Config struct {
+	// Keepers is the list of keepers to mirror. Reads are made from the first
+	// keeper able to answer.
+	Keepers []string `mapstructure:"keepers" yaml:"keepers"`
+
+	// Quorum is the number of keepers that must accept a write: all, majority,
+	// or first. The default is all.
+	Quorum string `mapstructure:"quorum" yaml:"quorum,omitempty"`
+
+	// StatePath is the file to save the ID mapping and the repair log to. It is
+	// required, as writes missed by a keeper could not be repaired without it.
+	StatePath string `mapstructure:"state_path" yaml:"state_path"`
+}
+
+// Builder creates a new mirror keeper from the given configuration.
+func Builder(ctx context.Context, c any) (secrets.Keeper, error) {
+	cfg, isMirror := c.(*Config)
+	if !isMirror {
+		return nil, plugin.ErrConfig
+	}
+
+	members := make([]Member, len(cfg.Keepers))
+	for i, k := range cfg.Keepers {
+		kpr, err := keeper.Build(ctx, k)
+		if err != nil {
+			return nil, fmt.Errorf("unable to build the secret keeper named %q for the mirror keeper: %w", k, err)
+		}
+		members[i] = Member{Name: k, Keeper: kpr}
+	}
+
+	quorum, err := ParseQuorum(cfg.Quorum)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := homedir.Expand(os.ExpandEnv(cfg.StatePath))
+	if err != nil {
+		return nil, fmt.Errorf("unable to expand mirror state path %q: %w", cfg.StatePath, err)
+	}
+
+	return NewMirror(members,
+		WithQuorum(quorum),
+		WithState(fssafe.NewFileSystemLoaderSaver(path)))
+}
+
+// Validate checks that the configuration is correct for the mirror keeper. It
+// will check that every mirrored keeper exists and is only listed once, that
+// the quorum is known, and that the state path is set.
+func Validate(ctx context.Context, c any) error {
+	cfg, isMirror := c.(*Config)
+	if !isMirror {
+		return plugin.ErrConfig
+	}
+
+	errs := plugin.NewValidationError()
+
+	if len(cfg.Keepers) == 0 {
+		errs.Append(fmt.Errorf("mirror keeper has no keepers"))
+	}
+
+	seen := set.New[string]()
+	for _, k := range cfg.Keepers {
+		if seen.Contains(k) {
+			errs.Append(fmt.Errorf("mirror keeper %q is listed more than once", k))
+		}
+		seen.Insert(k)
+
+		if !keeper.Exists(ctx, k) {
+			errs.Append(fmt.Errorf("mirror keeper %q does not exist", k))
+		}
+	}
+
+	if _, err := ParseQuorum(cfg.Quorum); err != nil {
+		errs.Append(err)
+	}
+
+	if cfg.StatePath == "" {
+		errs.Append(fmt.Errorf("mirror keeper has no state path"))
+	}
+
+	return errs.Return()
+}
+
+// Print is the config printer for the mirror keeper.
+func Print(c any, w io.Writer) error {
+	cfg, isMirror := c.(*Config)
+	if !isMirror {
+		return plugin.ErrConfig
+	}
+
+	quorum := cfg.Quorum
+	if quorum == "" {
+		quorum = QuorumAll.String()
+	}
+
+	fmt.Fprintln(w, "keepers:", strings.Join(cfg.Keepers, ", "))
+	fmt.Fprintln(w, "quorum:", quorum)
+	fmt.Fprintln(w, "state path:", cfg.StatePath)
+	return nil
+}
+
+func init() {
+	var (
+		keepers   []string
+		quorum    string
+		statePath string
+	)
+
+	cmd := plugin.CmdConfig{
+		Short: "Configure a mirror keeper that writes to several keepers at once",
+		Run: func(_ string, _ map[string]any) (config.KeeperConfig, error) {
+			kc := config.KeeperConfig{
+				"type":       ConfigType,
+				"keepers":    keepers,
+				"state_path": statePath,
+			}
+
+			if quorum != "" {
+				kc["quorum"] = quorum
+			}
+
+			return kc, nil
+		},
+		FlagInit: func(flags *pflag.FlagSet) error {
+			flags.StringSliceVar(&keepers, "keepers", []string{}, "the names of the keepers to mirror")
+			flags.StringVar(&quorum, "quorum", "", "how many keepers must accept a write: all, majority, or first (default all)")
+			flags.StringVar(&statePath, "state-path", "", "the file to save the ID mapping and repair log to")
+
+			if err := cobra.MarkFlagRequired(flags, "keepers"); err != nil {
+				return err
+			}
+
+			if err := cobra.MarkFlagRequired(flags, "state-path"); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	plugin.Register(ConfigType, reflect.TypeOf(Config{}), Builder, Validate, Print, cmd)
+}
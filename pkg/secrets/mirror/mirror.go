@@ -0,0 +1,608 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/zostay/fssafe"
+	"github.com/zostay/go-std/set"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// Quorum determines how many members of a Mirror must accept a write for the
+// write to succeed.
+type Quorum int
+
+const (
+	// QuorumAll requires every member to accept the write.
+	QuorumAll Quorum = iota
+
+	// QuorumMajority requires more than half the members to accept the write.
+	QuorumMajority
+
+	// QuorumFirst requires the first member to accept the write.
+	QuorumFirst
+)
+
+// String returns the name of the quorum as used in the configuration.
+func (q Quorum) String() string {
+	switch q {
+	case QuorumAll:
+		return "all"
+	case QuorumMajority:
+		return "majority"
+	case QuorumFirst:
+		return "first"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseQuorum parses the name of a quorum. An empty string is QuorumAll.
+func ParseQuorum(name string) (Quorum, error) {
+	switch name {
+	case "", "all":
+		return QuorumAll, nil
+	case "majority":
+		return QuorumMajority, nil
+	case "first":
+		return QuorumFirst, nil
+	default:
+		return QuorumAll, fmt.Errorf("unknown quorum %q", name)
+	}
+}
+
+// met returns true if the members that accepted the write make a quorum.
+func (q Quorum) met(ok []bool) bool {
+	n := 0
+	for _, o := range ok {
+		if o {
+			n++
+		}
+	}
+
+	switch q {
+	case QuorumMajority:
+		return n > len(ok)/2
+	case QuorumFirst:
+		return len(ok) > 0 && ok[0]
+	default:
+		return n == len(ok)
+	}
+}
+
+// ErrNoQuorum is returned when too few members accept a write. The members
+// that did accept the write keep it and the others are recorded for repair.
+var ErrNoQuorum = errors.New("mirror write did not reach quorum")
+
+// Member is a keeper mirrored by a Mirror.
+type Member struct {
+	// Name identifies the member in the ID mapping and the repair log.
+	Name string
+
+	// Keeper is the mirrored keeper.
+	Keeper secrets.Keeper
+}
+
+// Mirror is a Keeper that writes every change to all of its members and reads
+// from the first member able to answer.
+//
+// Each member assigns its own IDs to secrets. The ID of a secret in the Mirror
+// is the ID assigned by the first member to accept the secret and the Mirror
+// tracks the ID of the secret in each of the other members. When the Mirror has
+// not seen a secret before, such as a secret that existed before the Mirror
+// was configured, the secret is found in the other members by name, location,
+// and username.
+//
+// Members that fail to accept a write are recorded in a repair log. Calling
+// Repair will copy the missed changes to them.
+type Mirror struct {
+	members []Member
+	quorum  Quorum
+
+	lock  sync.Mutex
+	state *state
+	store fssafe.LoaderSaver
+}
+
+var (
+	_ secrets.Keeper  = &Mirror{}
+	_ secrets.Wrapper = &Mirror{}
+)
+
+// Option is an option for a Mirror.
+type Option func(*Mirror)
+
+// WithQuorum sets the quorum required for writes. The default is QuorumAll.
+func WithQuorum(q Quorum) Option {
+	return func(m *Mirror) {
+		m.quorum = q
+	}
+}
+
+// WithState saves the ID mapping and the repair log using ls so they survive
+// a restart. Without this, they are only kept in memory.
+func WithState(ls fssafe.LoaderSaver) Option {
+	return func(m *Mirror) {
+		m.store = ls
+	}
+}
+
+// NewMirror returns a new Mirror of the given members. Members with no name
+// are named after their position, starting from 0.
+func NewMirror(members []Member, opts ...Option) (*Mirror, error) {
+	if len(members) == 0 {
+		return nil, errors.New("no keepers given")
+	}
+
+	m := &Mirror{
+		members: make([]Member, len(members)),
+		state:   newState(),
+	}
+
+	names := set.New[string]()
+	for i, mem := range members {
+		if mem.Name == "" {
+			mem.Name = strconv.Itoa(i)
+		}
+
+		if names.Contains(mem.Name) {
+			return nil, fmt.Errorf("member name %q is used more than once", mem.Name)
+		}
+		names.Insert(mem.Name)
+
+		m.members[i] = mem
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if err := m.loadState(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Unwrap returns the member Keepers.
+func (m *Mirror) Unwrap() []secrets.Keeper {
+	kprs := make([]secrets.Keeper, len(m.members))
+	for i, mem := range m.members {
+		kprs[i] = mem.Keeper
+	}
+	return kprs
+}
+
+// Members returns the names of the members in order.
+func (m *Mirror) Members() []string {
+	names := make([]string, len(m.members))
+	for i, mem := range m.members {
+		names[i] = mem.Name
+	}
+	return names
+}
+
+// firstHealthy calls run for each member in turn until one returns without
+// error. The errors are returned together if every member fails.
+func (m *Mirror) firstHealthy(run func(i int, mem Member) error) error {
+	errs := make([]error, 0, len(m.members))
+	for i, mem := range m.members {
+		err := run(i, mem)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("mirror member %q: %w", mem.Name, err))
+	}
+	return errors.Join(errs...)
+}
+
+// ListLocations returns the locations of the first member able to list them.
+func (m *Mirror) ListLocations(ctx context.Context) ([]string, error) {
+	var locs []string
+	err := m.firstHealthy(func(_ int, mem Member) error {
+		var err error
+		locs, err = mem.Keeper.ListLocations(ctx)
+		return err
+	})
+	return locs, err
+}
+
+// ListSecrets returns the IDs of the secrets in the location from the first
+// member able to list them.
+func (m *Mirror) ListSecrets(
+	ctx context.Context,
+	location string,
+) ([]string, error) {
+	var ids []string
+	err := m.firstHealthy(func(i int, mem Member) error {
+		memIds, err := mem.Keeper.ListSecrets(ctx, location)
+		if err != nil {
+			return err
+		}
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		ids = make([]string, len(memIds))
+		for j, id := range memIds {
+			ids[j] = m.state.seen(mem.Name, id, nil)
+		}
+		return nil
+	})
+	return ids, err
+}
+
+// GetSecret returns the secret from the first member that has it. A member
+// that does not have the secret may be waiting for repair, so the remaining
+// members are checked before secrets.ErrNotFound is returned.
+func (m *Mirror) GetSecret(
+	ctx context.Context,
+	id string,
+) (secrets.Secret, error) {
+	var sec secrets.Secret
+	notFound := 0
+	err := m.firstHealthy(func(i int, mem Member) error {
+		memId, err := m.memberId(ctx, i, id)
+		if err == nil && memId == "" {
+			err = secrets.ErrNotFound
+		}
+
+		var s secrets.Secret
+		if err == nil {
+			s, err = mem.Keeper.GetSecret(ctx, memId)
+		}
+
+		if err != nil {
+			if errors.Is(err, secrets.ErrNotFound) {
+				notFound++
+			}
+			return err
+		}
+
+		m.lock.Lock()
+		m.state.seen(mem.Name, memId, s)
+		m.lock.Unlock()
+
+		sec = secrets.NewSingleFromSecret(s, secrets.WithID(id))
+		return nil
+	})
+
+	if err != nil && notFound > 0 {
+		return nil, secrets.ErrNotFound
+	}
+
+	return sec, err
+}
+
+// GetSecretsByName returns the secrets with the given name from the first
+// member able to search for them.
+func (m *Mirror) GetSecretsByName(
+	ctx context.Context,
+	name string,
+) ([]secrets.Secret, error) {
+	var secs []secrets.Secret
+	err := m.firstHealthy(func(_ int, mem Member) error {
+		memSecs, err := mem.Keeper.GetSecretsByName(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		secs = make([]secrets.Secret, len(memSecs))
+		for j, s := range memSecs {
+			secs[j] = secrets.NewSingleFromSecret(s,
+				secrets.WithID(m.state.seen(mem.Name, s.ID(), s)))
+		}
+		return nil
+	})
+	return secs, err
+}
+
+// memberId returns the ID of the mirrored secret in the given member. It
+// returns an empty string if the member does not have the secret.
+//
+// If the member's ID for the secret is not known, the secret is read from the
+// member the ID came from, which is the first member unless the ID was read
+// from another, and found in the given member by name, location, and username.
+// If the member the ID came from cannot be reached, the name, location, and
+// username recorded when the secret was last read through the Mirror are used.
+func (m *Mirror) memberId(ctx context.Context, i int, id string) (string, error) {
+	m.lock.Lock()
+	memId, isMapped := m.state.memberId(id, m.members[i].Name)
+	origName, origId, hasOrigin := m.state.origin(id, m.members[i].Name)
+	ident, isKnown := m.state.Identities[id]
+	m.lock.Unlock()
+
+	if isMapped {
+		return memId, nil
+	}
+
+	orig := 0
+	if hasOrigin {
+		orig = m.member(origName)
+	} else {
+		origId = id
+	}
+
+	if orig < 0 {
+		return "", nil
+	}
+
+	if i == orig {
+		return id, nil
+	}
+
+	origSec, err := m.members[orig].Keeper.GetSecret(ctx, origId)
+	switch {
+	case err == nil:
+		ident = identityOf(origSec)
+	case errors.Is(err, secrets.ErrNotFound) || !isKnown:
+		return "", err
+	}
+
+	secs, err := m.members[i].Keeper.GetSecretsByName(ctx, ident.Name)
+	if err != nil {
+		return "", err
+	}
+
+	for _, sec := range secs {
+		if ident.matches(sec) {
+			m.lock.Lock()
+			m.state.mapId(id, m.members[orig].Name, origId)
+			m.state.mapId(id, m.members[i].Name, sec.ID())
+			m.state.Identities[id] = ident
+			m.lock.Unlock()
+
+			return sec.ID(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// result is the outcome of a write to a single member.
+type result struct {
+	sec secrets.Secret
+	err error
+}
+
+// fanOut runs the write on every member at once and returns the results in
+// member order.
+func (m *Mirror) fanOut(run func(i int, mem Member) (secrets.Secret, error)) []result {
+	results := make([]result, len(m.members))
+
+	var wg sync.WaitGroup
+	for i, mem := range m.members {
+		wg.Add(1)
+		go func(i int, mem Member) {
+			defer wg.Done()
+			sec, err := run(i, mem)
+			results[i] = result{sec, err}
+		}(i, mem)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// failed returns true if every write failed.
+func failed(results []result) bool {
+	for _, r := range results {
+		if r.err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// settle records the results of a write and checks them against the quorum.
+//
+// If created is true, the write created a new secret. The ID of the new secret
+// is taken from the first member to accept it and each member's ID for the
+// new secret is mapped to it. Otherwise, any member that returns a secret has
+// its ID for the secret mapped to the given ID.
+//
+// Each member that failed is recorded for repair using the ID of the secret
+// and the member's ID, if given in memIds. It returns the secret written, if
+// any. If every member failed, nothing is recorded and the errors are
+// returned.
+func (m *Mirror) settle(
+	op string,
+	id string,
+	results []result,
+	created bool,
+	memIds []string,
+) (secrets.Secret, error) {
+	var errs []error
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, fmt.Errorf("mirror member %q: %w", m.members[i].Name, r.err))
+		}
+	}
+
+	if failed(results) {
+		return nil, errors.Join(errs...)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var (
+		ok  = make([]bool, len(results))
+		sec secrets.Secret
+	)
+	for i, r := range results {
+		if r.err != nil {
+			continue
+		}
+
+		ok[i] = true
+		if r.sec == nil {
+			continue
+		}
+
+		if sec == nil {
+			if created {
+				id = r.sec.ID()
+			}
+			sec = secrets.NewSingleFromSecret(r.sec, secrets.WithID(id))
+		}
+
+		m.state.mapId(id, m.members[i].Name, r.sec.ID())
+	}
+
+	for i, r := range results {
+		if r.err != nil && created {
+			m.state.mapId(id, m.members[i].Name, "")
+		}
+
+		if r.err != nil {
+			memId := ""
+			if memIds != nil {
+				memId = memIds[i]
+			}
+			m.state.addRepair(op, id, m.members[i].Name, memId, r.err)
+		}
+	}
+
+	err := m.saveState()
+
+	if !m.quorum.met(ok) {
+		return sec, fmt.Errorf("%w (%s): %w", ErrNoQuorum, m.quorum, errors.Join(errs...))
+	}
+
+	return sec, err
+}
+
+// SetSecret writes the secret to every member.
+func (m *Mirror) SetSecret(
+	ctx context.Context,
+	sec secrets.Secret,
+) (secrets.Secret, error) {
+	results := m.fanOut(func(i int, mem Member) (secrets.Secret, error) {
+		memId := ""
+		if sec.ID() != "" {
+			var err error
+			memId, err = m.memberId(ctx, i, sec.ID())
+			if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+				return nil, err
+			}
+		}
+
+		return mem.Keeper.SetSecret(ctx,
+			secrets.NewSingleFromSecret(sec, secrets.WithID(memId)))
+	})
+
+	return m.settle(opSet, sec.ID(), results, sec.ID() == "", nil)
+}
+
+// DeleteSecret deletes the secret from every member. A member that does not
+// have the secret is treated as having deleted it.
+func (m *Mirror) DeleteSecret(
+	ctx context.Context,
+	id string,
+) error {
+	memIds := make([]string, len(m.members))
+	results := m.fanOut(func(i int, mem Member) (secrets.Secret, error) {
+		memId, err := m.memberId(ctx, i, id)
+		if errors.Is(err, secrets.ErrNotFound) || (err == nil && memId == "") {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		memIds[i] = memId
+		err = mem.Keeper.DeleteSecret(ctx, memId)
+		if errors.Is(err, secrets.ErrNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	})
+
+	_, err := m.settle(opDelete, id, results, false, memIds)
+
+	m.lock.Lock()
+	m.state.forget(id)
+	m.lock.Unlock()
+
+	return err
+}
+
+// CopySecret copies the secret to the new location in every member.
+func (m *Mirror) CopySecret(
+	ctx context.Context,
+	id string,
+	location string,
+) (secrets.Secret, error) {
+	results := m.fanOut(func(i int, mem Member) (secrets.Secret, error) {
+		memId, err := m.memberId(ctx, i, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if memId == "" {
+			return nil, secrets.ErrNotFound
+		}
+
+		return mem.Keeper.CopySecret(ctx, memId, location)
+	})
+
+	return m.settle(opSet, "", results, true, nil)
+}
+
+// MoveSecret moves the secret to the new location in every member. A member
+// that fails to move the secret is recorded for repair of both the new
+// location and the old.
+func (m *Mirror) MoveSecret(
+	ctx context.Context,
+	id string,
+	location string,
+) (secrets.Secret, error) {
+	memIds := make([]string, len(m.members))
+	results := m.fanOut(func(i int, mem Member) (secrets.Secret, error) {
+		memId, err := m.memberId(ctx, i, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if memId == "" {
+			return nil, secrets.ErrNotFound
+		}
+
+		memIds[i] = memId
+		return mem.Keeper.MoveSecret(ctx, memId, location)
+	})
+
+	if failed(results) {
+		return m.settle(opSet, "", results, true, nil)
+	}
+
+	// some keepers keep the ID when moving, so forget the old mapping first
+	m.lock.Lock()
+	m.state.forget(id)
+	m.lock.Unlock()
+
+	sec, err := m.settle(opSet, "", results, true, nil)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for i, r := range results {
+		if r.err != nil && memIds[i] != "" {
+			m.state.addRepair(opDelete, id, m.members[i].Name, memIds[i], r.err)
+		}
+	}
+
+	if saveErr := m.saveState(); err == nil {
+		err = saveErr
+	}
+
+	return sec, err
+}
@@ -0,0 +1,354 @@
+package mirror_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zostay/fssafe"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/keepertest"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+	"github.com/zostay/ghost/pkg/secrets/mirror"
+)
+
+func TestMirror(t *testing.T) { //nolint:tparallel // it is parallel, you dolt
+	t.Parallel()
+
+	factory := func() (secrets.Keeper, error) {
+		m1, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+
+		m2, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+
+		return mirror.NewMirror([]mirror.Member{
+			{Keeper: m1},
+			{Keeper: m2},
+		})
+	}
+
+	ts := keepertest.New(factory)
+	ts.Run(t)
+}
+
+// downKeeper fails everything while down is set.
+type downKeeper struct {
+	*memory.Memory
+	down bool
+}
+
+var errDown = errors.New("connection refused")
+
+func (k *downKeeper) ListLocations(ctx context.Context) ([]string, error) {
+	if k.down {
+		return nil, errDown
+	}
+	return k.Memory.ListLocations(ctx)
+}
+
+func (k *downKeeper) ListSecrets(ctx context.Context, location string) ([]string, error) {
+	if k.down {
+		return nil, errDown
+	}
+	return k.Memory.ListSecrets(ctx, location)
+}
+
+func (k *downKeeper) GetSecretsByName(ctx context.Context, name string) ([]secrets.Secret, error) {
+	if k.down {
+		return nil, errDown
+	}
+	return k.Memory.GetSecretsByName(ctx, name)
+}
+
+func (k *downKeeper) GetSecret(ctx context.Context, id string) (secrets.Secret, error) {
+	if k.down {
+		return nil, errDown
+	}
+	return k.Memory.GetSecret(ctx, id)
+}
+
+func (k *downKeeper) SetSecret(ctx context.Context, sec secrets.Secret) (secrets.Secret, error) {
+	if k.down {
+		return nil, errDown
+	}
+	return k.Memory.SetSecret(ctx, sec)
+}
+
+func (k *downKeeper) DeleteSecret(ctx context.Context, id string) error {
+	if k.down {
+		return errDown
+	}
+	return k.Memory.DeleteSecret(ctx, id)
+}
+
+func newMembers(t *testing.T, n int) ([]*downKeeper, []mirror.Member) {
+	t.Helper()
+
+	kprs := make([]*downKeeper, n)
+	members := make([]mirror.Member, n)
+	for i := range kprs {
+		m, err := memory.New()
+		require.NoError(t, err)
+
+		kprs[i] = &downKeeper{Memory: m}
+		members[i] = mirror.Member{Name: string(rune('a' + i)), Keeper: kprs[i]}
+	}
+
+	return kprs, members
+}
+
+func TestMirror_FanOut(t *testing.T) {
+	t.Parallel()
+
+	kprs, members := newMembers(t, 3)
+	m, err := mirror.NewMirror(members)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	sec, err := m.SetSecret(ctx,
+		secrets.NewSecret("test", "user", "one", secrets.WithLocation("Personal")))
+	require.NoError(t, err)
+
+	for _, k := range kprs {
+		secs, err := k.GetSecretsByName(ctx, "test")
+		require.NoError(t, err)
+		require.Len(t, secs, 1)
+		assert.Equal(t, "one", secs[0].Password())
+	}
+
+	sec, err = m.SetSecret(ctx,
+		secrets.NewSingleFromSecret(sec, secrets.WithPassword("two")))
+	require.NoError(t, err)
+
+	moved, err := m.MoveSecret(ctx, sec.ID(), "Work")
+	require.NoError(t, err)
+	assert.Equal(t, "Work", moved.Location())
+
+	for _, k := range kprs {
+		secs, err := k.GetSecretsByName(ctx, "test")
+		require.NoError(t, err)
+		require.Len(t, secs, 1)
+		assert.Equal(t, "two", secs[0].Password())
+		assert.Equal(t, "Work", secs[0].Location())
+	}
+
+	// reads fall back to the next member
+	kprs[0].down = true
+	got, err := m.GetSecret(ctx, moved.ID())
+	require.NoError(t, err)
+	assert.Equal(t, moved.ID(), got.ID())
+	assert.Equal(t, "two", got.Password())
+	kprs[0].down = false
+
+	err = m.DeleteSecret(ctx, moved.ID())
+	require.NoError(t, err)
+
+	for _, k := range kprs {
+		secs, err := k.GetSecretsByName(ctx, "test")
+		require.NoError(t, err)
+		assert.Empty(t, secs)
+	}
+
+	err = m.DeleteSecret(ctx, moved.ID())
+	assert.NoError(t, err)
+}
+
+func TestMirror_Unmapped(t *testing.T) {
+	t.Parallel()
+
+	kprs, members := newMembers(t, 2)
+	m, err := mirror.NewMirror(members)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	// secrets written to the members before the mirror was configured
+	for _, k := range kprs {
+		for _, name := range []string{"one", "two"} {
+			_, err := k.Memory.SetSecret(ctx, secrets.NewSecret(name, "user", name,
+				secrets.WithLocation("Personal")))
+			require.NoError(t, err)
+		}
+	}
+
+	// an ID read while the first member is up can be read from the second
+	// once the first is down
+	secs, err := m.GetSecretsByName(ctx, "one")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	one := secs[0].ID()
+
+	kprs[0].down = true
+	got, err := m.GetSecret(ctx, one)
+	require.NoError(t, err)
+	assert.Equal(t, one, got.ID())
+	assert.Equal(t, "one", got.Password())
+
+	// an ID listed while the first member is down can be read from either
+	ids, err := m.ListSecrets(ctx, "Personal")
+	require.NoError(t, err)
+	require.Len(t, ids, 2)
+
+	var two string
+	for _, id := range ids {
+		if id != one {
+			two = id
+		}
+	}
+	require.NotEmpty(t, two)
+
+	got, err = m.GetSecret(ctx, two)
+	require.NoError(t, err)
+	assert.Equal(t, "two", got.Password())
+
+	kprs[0].down = false
+	kprs[1].down = true
+	got, err = m.GetSecret(ctx, two)
+	require.NoError(t, err)
+	assert.Equal(t, two, got.ID())
+	assert.Equal(t, "two", got.Password())
+}
+
+func TestMirror_Quorum(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		quorum mirror.Quorum
+		down   int
+		ok     bool
+	}{
+		{mirror.QuorumAll, 2, false},
+		{mirror.QuorumMajority, 2, true},
+		{mirror.QuorumMajority, 0, false},
+		{mirror.QuorumFirst, 2, true},
+		{mirror.QuorumFirst, 0, false},
+	}
+
+	for _, tt := range tests {
+		kprs, members := newMembers(t, 3)
+		m, err := mirror.NewMirror(members, mirror.WithQuorum(tt.quorum))
+		require.NoError(t, err)
+
+		kprs[tt.down].down = true
+		if tt.down == 0 {
+			kprs[1].down = true
+		}
+
+		_, err = m.SetSecret(ctx, secrets.NewSecret("test", "user", "one"))
+		if tt.ok {
+			assert.NoError(t, err, tt.quorum.String())
+		} else {
+			assert.ErrorIs(t, err, mirror.ErrNoQuorum, tt.quorum.String())
+		}
+	}
+}
+
+func TestMirror_Repair(t *testing.T) {
+	t.Parallel()
+
+	kprs, members := newMembers(t, 2)
+	ls := fssafe.NewTestingLoaderSaver()
+	m, err := mirror.NewMirror(members,
+		mirror.WithQuorum(mirror.QuorumFirst),
+		mirror.WithState(ls))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	kprs[1].down = true
+	sec, err := m.SetSecret(ctx, secrets.NewSecret("test", "user", "one"))
+	require.NoError(t, err)
+
+	reps := m.PendingRepairs()
+	require.Len(t, reps, 1)
+	assert.Equal(t, "set", reps[0].Op)
+	assert.Equal(t, "b", reps[0].Member)
+	assert.Equal(t, sec.ID(), reps[0].ID)
+
+	// still down, so the repair is kept
+	n, err := m.Repair(ctx)
+	assert.ErrorIs(t, err, errDown)
+	assert.Equal(t, 0, n)
+	assert.Len(t, m.PendingRepairs(), 1)
+
+	// the repair log survives a restart
+	m, err = mirror.NewMirror(members,
+		mirror.WithQuorum(mirror.QuorumFirst),
+		mirror.WithState(ls))
+	require.NoError(t, err)
+	require.Len(t, m.PendingRepairs(), 1)
+
+	kprs[1].down = false
+	n, err = m.Repair(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Empty(t, m.PendingRepairs())
+
+	secs, err := kprs[1].GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, "one", secs[0].Password())
+
+	// the repaired copy is mapped, so deletes reach it
+	kprs[0].down = true
+	err = m.DeleteSecret(ctx, sec.ID())
+	assert.ErrorIs(t, err, mirror.ErrNoQuorum)
+
+	secs, err = kprs[1].GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	assert.Empty(t, secs)
+
+	kprs[0].down = false
+	n, err = m.Repair(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	secs, err = kprs[0].GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	assert.Empty(t, secs)
+}
+
+func TestBuilder_StatePath(t *testing.T) {
+	t.Parallel()
+
+	statePath := filepath.Join(t.TempDir(), "mirror.yaml")
+
+	c := config.New()
+	c.Keepers["one"] = config.KeeperConfig{"type": memory.ConfigType}
+	c.Keepers["two"] = config.KeeperConfig{"type": memory.ConfigType}
+	c.Keepers["both"] = config.KeeperConfig{
+		"type":    mirror.ConfigType,
+		"keepers": []string{"one", "two"},
+	}
+
+	ctx := keeper.WithBuilder(context.Background(), c)
+
+	// missed writes could not be repaired without somewhere to record them
+	_, err := keeper.Build(ctx, "both")
+	assert.Error(t, err)
+
+	c.Keepers["both"]["state_path"] = statePath
+	kpr, err := keeper.Build(ctx, "both")
+	require.NoError(t, err)
+
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("test", "test", "one"))
+	require.NoError(t, err)
+
+	_, err = os.Stat(statePath)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,442 @@
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+const (
+	opSet    = "set"
+	opDelete = "delete"
+)
+
+// ErrUnsupportedStateVersion is returned when the saved state was written by a
+// newer version of ghost.
+var ErrUnsupportedStateVersion = errors.New("unsupported mirror state version")
+
+// Repair is a write missed by a member of a Mirror.
+type Repair struct {
+	// Op is "set" if the member is missing the current copy of the secret or
+	// "delete" if the member still has a copy that should have been deleted.
+	Op string `yaml:"op"`
+
+	// ID is the ID of the secret in the Mirror.
+	ID string `yaml:"id"`
+
+	// Member is the name of the member that missed the write.
+	Member string `yaml:"member"`
+
+	// MemberID is the ID of the secret to delete from the member.
+	MemberID string `yaml:"member_id,omitempty"`
+
+	// Error is the error from the most recent failed attempt.
+	Error string `yaml:"error,omitempty"`
+
+	// Failed is the time of the most recent failed attempt.
+	Failed time.Time `yaml:"failed"`
+
+	// Attempts is the number of failed attempts.
+	Attempts int `yaml:"attempts"`
+}
+
+// Identity is what identifies a secret across the members of a Mirror.
+type Identity struct {
+	Name     string `yaml:"name"`
+	Location string `yaml:"location,omitempty"`
+	Username string `yaml:"username,omitempty"`
+}
+
+// identityOf returns the identity of the secret.
+func identityOf(sec secrets.Secret) Identity {
+	return Identity{
+		Name:     sec.Name(),
+		Location: sec.Location(),
+		Username: sec.Username(),
+	}
+}
+
+// matches returns true if the secret has this identity.
+func (ident Identity) matches(sec secrets.Secret) bool {
+	return sec.Name() == ident.Name &&
+		sec.Location() == ident.Location &&
+		sec.Username() == ident.Username
+}
+
+// state is the ID mapping and the repair log of a Mirror.
+type state struct {
+	Version string `yaml:"version"`
+
+	// IDs maps the ID of each secret in the Mirror to the ID of the secret in
+	// each member, by member name.
+	IDs map[string]map[string]string `yaml:"ids"`
+
+	// Repairs lists the writes missed by members.
+	Repairs []*Repair `yaml:"repairs"`
+
+	// Identities holds the name, location, and username of each secret read
+	// through the Mirror, so that it may be found in the other members while
+	// the member it was read from is down.
+	Identities map[string]Identity `yaml:"identities,omitempty"`
+
+	// reverse maps the ID of each secret in each member, by member name, to
+	// the ID in the Mirror.
+	reverse map[string]map[string]string
+}
+
+func newState() *state {
+	return &state{
+		Version:    "1",
+		IDs:        map[string]map[string]string{},
+		Identities: map[string]Identity{},
+		reverse:    map[string]map[string]string{},
+	}
+}
+
+// mapId records the ID of the mirrored secret in the member. An empty member
+// ID records that the member does not have the secret.
+func (s *state) mapId(id, member, memId string) {
+	if s.IDs[id] == nil {
+		s.IDs[id] = map[string]string{}
+	}
+
+	if old, isMapped := s.IDs[id][member]; isMapped {
+		delete(s.reverse[member], old)
+	}
+
+	s.IDs[id][member] = memId
+	if memId == "" {
+		return
+	}
+
+	if s.reverse[member] == nil {
+		s.reverse[member] = map[string]string{}
+	}
+	s.reverse[member][memId] = id
+}
+
+// memberId returns the ID of the mirrored secret in the member, if known.
+func (s *state) memberId(id, member string) (string, bool) {
+	memId, isMapped := s.IDs[id][member]
+	return memId, isMapped
+}
+
+// mirrorId returns the ID in the Mirror of the secret in the member. Secrets
+// the Mirror has not seen before keep their ID.
+func (s *state) mirrorId(member, memId string) string {
+	if id, isMapped := s.reverse[member][memId]; isMapped {
+		return id
+	}
+	return memId
+}
+
+// seen records that the mirrored secret was read from the member with the
+// member ID, unless the Mirror already knows the secret by another ID. It
+// returns the ID of the secret in the Mirror.
+func (s *state) seen(member, memId string, sec secrets.Secret) string {
+	id := s.mirrorId(member, memId)
+	if _, isMapped := s.memberId(id, member); !isMapped {
+		s.mapId(id, member, memId)
+	}
+
+	if sec != nil {
+		s.Identities[id] = identityOf(sec)
+	}
+
+	return id
+}
+
+// origin returns the name and ID of the secret in a member other than the
+// one named that is known to have the mirrored secret. It returns false if
+// no other member is known to have it.
+func (s *state) origin(id, except string) (string, string, bool) {
+	for member, memId := range s.IDs[id] {
+		if member != except && memId != "" {
+			return member, memId, true
+		}
+	}
+	return "", "", false
+}
+
+// forget removes the mapping of the mirrored secret.
+func (s *state) forget(id string) {
+	for member, memId := range s.IDs[id] {
+		delete(s.reverse[member], memId)
+	}
+	delete(s.IDs, id)
+	delete(s.Identities, id)
+}
+
+// addRepair records a write missed by a member. A repair already recorded for
+// the same secret and member is updated instead.
+func (s *state) addRepair(op, id, member, memId string, err error) {
+	var rep *Repair
+	for _, r := range s.Repairs {
+		if r.Op == op && r.ID == id && r.Member == member && r.MemberID == memId {
+			rep = r
+			break
+		}
+	}
+
+	if rep == nil {
+		rep = &Repair{Op: op, ID: id, Member: member, MemberID: memId}
+		s.Repairs = append(s.Repairs, rep)
+	}
+
+	rep.Failed = time.Now()
+	rep.Attempts++
+	if err != nil {
+		rep.Error = err.Error()
+	}
+}
+
+// pendingRepair returns true if the member has a repair recorded for the
+// secret.
+func (s *state) pendingRepair(id, member string) bool {
+	for _, r := range s.Repairs {
+		if r.ID == id && r.Member == member {
+			return true
+		}
+	}
+	return false
+}
+
+// loadState loads the saved state, if there is one.
+func (m *Mirror) loadState() error {
+	if m.store == nil {
+		return nil
+	}
+
+	r, err := m.store.Loader()
+	if err != nil {
+		// no saved state yet
+		return nil
+	}
+	defer func() { _ = r.Close() }()
+
+	yamlState, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	st := newState()
+	err = yaml.Unmarshal(yamlState, st)
+	if err != nil {
+		return err
+	}
+
+	version, err := strconv.Atoi(st.Version)
+	if err != nil {
+		version = 1
+	}
+
+	if version != 1 {
+		return ErrUnsupportedStateVersion
+	}
+
+	if st.Identities == nil {
+		st.Identities = map[string]Identity{}
+	}
+
+	ids := st.IDs
+	st.IDs = map[string]map[string]string{}
+	for id, memIds := range ids {
+		for member, memId := range memIds {
+			st.mapId(id, member, memId)
+		}
+	}
+
+	m.state = st
+	return nil
+}
+
+// saveState saves the state, if a store is configured. The lock must be held.
+func (m *Mirror) saveState() error {
+	if m.store == nil {
+		return nil
+	}
+
+	w, err := m.store.Saver()
+	if err != nil {
+		return fmt.Errorf("unable to save mirror state: %w", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	yamlState, err := yaml.Marshal(m.state)
+	if err != nil {
+		return fmt.Errorf("unable to save mirror state: %w", err)
+	}
+
+	_, err = w.Write(yamlState)
+	if err != nil {
+		return fmt.Errorf("unable to save mirror state: %w", err)
+	}
+
+	return nil
+}
+
+// PendingRepairs returns a copy of the writes missed by members.
+func (m *Mirror) PendingRepairs() []Repair {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	reps := make([]Repair, len(m.state.Repairs))
+	for i, r := range m.state.Repairs {
+		reps[i] = *r
+	}
+	return reps
+}
+
+// member returns the index of the named member or -1.
+func (m *Mirror) member(name string) int {
+	for i, mem := range m.members {
+		if mem.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Repair copies each write missed by a member to that member. The current copy
+// of each secret is taken from the first member that has no repair pending
+// for it. It returns the number of repairs made. Repairs that fail are kept
+// for the next attempt and their errors are returned together.
+func (m *Mirror) Repair(ctx context.Context) (int, error) {
+	repaired := 0
+	var errs []error
+	for _, rep := range m.PendingRepairs() {
+		if ctx.Err() != nil {
+			errs = append(errs, ctx.Err())
+			break
+		}
+
+		err := m.repair(ctx, rep)
+
+		m.lock.Lock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to %s secret %q in mirror member %q: %w", rep.Op, rep.ID, rep.Member, err))
+			m.state.addRepair(rep.Op, rep.ID, rep.Member, rep.MemberID, err)
+		} else {
+			repaired++
+			m.removeRepair(rep)
+		}
+		m.lock.Unlock()
+	}
+
+	m.lock.Lock()
+	if err := m.saveState(); err != nil {
+		errs = append(errs, err)
+	}
+	m.lock.Unlock()
+
+	return repaired, errors.Join(errs...)
+}
+
+// removeRepair removes the repair from the log. The lock must be held.
+func (m *Mirror) removeRepair(rep Repair) {
+	for i, r := range m.state.Repairs {
+		if r.Op == rep.Op && r.ID == rep.ID && r.Member == rep.Member && r.MemberID == rep.MemberID {
+			m.state.Repairs = append(m.state.Repairs[:i], m.state.Repairs[i+1:]...)
+			return
+		}
+	}
+}
+
+// repair makes a single repair.
+func (m *Mirror) repair(ctx context.Context, rep Repair) error {
+	target := m.member(rep.Member)
+	if target < 0 {
+		// the member is no longer part of the mirror
+		return nil
+	}
+
+	kpr := m.members[target].Keeper
+
+	if rep.Op == opDelete {
+		err := kpr.DeleteSecret(ctx, rep.MemberID)
+		if errors.Is(err, secrets.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	sec, err := m.currentCopy(ctx, rep.ID, target)
+	if errors.Is(err, secrets.ErrNotFound) {
+		// deleted since, so there is nothing left to copy
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	memId, err := m.memberId(ctx, target, rep.ID)
+	if err != nil && !errors.Is(err, secrets.ErrNotFound) {
+		return err
+	}
+
+	newSec, err := kpr.SetSecret(ctx,
+		secrets.NewSingleFromSecret(sec, secrets.WithID(memId)))
+	if err != nil {
+		return err
+	}
+
+	m.lock.Lock()
+	m.state.mapId(rep.ID, rep.Member, newSec.ID())
+	m.lock.Unlock()
+
+	return nil
+}
+
+// currentCopy returns the secret from the first member other than the target
+// that has no repair pending for it.
+func (m *Mirror) currentCopy(ctx context.Context, id string, target int) (secrets.Secret, error) {
+	var errs []error
+	for i, mem := range m.members {
+		if i == target {
+			continue
+		}
+
+		m.lock.Lock()
+		pending := m.state.pendingRepair(id, mem.Name)
+		m.lock.Unlock()
+
+		if pending {
+			continue
+		}
+
+		memId, err := m.memberId(ctx, i, id)
+		if err == nil && memId == "" {
+			err = secrets.ErrNotFound
+		}
+
+		var sec secrets.Secret
+		if err == nil {
+			sec, err = mem.Keeper.GetSecret(ctx, memId)
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return sec, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("no member has a current copy of the secret")
+	}
+
+	for _, err := range errs {
+		if !errors.Is(err, secrets.ErrNotFound) {
+			return nil, errors.Join(errs...)
+		}
+	}
+
+	return nil, secrets.ErrNotFound
+}
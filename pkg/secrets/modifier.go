@@ -2,6 +2,7 @@ package secrets
 
 import (
 	"net/url"
+	"sort"
 	"time"
 
 	"github.com/zostay/go-std/maps"
@@ -20,6 +21,9 @@ type modifier struct {
 	lastModified *time.Time
 	url          *url.URL
 	location     *string
+
+	attachments       map[string][]byte
+	removeAttachments set.Set[string]
 }
 
 func (m *modifier) ID() string {
@@ -166,6 +170,61 @@ func (m *modifier) Location() string {
 	return m.base.Location()
 }
 
+func (m *modifier) Attachments() []string {
+	names := set.New(Attachments(m.base)...)
+	for name := range m.attachments {
+		names.Insert(name)
+	}
+	if m.removeAttachments != nil {
+		for _, name := range m.removeAttachments.Keys() {
+			names.Delete(name)
+		}
+	}
+
+	list := names.Keys()
+	sort.Strings(list)
+	return list
+}
+
+func (m *modifier) GetAttachment(name string) ([]byte, bool) {
+	if data, ok := m.attachments[name]; ok {
+		return data, true
+	}
+	if m.removeAttachments != nil && m.removeAttachments.Contains(name) {
+		return nil, false
+	}
+	return GetAttachment(m.base, name)
+}
+
+func (m *modifier) SetAttachment(name string, data []byte) {
+	if wa, isWa := m.base.(WithAttachments); isWa {
+		wa.SetAttachment(name, data)
+		return
+	}
+
+	if m.attachments == nil {
+		m.attachments = map[string][]byte{}
+	}
+	m.attachments[name] = data
+
+	if m.removeAttachments != nil {
+		m.removeAttachments.Delete(name)
+	}
+}
+
+func (m *modifier) DeleteAttachment(name string) {
+	if wa, isWa := m.base.(WithAttachments); isWa {
+		wa.DeleteAttachment(name)
+		return
+	}
+
+	delete(m.attachments, name)
+	if m.removeAttachments == nil {
+		m.removeAttachments = set.New[string]()
+	}
+	m.removeAttachments.Insert(name)
+}
+
 func SetName(secret Secret, name string) Secret {
 	if mod, isMod := secret.(SettableName); isMod {
 		mod.SetName(name)
@@ -214,3 +273,11 @@ func SetUrl(secret Secret, url *url.URL) Secret {
 	}
 	return &modifier{base: secret, url: url}
 }
+
+func SetAttachment(secret Secret, name string, data []byte) Secret {
+	if mod, isMod := secret.(WithAttachments); isMod {
+		mod.SetAttachment(name, data)
+		return secret
+	}
+	return &modifier{base: secret, attachments: map[string][]byte{name: data}}
+}
@@ -0,0 +1,52 @@
+package secrets
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// MatchFunc returns true if the string matches a compiled pattern.
+type MatchFunc func(string) bool
+
+// IsRegexpPattern returns true if the pattern is a regular expression, which is
+// written surrounded by slashes, like /^Work\//.
+func IsRegexpPattern(pattern string) bool {
+	return len(pattern) >= 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/")
+}
+
+// IsPattern returns true if the pattern is a regular expression or a glob that
+// uses any glob syntax. Anything else only matches itself.
+func IsPattern(pattern string) bool {
+	return IsRegexpPattern(pattern) || strings.ContainsAny(pattern, `*?[]{}\`)
+}
+
+// CompilePattern compiles a pattern used to match secrets. A pattern
+// surrounded by slashes, like /^Work\//, is a regular expression. Anything else
+// is a glob. The separators, if given, are the characters that a * in a glob
+// will not match, which ** will.
+func CompilePattern(pattern string, separators ...rune) (MatchFunc, error) {
+	if IsRegexpPattern(pattern) {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	gl, err := glob.Compile(pattern, separators...)
+	if err != nil {
+		return nil, err
+	}
+	return gl.Match, nil
+}
+
+// MustCompilePattern is like CompilePattern, but panics on error.
+func MustCompilePattern(pattern string, separators ...rune) MatchFunc {
+	m, err := CompilePattern(pattern, separators...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
@@ -1,13 +1,6 @@
 package policy
 
-import (
-	"regexp"
-	"strings"
-
-	"github.com/gobwas/glob"
-
-	"github.com/zostay/ghost/pkg/secrets"
-)
+import "github.com/zostay/ghost/pkg/secrets"
 
 type matchStatus int
 
@@ -17,14 +10,12 @@ const (
 	matchNo
 )
 
-type matchFunc func(string) bool
-
 type Match struct {
 	m MatchConfig
 }
 
 var (
-	matcherCache = map[string]matchFunc{}
+	matcherCache = map[string]secrets.MatchFunc{}
 )
 
 func matchToStatus(m bool) matchStatus {
@@ -43,15 +34,9 @@ func matchString(match, against string) matchStatus {
 		return matchToStatus(matcher(against))
 	}
 
-	if strings.HasPrefix(match, "/") && strings.HasSuffix(match, "/") {
-		re := regexp.MustCompile(match[1 : len(match)-1])
-		matcherCache[match] = re.MatchString
-		return matchToStatus(re.MatchString(against))
-	}
-
-	gl := glob.MustCompile(match)
-	matcherCache[match] = gl.Match
-	return matchToStatus(gl.Match(against))
+	matcher := secrets.MustCompilePattern(match)
+	matcherCache[match] = matcher
+	return matchToStatus(matcher(against))
 }
 
 func (m Match) matchLocation(loc string) matchStatus {
@@ -17,7 +17,10 @@ type Policy struct {
 	matchRule   []*MatchRule
 }
 
-var _ secrets.Keeper = &Policy{}
+var (
+	_ secrets.Keeper  = &Policy{}
+	_ secrets.Wrapper = &Policy{}
+)
 
 // New creates a new policy secret keeper.
 func New(kpr secrets.Keeper) *Policy {
@@ -31,6 +34,11 @@ func New(kpr secrets.Keeper) *Policy {
 	}
 }
 
+// Unwrap returns the nested keeper.
+func (p *Policy) Unwrap() []secrets.Keeper {
+	return []secrets.Keeper{p.Keeper}
+}
+
 // AddRule adds a rule to the policy.
 func (p *Policy) AddRule(r *MatchRule) {
 	p.matchRule = append(p.matchRule, r)
@@ -0,0 +1,298 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Op is the comparison made by a query condition.
+type Op string
+
+const (
+	// OpEqual matches when the value is exactly equal.
+	OpEqual Op = "="
+
+	// OpNotEqual matches when the value is not exactly equal.
+	OpNotEqual Op = "!="
+
+	// OpMatch matches when the value matches a glob or, if surrounded by
+	// slashes, a regular expression.
+	OpMatch Op = "~"
+
+	// OpNotMatch matches when the value does not match the pattern.
+	OpNotMatch Op = "!~"
+
+	// OpBefore matches when the time is before the given time.
+	OpBefore Op = "<"
+
+	// OpAfter matches when the time is after the given time.
+	OpAfter Op = ">"
+)
+
+// ops lists the operators with the longer operators first, as they are
+// searched for in that order.
+var ops = []Op{OpNotEqual, OpNotMatch, OpEqual, OpMatch, OpBefore, OpAfter}
+
+// timeFormats are the formats accepted for times in query conditions.
+var timeFormats = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// Condition is a single test made against each secret by a Query.
+//
+// The field names the value to test. The standard fields are id, name,
+// username, type, location, url, and modified. The parts of the URL may be
+// tested with url.scheme, url.host, and url.path. Any other name is the name
+// of a field of the secret, which may also be written with a field. prefix.
+// The password may not be searched.
+type Condition struct {
+	Field string
+	Op    Op
+	Value string
+
+	match MatchFunc
+	time  time.Time
+}
+
+// Query is a filter for finding secrets. A secret matches the query if it
+// matches every condition. A query with no conditions matches every secret.
+type Query struct {
+	Conditions []*Condition
+}
+
+// Searchable is implemented by a Keeper that can find the secrets matching a
+// query itself, which may be much faster than the Search function testing
+// every secret.
+type Searchable interface {
+	// Search returns the secrets matching the query.
+	Search(ctx context.Context, q *Query) ([]Secret, error)
+}
+
+// NewCondition returns a new query condition after checking the field,
+// operator, and value are usable together.
+func NewCondition(field string, op Op, value string) (*Condition, error) {
+	c := &Condition{
+		Field: strings.ToLower(field),
+		Op:    op,
+		Value: value,
+	}
+
+	switch c.Field {
+	case "":
+		return nil, fmt.Errorf("query condition %q has no field", c)
+	case "password":
+		return nil, fmt.Errorf("query condition %q: the password cannot be searched", c)
+	case "last-modified", "last_modified":
+		c.Field = "modified"
+	}
+
+	switch op {
+	case OpEqual, OpNotEqual:
+	case OpMatch, OpNotMatch:
+		m, err := CompilePattern(value)
+		if err != nil {
+			return nil, fmt.Errorf("query condition %q: %w", c, err)
+		}
+		c.match = m
+	case OpBefore, OpAfter:
+		if c.Field != "modified" {
+			return nil, fmt.Errorf("query condition %q: only modified may be compared with %s", c, op)
+		}
+
+		t, err := parseTime(value)
+		if err != nil {
+			return nil, fmt.Errorf("query condition %q: %w", c, err)
+		}
+		c.time = t
+	default:
+		return nil, fmt.Errorf("query condition %q has unknown operator", c)
+	}
+
+	if c.Field == "modified" && op != OpBefore && op != OpAfter {
+		return nil, fmt.Errorf("query condition %q: modified may only be compared with %s or %s", c, OpBefore, OpAfter)
+	}
+
+	return c, nil
+}
+
+// parseTime parses a time given in a query condition.
+func parseTime(value string) (time.Time, error) {
+	for _, f := range timeFormats {
+		if t, err := time.ParseInLocation(f, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time %q", value)
+}
+
+// ParseCondition parses a condition written as field, operator, and value,
+// such as type=ssh-key, url.host~*.github.com, or modified<2025-01-01.
+func ParseCondition(term string) (*Condition, error) {
+	ix := strings.IndexAny(term, "=!~<>")
+	if ix < 0 {
+		return nil, fmt.Errorf("query condition %q has no operator", term)
+	}
+
+	for _, op := range ops {
+		if strings.HasPrefix(term[ix:], string(op)) {
+			return NewCondition(term[:ix], op, term[ix+len(op):])
+		}
+	}
+
+	return nil, fmt.Errorf("query condition %q has unknown operator", term)
+}
+
+// ParseQuery parses each term as a condition and returns a query requiring all
+// of them.
+func ParseQuery(terms ...string) (*Query, error) {
+	q := &Query{Conditions: make([]*Condition, 0, len(terms))}
+	for _, term := range terms {
+		c, err := ParseCondition(term)
+		if err != nil {
+			return nil, err
+		}
+		q.Conditions = append(q.Conditions, c)
+	}
+	return q, nil
+}
+
+// String returns the condition as it would be parsed.
+func (c *Condition) String() string {
+	return c.Field + string(c.Op) + c.Value
+}
+
+// String returns the conditions of the query separated by spaces.
+func (q *Query) String() string {
+	terms := make([]string, len(q.Conditions))
+	for i, c := range q.Conditions {
+		terms[i] = c.String()
+	}
+	return strings.Join(terms, " ")
+}
+
+// value returns the value of the secret the condition tests.
+func (c *Condition) value(sec Secret) string {
+	switch c.Field {
+	case "id":
+		return sec.ID()
+	case "name":
+		return sec.Name()
+	case "username":
+		return sec.Username()
+	case "type":
+		return sec.Type()
+	case "location":
+		return sec.Location()
+	case "url":
+		return UrlString(sec)
+	case "url.scheme", "url.host", "url.path":
+		u := sec.Url()
+		if u == nil {
+			return ""
+		}
+
+		switch c.Field {
+		case "url.scheme":
+			return u.Scheme
+		case "url.host":
+			return u.Hostname()
+		default:
+			return u.Path
+		}
+	default:
+		return sec.GetField(strings.TrimPrefix(c.Field, "field."))
+	}
+}
+
+// Match returns true if the secret passes the condition.
+func (c *Condition) Match(sec Secret) bool {
+	switch c.Op {
+	case OpEqual:
+		return c.value(sec) == c.Value
+	case OpNotEqual:
+		return c.value(sec) != c.Value
+	case OpMatch:
+		return c.match(c.value(sec))
+	case OpNotMatch:
+		return !c.match(c.value(sec))
+	case OpBefore:
+		return sec.LastModified().Before(c.time)
+	case OpAfter:
+		return sec.LastModified().After(c.time)
+	default:
+		return false
+	}
+}
+
+// Match returns true if the secret passes every condition of the query.
+func (q *Query) Match(sec Secret) bool {
+	for _, c := range q.Conditions {
+		if !c.Match(sec) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalTo returns the value the field must be equal to, if the query has such
+// a condition.
+func (q *Query) equalTo(field string) (string, bool) {
+	for _, c := range q.Conditions {
+		if c.Field == field && c.Op == OpEqual {
+			return c.Value, true
+		}
+	}
+	return "", false
+}
+
+// Search returns the secrets in the keeper that match the query. If the keeper
+// implements Searchable, the search is left to the keeper. Otherwise, the
+// secrets with the name the query requires, or in the location it requires,
+// or else every secret in the keeper, are tested against the query.
+func Search(ctx context.Context, kpr Keeper, q *Query) ([]Secret, error) {
+	if s, isSearchable := kpr.(Searchable); isSearchable {
+		return s.Search(ctx, q)
+	}
+
+	var found []Secret
+	if name, hasName := q.equalTo("name"); hasName {
+		secs, err := kpr.GetSecretsByName(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sec := range secs {
+			if q.Match(sec) {
+				found = append(found, sec)
+			}
+		}
+
+		return found, nil
+	}
+
+	add := func(sec Secret) error {
+		if q.Match(sec) {
+			found = append(found, sec)
+		}
+		return nil
+	}
+
+	var err error
+	if loc, hasLoc := q.equalTo("location"); hasLoc {
+		err = ForEachInLocation(ctx, kpr, loc, add)
+	} else {
+		err = ForEach(ctx, kpr, add)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
@@ -0,0 +1,160 @@
+package redact
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/plugin"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// ConfigType is the type name for the redact keeper.
+const ConfigType = "redact"
+
+// Config is the configuration for the redact keeper.
+type Config struct {
+	// Keeper is the name of the keeper to redact.
+	Keeper string `mapstructure:"keeper" yaml:"keeper"`
+
+	// RemoveFields lists the fields to remove from every secret.
+	RemoveFields []string `mapstructure:"remove_fields" yaml:"remove_fields"`
+
+	// MaskFields lists the fields to mask in every secret.
+	MaskFields []string `mapstructure:"mask_fields" yaml:"mask_fields"`
+
+	// HashValues replaces the password and masked fields with a stable keyed
+	// hash rather than blanking them.
+	HashValues bool `mapstructure:"hash_values" yaml:"hash_values"`
+
+	// HashKey is the key to use when hashing values. This may be a secret
+	// reference.
+	HashKey string `mapstructure:"hash_key" yaml:"hash_key"`
+}
+
+// Builder creates a new redact keeper from the given configuration.
+func Builder(ctx context.Context, c any) (secrets.Keeper, error) {
+	cfg, isRedact := c.(*Config)
+	if !isRedact {
+		return nil, plugin.ErrConfig
+	}
+
+	kpr, err := keeper.Build(ctx, cfg.Keeper)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load keeper to redact %q: %w", cfg.Keeper, err)
+	}
+
+	opts := []Option{
+		WithRemovedFields(cfg.RemoveFields...),
+		WithMaskedFields(cfg.MaskFields...),
+	}
+
+	if cfg.HashValues {
+		opts = append(opts, WithHashedValues([]byte(cfg.HashKey)))
+	}
+
+	return New(kpr, opts...), nil
+}
+
+// Validate checks that the configuration is correct for the redact keeper. It
+// will check that the wrapped keeper exists and that no field is both removed
+// and masked.
+func Validate(ctx context.Context, c any) error {
+	cfg, isRedact := c.(*Config)
+	if !isRedact {
+		return plugin.ErrConfig
+	}
+
+	errs := plugin.NewValidationError()
+
+	if !keeper.Exists(ctx, cfg.Keeper) {
+		errs.Append(fmt.Errorf("redact keeper %q does not exist", cfg.Keeper))
+	}
+
+	for _, rf := range cfg.RemoveFields {
+		for _, mf := range cfg.MaskFields {
+			if rf == mf {
+				errs.Append(fmt.Errorf("redact field %q cannot be both removed and masked", rf))
+			}
+		}
+	}
+
+	if cfg.HashKey != "" && !cfg.HashValues {
+		errs.Append(fmt.Errorf("redact hash key is set, but hash values is not enabled"))
+	}
+
+	return errs.Return()
+}
+
+// Print is the config printer for the redact keeper.
+func Print(c any, w io.Writer) error {
+	cfg, isRedact := c.(*Config)
+	if !isRedact {
+		return plugin.ErrConfig
+	}
+
+	fmt.Fprintln(w, "redact keeper:", cfg.Keeper)
+	fmt.Fprintln(w, "remove fields:", strings.Join(cfg.RemoveFields, ", "))
+	fmt.Fprintln(w, "mask fields:", strings.Join(cfg.MaskFields, ", "))
+	fmt.Fprintln(w, "hash values:", cfg.HashValues)
+	if cfg.HashValues {
+		keyVal := "<not set>"
+		if cfg.HashKey != "" {
+			keyVal = "<hidden>"
+		}
+		fmt.Fprintln(w, "hash key:", keyVal)
+	}
+	return nil
+}
+
+func init() {
+	var (
+		keeperName   string
+		removeFields []string
+		maskFields   []string
+		hashValues   bool
+	)
+
+	cmd := plugin.CmdConfig{
+		Short: "Configure a redacting keeper that hides the secret values of another keeper",
+		Fields: map[string]string{
+			"hash-key": "The key to use when hashing values",
+		},
+		Run: func(_ string, fields map[string]any) (config.KeeperConfig, error) {
+			kc := config.KeeperConfig{
+				"type":          ConfigType,
+				"keeper":        keeperName,
+				"remove_fields": removeFields,
+				"mask_fields":   maskFields,
+				"hash_values":   hashValues,
+			}
+
+			if hashKey, ok := fields["hash-key"]; ok {
+				kc["hash_key"] = hashKey
+			}
+
+			return kc, nil
+		},
+		FlagInit: func(flags *pflag.FlagSet) error {
+			flags.StringVar(&keeperName, "keeper", "", "the name of the keeper to redact")
+			flags.StringSliceVar(&removeFields, "remove-field", []string{}, "a field to remove from every secret")
+			flags.StringSliceVar(&maskFields, "mask-field", []string{}, "a field to mask in every secret")
+			flags.BoolVar(&hashValues, "hash-values", false, "replace redacted values with a stable hash")
+
+			if err := cobra.MarkFlagRequired(flags, "keeper"); err != nil {
+				return err
+			}
+
+			return nil
+		},
+	}
+
+	plugin.Register(ConfigType, reflect.TypeOf(Config{}), Builder, Validate, Print, cmd)
+}
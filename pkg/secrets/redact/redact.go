@@ -0,0 +1,197 @@
+package redact
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/zostay/go-std/set"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// Masked is the value used in place of a masked field when hashing is not
+// enabled.
+const Masked = "<redacted>"
+
+// HashPrefix is prepended to every hashed value so that it is obvious that the
+// value is a hash and not the original value.
+const HashPrefix = "hmac-sha256:"
+
+// ErrReadOnly is returned by every write operation on the redacting keeper.
+var ErrReadOnly = errors.New("redacting secret keeper does not allow writes")
+
+// Redact is a secret keeper that wraps another secret keeper and provides a
+// view of it with the sensitive values removed. The password of every secret
+// returned is blanked. Fields may be configured to be removed entirely or
+// masked. If hashing is enabled, the password and masked fields are replaced
+// with a keyed hash of the original value, which allows duplicate values to be
+// detected without revealing them.
+//
+// The view is read-only. All write operations fail with ErrReadOnly.
+type Redact struct {
+	secrets.Keeper
+
+	removeFields set.Set[string]
+	maskFields   set.Set[string]
+
+	hash    bool
+	hashKey []byte
+}
+
+var (
+	_ secrets.Keeper  = &Redact{}
+	_ secrets.Wrapper = &Redact{}
+)
+
+// Option is used to customize the redacting keeper during construction.
+type Option func(*Redact)
+
+// WithRemovedFields names fields that will be removed from every secret
+// returned.
+func WithRemovedFields(names ...string) Option {
+	return func(r *Redact) {
+		for _, name := range names {
+			r.removeFields.Insert(name)
+		}
+	}
+}
+
+// WithMaskedFields names fields that will have their value replaced with
+// Masked (or a hash, if WithHashedValues is set) in every secret returned.
+func WithMaskedFields(names ...string) Option {
+	return func(r *Redact) {
+		for _, name := range names {
+			r.maskFields.Insert(name)
+		}
+	}
+}
+
+// WithHashedValues causes the password and masked fields to be replaced with
+// an HMAC-SHA256 of the original value rather than being blanked. The given key
+// is used for the HMAC. The same key must be used to compare hashes between
+// runs.
+func WithHashedValues(key []byte) Option {
+	return func(r *Redact) {
+		r.hash = true
+		r.hashKey = key
+	}
+}
+
+// New creates a new redacting secret keeper that wraps the given keeper.
+func New(kpr secrets.Keeper, opts ...Option) *Redact {
+	r := &Redact{
+		Keeper:       kpr,
+		removeFields: set.New[string](),
+		maskFields:   set.New[string](),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Unwrap returns the wrapped keeper.
+func (r *Redact) Unwrap() []secrets.Keeper {
+	return []secrets.Keeper{r.Keeper}
+}
+
+// hashValue returns the keyed hash of the value.
+func (r *Redact) hashValue(value string) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	_, _ = mac.Write([]byte(value))
+	return HashPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// redactValue returns the value to use in place of a sensitive value. Empty
+// values remain empty.
+func (r *Redact) redactValue(value, masked string) string {
+	switch {
+	case value == "":
+		return ""
+	case r.hash:
+		return r.hashValue(value)
+	default:
+		return masked
+	}
+}
+
+// redactSecret returns a copy of the secret with sensitive values removed. The
+// copy is built from scratch so that the fields of the original are never
+// shared with the redacted copy.
+func (r *Redact) redactSecret(sec secrets.Secret) secrets.Secret {
+	opts := []secrets.SingleOption{
+		secrets.WithID(sec.ID()),
+		secrets.WithType(sec.Type()),
+		secrets.WithLastModified(sec.LastModified()),
+		secrets.WithUrl(sec.Url()),
+		secrets.WithLocation(sec.Location()),
+	}
+
+	for k, v := range sec.Fields() {
+		switch {
+		case r.removeFields.Contains(k):
+			continue
+		case r.maskFields.Contains(k):
+			opts = append(opts, secrets.WithField(k, r.redactValue(v, Masked)))
+		default:
+			opts = append(opts, secrets.WithField(k, v))
+		}
+	}
+
+	return secrets.NewSecret(
+		sec.Name(),
+		sec.Username(),
+		r.redactValue(sec.Password(), ""),
+		opts...)
+}
+
+// GetSecret returns the redacted secret from the wrapped keeper.
+func (r *Redact) GetSecret(ctx context.Context, id string) (secrets.Secret, error) {
+	sec, err := r.Keeper.GetSecret(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.redactSecret(sec), nil
+}
+
+// GetSecretsByName returns the redacted secrets with the given name from the
+// wrapped keeper.
+func (r *Redact) GetSecretsByName(ctx context.Context, name string) ([]secrets.Secret, error) {
+	secs, err := r.Keeper.GetSecretsByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	redSecs := make([]secrets.Secret, len(secs))
+	for i, sec := range secs {
+		redSecs[i] = r.redactSecret(sec)
+	}
+
+	return redSecs, nil
+}
+
+// SetSecret cannot be used and always fails with ErrReadOnly.
+func (r *Redact) SetSecret(context.Context, secrets.Secret) (secrets.Secret, error) {
+	return nil, ErrReadOnly
+}
+
+// CopySecret cannot be used and always fails with ErrReadOnly.
+func (r *Redact) CopySecret(context.Context, string, string) (secrets.Secret, error) {
+	return nil, ErrReadOnly
+}
+
+// MoveSecret cannot be used and always fails with ErrReadOnly.
+func (r *Redact) MoveSecret(context.Context, string, string) (secrets.Secret, error) {
+	return nil, ErrReadOnly
+}
+
+// DeleteSecret cannot be used and always fails with ErrReadOnly.
+func (r *Redact) DeleteSecret(context.Context, string) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,129 @@
+package redact_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/keepertest"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+	"github.com/zostay/ghost/pkg/secrets/redact"
+)
+
+func TestRedact(t *testing.T) { //nolint:tparallel // it is parallel, you dolt
+	t.Parallel()
+
+	factory := func() (secrets.Keeper, error) {
+		m, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+		return redact.New(m), nil
+	}
+
+	ts := keepertest.New(factory)
+	t.Run("SecretKeeperGetMissingTest", ts.SecretKeeperGetMissingTest)
+}
+
+func TestRedact_GetSecret(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	r := redact.New(m,
+		redact.WithRemovedFields("recovery"),
+		redact.WithMaskedFields("pin"))
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("test", "user", "hunter2",
+		secrets.WithField("recovery", "abc123"),
+		secrets.WithField("pin", "1234"),
+		secrets.WithField("note", "plain")))
+	require.NoError(t, err)
+
+	s2, err := r.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	require.NotNil(t, s2)
+
+	assert.Equal(t, s1.ID(), s2.ID())
+	assert.Equal(t, "test", s2.Name())
+	assert.Equal(t, "user", s2.Username())
+	assert.Empty(t, s2.Password())
+	assert.Equal(t, map[string]string{
+		"pin":  redact.Masked,
+		"note": "plain",
+	}, s2.Fields())
+
+	orig, err := m.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", orig.Password())
+	assert.Equal(t, "abc123", orig.GetField("recovery"))
+}
+
+func TestRedact_HashedValues(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	r := redact.New(m,
+		redact.WithMaskedFields("pin"),
+		redact.WithHashedValues([]byte("key")))
+
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("one", "user", "hunter2",
+		secrets.WithField("pin", "1234")))
+	require.NoError(t, err)
+	s2, err := m.SetSecret(ctx, secrets.NewSecret("two", "user", "hunter2"))
+	require.NoError(t, err)
+	s3, err := m.SetSecret(ctx, secrets.NewSecret("three", "user", "swordfish"))
+	require.NoError(t, err)
+
+	r1, err := r.GetSecret(ctx, s1.ID())
+	require.NoError(t, err)
+	r2, err := r.GetSecret(ctx, s2.ID())
+	require.NoError(t, err)
+	r3, err := r.GetSecret(ctx, s3.ID())
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(r1.Password(), redact.HashPrefix))
+	assert.NotContains(t, r1.Password(), "hunter2")
+	assert.Equal(t, r1.Password(), r2.Password())
+	assert.NotEqual(t, r1.Password(), r3.Password())
+	assert.True(t, strings.HasPrefix(r1.GetField("pin"), redact.HashPrefix))
+}
+
+func TestRedact_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	r := redact.New(m)
+	ctx := context.Background()
+
+	s1, err := m.SetSecret(ctx, secrets.NewSecret("test", "user", "hunter2"))
+	require.NoError(t, err)
+
+	_, err = r.SetSecret(ctx, secrets.NewSecret("new", "user", "pass"))
+	assert.ErrorIs(t, err, redact.ErrReadOnly)
+
+	_, err = r.CopySecret(ctx, s1.ID(), "elsewhere")
+	assert.ErrorIs(t, err, redact.ErrReadOnly)
+
+	_, err = r.MoveSecret(ctx, s1.ID(), "elsewhere")
+	assert.ErrorIs(t, err, redact.ErrReadOnly)
+
+	err = r.DeleteSecret(ctx, s1.ID())
+	assert.ErrorIs(t, err, redact.ErrReadOnly)
+
+	_, err = m.GetSecret(ctx, s1.ID())
+	assert.NoError(t, err)
+}
@@ -32,12 +32,48 @@ type Config struct {
 
 // RouteConfig is the configuration for a route in the router.
 type RouteConfig struct {
-	// Locations is the list of locations to route to the keeper.
-	Locations []string `mapstructure:"locations" yaml:"locations"`
+	// Locations is the list of locations to route to the keeper. These may be
+	// location patterns.
+	Locations []string `mapstructure:"locations" yaml:"locations,omitempty"`
+	// NamePrefixes is the list of secret name prefixes to route to the keeper.
+	NamePrefixes []string `mapstructure:"name_prefixes" yaml:"name_prefixes,omitempty"`
+	// Types is the list of secret types to route to the keeper.
+	Types []string `mapstructure:"types" yaml:"types,omitempty"`
+	// Mount is the location at which to mount the keeper. This may not be used
+	// with locations, name prefixes, or types.
+	Mount string `mapstructure:"mount" yaml:"mount,omitempty"`
 	// Keeper is the name of the keeper to use for the route.
 	Keeper string `mapstructure:"keeper" yaml:"keeper"`
 }
 
+// Route returns the Route described by this configuration.
+func (rc RouteConfig) Route() Route {
+	return Route{
+		Locations:    rc.Locations,
+		NamePrefixes: rc.NamePrefixes,
+		Types:        rc.Types,
+		Mount:        rc.Mount,
+	}
+}
+
+// describe returns a short description of the route for use in errors.
+func (rc RouteConfig) describe() string {
+	var parts []string
+	if rc.Mount != "" {
+		parts = append(parts, "mount "+rc.Mount)
+	}
+	if len(rc.Locations) > 0 {
+		parts = append(parts, "locations "+strings.Join(rc.Locations, ","))
+	}
+	if len(rc.NamePrefixes) > 0 {
+		parts = append(parts, "name prefixes "+strings.Join(rc.NamePrefixes, ","))
+	}
+	if len(rc.Types) > 0 {
+		parts = append(parts, "types "+strings.Join(rc.Types, ","))
+	}
+	return strings.Join(parts, "; ")
+}
+
 // Print prints the configuration for the router secret keeper.
 func Print(c any, w io.Writer) error {
 	cfg, isRouter := c.(*Config)
@@ -48,8 +84,19 @@ func Print(c any, w io.Writer) error {
 	fmt.Fprintln(w, "default route:", cfg.DefaultRoute)
 	fmt.Fprintln(w, "routers:")
 	for _, r := range cfg.Routes {
-		fmt.Fprintln(w, "- locations:", strings.Join(r.Locations, ","))
-		fmt.Fprintln(w, "  keeper:", r.Keeper)
+		fmt.Fprintln(w, "- keeper:", r.Keeper)
+		if r.Mount != "" {
+			fmt.Fprintln(w, "  mount:", r.Mount)
+		}
+		if len(r.Locations) > 0 {
+			fmt.Fprintln(w, "  locations:", strings.Join(r.Locations, ","))
+		}
+		if len(r.NamePrefixes) > 0 {
+			fmt.Fprintln(w, "  name prefixes:", strings.Join(r.NamePrefixes, ","))
+		}
+		if len(r.Types) > 0 {
+			fmt.Fprintln(w, "  types:", strings.Join(r.Types, ","))
+		}
 	}
 	return nil
 }
@@ -70,14 +117,12 @@ func Builder(ctx context.Context, c any) (secrets.Keeper, error) {
 	for _, rt := range cfg.Routes {
 		kpr, err := keeper.Build(ctx, rt.Keeper)
 		if err != nil {
-			locs := strings.Join(rt.Locations, ",")
-			return nil, fmt.Errorf("unable to build the secret keeper named %q for the route to %q: %w", rt.Keeper, locs, err)
+			return nil, fmt.Errorf("unable to build the secret keeper named %q for the route to %q: %w", rt.Keeper, rt.describe(), err)
 		}
 
-		err = r.AddKeeper(kpr, rt.Locations...)
+		err = r.AddRoute(kpr, rt.Route())
 		if err != nil {
-			locs := strings.Join(rt.Locations, ",")
-			return nil, fmt.Errorf("unable to add a route for the secret keeper named %q for the route to %q: %w", rt.Keeper, locs, err)
+			return nil, fmt.Errorf("unable to add a route for the secret keeper named %q for the route to %q: %w", rt.Keeper, rt.describe(), err)
 		}
 	}
 	return r, nil
@@ -103,11 +148,16 @@ func Validator(ctx context.Context, c any) error {
 			errs.Append(fmt.Errorf("route keeper %q does not exist", r.Keeper))
 		}
 
-		if len(r.Locations) == 0 {
-			errs.Append(fmt.Errorf("route keeper %q has no locations", r.Keeper))
+		if r.Mount == "" && len(r.Locations) == 0 && len(r.NamePrefixes) == 0 && len(r.Types) == 0 {
+			errs.Append(fmt.Errorf("route keeper %q has no mount, locations, name prefixes, or types", r.Keeper))
 		}
 	}
 
+	routes := slices.Map(cfg.Routes, func(rc RouteConfig) Route { return rc.Route() })
+	if err := CheckRoutes(routes...); err != nil {
+		errs.Append(fmt.Errorf("routes overlap: %w", err))
+	}
+
 	return errs.Return()
 }
 
@@ -115,6 +165,9 @@ func init() {
 	var (
 		removeLocations []string
 		addLocations    []string
+		addNamePrefixes []string
+		addTypes        []string
+		addMount        string
 		addKeeper       string
 		defaultKeeper   string
 	)
@@ -123,13 +176,30 @@ func init() {
 		Short: "Configure a router secret keeper",
 		FlagInit: func(flags *pflag.FlagSet) error {
 			flags.StringSliceVar(&removeLocations, "remove", []string{}, "Remove one or more locations from the router")
-			flags.StringSliceVar(&addLocations, "add", []string{}, "Add one or more locations to the router")
+			flags.StringSliceVar(&addLocations, "add", []string{}, "Add one or more locations or location patterns to the router")
+			flags.StringSliceVar(&addNamePrefixes, "add-name-prefix", []string{}, "Add one or more secret name prefixes to the router")
+			flags.StringSliceVar(&addTypes, "add-type", []string{}, "Add one or more secret types to the router")
+			flags.StringVar(&addMount, "mount", "", "Mount the keeper at the given location")
 			flags.StringVar(&addKeeper, "keeper", "", "Keeper to use with to the added locations")
 			flags.StringVar(&defaultKeeper, "default", "", "Default keeper to use with the router")
 			return nil
 		},
 		Run: func(keeperName string, fields map[string]any) (config.KeeperConfig, error) {
-			if len(removeLocations) > 0 && len(addLocations) > 0 {
+			adding := len(addLocations) > 0 || len(addNamePrefixes) > 0 || len(addTypes) > 0
+
+			if adding && addMount != "" {
+				return nil, errors.New("cannot mount a keeper and add locations, name prefixes, or types in the same step")
+			}
+
+			if len(removeLocations) > 0 && addMount != "" {
+				return nil, errors.New("cannot remove locations and mount a keeper in the same step")
+			}
+
+			if addMount != "" && addKeeper == "" {
+				return nil, errors.New("must specify a keeper to mount")
+			}
+
+			if len(removeLocations) > 0 && adding {
 				return nil, errors.New("cannot remove and add locations in the same step")
 			}
 
@@ -141,8 +211,8 @@ func init() {
 				return nil, errors.New("cannot specify keeper while removing locaitons")
 			}
 
-			if len(addLocations) > 0 && addKeeper == "" {
-				return nil, errors.New("must specify a keeper to use with the added locations")
+			if adding && addKeeper == "" {
+				return nil, errors.New("must specify a keeper to use with the added locations, name prefixes, or types")
 			}
 
 			c := config.Instance()
@@ -163,7 +233,15 @@ func init() {
 				return kc, nil
 			}
 
-			AddRoute(kc, addKeeper, addLocations...)
+			if addMount != "" {
+				SetMount(kc, addKeeper, addMount)
+			}
+
+			if adding {
+				AddRoute(kc, addKeeper, addLocations...)
+				AddNamePrefixes(kc, addKeeper, addNamePrefixes...)
+				AddTypes(kc, addKeeper, addTypes...)
+			}
 
 			return kc, nil
 		},
@@ -173,11 +251,85 @@ func init() {
 
 // AddRoute adds a route to the router configuration.
 func AddRoute(rc config.KeeperConfig, keeper string, locations ...string) {
-	routes := rc["routes"].([]map[string]any)
-	if routes == nil {
-		routes = make([]map[string]any, 0, 1)
+	addToRoute(rc, keeper, "locations", locations)
+}
+
+// AddNamePrefixes adds secret name prefixes to the route for the keeper in the
+// router configuration, adding the route if needed.
+func AddNamePrefixes(rc config.KeeperConfig, keeper string, prefixes ...string) {
+	addToRoute(rc, keeper, "name_prefixes", prefixes)
+}
+
+// AddTypes adds secret types to the route for the keeper in the router
+// configuration, adding the route if needed.
+func AddTypes(rc config.KeeperConfig, keeper string, types ...string) {
+	addToRoute(rc, keeper, "types", types)
+}
+
+// SetMount sets the mount of the route for the keeper in the router
+// configuration, adding the route if needed.
+func SetMount(rc config.KeeperConfig, keeper, mount string) {
+	routes := configRoutes(rc)
+	for _, r := range routes {
+		if r["keeper"] == keeper {
+			r["mount"] = mount
+			rc["routes"] = routes
+			return
+		}
 	}
 
+	rc["routes"] = append(routes, map[string]any{
+		"mount":  mount,
+		"keeper": keeper,
+	})
+}
+
+// configRoutes returns the routes of the router configuration, which may have
+// been loaded from the configuration file or set by this package. Routes loaded
+// from the file are a []any holding a config.KeeperConfig for each route. The
+// routes returned share their maps with the configuration.
+func configRoutes(rc config.KeeperConfig) []map[string]any {
+	switch rs := rc["routes"].(type) {
+	case []map[string]any:
+		return rs
+	case []any:
+		routes := make([]map[string]any, 0, len(rs))
+		for _, r := range rs {
+			switch route := r.(type) {
+			case map[string]any:
+				routes = append(routes, route)
+			case config.KeeperConfig:
+				routes = append(routes, route)
+			}
+		}
+		return routes
+	default:
+		return []map[string]any{}
+	}
+}
+
+// routeValues returns the list held in the given route field, which may have
+// been loaded from the configuration file or set by this package.
+func routeValues(v any) []any {
+	switch vs := v.(type) {
+	case []any:
+		return vs
+	case []string:
+		return slices.Map(vs, func(s string) any { return s })
+	default:
+		return []any{}
+	}
+}
+
+// addToRoute adds values to the list in the named field of the route for the
+// keeper, adding the route if needed.
+func addToRoute(rc config.KeeperConfig, keeper, field string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	routes := configRoutes(rc)
+
 	var foundRoute map[string]any
 	for _, r := range routes {
 		if r["keeper"] == keeper {
@@ -187,21 +339,24 @@ func AddRoute(rc config.KeeperConfig, keeper string, locations ...string) {
 	}
 
 	if foundRoute != nil {
-		for _, loc := range locations {
-			ix := slices.FirstIndex(foundRoute["locations"].([]any), func(v any) bool {
-				return v.(string) == loc
+		list := routeValues(foundRoute[field])
+		for _, v := range values {
+			ix := slices.FirstIndex(list, func(lv any) bool {
+				return lv.(string) == v
 			})
 			if ix < 0 {
-				foundRoute["locations"] = append(foundRoute["locations"].([]any), loc)
+				list = append(list, v)
 			}
 		}
+		foundRoute[field] = list
+		rc["routes"] = routes
 
 		return
 	}
 
 	routes = append(routes, map[string]any{
-		"locations": locations,
-		"keeper":    keeper,
+		field:    routeValues(values),
+		"keeper": keeper,
 	})
 
 	rc["routes"] = routes
@@ -210,12 +365,12 @@ func AddRoute(rc config.KeeperConfig, keeper string, locations ...string) {
 // RemoveLocationsAndRoutes removes the given locations from the router
 // configuration.
 func RemoveLocationsAndRoutes(rc config.KeeperConfig, removeLocations ...string) {
-	routes := rc["routes"].([]map[string]any)
+	routes := configRoutes(rc)
 	removeSet := set.New(removeLocations...)
 
 	var deleteRoutes []int
 	for i, r := range routes {
-		locations := r["locations"].([]any)
+		locations := routeValues(r["locations"])
 		for _, loc := range locations {
 			if removeSet.Contains(loc.(string)) {
 				for {
@@ -226,7 +381,7 @@ func RemoveLocationsAndRoutes(rc config.KeeperConfig, removeLocations ...string)
 						break
 					}
 					locations = slices.Delete(locations, ix)
-					if len(locations) == 0 {
+					if len(locations) == 0 && len(routeValues(r["name_prefixes"])) == 0 && len(routeValues(r["types"])) == 0 && r["mount"] == nil {
 						deleteRoutes = append(deleteRoutes, i)
 						break
 					}
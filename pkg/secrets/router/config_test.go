@@ -0,0 +1,134 @@
+package router_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/zostay/ghost/pkg/config"
+	"github.com/zostay/ghost/pkg/secrets/router"
+)
+
+const routerYAML = `master: r
+keepers:
+  r:
+    type: router
+    default: def
+    routes:
+      - keeper: b
+        locations: [Work, Team]
+        types: [password]
+      - keeper: m
+        mount: Mounted
+`
+
+// loadRouter loads the router configuration from a configuration file.
+func loadRouter(t *testing.T, path string) config.KeeperConfig {
+	t.Helper()
+
+	c := config.New()
+	require.NoError(t, c.Load(path))
+	require.NotNil(t, c.Keepers["r"])
+	return c.Keepers["r"]
+}
+
+// saveRouter saves the router configuration to the configuration file and
+// reads back the routes saved.
+func saveRouter(t *testing.T, path string, kc config.KeeperConfig) []router.RouteConfig {
+	t.Helper()
+
+	c := config.New()
+	c.MasterKeeper = "r"
+	c.Keepers["r"] = kc
+	require.NoError(t, c.Save(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	var saved struct {
+		Keepers map[string]router.Config `yaml:"keepers"`
+	}
+	require.NoError(t, yaml.Unmarshal(data, &saved))
+	return saved.Keepers["r"].Routes
+}
+
+func TestConfig_FromYAML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		update func(kc config.KeeperConfig)
+		routes []router.RouteConfig
+	}{
+		{
+			name: "add locations",
+			update: func(kc config.KeeperConfig) {
+				router.AddRoute(kc, "b", "Home", "Work")
+				router.AddRoute(kc, "c", "Other")
+			},
+			routes: []router.RouteConfig{
+				{Keeper: "b", Locations: []string{"Work", "Team", "Home"}, Types: []string{"password"}},
+				{Keeper: "m", Mount: "Mounted"},
+				{Keeper: "c", Locations: []string{"Other"}},
+			},
+		},
+		{
+			name: "add name prefixes and types",
+			update: func(kc config.KeeperConfig) {
+				router.AddNamePrefixes(kc, "b", "db-")
+				router.AddTypes(kc, "c", "ssh-key")
+			},
+			routes: []router.RouteConfig{
+				{Keeper: "b", Locations: []string{"Work", "Team"}, NamePrefixes: []string{"db-"}, Types: []string{"password"}},
+				{Keeper: "m", Mount: "Mounted"},
+				{Keeper: "c", Types: []string{"ssh-key"}},
+			},
+		},
+		{
+			name: "set mount",
+			update: func(kc config.KeeperConfig) {
+				router.SetMount(kc, "m", "Moved")
+				router.SetMount(kc, "n", "New")
+			},
+			routes: []router.RouteConfig{
+				{Keeper: "b", Locations: []string{"Work", "Team"}, Types: []string{"password"}},
+				{Keeper: "m", Mount: "Moved"},
+				{Keeper: "n", Mount: "New"},
+			},
+		},
+		{
+			name: "remove locations",
+			update: func(kc config.KeeperConfig) {
+				router.RemoveLocationsAndRoutes(kc, "Work")
+			},
+			routes: []router.RouteConfig{
+				{Keeper: "b", Locations: []string{"Team"}, Types: []string{"password"}},
+				{Keeper: "m", Mount: "Mounted"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), ".ghost.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(routerYAML), 0o600))
+
+			kc := loadRouter(t, path)
+			tc.update(kc)
+			assert.Equal(t, tc.routes, saveRouter(t, path, kc))
+
+			// the saved configuration can be loaded and changed again
+			kc = loadRouter(t, path)
+			router.AddTypes(kc, "z", "note")
+			routes := saveRouter(t, path, kc)
+			assert.Equal(t, append(tc.routes, router.RouteConfig{Keeper: "z", Types: []string{"note"}}), routes)
+		})
+	}
+}
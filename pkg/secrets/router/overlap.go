@@ -0,0 +1,303 @@
+package router
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// patternsOverlap returns true if some location matches both location
+// patterns. Both patterns are compiled to regular expression programs and the
+// programs are run side by side over every input at once to find one both
+// accept. Assertions other than the start and end of text, such as \b, are
+// assumed to pass, so a pattern using them may be reported to overlap when it
+// does not. If either pattern cannot be compiled this way, the patterns
+// overlap if either matches the text of the other.
+func patternsOverlap(a, b string) bool {
+	pa, errA := compileOverlapPattern(a)
+	pb, errB := compileOverlapPattern(b)
+	if errA != nil || errB != nil {
+		ma, errA := compileLocationPattern(a)
+		mb, errB := compileLocationPattern(b)
+		return errA == nil && errB == nil && (ma(b) || mb(a))
+	}
+
+	return progsOverlap(pa, pb)
+}
+
+// compileOverlapPattern compiles a location pattern to a regular expression
+// program matching the same locations.
+func compileOverlapPattern(pattern string) (*syntax.Prog, error) {
+	var expr string
+	if secrets.IsRegexpPattern(pattern) {
+		// a regular expression may match anywhere in the location
+		expr = `(?s:.*)(?:` + pattern[1:len(pattern)-1] + `)(?s:.*)`
+	} else {
+		globExpr, rest, err := globToRegexp([]rune(pattern), false)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) > 0 {
+			return nil, errors.New("unexpected " + string(rest[0]) + " in glob")
+		}
+		expr = `^` + globExpr + `$`
+	}
+
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	return syntax.Compile(re.Simplify())
+}
+
+// globToRegexp translates a glob to a regular expression. When inAlt is true,
+// the translation stops at the comma or closing brace ending an alternative of
+// {a,b} and returns the rest of the glob from there.
+func globToRegexp(glob []rune, inAlt bool) (string, []rune, error) {
+	var expr strings.Builder
+	for len(glob) > 0 {
+		switch c := glob[0]; c {
+		case '\\':
+			if len(glob) < 2 {
+				return "", nil, errors.New("glob ends with an escape")
+			}
+			expr.WriteString(regexp.QuoteMeta(string(glob[1])))
+			glob = glob[2:]
+		case '*':
+			if len(glob) > 1 && glob[1] == '*' {
+				expr.WriteString(`(?s:.*)`)
+				glob = glob[2:]
+				continue
+			}
+			expr.WriteString(`[^/]*`)
+			glob = glob[1:]
+		case '?':
+			expr.WriteString(`[^/]`)
+			glob = glob[1:]
+		case '[':
+			end := indexRune(glob, ']')
+			if end < 0 {
+				return "", nil, errors.New("glob has an unclosed [")
+			}
+			expr.WriteString(globClassToRegexp(glob[1:end]))
+			glob = glob[end+1:]
+		case '{':
+			expr.WriteString(`(?:`)
+			glob = glob[1:]
+			for {
+				alt, rest, err := globToRegexp(glob, true)
+				if err != nil {
+					return "", nil, err
+				}
+				if len(rest) == 0 {
+					return "", nil, errors.New("glob has an unclosed {")
+				}
+				expr.WriteString(alt)
+				glob = rest[1:]
+				if rest[0] == '}' {
+					break
+				}
+				expr.WriteString(`|`)
+			}
+			expr.WriteString(`)`)
+		case ',', '}':
+			if inAlt {
+				return expr.String(), glob, nil
+			}
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+			glob = glob[1:]
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+			glob = glob[1:]
+		}
+	}
+
+	return expr.String(), nil, nil
+}
+
+// globClassToRegexp translates the inside of a glob character class, like
+// a-z or !abc, to a regular expression character class.
+func globClassToRegexp(class []rune) string {
+	var expr strings.Builder
+	expr.WriteString(`[`)
+	if len(class) > 0 && class[0] == '!' {
+		expr.WriteString(`^`)
+		class = class[1:]
+	}
+
+	for _, c := range class {
+		switch c {
+		case '\\', '[', ']', '^':
+			expr.WriteRune('\\')
+		}
+		expr.WriteRune(c)
+	}
+
+	expr.WriteString(`]`)
+	return expr.String()
+}
+
+// indexRune returns the index of the first r in rs or -1.
+func indexRune(rs []rune, r rune) int {
+	for i, c := range rs {
+		if c == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// progState is a place in a program while matching. Once the end of text has
+// been asserted, no more runes may be read.
+type progState struct {
+	pc    uint32
+	ended bool
+}
+
+// progPair is a place in each of two programs after reading the same input.
+type progPair struct {
+	a, b  progState
+	start bool
+}
+
+// progsOverlap returns true if there is any input both programs match.
+func progsOverlap(a, b *syntax.Prog) bool {
+	first := progPair{
+		a:     progState{pc: uint32(a.Start)},
+		b:     progState{pc: uint32(b.Start)},
+		start: true,
+	}
+
+	seen := map[progPair]bool{first: true}
+	queue := []progPair{first}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		runesA, matchA := progClosure(a, p.a, p.start)
+		runesB, matchB := progClosure(b, p.b, p.start)
+		if matchA && matchB {
+			return true
+		}
+
+		for _, ia := range runesA {
+			for _, ib := range runesB {
+				instA, instB := &a.Inst[ia], &b.Inst[ib]
+				if !runesOverlap(instA, instB) {
+					continue
+				}
+
+				next := progPair{
+					a: progState{pc: instA.Out},
+					b: progState{pc: instB.Out},
+				}
+				if !seen[next] {
+					seen[next] = true
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// progClosure follows the program from the state without reading a rune. It
+// returns the instructions reached that read a rune and whether a match is
+// reached. The start flag is true when no input has been read yet.
+func progClosure(prog *syntax.Prog, st progState, start bool) ([]uint32, bool) {
+	var (
+		runes   []uint32
+		matched bool
+		seen    = map[progState]bool{st: true}
+		stack   = []progState{st}
+	)
+
+	push := func(s progState) {
+		if !seen[s] {
+			seen[s] = true
+			stack = append(stack, s)
+		}
+	}
+
+	for len(stack) > 0 {
+		s := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		inst := &prog.Inst[s.pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			push(progState{inst.Out, s.ended})
+			push(progState{inst.Arg, s.ended})
+		case syntax.InstCapture, syntax.InstNop:
+			push(progState{inst.Out, s.ended})
+		case syntax.InstEmptyWidth:
+			op := syntax.EmptyOp(inst.Arg)
+			if op&syntax.EmptyBeginText != 0 && !start {
+				continue
+			}
+			push(progState{inst.Out, s.ended || op&syntax.EmptyEndText != 0})
+		case syntax.InstMatch:
+			matched = true
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			if !s.ended {
+				runes = append(runes, s.pc)
+			}
+		}
+	}
+
+	return runes, matched
+}
+
+// runesOverlap returns true if some rune is read by both instructions.
+func runesOverlap(a, b *syntax.Inst) bool {
+	for _, r := range append(runeCandidates(a), runeCandidates(b)...) {
+		if readsRune(a, r) && readsRune(b, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// runeCandidates returns runes to try when looking for a rune read by the
+// instruction and another. If two sets of rune ranges intersect, the start of
+// one of the ranges is in both.
+func runeCandidates(inst *syntax.Inst) []rune {
+	switch inst.Op {
+	case syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		return []rune{'a'}
+	}
+
+	var rs []rune
+	step := 2
+	if len(inst.Rune) == 1 {
+		step = 1
+	}
+	for i := 0; i < len(inst.Rune); i += step {
+		r := inst.Rune[i]
+		rs = append(rs, r)
+		if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 {
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				rs = append(rs, f)
+			}
+		}
+	}
+	return rs
+}
+
+// readsRune returns true if the instruction reads the rune.
+func readsRune(inst *syntax.Inst, r rune) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	default:
+		return inst.MatchRune(r)
+	}
+}
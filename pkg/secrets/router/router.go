@@ -13,14 +13,98 @@ import (
 	"github.com/zostay/ghost/pkg/secrets"
 )
 
+// Route selects the secrets that a Router sends to a keeper.
+type Route struct {
+	// Locations lists the locations routed to the keeper. Each may be an exact
+	// location or a pattern. A pattern surrounded by slashes is a regular
+	// expression. Any other pattern is a glob in which * matches anything but a
+	// slash and ** matches anything.
+	Locations []string
+
+	// NamePrefixes routes secrets with a name starting with any of these.
+	NamePrefixes []string
+
+	// Types routes secrets with any of these types.
+	Types []string
+
+	// Mount routes the location named and every location beneath it to the
+	// keeper. The keeper's own locations are presented beneath the mount, so a
+	// location named "Team" in the keeper appears as "Work/Team" when mounted
+	// at "Work". A route with a mount may not set anything else.
+	Mount string
+}
+
 type keeperMap struct {
-	id        string
-	keeper    secrets.Keeper
-	locations set.Set[string]
+	id           string
+	keeper       secrets.Keeper
+	locations    set.Set[string]
+	patterns     []secrets.MatchFunc
+	namePrefixes []string
+	types        set.Set[string]
+	mount        string
+}
+
+// toChild converts a location as presented by the router to the location
+// in the keeper. The mount itself is the empty location in the keeper.
+func (m *keeperMap) toChild(location string) string {
+	switch {
+	case m.mount == "":
+		return location
+	case location == m.mount:
+		return ""
+	default:
+		return strings.TrimPrefix(location, m.mount+"/")
+	}
+}
+
+// fromChild converts a location in the keeper to the location as presented by
+// the router.
+func (m *keeperMap) fromChild(location string) string {
+	switch {
+	case m.mount == "":
+		return location
+	case location == "":
+		return m.mount
+	default:
+		return m.mount + "/" + location
+	}
+}
+
+// present wraps a secret from the keeper as presented by the router.
+func (m *keeperMap) present(secret secrets.Secret) *Secret {
+	return newSecret(m.id, m.fromChild(secret.Location()), secret)
+}
+
+// mounts returns true if the location is the mount or beneath it.
+func (m *keeperMap) mounts(location string) bool {
+	return m.mount != "" &&
+		(location == m.mount || strings.HasPrefix(location, m.mount+"/"))
+}
+
+// routesByContent returns true if the route selects secrets by name or type,
+// which means the keeper may hold secrets in any location not routed by
+// location.
+func (m *keeperMap) routesByContent() bool {
+	return len(m.namePrefixes) > 0 || m.types.Len() > 0
 }
 
-// Router is a Keeper that maps locations to other Keepers. Keepers are added to
-// the Router using the AddKeeper method.
+// exactOnly returns true if the route only selects exact locations.
+func (m *keeperMap) exactOnly() bool {
+	return len(m.patterns) == 0 && m.mount == "" && !m.routesByContent()
+}
+
+// matchesPattern returns true if any location pattern matches the location.
+func (m *keeperMap) matchesPattern(location string) bool {
+	for _, p := range m.patterns {
+		if p(location) {
+			return true
+		}
+	}
+	return false
+}
+
+// Router is a Keeper that maps secrets to other Keepers. Keepers are added to
+// the Router using the AddKeeper or AddRoute methods.
 //
 // The added secrets.Keepers are associated with this secrets.Keeper operate as
 // peers, rather than children. That is, if you add another keeper for locations
@@ -28,119 +112,384 @@ type keeperMap struct {
 // "Personal" or for "Work", that keeper will be used. This is NOT a
 // parent-child relationship with pathing or anything of that sort.
 //
-// Whenever secrets are fetched, saved, etc. The location of the secret will
-// match those associated with a Keeper. For example, if GetSecretsByName is
-// called, secrets that match that name, but are not in an associated location
-// will not be returned.
+// A route may instead mount a keeper at a location, which is then presented as
+// a branch of the router's locations. Each location of the mounted keeper is
+// presented beneath the mount and the mount is removed from the location of
+// secrets written to it.
+//
+// A secret is routed to a keeper using the first of these rules that matches:
+//
+//  1. A route naming the exact location of the secret.
+//  2. A route mounted at or above the location of the secret. If more than one
+//     matches, the longest mount is used.
+//  3. A route with a location pattern matching the location of the secret. If
+//     more than one matches, the route added first is used.
+//  4. A route with a name prefix matching the name of the secret. If more than
+//     one matches, the longest prefix is used.
+//  5. A route naming the type of the secret.
+//  6. The default keeper.
+//
+// Whenever secrets are fetched, saved, etc. the secret must be routed to the
+// keeper it is found in. For example, if GetSecretsByName is called, secrets
+// that match that name, but are found in a keeper other than the one they
+// would be routed to will not be returned.
 //
 // The IDs used by this library will differ from those returned by each
 // associated keeper.
 type Router struct {
-	defaultId     string
-	defaultKeeper secrets.Keeper
-	keepers       []keeperMap
+	defaultKeeper *keeperMap
+	keepers       []*keeperMap
 
 	usedLocations set.Set[string]
 }
 
-var _ secrets.Keeper = &Router{}
+var (
+	_ secrets.Keeper  = &Router{}
+	_ secrets.Wrapper = &Router{}
+)
 
 // NewRouter returns a new router with the given Keeper as the default Keeper.
 func NewRouter(defaultKeeper secrets.Keeper) *Router {
 	return &Router{
-		defaultId:     ulid.Make().String(),
-		defaultKeeper: defaultKeeper,
-		keepers:       []keeperMap{},
+		defaultKeeper: &keeperMap{
+			id:        ulid.Make().String(),
+			keeper:    defaultKeeper,
+			locations: set.New[string](),
+			types:     set.New[string](),
+		},
+		keepers:       []*keeperMap{},
 		usedLocations: set.New[string](),
 	}
 }
 
 // AddKeeper adds a new Keeper, which will be used for storing at the given
-// locations. The same location may not be used for more than one Keeper.
+// locations. This is the same as calling AddRoute with a Route listing only
+// locations.
 func (r *Router) AddKeeper(keeper secrets.Keeper, locations ...string) error {
-	newLocs := set.New(locations...)
-	if set.Intersects(r.usedLocations, newLocs) {
-		return fmt.Errorf("these locations are already in use: %s",
-			strings.Join(
-				set.Intersection(r.usedLocations, newLocs).Keys(),
-				", "))
+	return r.AddRoute(keeper, Route{Locations: locations})
+}
+
+// AddRoute adds a new Keeper, which will be used for storing secrets selected
+// by the given route. Overlapping routes are resolved according to the rules
+// described for Router. Use CheckRoutes to find overlapping routes before
+// adding them.
+func (r *Router) AddRoute(keeper secrets.Keeper, rt Route) error {
+	if err := checkMount(rt); err != nil {
+		return err
+	}
+
+	m := &keeperMap{
+		mount:        strings.TrimSuffix(rt.Mount, "/"),
+		id:           ulid.Make().String(),
+		keeper:       keeper,
+		locations:    set.New[string](),
+		namePrefixes: rt.NamePrefixes,
+		types:        set.New(rt.Types...),
+	}
+
+	for _, loc := range rt.Locations {
+		if !secrets.IsPattern(loc) {
+			m.locations.Insert(loc)
+			continue
+		}
+
+		p, err := compileLocationPattern(loc)
+		if err != nil {
+			return fmt.Errorf("bad location pattern %q: %w", loc, err)
+		}
+		m.patterns = append(m.patterns, p)
 	}
 
-	r.keepers = append(r.keepers, keeperMap{
-		id:        ulid.Make().String(),
-		keeper:    keeper,
-		locations: set.New(locations...),
-	})
-	r.usedLocations = set.Union(r.usedLocations, newLocs)
+	r.keepers = append(r.keepers, m)
+	r.usedLocations = set.Union(r.usedLocations, m.locations)
+	if m.mount != "" {
+		r.usedLocations.Insert(m.mount)
+	}
 
 	return nil
 }
 
-// ListLocations returns all the locations that this secrets.Keeper provides.
-func (r *Router) ListLocations(ctx context.Context) ([]string, error) {
-	locs, err := r.defaultKeeper.ListLocations(ctx)
-	if err != nil {
-		return nil, err
+// checkMount returns an error if the route has a mount and anything else or
+// the mount is a pattern.
+func checkMount(rt Route) error {
+	if rt.Mount == "" {
+		return nil
+	}
+
+	if len(rt.Locations) > 0 || len(rt.NamePrefixes) > 0 || len(rt.Types) > 0 {
+		return fmt.Errorf("route mounted at %q may not also have locations, name prefixes, or types", rt.Mount)
 	}
 
-	return set.Union(set.New(locs...), r.usedLocations).Keys(), nil
+	if secrets.IsPattern(rt.Mount) {
+		return fmt.Errorf("mount %q may not be a pattern", rt.Mount)
+	}
+
+	return nil
 }
 
-var errStop = errors.New("stop")
+// compileLocationPattern compiles a location pattern.
+func compileLocationPattern(pattern string) (secrets.MatchFunc, error) {
+	return secrets.CompilePattern(pattern, '/')
+}
 
-func handleStop(err error) error {
-	if errors.Is(err, errStop) {
-		return nil
+// CheckRoutes returns an error describing every overlap found between the
+// given routes, which would make the routing of some secrets depend on the
+// order the routes were added in. Two routes overlap if they name the same
+// location, location pattern, mount, name prefix, or type, or if some location
+// matches a location pattern of each, as Work/* and */Prod both match
+// Work/Prod.
+// An exact location never overlaps a location pattern or mount because the
+// exact location always takes precedence. Nor do nested mounts overlap because
+// the longest mount is used. It also reports bad location patterns and mounts
+// and empty name prefixes.
+func CheckRoutes(routes ...Route) error {
+	var errs []error
+
+	type seenIn struct {
+		route int
+		what  string
 	}
-	return err
+
+	var (
+		seen     = map[string]seenIn{}
+		patterns = make([][]string, len(routes))
+	)
+
+	note := func(i int, kind, what string) {
+		key := kind + "\x00" + what
+		if prev, isSeen := seen[key]; isSeen && prev.route != i {
+			errs = append(errs, fmt.Errorf("routes %d and %d both use the %s %q", prev.route+1, i+1, kind, what))
+			return
+		}
+		seen[key] = seenIn{i, what}
+	}
+
+	for i, rt := range routes {
+		if err := checkMount(rt); err != nil {
+			errs = append(errs, fmt.Errorf("route %d: %w", i+1, err))
+		} else if rt.Mount != "" {
+			note(i, "mount", strings.TrimSuffix(rt.Mount, "/"))
+		}
+
+		for _, loc := range rt.Locations {
+			if !secrets.IsPattern(loc) {
+				note(i, "location", loc)
+				continue
+			}
+
+			note(i, "location pattern", loc)
+			if _, err := compileLocationPattern(loc); err != nil {
+				errs = append(errs, fmt.Errorf("route %d has a bad location pattern %q: %w", i+1, loc, err))
+				continue
+			}
+			patterns[i] = append(patterns[i], loc)
+		}
+
+		for _, pfx := range rt.NamePrefixes {
+			if pfx == "" {
+				errs = append(errs, fmt.Errorf("route %d has an empty name prefix", i+1))
+				continue
+			}
+			note(i, "name prefix", pfx)
+		}
+
+		for _, typ := range rt.Types {
+			note(i, "type", typ)
+		}
+	}
+
+	for i := range routes {
+		for j := i + 1; j < len(routes); j++ {
+			for _, pi := range patterns[i] {
+				for _, pj := range patterns[j] {
+					if pi != pj && patternsOverlap(pi, pj) {
+						errs = append(errs, fmt.Errorf("route %d location pattern %q overlaps route %d location pattern %q", i+1, pi, j+1, pj))
+					}
+				}
+			}
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
-func (r *Router) keeperForLocation(
-	_ context.Context,
-	location string,
-) (string, secrets.Keeper) {
+// Unwrap returns the default Keeper followed by every routed Keeper.
+func (r *Router) Unwrap() []secrets.Keeper {
+	kprs := make([]secrets.Keeper, 0, len(r.keepers)+1)
+	kprs = append(kprs, r.defaultKeeper.keeper)
+	for _, m := range r.keepers {
+		kprs = append(kprs, m.keeper)
+	}
+	return kprs
+}
+
+// allKeepers returns every routed keeper followed by the default keeper.
+func (r *Router) allKeepers() []*keeperMap {
+	kms := make([]*keeperMap, 0, len(r.keepers)+1)
+	return append(append(kms, r.keepers...), r.defaultKeeper)
+}
+
+// routesByContent returns true if any route selects secrets by name or type.
+func (r *Router) routesByContent() bool {
+	for _, m := range r.keepers {
+		if m.routesByContent() {
+			return true
+		}
+	}
+	return false
+}
+
+// keeperForId returns the keeper with the given router keeper ID or nil.
+func (r *Router) keeperForId(keeperId string) *keeperMap {
+	for _, m := range r.allKeepers() {
+		if m.id == keeperId {
+			return m
+		}
+	}
+	return nil
+}
+
+// routeLocation returns the keeper the location is routed to by location
+// alone. It returns false if no route selects the location.
+func (r *Router) routeLocation(location string) (*keeperMap, bool) {
 	for _, m := range r.keepers {
 		if m.locations.Contains(location) {
-			return m.id, m.keeper
+			return m, true
 		}
 	}
-	return r.defaultId, r.defaultKeeper
+
+	var mounted *keeperMap
+	for _, m := range r.keepers {
+		if m.mounts(location) && (mounted == nil || len(m.mount) > len(mounted.mount)) {
+			mounted = m
+		}
+	}
+
+	if mounted != nil {
+		return mounted, true
+	}
+
+	for _, m := range r.keepers {
+		if m.matchesPattern(location) {
+			return m, true
+		}
+	}
+
+	return nil, false
 }
 
-func (r *Router) defaultLocations(
+// route returns the keeper a secret with the given location, name, and type is
+// routed to.
+func (r *Router) route(location, name, typ string) *keeperMap {
+	if m, isRouted := r.routeLocation(location); isRouted {
+		return m
+	}
+
+	var (
+		best    *keeperMap
+		bestLen int
+	)
+	for _, m := range r.keepers {
+		for _, pfx := range m.namePrefixes {
+			if len(pfx) > bestLen && strings.HasPrefix(name, pfx) {
+				best, bestLen = m, len(pfx)
+			}
+		}
+	}
+
+	if best != nil {
+		return best
+	}
+
+	for _, m := range r.keepers {
+		if m.types.Contains(typ) {
+			return m
+		}
+	}
+
+	return r.defaultKeeper
+}
+
+// routeSecret returns the keeper the secret is routed to. The secret must be
+// as presented by the router.
+func (r *Router) routeSecret(sec secrets.Secret) *keeperMap {
+	return r.route(sec.Location(), sec.Name(), sec.Type())
+}
+
+// mayHold returns true if secrets in the location may be routed to the keeper.
+func (r *Router) mayHold(m *keeperMap, location string) bool {
+	if lm, isRouted := r.routeLocation(location); isRouted {
+		return lm == m
+	}
+
+	return m == r.defaultKeeper || m.routesByContent()
+}
+
+// keeperLocations returns the locations of the keeper that may hold secrets
+// routed to it.
+func (r *Router) keeperLocations(
 	ctx context.Context,
-) (set.Set[string], error) {
-	defaultLocs, err := r.defaultKeeper.ListLocations(ctx)
+	m *keeperMap,
+) ([]string, error) {
+	if m != r.defaultKeeper && m.exactOnly() {
+		return m.locations.Keys(), nil
+	}
+
+	locs, err := m.keeper.ListLocations(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return set.Difference(set.New(defaultLocs...), r.usedLocations), nil
+	held := set.New[string]()
+	for _, loc := range locs {
+		loc = m.fromChild(loc)
+		if r.mayHold(m, loc) {
+			held.Insert(loc)
+		}
+	}
+
+	return held.Keys(), nil
 }
 
-func (r *Router) forEachKeeperLocation(
-	ctx context.Context,
-	run func(string, secrets.Keeper, string) error,
-) error {
-	for _, m := range r.keepers {
-		for _, loc := range m.locations.Keys() {
-			err := run(m.id, m.keeper, loc)
-			if err != nil {
-				return err
-			}
+// ListLocations returns all the locations that this secrets.Keeper provides.
+func (r *Router) ListLocations(ctx context.Context) ([]string, error) {
+	locs := set.New(r.usedLocations.Keys()...)
+	for _, m := range r.allKeepers() {
+		mLocs, err := r.keeperLocations(ctx, m)
+		if err != nil {
+			return nil, err
 		}
+		locs.Insert(mLocs...)
 	}
 
-	defaultLocs, err := r.defaultLocations(ctx)
-	if err != nil {
-		return err
+	return locs.Keys(), nil
+}
+
+var errStop = errors.New("stop")
+
+func handleStop(err error) error {
+	if errors.Is(err, errStop) {
+		return nil
 	}
+	return err
+}
 
-	for _, loc := range defaultLocs.Keys() {
-		err := run(r.defaultId, r.defaultKeeper, loc)
+func (r *Router) forEachKeeperLocation(
+	ctx context.Context,
+	run func(*keeperMap, string) error,
+) error {
+	for _, m := range r.allKeepers() {
+		locs, err := r.keeperLocations(ctx, m)
 		if err != nil {
 			return err
 		}
+
+		for _, loc := range locs {
+			err := run(m, loc)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -148,23 +497,27 @@ func (r *Router) forEachKeeperLocation(
 
 func (r *Router) forEachSecretInKeeperLocation(
 	ctx context.Context,
-	keeperId string,
-	keeper secrets.Keeper,
+	m *keeperMap,
 	location string,
 	run func(secrets.Secret) error,
 ) error {
-	ids, err := keeper.ListSecrets(ctx, location)
+	ids, err := m.keeper.ListSecrets(ctx, m.toChild(location))
 	if err != nil {
 		return err
 	}
 
 	for _, id := range ids {
-		secret, err := keeper.GetSecret(ctx, id)
+		secret, err := m.keeper.GetSecret(ctx, id)
 		if err != nil {
 			return err
 		}
 
-		err = run(newSecret(keeperId, secret))
+		sec := m.present(secret)
+		if r.routeSecret(sec) != m {
+			continue
+		}
+
+		err = run(sec)
 		if err != nil {
 			return err
 		}
@@ -178,79 +531,91 @@ func (r *Router) forEachSecret(
 	run func(secrets.Secret) error,
 ) error {
 	err := r.forEachKeeperLocation(ctx,
-		func(id string, k secrets.Keeper, loc string) error {
-			return r.forEachSecretInKeeperLocation(ctx, id, k, loc, run)
+		func(m *keeperMap, loc string) error {
+			return r.forEachSecretInKeeperLocation(ctx, m, loc, run)
 		},
 	)
 
 	return err
 }
 
+// findSecretMatchingId returns the keeper holding the secret with the given
+// router ID, the ID of the secret in that keeper, and the secret as presented
+// by the router.
 func (r *Router) findSecretMatchingId(
 	ctx context.Context,
 	id string,
-) (string, secrets.Keeper, secrets.Secret, error) {
+) (*keeperMap, string, *Secret, error) {
 	parts := strings.SplitN(id, ":", 2)
 	if len(parts) != 2 {
-		return "", nil, nil, errors.New("bad secret ID in secret router")
+		return nil, "", nil, errors.New("bad secret ID in secret router")
 	}
 
 	keeperId, secretId := parts[0], parts[1]
 
-	var (
-		keeper    secrets.Keeper
-		locations set.Set[string]
-	)
-	if r.defaultId == keeperId {
-		var err error
-		keeper = r.defaultKeeper
-		locations, err = r.defaultLocations(ctx)
-		if err != nil {
-			return "", nil, nil, err
-		}
-	} else {
-		for _, m := range r.keepers {
-			if m.id == keeperId {
-				keeper = m.keeper
-				locations = m.locations
-			}
-		}
-	}
-
-	if keeper == nil {
-		return "", nil, nil, fmt.Errorf("unknown keeper ID: %s", keeperId)
+	m := r.keeperForId(keeperId)
+	if m == nil {
+		return nil, "", nil, fmt.Errorf("unknown keeper ID: %s", keeperId)
 	}
 
-	if locations == nil {
-		return "", nil, nil, fmt.Errorf("unknown locations for keeper ID: %s", keeperId)
-	}
-
-	secret, err := keeper.GetSecret(ctx, secretId)
+	secret, err := m.keeper.GetSecret(ctx, secretId)
 	if err != nil {
-		return "", nil, nil, err
+		return nil, "", nil, err
 	}
 
-	if !locations.Contains(secret.Location()) {
-		return "", nil, nil, secrets.ErrNotFound
+	sec := m.present(secret)
+	if r.routeSecret(sec) != m {
+		return nil, "", nil, secrets.ErrNotFound
 	}
 
-	return keeperId, keeper, secret, nil
+	return m, secretId, sec, nil
 }
 
-// ListSecrets will list all secrets from the secrets.Keeper store that owns the
-// given location.
+// ListSecrets will list all secrets from the secrets.Keeper stores that own
+// the given location.
 func (r *Router) ListSecrets(
 	ctx context.Context,
 	location string,
 ) ([]string, error) {
-	keeperId, keeper := r.keeperForLocation(ctx, location)
-	ids, err := keeper.ListSecrets(ctx, location)
-	if err != nil {
-		return nil, err
+	if m, isRouted := r.routeLocation(location); isRouted {
+		ids, err := m.keeper.ListSecrets(ctx, m.toChild(location))
+		if err != nil {
+			return nil, err
+		}
+		return slices.Map(ids, func(id string) string {
+			return makeId(m.id, id)
+		}), nil
 	}
-	return slices.Map(ids, func(id string) string {
-		return makeId(keeperId, id)
-	}), nil
+
+	if !r.routesByContent() {
+		ids, err := r.defaultKeeper.keeper.ListSecrets(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		return slices.Map(ids, func(id string) string {
+			return makeId(r.defaultKeeper.id, id)
+		}), nil
+	}
+
+	// when routing by name or type, any of several keepers may hold secrets
+	// in this location, and the secrets must be checked one by one
+	var ids []string
+	for _, m := range r.allKeepers() {
+		if !r.mayHold(m, location) {
+			continue
+		}
+
+		err := r.forEachSecretInKeeperLocation(ctx, m, location,
+			func(sec secrets.Secret) error {
+				ids = append(ids, sec.ID())
+				return nil
+			})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ids, nil
 }
 
 // GetSecret will retrieve the identified secret from one of the available
@@ -261,7 +626,10 @@ func (r *Router) GetSecret(
 	id string,
 ) (secrets.Secret, error) {
 	_, _, secret, err := r.findSecretMatchingId(ctx, id)
-	return secret, err
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
 }
 
 // GetSecretsByName will retrieve every secret in every secret store with the
@@ -283,21 +651,20 @@ func (r *Router) GetSecretsByName(
 }
 
 // SetSecret will find the secrets.Keeper store used for the new secret's
-// location. It will then add the secret to that Keeper.
+// location, name, and type. It will then add the secret to that Keeper, after
+// removing the mount from the location, if the Keeper is mounted.
 func (r *Router) SetSecret(
 	ctx context.Context,
 	sec secrets.Secret,
 ) (secrets.Secret, error) {
-	keeperId, keeper, secret, err := r.findSecretMatchingId(ctx, sec.ID())
+	if sec.ID() == "" {
+		return r.createSecret(ctx, sec)
+	}
+
+	m, secretId, secret, err := r.findSecretMatchingId(ctx, sec.ID())
 	if err != nil {
 		if errors.Is(err, secrets.ErrNotFound) {
-			keeperId, keeper = r.keeperForLocation(ctx, sec.Location())
-			newSec, err := keeper.SetSecret(ctx, sec)
-			if err != nil {
-				return nil, err
-			}
-
-			return newSecret(keeperId, newSec), nil
+			return r.createSecret(ctx, sec)
 		}
 		return nil, err
 	}
@@ -306,12 +673,36 @@ func (r *Router) SetSecret(
 		return nil, errors.New("cannot move secret location with SetSecret in secret router")
 	}
 
-	newSec, err := keeper.SetSecret(ctx, sec)
+	if r.routeSecret(sec) != m {
+		return nil, errors.New("cannot change the keeper of a secret with SetSecret in secret router")
+	}
+
+	newSec, err := m.keeper.SetSecret(ctx,
+		secrets.NewSingleFromSecret(sec,
+			secrets.WithID(secretId),
+			secrets.WithLocation(m.toChild(sec.Location()))))
 	if err != nil {
 		return nil, err
 	}
 
-	return newSecret(keeperId, newSec), nil
+	return m.present(newSec), nil
+}
+
+// createSecret creates a new secret in the keeper it is routed to.
+func (r *Router) createSecret(
+	ctx context.Context,
+	sec secrets.Secret,
+) (*Secret, error) {
+	m := r.routeSecret(sec)
+	newSec, err := m.keeper.SetSecret(ctx,
+		secrets.NewSingleFromSecret(sec,
+			secrets.WithID(""),
+			secrets.WithLocation(m.toChild(sec.Location()))))
+	if err != nil {
+		return nil, err
+	}
+
+	return m.present(newSec), nil
 }
 
 // DeleteSecret finds the store that holds the identified secret and deletes it.
@@ -319,12 +710,12 @@ func (r *Router) DeleteSecret(
 	ctx context.Context,
 	id string,
 ) error {
-	_, keeper, _, err := r.findSecretMatchingId(ctx, id)
+	m, secretId, _, err := r.findSecretMatchingId(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	return keeper.DeleteSecret(ctx, id)
+	return m.keeper.DeleteSecret(ctx, secretId)
 }
 
 // CopySecret will copy the secret from one location to another, possibly moving
@@ -339,25 +730,17 @@ func (r *Router) CopySecret(
 	id string,
 	location string,
 ) (secrets.Secret, error) {
-	keeperId, _, secret, err := r.findSecretMatchingId(ctx, id)
+	_, _, secret, err := r.findSecretMatchingId(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if secret.Location() == location {
-		return newSecret(keeperId, secret), nil
-	}
-
-	secret = secrets.NewSingleFromSecret(secret,
-		secrets.WithID(""),
-		secrets.WithLocation(location))
-	keeperId, keeper := r.keeperForLocation(ctx, location)
-	newSec, err := keeper.SetSecret(ctx, secret)
-	if err != nil {
-		return nil, err
+		return secret, nil
 	}
 
-	return newSecret(keeperId, newSec), nil
+	return r.createSecret(ctx,
+		secrets.NewSingleFromSecret(secret, secrets.WithLocation(location)))
 }
 
 // MoveSecret will move the secret from one location to another, possibly moving
@@ -374,24 +757,21 @@ func (r *Router) MoveSecret(
 	id string,
 	location string,
 ) (secrets.Secret, error) {
-	keeperId, keeper, secret, err := r.findSecretMatchingId(ctx, id)
+	m, secretId, secret, err := r.findSecretMatchingId(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if secret.Location() == location {
-		return newSecret(keeperId, secret), nil
+		return secret, nil
 	}
 
-	newKeeperId, newKeeper := r.keeperForLocation(ctx, location)
-	newSingle := secrets.NewSingleFromSecret(secret,
-		secrets.WithID(""),
-		secrets.WithLocation(location))
-	newSec, err := newKeeper.SetSecret(ctx, newSingle)
+	newSec, err := r.createSecret(ctx,
+		secrets.NewSingleFromSecret(secret, secrets.WithLocation(location)))
 	if err != nil {
 		return nil, err
 	}
 
-	err = keeper.DeleteSecret(ctx, id)
-	return newSecret(newKeeperId, newSec), err
+	err = m.keeper.DeleteSecret(ctx, secretId)
+	return newSec, err
 }
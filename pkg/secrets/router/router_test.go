@@ -0,0 +1,415 @@
+package router_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/keepertest"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+	"github.com/zostay/ghost/pkg/secrets/router"
+)
+
+func TestRouter(t *testing.T) {
+	t.Parallel()
+
+	factory := func() (secrets.Keeper, error) {
+		def, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+
+		work, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+
+		r := router.NewRouter(def)
+		if err := r.AddKeeper(work, "Work"); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+
+	ts := keepertest.New(factory)
+	ts.Run(t)
+}
+
+// routed sets up a router with one memory keeper per named route, in order.
+func routed(t *testing.T, routes []namedRoute) (*router.Router, map[string]*memory.Memory) {
+	t.Helper()
+
+	def, err := memory.New()
+	require.NoError(t, err)
+
+	r := router.NewRouter(def)
+	kprs := map[string]*memory.Memory{"default": def}
+	for _, rt := range routes {
+		k, err := memory.New()
+		require.NoError(t, err)
+
+		require.NoError(t, r.AddRoute(k, rt.route))
+		kprs[rt.name] = k
+	}
+
+	return r, kprs
+}
+
+type namedRoute struct {
+	name  string
+	route router.Route
+}
+
+func TestRouter_Precedence(t *testing.T) {
+	t.Parallel()
+
+	r, kprs := routed(t, []namedRoute{
+		{"glob", router.Route{Locations: []string{"Work/*"}}},
+		{"regexp", router.Route{Locations: []string{"/^Work/"}}},
+		{"exact", router.Route{Locations: []string{"Work/Prod"}}},
+		{"mount", router.Route{Mount: "Work/Team"}},
+		{"deep mount", router.Route{Mount: "Work/Team/Ops"}},
+		{"prefix", router.Route{NamePrefixes: []string{"db"}}},
+		{"long prefix", router.Route{NamePrefixes: []string{"db-prod"}}},
+		{"type", router.Route{Types: []string{"ssh"}}},
+	})
+
+	tests := []struct {
+		location, name, typ string
+		keeper              string
+		childLocation       string
+	}{
+		{"Work/Prod", "exact beats pattern", "", "exact", "Work/Prod"},
+		{"Work/Team", "mount root beats pattern", "", "mount", ""},
+		{"Work/Team/Dev", "mount", "", "mount", "Dev"},
+		{"Work/Team/Ops/A", "longest mount", "", "deep mount", "A"},
+		{"Work/Teams", "not beneath mount", "", "glob", "Work/Teams"},
+		{"Work/Dev", "first pattern", "", "glob", "Work/Dev"},
+		{"Work/Dev/A", "second pattern", "", "regexp", "Work/Dev/A"},
+		{"Work/Dev", "db-prod pattern beats prefix", "ssh", "glob", "Work/Dev"},
+		{"Home", "db-1", "", "prefix", "Home"},
+		{"Home", "db-prod-1", "", "long prefix", "Home"},
+		{"Home", "db-prod-2", "ssh", "long prefix", "Home"},
+		{"Home", "key", "ssh", "type", "Home"},
+		{"Home", "other", "", "default", "Home"},
+		{"Homework/Prod", "other-2", "", "default", "Homework/Prod"},
+	}
+
+	ctx := context.Background()
+	for _, tc := range tests {
+		sec, err := r.SetSecret(ctx, secrets.NewSecret(tc.name, "user", "secret",
+			secrets.WithLocation(tc.location),
+			secrets.WithType(tc.typ)))
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.location, sec.Location(), tc.name)
+
+		for name, k := range kprs {
+			secs, err := k.GetSecretsByName(ctx, tc.name)
+			require.NoError(t, err)
+
+			if name != tc.keeper {
+				assert.Empty(t, secs, "%s is not in the %s keeper", tc.name, name)
+				continue
+			}
+
+			if assert.Len(t, secs, 1, "%s is in the %s keeper", tc.name, name) {
+				assert.Equal(t, tc.childLocation, secs[0].Location(), tc.name)
+			}
+		}
+
+		got, err := r.GetSecretsByName(ctx, tc.name)
+		require.NoError(t, err)
+		if assert.Len(t, got, 1, tc.name) {
+			assert.Equal(t, sec.ID(), got[0].ID(), tc.name)
+			assert.Equal(t, tc.location, got[0].Location(), tc.name)
+		}
+	}
+}
+
+func TestRouter_AddRoute(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		route router.Route
+		err   string
+	}{
+		{"locations", router.Route{Locations: []string{"Work", "Home/*"}}, ""},
+		{"bad pattern", router.Route{Locations: []string{"Work/[a"}}, `bad location pattern "Work/[a"`},
+		{"mount", router.Route{Mount: "Work/"}, ""},
+		{"mount pattern", router.Route{Mount: "Work/*"}, "may not be a pattern"},
+		{"mount and more", router.Route{Mount: "Work", Types: []string{"ssh"}}, "may not also have"},
+	}
+
+	for _, tc := range tests {
+		def, err := memory.New()
+		require.NoError(t, err)
+
+		err = router.NewRouter(def).AddRoute(def, tc.route)
+		if tc.err == "" {
+			assert.NoError(t, err, tc.name)
+		} else {
+			assert.ErrorContains(t, err, tc.err, tc.name)
+		}
+	}
+}
+
+func TestCheckRoutes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		routes []router.Route
+		errs   []string
+	}{
+		{
+			name: "disjoint",
+			routes: []router.Route{
+				{Locations: []string{"Work", "Work/*"}},
+				{Locations: []string{"Home", "Home/**", "/^Play$/"}},
+				{Mount: "Work/Team"},
+				{NamePrefixes: []string{"db"}, Types: []string{"ssh"}},
+				{NamePrefixes: []string{"db-prod"}, Types: []string{"gpg"}},
+			},
+		},
+		{
+			name: "same location",
+			routes: []router.Route{
+				{Locations: []string{"Work"}},
+				{Locations: []string{"Home", "Work"}},
+			},
+			errs: []string{`routes 1 and 2 both use the location "Work"`},
+		},
+		{
+			name: "same location in one route",
+			routes: []router.Route{
+				{Locations: []string{"Work", "Work"}},
+			},
+		},
+		{
+			name: "same pattern",
+			routes: []router.Route{
+				{Locations: []string{"Work/*"}},
+				{Locations: []string{"Work/*"}},
+			},
+			errs: []string{`routes 1 and 2 both use the location pattern "Work/*"`},
+		},
+		{
+			name: "same mount",
+			routes: []router.Route{
+				{Mount: "Work"},
+				{Mount: "Work/"},
+			},
+			errs: []string{`routes 1 and 2 both use the mount "Work"`},
+		},
+		{
+			name: "same name prefix and type",
+			routes: []router.Route{
+				{NamePrefixes: []string{"db"}},
+				{Types: []string{"ssh"}},
+				{NamePrefixes: []string{"db"}, Types: []string{"ssh"}},
+			},
+			errs: []string{
+				`routes 1 and 3 both use the name prefix "db"`,
+				`routes 2 and 3 both use the type "ssh"`,
+			},
+		},
+		{
+			name: "bad routes",
+			routes: []router.Route{
+				{Locations: []string{"Work/[a"}},
+				{NamePrefixes: []string{""}},
+				{Mount: "Work/*"},
+			},
+			errs: []string{
+				`route 1 has a bad location pattern "Work/[a"`,
+				`route 2 has an empty name prefix`,
+				`route 3: mount "Work/*" may not be a pattern`,
+			},
+		},
+		{
+			name: "pattern matches pattern",
+			routes: []router.Route{
+				{Locations: []string{"Work/**"}},
+				{Locations: []string{"Work/*"}},
+			},
+			errs: []string{`route 1 location pattern "Work/**" overlaps route 2 location pattern "Work/*"`},
+		},
+		{
+			name: "crossing globs",
+			routes: []router.Route{
+				{Locations: []string{"Work/*"}},
+				{Locations: []string{"*/Prod"}},
+			},
+			errs: []string{`route 1 location pattern "Work/*" overlaps route 2 location pattern "*/Prod"`},
+		},
+		{
+			name: "glob and regexp",
+			routes: []router.Route{
+				{Locations: []string{"{Home,Work}/?ev"}},
+				{Locations: []string{"/Dev$/"}},
+			},
+			errs: []string{`route 1 location pattern "{Home,Work}/?ev" overlaps route 2 location pattern "/Dev$/"`},
+		},
+		{
+			name: "anchored regexps",
+			routes: []router.Route{
+				{Locations: []string{"/^Work/"}},
+				{Locations: []string{"/^Home/", "/(?i)^work$/"}},
+			},
+			errs: []string{`route 1 location pattern "/^Work/" overlaps route 2 location pattern "/(?i)^work$/"`},
+		},
+		{
+			name: "disjoint patterns",
+			routes: []router.Route{
+				{Locations: []string{"Work/*", "[a-m]*"}},
+				{Locations: []string{"Work/*/*", "[!A-Z]*/**", "/^Home$/"}},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		err := router.CheckRoutes(tc.routes...)
+		if len(tc.errs) == 0 {
+			assert.NoError(t, err, tc.name)
+			continue
+		}
+
+		if assert.Error(t, err, tc.name) {
+			for _, msg := range tc.errs {
+				assert.Contains(t, err.Error(), msg, tc.name)
+			}
+			assert.Len(t, strings.Split(err.Error(), "\n"), len(tc.errs), tc.name)
+		}
+	}
+}
+
+func TestRouter_Mount(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	r, kprs := routed(t, []namedRoute{
+		{"team", router.Route{Mount: "Work/Team"}},
+	})
+	def, team := kprs["default"], kprs["team"]
+
+	// secrets already in the keeper appear beneath the mount
+	_, err := team.SetSecret(ctx, secrets.NewSecret("dev", "user", "dev",
+		secrets.WithLocation("Dev")))
+	require.NoError(t, err)
+
+	root, err := r.SetSecret(ctx, secrets.NewSecret("root", "user", "root",
+		secrets.WithLocation("Work/Team")))
+	require.NoError(t, err)
+	assert.Equal(t, "Work/Team", root.Location())
+
+	ops, err := r.SetSecret(ctx, secrets.NewSecret("ops", "user", "ops",
+		secrets.WithLocation("Work/Team/Ops")))
+	require.NoError(t, err)
+	assert.Equal(t, "Work/Team/Ops", ops.Location())
+
+	home, err := r.SetSecret(ctx, secrets.NewSecret("home", "user", "home",
+		secrets.WithLocation("Home")))
+	require.NoError(t, err)
+
+	// childLocation returns the location of the named secret in the keeper
+	childLocation := func(k *memory.Memory, name string) []string {
+		secs, err := k.GetSecretsByName(ctx, name)
+		require.NoError(t, err)
+
+		locs := make([]string, len(secs))
+		for i, sec := range secs {
+			locs[i] = sec.Location()
+		}
+		return locs
+	}
+
+	assert.Equal(t, []string{""}, childLocation(team, "root"))
+	assert.Equal(t, []string{"Ops"}, childLocation(team, "ops"))
+	assert.Equal(t, []string{"Home"}, childLocation(def, "home"))
+
+	locs, err := r.ListLocations(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t,
+		[]string{"Home", "Work/Team", "Work/Team/Dev", "Work/Team/Ops"}, locs)
+
+	listed := func(loc string) []string {
+		ids, err := r.ListSecrets(ctx, loc)
+		require.NoError(t, err)
+
+		names := make([]string, len(ids))
+		for i, id := range ids {
+			sec, err := r.GetSecret(ctx, id)
+			require.NoError(t, err)
+			assert.Equal(t, loc, sec.Location())
+			names[i] = sec.Name()
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"root"}, listed("Work/Team"))
+	assert.Equal(t, []string{"ops"}, listed("Work/Team/Ops"))
+	assert.Equal(t, []string{"dev"}, listed("Work/Team/Dev"))
+	assert.Equal(t, []string{"home"}, listed("Home"))
+	assert.Empty(t, listed("Work/Teams"))
+
+	got, err := r.GetSecret(ctx, root.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "root", got.Name())
+	assert.Equal(t, "Work/Team", got.Location())
+
+	secs, err := r.GetSecretsByName(ctx, "dev")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, "Work/Team/Dev", secs[0].Location())
+
+	// updating a secret at the mount root leaves it at the root
+	updated, err := r.SetSecret(ctx, secrets.NewSingleFromSecret(root,
+		secrets.WithPassword("new root")))
+	require.NoError(t, err)
+	assert.Equal(t, root.ID(), updated.ID())
+	assert.Equal(t, "Work/Team", updated.Location())
+	assert.Equal(t, []string{""}, childLocation(team, "root"))
+
+	_, err = r.SetSecret(ctx, secrets.NewSingleFromSecret(root,
+		secrets.WithLocation("Work/Team/Ops")))
+	assert.Error(t, err)
+
+	// moving within the mount, to the root, out of it, and into it
+	moved, err := r.MoveSecret(ctx, ops.ID(), "Work/Team")
+	require.NoError(t, err)
+	assert.Equal(t, "Work/Team", moved.Location())
+	assert.Equal(t, []string{""}, childLocation(team, "ops"))
+
+	moved, err = r.MoveSecret(ctx, moved.ID(), "Work/Team/Dev/Deep")
+	require.NoError(t, err)
+	assert.Equal(t, "Work/Team/Dev/Deep", moved.Location())
+	assert.Equal(t, []string{"Dev/Deep"}, childLocation(team, "ops"))
+
+	moved, err = r.MoveSecret(ctx, moved.ID(), "Home")
+	require.NoError(t, err)
+	assert.Equal(t, "Home", moved.Location())
+	assert.Empty(t, childLocation(team, "ops"))
+	assert.Equal(t, []string{"Home"}, childLocation(def, "ops"))
+
+	moved, err = r.MoveSecret(ctx, home.ID(), "Work/Team")
+	require.NoError(t, err)
+	assert.Equal(t, "Work/Team", moved.Location())
+	assert.Empty(t, childLocation(def, "home"))
+	assert.Equal(t, []string{""}, childLocation(team, "home"))
+
+	copied, err := r.CopySecret(ctx, moved.ID(), "Work/Team/Ops")
+	require.NoError(t, err)
+	assert.Equal(t, "Work/Team/Ops", copied.Location())
+	assert.ElementsMatch(t, []string{"", "Ops"}, childLocation(team, "home"))
+
+	assert.ElementsMatch(t, []string{"root", "home"}, listed("Work/Team"))
+	assert.Equal(t, []string{"home"}, listed("Work/Team/Ops"))
+
+	require.NoError(t, r.DeleteSecret(ctx, moved.ID()))
+	assert.Equal(t, []string{"Ops"}, childLocation(team, "home"))
+}
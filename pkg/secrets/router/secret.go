@@ -7,10 +7,12 @@ import (
 )
 
 // Secret is a special wrapper around secrets returned from Router that manages
-// the ID.
+// the ID and the location, which differs from that in the wrapped keeper when
+// the keeper is mounted.
 type Secret struct {
 	secrets.Secret
-	id string
+	id       string
+	location string
 }
 
 var _ secrets.Secret = &Secret{}
@@ -20,11 +22,13 @@ func makeId(keeperId, secretId string) string {
 	return strings.Join([]string{keeperId, secretId}, ":")
 }
 
-// newSecret creates a new secret combined with the given keeper ID.
-func newSecret(keeperId string, secret secrets.Secret) *Secret {
+// newSecret creates a new secret combined with the given keeper ID and
+// presented at the given location.
+func newSecret(keeperId, location string, secret secrets.Secret) *Secret {
 	return &Secret{
-		Secret: secret,
-		id:     makeId(keeperId, secret.ID()),
+		Secret:   secret,
+		id:       makeId(keeperId, secret.ID()),
+		location: location,
 	}
 }
 
@@ -32,3 +36,8 @@ func newSecret(keeperId string, secret secrets.Secret) *Secret {
 func (s *Secret) ID() string {
 	return s.id
 }
+
+// Location returns the location of the secret as presented by the router.
+func (s *Secret) Location() string {
+	return s.location
+}
@@ -49,6 +49,13 @@ func WithField(name, value string) SingleOption {
 	})
 }
 
+// WithAttachment sets an attachment on the secret.
+func WithAttachment(name string, data []byte) SingleOption {
+	return option(func(s *Single) {
+		s.SetAttachment(name, data)
+	})
+}
+
 // WithID sets the ID of the secret, which is useful when copying a secret using
 // NewSingleFromSecret or when initializing a secret with a known ID using
 // NewSecret.
@@ -95,6 +102,8 @@ type Single struct {
 	lastModified time.Time // the time the secret was last modified
 	url          *url.URL  // the URL associated with the secret
 	location     string    // the location/group the secret is in
+
+	attachments map[string][]byte // binary attachments associated with the secret
 }
 
 // NewSecret creates a secret from the given settings.
@@ -114,8 +123,14 @@ func NewSecret(name, username, password string, opts ...SingleOption) *Single {
 }
 
 // NewSingleFromSecret creates a *Single from the given secret with the
-// requested modifications applied.
+// requested modifications applied. The fields and attachments are copied, so
+// changing the new secret does not change the original.
 func NewSingleFromSecret(s Secret, opts ...SingleOption) *Single {
+	fields := make(map[string]string, len(s.Fields()))
+	for k, v := range s.Fields() {
+		fields[k] = v
+	}
+
 	sec := &Single{
 		id:       s.ID(),
 		name:     s.Name(),
@@ -123,13 +138,19 @@ func NewSingleFromSecret(s Secret, opts ...SingleOption) *Single {
 		password: s.Password(),
 
 		typ:    s.Type(),
-		fields: s.Fields(),
+		fields: fields,
 
 		lastModified: s.LastModified(),
 		url:          s.Url(),
 		location:     s.Location(),
 	}
 
+	for _, name := range Attachments(s) {
+		if data, hasAttachment := GetAttachment(s, name); hasAttachment {
+			sec.SetAttachment(name, data)
+		}
+	}
+
 	for _, opt := range opts {
 		opt.apply(sec)
 	}
@@ -243,3 +264,29 @@ func (s *Single) DeleteField(name string) {
 	}
 	delete(s.fields, name)
 }
+
+// Attachments returns the names of the attachments of the secret in sorted
+// order.
+func (s *Single) Attachments() []string {
+	return sortedKeys(s.attachments)
+}
+
+// GetAttachment returns the content of the named attachment.
+func (s *Single) GetAttachment(name string) ([]byte, bool) {
+	data, hasAttachment := s.attachments[name]
+	return data, hasAttachment
+}
+
+// SetAttachment adds or replaces the named attachment. This works safely
+// whether the attachments have been initialized or not.
+func (s *Single) SetAttachment(name string, data []byte) {
+	if s.attachments == nil {
+		s.attachments = map[string][]byte{}
+	}
+	s.attachments[name] = data
+}
+
+// DeleteAttachment removes the named attachment.
+func (s *Single) DeleteAttachment(name string) {
+	delete(s.attachments, name)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/zostay/go-std/slices"
@@ -23,6 +24,24 @@ const ConfigType = "seq"
 type Config struct {
 	// Keepers is the list of keepers to use for the seq keeper.
 	Keepers []string `mapstructure:"keepers" yaml:"keepers"`
+
+	// Parallel causes reads to be made from every keeper at once.
+	Parallel bool `mapstructure:"parallel" yaml:"parallel,omitempty"`
+
+	// Timeout limits the time each keeper is given to answer a read.
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout,omitempty"`
+
+	// TolerateErrors causes reads to return partial results with warnings
+	// when some keepers fail.
+	TolerateErrors bool `mapstructure:"tolerate_errors" yaml:"tolerate_errors,omitempty"`
+
+	// Dedupe causes lookups by name to keep only the newest of the secrets
+	// with the same name, username, and location.
+	Dedupe bool `mapstructure:"dedupe" yaml:"dedupe,omitempty"`
+
+	// Get selects which secret to return when more than one keeper has it:
+	// first or newest. The default is first.
+	Get string `mapstructure:"get" yaml:"get,omitempty"`
 }
 
 // Print prints the configuration for the seq secret keeper.
@@ -36,6 +55,19 @@ func Print(c any, w io.Writer) error {
 	for _, k := range cfg.Keepers {
 		fmt.Fprintln(w, "-", k)
 	}
+
+	getMode := cfg.Get
+	if getMode == "" {
+		getMode = GetFirst.String()
+	}
+
+	fmt.Fprintln(w, "parallel:", cfg.Parallel)
+	if cfg.Timeout > 0 {
+		fmt.Fprintln(w, "timeout:", cfg.Timeout)
+	}
+	fmt.Fprintln(w, "tolerate errors:", cfg.TolerateErrors)
+	fmt.Fprintln(w, "dedupe:", cfg.Dedupe)
+	fmt.Fprintln(w, "get:", getMode)
 	return nil
 }
 
@@ -54,7 +86,27 @@ func Builder(ctx context.Context, c any) (secrets.Keeper, error) {
 			return nil, fmt.Errorf("unable to build the secret keeper named %q for the seq keeper: %w", k, err)
 		}
 	}
-	return NewSeq(keepers...)
+
+	getMode, err := ParseGetMode(cfg.Get)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []Option{WithGetMode(getMode)}
+	if cfg.Parallel {
+		opts = append(opts, WithParallel())
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, WithTimeout(cfg.Timeout))
+	}
+	if cfg.TolerateErrors {
+		opts = append(opts, WithTolerateErrors(nil))
+	}
+	if cfg.Dedupe {
+		opts = append(opts, WithDeduplication())
+	}
+
+	return New(keepers, opts...)
 }
 
 // Validator validates the seq keeper configuration.
@@ -82,20 +134,57 @@ func Validator(ctx context.Context, c any) error {
 		}
 	}
 
+	if _, err := ParseGetMode(cfg.Get); err != nil {
+		errs.Append(err)
+	}
+
+	if cfg.Timeout < 0 {
+		errs.Append(fmt.Errorf("seq timeout must not be negative"))
+	}
+
 	return errs.Return()
 }
 
+// keeperList returns the configured list of keepers, which is a []any when
+// read from the configuration file.
+func keeperList(v any) []any {
+	switch keepers := v.(type) {
+	case []any:
+		return keepers
+	case []string:
+		list := make([]any, len(keepers))
+		for i, k := range keepers {
+			list[i] = k
+		}
+		return list
+	default:
+		return []any{}
+	}
+}
+
 func init() {
 	var (
 		modKeepers                   []string
 		appendKeepers, deleteKeepers bool
+
+		parallel, tolerateErrors, dedupe bool
+		timeout                          time.Duration
+		getMode                          string
+
+		flagSet *pflag.FlagSet
 	)
 	cmd := plugin.CmdConfig{
 		Short: "Configure a sequential secret keeper",
 		FlagInit: func(flags *pflag.FlagSet) error {
+			flagSet = flags
 			flags.StringSliceVarP(&modKeepers, "keepers", "k", []string{}, "The list of keepers to make changes with")
 			flags.BoolVarP(&appendKeepers, "append", "a", false, "Append the named keepers to the end of the sequence (default is to replace)")
 			flags.BoolVarP(&deleteKeepers, "delete", "d", false, "Remove the named keepers from the sequence")
+			flags.BoolVar(&parallel, "parallel", false, "Read from every keeper at once")
+			flags.DurationVar(&timeout, "timeout", 0, "The time each keeper is given to answer a read (0 is no limit)")
+			flags.BoolVar(&tolerateErrors, "tolerate-errors", false, "Return partial results with warnings when some keepers fail")
+			flags.BoolVar(&dedupe, "dedupe", false, "Keep only the newest secret with the same name, username, and location")
+			flags.StringVar(&getMode, "get", "", "Which secret to get when several keepers have it: first or newest")
 			return nil
 		},
 		Run: func(keeperName string, fields map[string]any) (config.KeeperConfig, error) {
@@ -112,8 +201,29 @@ func init() {
 				return nil, errors.New("cannot append and delete at the same time")
 			}
 
+			// only the settings given are changed
+			if flagSet.Changed("parallel") {
+				kc["parallel"] = parallel
+			}
+			if flagSet.Changed("tolerate-errors") {
+				kc["tolerate_errors"] = tolerateErrors
+			}
+			if flagSet.Changed("dedupe") {
+				kc["dedupe"] = dedupe
+			}
+			if flagSet.Changed("timeout") {
+				kc["timeout"] = timeout
+			}
+			if flagSet.Changed("get") {
+				kc["get"] = getMode
+			}
+
+			if !flagSet.Changed("keepers") {
+				return kc, nil
+			}
+
 			if appendKeepers {
-				keepers := kc["keepers"].([]any)
+				keepers := keeperList(kc["keepers"])
 				for _, k := range modKeepers {
 					keepers = append(keepers, k)
 				}
@@ -122,7 +232,7 @@ func init() {
 			}
 
 			if deleteKeepers {
-				keepers := kc["keepers"].([]any)
+				keepers := keeperList(kc["keepers"])
 				for _, k := range modKeepers {
 					for {
 						ix := slices.FirstIndex(keepers, func(v any) bool {
@@ -135,6 +245,7 @@ func init() {
 					}
 				}
 				kc["keepers"] = keepers
+				return kc, nil
 			}
 
 			kc["keepers"] = modKeepers
@@ -3,42 +3,250 @@ package seq
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/zostay/go-std/set"
 
 	"github.com/zostay/ghost/pkg/secrets"
 )
 
+// GetMode selects how GetSecret chooses between keepers holding the same ID.
+type GetMode int
+
+const (
+	// GetFirst returns the secret from the first keeper that has it.
+	GetFirst GetMode = iota
+
+	// GetNewest returns the most recently modified secret among the keepers
+	// that have it.
+	GetNewest
+)
+
+// String returns the name of the mode as used in the configuration.
+func (m GetMode) String() string {
+	switch m {
+	case GetFirst:
+		return "first"
+	case GetNewest:
+		return "newest"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseGetMode parses the name of a get mode. An empty string is GetFirst.
+func ParseGetMode(name string) (GetMode, error) {
+	switch name {
+	case "", "first":
+		return GetFirst, nil
+	case "newest":
+		return GetNewest, nil
+	default:
+		return GetFirst, fmt.Errorf("unknown get mode %q", name)
+	}
+}
+
 // Seq is a Keeper that gets secrets from the first Keeper that returns it.
 type Seq struct {
 	keepers []secrets.Keeper
+
+	parallel bool
+	timeout  time.Duration
+	tolerate bool
+	logger   *log.Logger
+	dedupe   bool
+	getMode  GetMode
+}
+
+var (
+	_ secrets.Keeper  = &Seq{}
+	_ secrets.Wrapper = &Seq{}
+)
+
+// Option is an option for a Seq.
+type Option func(*Seq)
+
+// WithParallel causes reads to be made from every Keeper at once, rather than
+// one after another.
+func WithParallel() Option {
+	return func(s *Seq) {
+		s.parallel = true
+	}
+}
+
+// WithTimeout limits the time each Keeper is given to answer a read. A Keeper
+// that takes longer fails with context.DeadlineExceeded.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Seq) {
+		s.timeout = timeout
+	}
+}
+
+// WithTolerateErrors causes reads to return the results of the Keepers that
+// answer when other Keepers fail. The errors of the failing Keepers are
+// written to the logger as warnings. If every Keeper fails, the errors are
+// returned. If logger is nil, the standard logger is used.
+func WithTolerateErrors(logger *log.Logger) Option {
+	return func(s *Seq) {
+		if logger == nil {
+			logger = log.Default()
+		}
+		s.tolerate = true
+		s.logger = logger
+	}
 }
 
-var _ secrets.Keeper = &Seq{}
+// WithDeduplication causes GetSecretsByName to return only the most recently
+// modified of the secrets with the same name, username, and location.
+func WithDeduplication() Option {
+	return func(s *Seq) {
+		s.dedupe = true
+	}
+}
+
+// WithGetMode sets how GetSecret chooses between Keepers. The default is
+// GetFirst.
+func WithGetMode(mode GetMode) Option {
+	return func(s *Seq) {
+		s.getMode = mode
+	}
+}
 
 // NewSeq returns a new sequential keeper with the given list of Keepers.
 func NewSeq(keepers ...secrets.Keeper) (*Seq, error) {
+	return New(keepers)
+}
+
+// New returns a new sequential keeper with the given list of Keepers and
+// options.
+func New(keepers []secrets.Keeper, opts ...Option) (*Seq, error) {
 	if len(keepers) == 0 {
 		return nil, errors.New("no keepers given")
 	}
 
-	return &Seq{
+	s := &Seq{
 		keepers: keepers,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
+
+// Unwrap returns the Keepers in the sequence.
+func (s *Seq) Unwrap() []secrets.Keeper {
+	return s.keepers
+}
+
+// keeperContext returns the context for a single Keeper, limited by the
+// timeout, if set.
+func (s *Seq) keeperContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(ctx, s.timeout)
+	}
+	return ctx, func() {}
+}
+
+// each runs the read against each Keeper, at once or in turn, and returns the
+// error from each in the order of the Keepers. When run in turn, it stops after
+// the first error unless errors are tolerated.
+func (s *Seq) each(
+	ctx context.Context,
+	run func(ctx context.Context, i int, k secrets.Keeper) error,
+) []error {
+	errs := make([]error, len(s.keepers))
+
+	runOne := func(i int, k secrets.Keeper) {
+		kctx, cancel := s.keeperContext(ctx)
+		defer cancel()
+
+		errs[i] = run(kctx, i, k)
+	}
+
+	if !s.parallel {
+		for i, k := range s.keepers {
+			runOne(i, k)
+			if errs[i] != nil && !s.tolerate {
+				break
+			}
+		}
+		return errs
+	}
+
+	var wg sync.WaitGroup
+	for i, k := range s.keepers {
+		wg.Add(1)
+		go func(i int, k secrets.Keeper) {
+			defer wg.Done()
+			runOne(i, k)
+		}(i, k)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// check returns the error to report for a read. Without tolerated errors, this
+// is the first error. Otherwise, each error is logged as a warning and the
+// errors are returned only if every Keeper failed.
+func (s *Seq) check(errs []error) error {
+	failed := 0
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		if !s.tolerate {
+			return err
+		}
+
+		failed++
+	}
+
+	if failed == 0 {
+		return nil
+	}
+
+	if failed == len(errs) {
+		return errors.Join(errs...)
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			s.logger.Printf("WARNING: seq keeper %d failed: %v", i, err)
+		}
+	}
+
+	return nil
 }
 
 // ListLocations returns the list of locations from all Keepers.
 func (s *Seq) ListLocations(ctx context.Context) ([]string, error) {
-	locations := set.New[string]()
-	for _, k := range s.keepers {
+	var (
+		lock      sync.Mutex
+		locations = set.New[string]()
+	)
+	errs := s.each(ctx, func(ctx context.Context, _ int, k secrets.Keeper) error {
 		locs, err := k.ListLocations(ctx)
 		if err != nil {
-			return nil, err
-		}
-		for _, loc := range locs {
-			locations.Insert(loc)
+			return err
 		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		locations.Insert(locs...)
+		return nil
+	})
+
+	if err := s.check(errs); err != nil {
+		return nil, err
 	}
+
 	return locations.Keys(), nil
 }
 
@@ -48,50 +256,177 @@ func (s *Seq) ListSecrets(
 	ctx context.Context,
 	location string,
 ) ([]string, error) {
-	secSet := set.New[string]()
-	for _, k := range s.keepers {
+	var (
+		lock   sync.Mutex
+		secSet = set.New[string]()
+	)
+	errs := s.each(ctx, func(ctx context.Context, _ int, k secrets.Keeper) error {
 		secs, err := k.ListSecrets(ctx, location)
 		if err != nil {
-			return nil, err
-		}
-		for _, sec := range secs {
-			secSet.Insert(sec)
+			return err
 		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		secSet.Insert(secs...)
+		return nil
+	})
+
+	if err := s.check(errs); err != nil {
+		return nil, err
 	}
+
 	return secSet.Keys(), nil
 }
 
-// GetSecret returns the secret from the first Keeper that returns it.
+// GetSecret returns the secret from the first Keeper that returns it. If the
+// GetNewest mode is set, it returns the most recently modified secret of all
+// the Keepers that return it instead.
 func (s *Seq) GetSecret(
 	ctx context.Context,
 	id string,
 ) (secrets.Secret, error) {
-	for _, k := range s.keepers {
+	if !s.parallel && s.getMode == GetFirst {
+		return s.getFirst(ctx, id)
+	}
+
+	found := make([]secrets.Secret, len(s.keepers))
+	errs := s.each(ctx, func(ctx context.Context, i int, k secrets.Keeper) error {
 		sec, err := k.GetSecret(ctx, id)
-		if err != nil {
-			if !errors.Is(err, secrets.ErrNotFound) {
-				return nil, err
+		if errors.Is(err, secrets.ErrNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		found[i] = sec
+		return nil
+	})
+
+	if s.getMode == GetFirst {
+		// as if asked in turn, the earliest keeper to answer wins
+		for i, sec := range found {
+			if sec != nil {
+				return sec, nil
 			}
+
+			if errs[i] != nil && !s.tolerate {
+				return nil, errs[i]
+			}
+		}
+	}
+
+	if err := s.check(errs); err != nil {
+		return nil, err
+	}
+
+	var pick secrets.Secret
+	for _, sec := range found {
+		if sec == nil {
 			continue
 		}
-		return sec, nil
+
+		if pick == nil || sec.LastModified().After(pick.LastModified()) {
+			pick = sec
+		}
 	}
+
+	if pick == nil {
+		return nil, secrets.ErrNotFound
+	}
+
+	return pick, nil
+}
+
+// getFirst returns the secret from the first Keeper that returns it, asking
+// each Keeper in turn.
+func (s *Seq) getFirst(
+	ctx context.Context,
+	id string,
+) (secrets.Secret, error) {
+	errs := make([]error, 0, len(s.keepers))
+	for _, k := range s.keepers {
+		kctx, cancel := s.keeperContext(ctx)
+		sec, err := k.GetSecret(kctx, id)
+		cancel()
+
+		if err == nil {
+			return sec, nil
+		}
+
+		if errors.Is(err, secrets.ErrNotFound) {
+			continue
+		}
+
+		if !s.tolerate {
+			return nil, err
+		}
+
+		errs = append(errs, err)
+	}
+
+	if len(errs) == len(s.keepers) {
+		return nil, errors.Join(errs...)
+	}
+
+	for _, err := range errs {
+		s.logger.Printf("WARNING: seq keeper failed: %v", err)
+	}
+
 	return nil, secrets.ErrNotFound
 }
 
+// secretKey identifies duplicate secrets.
+type secretKey struct {
+	name, username, location string
+}
+
 // GetSecretsByName returns all secrets with the given name from all Keepers.
+// If deduplication is set, only the most recently modified secret with each
+// name, username, and location is returned.
 func (s *Seq) GetSecretsByName(
 	ctx context.Context,
 	name string,
 ) ([]secrets.Secret, error) {
-	allSecs := make([]secrets.Secret, 0, 1)
-	for _, k := range s.keepers {
+	found := make([][]secrets.Secret, len(s.keepers))
+	errs := s.each(ctx, func(ctx context.Context, i int, k secrets.Keeper) error {
 		secs, err := k.GetSecretsByName(ctx, name)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		found[i] = secs
+		return nil
+	})
+
+	if err := s.check(errs); err != nil {
+		return nil, err
+	}
+
+	allSecs := make([]secrets.Secret, 0, 1)
+	if !s.dedupe {
+		for _, secs := range found {
+			allSecs = append(allSecs, secs...)
 		}
-		allSecs = append(allSecs, secs...)
+		return allSecs, nil
 	}
+
+	newest := map[secretKey]int{}
+	for _, secs := range found {
+		for _, sec := range secs {
+			key := secretKey{sec.Name(), sec.Username(), sec.Location()}
+			if ix, isSeen := newest[key]; isSeen {
+				if sec.LastModified().After(allSecs[ix].LastModified()) {
+					allSecs[ix] = sec
+				}
+				continue
+			}
+
+			newest[key] = len(allSecs)
+			allSecs = append(allSecs, sec)
+		}
+	}
+
 	return allSecs, nil
 }
 
@@ -0,0 +1,305 @@
+package seq_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/keepertest"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+	"github.com/zostay/ghost/pkg/secrets/seq"
+)
+
+func TestSeq(t *testing.T) {
+	t.Parallel()
+
+	factory := func() (secrets.Keeper, error) {
+		m1, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+
+		m2, err := memory.New()
+		if err != nil {
+			return nil, err
+		}
+
+		return seq.NewSeq(m1, m2)
+	}
+
+	ts := keepertest.New(factory)
+	ts.Run(t)
+}
+
+var errDown = errors.New("keeper is down")
+
+// stubKeeper answers reads with its one secret, or fails, after a delay.
+type stubKeeper struct {
+	secrets.Keeper
+	sec   secrets.Secret
+	err   error
+	delay time.Duration
+
+	inFlight, maxInFlight *atomic.Int32
+}
+
+// newStub returns a stub keeper holding the secret, which may be nil.
+func newStub(t *testing.T, sec secrets.Secret) *stubKeeper {
+	t.Helper()
+
+	m, err := memory.New()
+	require.NoError(t, err)
+
+	return &stubKeeper{
+		Keeper:      m,
+		sec:         sec,
+		inFlight:    &atomic.Int32{},
+		maxInFlight: &atomic.Int32{},
+	}
+}
+
+// wait waits out the delay, recording how many reads are made at once.
+func (k *stubKeeper) wait(ctx context.Context) error {
+	n := k.inFlight.Add(1)
+	defer k.inFlight.Add(-1)
+
+	for {
+		mx := k.maxInFlight.Load()
+		if n <= mx || k.maxInFlight.CompareAndSwap(mx, n) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(k.delay):
+		return k.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (k *stubKeeper) ListLocations(ctx context.Context) ([]string, error) {
+	if err := k.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if k.sec == nil {
+		return []string{}, nil
+	}
+	return []string{k.sec.Location()}, nil
+}
+
+func (k *stubKeeper) GetSecret(ctx context.Context, id string) (secrets.Secret, error) {
+	if err := k.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if k.sec == nil || k.sec.ID() != id {
+		return nil, secrets.ErrNotFound
+	}
+	return k.sec, nil
+}
+
+func (k *stubKeeper) GetSecretsByName(ctx context.Context, name string) ([]secrets.Secret, error) {
+	if err := k.wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if k.sec == nil || k.sec.Name() != name {
+		return []secrets.Secret{}, nil
+	}
+	return []secrets.Secret{k.sec}, nil
+}
+
+// shared makes the stub keepers count the reads made at once together.
+func shared(kprs ...*stubKeeper) []secrets.Keeper {
+	out := make([]secrets.Keeper, len(kprs))
+	for i, k := range kprs {
+		k.inFlight = kprs[0].inFlight
+		k.maxInFlight = kprs[0].maxInFlight
+		out[i] = k
+	}
+	return out
+}
+
+// version returns a secret with the ID "shared" modified at the given hour.
+func version(password string, hour int) secrets.Secret {
+	return secrets.NewSecret("test", "user", password,
+		secrets.WithID("shared"),
+		secrets.WithLocation("Work"),
+		secrets.WithLastModified(time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC)))
+}
+
+func TestSeq_Parallel(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, parallel := range []bool{false, true} {
+		a, b, c := newStub(t, nil), newStub(t, version("old", 1)), newStub(t, version("new", 2))
+		a.delay, b.delay, c.delay = 50*time.Millisecond, 50*time.Millisecond, 50*time.Millisecond
+
+		var opts []seq.Option
+		if parallel {
+			opts = append(opts, seq.WithParallel())
+		}
+
+		s, err := seq.New(shared(a, b, c), opts...)
+		require.NoError(t, err)
+
+		locs, err := s.ListLocations(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Work"}, locs)
+
+		if parallel {
+			assert.Equal(t, int32(3), a.maxInFlight.Load())
+		} else {
+			assert.Equal(t, int32(1), a.maxInFlight.Load())
+		}
+
+		// the earliest keeper holding the secret wins, even when asked at once
+		sec, err := s.GetSecret(ctx, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, "old", sec.Password())
+	}
+}
+
+func TestSeq_GetNewest(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, parallel := range []bool{false, true} {
+		opts := []seq.Option{seq.WithGetMode(seq.GetNewest)}
+		if parallel {
+			opts = append(opts, seq.WithParallel())
+		}
+
+		s, err := seq.New(shared(
+			newStub(t, version("middle", 2)),
+			newStub(t, nil),
+			newStub(t, version("newest", 3)),
+			newStub(t, version("oldest", 1)),
+		), opts...)
+		require.NoError(t, err)
+
+		sec, err := s.GetSecret(ctx, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, "newest", sec.Password())
+
+		_, err = s.GetSecret(ctx, "missing")
+		assert.ErrorIs(t, err, secrets.ErrNotFound)
+	}
+}
+
+func TestSeq_TolerateErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, parallel := range []bool{false, true} {
+		down, up := newStub(t, nil), newStub(t, version("up", 1))
+		down.err = errDown
+
+		var parallelOpts []seq.Option
+		if parallel {
+			parallelOpts = append(parallelOpts, seq.WithParallel())
+		}
+
+		s, err := seq.New(shared(down, up), parallelOpts...)
+		require.NoError(t, err)
+
+		_, err = s.GetSecret(ctx, "shared")
+		assert.ErrorIs(t, err, errDown)
+
+		_, err = s.ListLocations(ctx)
+		assert.ErrorIs(t, err, errDown)
+
+		var buf bytes.Buffer
+		s, err = seq.New(shared(down, up),
+			append(parallelOpts, seq.WithTolerateErrors(log.New(&buf, "", 0)))...)
+		require.NoError(t, err)
+
+		sec, err := s.GetSecret(ctx, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, "up", sec.Password())
+
+		locs, err := s.ListLocations(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Work"}, locs)
+		assert.Contains(t, buf.String(), "WARNING")
+		assert.Contains(t, buf.String(), errDown.Error())
+
+		// when every keeper fails, the errors are returned
+		up.err = errDown
+		_, err = s.ListLocations(ctx)
+		assert.ErrorIs(t, err, errDown)
+
+		_, err = s.GetSecret(ctx, "shared")
+		assert.ErrorIs(t, err, errDown)
+	}
+}
+
+func TestSeq_Timeout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	for _, parallel := range []bool{false, true} {
+		slow, fast := newStub(t, version("slow", 2)), newStub(t, version("fast", 1))
+		slow.delay = time.Minute
+
+		opts := []seq.Option{seq.WithTimeout(10 * time.Millisecond)}
+		if parallel {
+			opts = append(opts, seq.WithParallel())
+		}
+
+		s, err := seq.New(shared(slow, fast), opts...)
+		require.NoError(t, err)
+
+		_, err = s.GetSecret(ctx, "shared")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		s, err = seq.New(shared(slow, fast),
+			append(opts, seq.WithTolerateErrors(log.New(&bytes.Buffer{}, "", 0)))...)
+		require.NoError(t, err)
+
+		sec, err := s.GetSecret(ctx, "shared")
+		require.NoError(t, err)
+		assert.Equal(t, "fast", sec.Password())
+	}
+}
+
+func TestSeq_Dedupe(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	other := secrets.NewSecret("test", "someone else", "other",
+		secrets.WithLocation("Work"))
+
+	kprs := shared(
+		newStub(t, version("old", 1)),
+		newStub(t, version("new", 2)),
+		newStub(t, other),
+	)
+
+	s, err := seq.New(kprs)
+	require.NoError(t, err)
+
+	secs, err := s.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	assert.Len(t, secs, 3)
+
+	s, err = seq.New(kprs, seq.WithDeduplication())
+	require.NoError(t, err)
+
+	secs, err = s.GetSecretsByName(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, secs, 2)
+	assert.Equal(t, "new", secs[0].Password())
+	assert.Equal(t, "other", secs[1].Password())
+}
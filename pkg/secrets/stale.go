@@ -0,0 +1,76 @@
+package secrets
+
+import "time"
+
+// Stale is implemented by secrets that may have been returned from an
+// out-of-date copy, such as an offline snapshot, because the keeper holding
+// the secret could not be reached.
+type Stale interface {
+	Secret
+
+	// StaleSince returns the time the copy was taken. It returns the zero time
+	// if the secret is not stale.
+	StaleSince() time.Time
+}
+
+// StaleSecret marks a secret as coming from an out-of-date copy.
+type StaleSecret struct {
+	Secret
+	since time.Time
+}
+
+var (
+	_ Stale           = &StaleSecret{}
+	_ WithAttachments = &StaleSecret{}
+)
+
+// NewStaleSecret marks the secret as coming from a copy taken at the given
+// time.
+func NewStaleSecret(sec Secret, since time.Time) *StaleSecret {
+	return &StaleSecret{
+		Secret: sec,
+		since:  since,
+	}
+}
+
+// StaleSince returns the time the copy was taken.
+func (s *StaleSecret) StaleSince() time.Time {
+	return s.since
+}
+
+// Attachments returns the names of the attachments of the secret.
+func (s *StaleSecret) Attachments() []string {
+	return Attachments(s.Secret)
+}
+
+// GetAttachment returns the content of the named attachment of the secret.
+func (s *StaleSecret) GetAttachment(name string) ([]byte, bool) {
+	return GetAttachment(s.Secret, name)
+}
+
+// SetAttachment adds or replaces the named attachment, if the secret carries
+// attachments.
+func (s *StaleSecret) SetAttachment(name string, data []byte) {
+	if wa, hasAttachments := s.Secret.(WithAttachments); hasAttachments {
+		wa.SetAttachment(name, data)
+	}
+}
+
+// DeleteAttachment removes the named attachment, if the secret carries
+// attachments.
+func (s *StaleSecret) DeleteAttachment(name string) {
+	if wa, hasAttachments := s.Secret.(WithAttachments); hasAttachments {
+		wa.DeleteAttachment(name)
+	}
+}
+
+// IsStale returns the time the copy of the secret was taken and true if the
+// secret came from an out-of-date copy. It returns false otherwise.
+func IsStale(sec Secret) (time.Time, bool) {
+	stale, isStale := sec.(Stale)
+	if !isStale || stale.StaleSince().IsZero() {
+		return time.Time{}, false
+	}
+
+	return stale.StaleSince(), true
+}
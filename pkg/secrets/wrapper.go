@@ -0,0 +1,40 @@
+package secrets
+
+import "errors"
+
+// ErrSkipWrapped may be returned by a Walk function to skip the keepers
+// wrapped by the current keeper.
+var ErrSkipWrapped = errors.New("skip wrapped keepers")
+
+// Wrapper is the interface for a secret keeper that wraps one or more other
+// secret keepers to provide additional services on top of them.
+type Wrapper interface {
+	// Unwrap returns the secret keepers wrapped by this one.
+	Unwrap() []Keeper
+}
+
+// Walk runs the given function for the given keeper and then for every keeper
+// it wraps, recursively, depth first. If the function returns ErrSkipWrapped,
+// the keepers wrapped by that keeper are skipped. Any other error stops the
+// walk and is returned.
+func Walk(kpr Keeper, run func(Keeper) error) error {
+	if err := run(kpr); err != nil {
+		if errors.Is(err, ErrSkipWrapped) {
+			return nil
+		}
+		return err
+	}
+
+	w, isWrapper := kpr.(Wrapper)
+	if !isWrapper {
+		return nil
+	}
+
+	for _, k := range w.Unwrap() {
+		if err := Walk(k, run); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,208 @@
+package secretservice
+
+import (
+	"github.com/godbus/dbus/v5"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// secretsAt returns every secret at the location. The lock must be held.
+func (s *Server) secretsAt(loc string) ([]secrets.Secret, error) {
+	ids, err := s.kpr.ListSecrets(s.ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	secs := make([]secrets.Secret, 0, len(ids))
+	for _, id := range ids {
+		sec, err := s.kpr.GetSecret(s.ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		secs = append(secs, sec)
+	}
+
+	return secs, nil
+}
+
+// hasAttributes returns true if the secret has a field matching each of the
+// attributes.
+func hasAttributes(sec secrets.Secret, attrs map[string]string) bool {
+	fields := sec.Fields()
+	for k, v := range attrs {
+		if fv, hasField := fields[k]; !hasField || fv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// searchItems returns the items at the location having all the attributes. The
+// lock must be held.
+func (s *Server) searchItems(loc string, attrs map[string]string) ([]dbus.ObjectPath, error) {
+	secs, err := s.secretsAt(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []dbus.ObjectPath
+	for _, sec := range secs {
+		if hasAttributes(sec, attrs) {
+			found = append(found, itemPath(sec))
+		}
+	}
+
+	return found, nil
+}
+
+// collectionObject provides the org.freedesktop.Secret.Collection interface.
+type collectionObject struct {
+	s *Server
+}
+
+// Delete deletes the collection and every item in it.
+func (o *collectionObject) Delete(msg dbus.Message) (dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loc, derr := s.collection(msgPath(msg))
+	if derr != nil {
+		return noPrompt, derr
+	}
+
+	if s.locked {
+		return noPrompt, errIsLocked
+	}
+
+	secs, err := s.secretsAt(loc)
+	if err != nil {
+		return noPrompt, keeperError(err)
+	}
+
+	for _, sec := range secs {
+		if err := s.kpr.DeleteSecret(s.ctx, sec.ID()); err != nil {
+			return noPrompt, keeperError(err)
+		}
+	}
+
+	for alias, aloc := range s.aliases {
+		if aloc == loc && alias != "default" {
+			delete(s.aliases, alias)
+		}
+	}
+
+	s.emit(servicePath, serviceIface+".CollectionDeleted", collectionPath(loc))
+	return noPrompt, nil
+}
+
+// SearchItems returns the items in the collection with all the given
+// attributes.
+func (o *collectionObject) SearchItems(
+	msg dbus.Message,
+	attrs map[string]string,
+) ([]dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loc, derr := s.collection(msgPath(msg))
+	if derr != nil {
+		return nil, derr
+	}
+
+	found, err := s.searchItems(loc, attrs)
+	if err != nil {
+		return nil, keeperError(err)
+	}
+
+	return nonNil(found), nil
+}
+
+// CreateItem creates an item in the collection with the label and attributes
+// given in the properties. The attributes are kept as fields of the secret and
+// the user or username attribute is also kept as the username. If replace is
+// set and an item in the collection has the same attributes, that item is
+// changed instead.
+func (o *collectionObject) CreateItem(
+	msg dbus.Message,
+	sender dbus.Sender,
+	props map[string]dbus.Variant,
+	sec secret,
+	replace bool,
+) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	collPath := msgPath(msg)
+	loc, derr := s.collection(collPath)
+	if derr != nil {
+		return noPrompt, noPrompt, derr
+	}
+
+	if s.locked {
+		return noPrompt, noPrompt, errIsLocked
+	}
+
+	sess, derr := s.session(string(sender), sec.Session)
+	if derr != nil {
+		return noPrompt, noPrompt, derr
+	}
+
+	password, derr := sess.decode(sec)
+	if derr != nil {
+		return noPrompt, noPrompt, derr
+	}
+
+	label, _ := props[itemIface+".Label"].Value().(string)
+	attrs, _ := props[itemIface+".Attributes"].Value().(map[string]string)
+
+	var existing secrets.Secret
+	if replace {
+		secs, err := s.secretsAt(loc)
+		if err != nil {
+			return noPrompt, noPrompt, keeperError(err)
+		}
+
+		for _, sec := range secs {
+			if len(sec.Fields()) == len(attrs) && hasAttributes(sec, attrs) {
+				existing = sec
+				break
+			}
+		}
+	}
+
+	var item *secrets.Single
+	if existing != nil {
+		item = secrets.NewSingleFromSecret(existing)
+		item.SetPassword(password)
+		if label != "" {
+			item.SetName(label)
+		}
+	} else {
+		username := attrs["username"]
+		if username == "" {
+			username = attrs["user"]
+		}
+
+		item = secrets.NewSecret(label, username, password, secrets.WithLocation(loc))
+		for k, v := range attrs {
+			item.SetField(k, v)
+		}
+	}
+
+	saved, err := s.kpr.SetSecret(s.ctx, item)
+	if err != nil {
+		return noPrompt, noPrompt, keeperError(err)
+	}
+
+	path := itemPath(saved)
+	if existing != nil {
+		s.emit(collectionPath(loc), collectionIface+".ItemChanged", path)
+	} else {
+		s.emit(collectionPath(loc), collectionIface+".ItemCreated", path)
+	}
+
+	return path, noPrompt, nil
+}
@@ -0,0 +1,35 @@
+package secretservice
+
+import (
+	"errors"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+var (
+	errNoSession    = dbus.NewError("org.freedesktop.Secret.Error.NoSession", []any{"no such session"})
+	errNoSuchObject = dbus.NewError("org.freedesktop.Secret.Error.NoSuchObject", []any{"no such object"})
+	errIsLocked     = dbus.NewError("org.freedesktop.Secret.Error.IsLocked", []any{"the collection is locked"})
+	errBadPublicKey = errors.New("the public key is out of range")
+)
+
+// errInvalidArgs returns an error for a call with bad arguments.
+func errInvalidArgs(msg string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []any{msg})
+}
+
+// errNotSupported returns an error for a call asking for something that is not
+// supported.
+func errNotSupported(msg string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.NotSupported", []any{msg})
+}
+
+// keeperError returns the error for a failed call to the secret keeper.
+func keeperError(err error) *dbus.Error {
+	if errors.Is(err, secrets.ErrNotFound) {
+		return errNoSuchObject
+	}
+	return dbus.MakeFailedError(err)
+}
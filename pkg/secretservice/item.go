@@ -0,0 +1,111 @@
+package secretservice
+
+import (
+	"github.com/godbus/dbus/v5"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// itemObject provides the org.freedesktop.Secret.Item interface.
+type itemObject struct {
+	s *Server
+}
+
+// Delete deletes the item.
+func (o *itemObject) Delete(msg dbus.Message) (dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := msgPath(msg)
+	sec, derr := s.item(path)
+	if derr != nil {
+		return noPrompt, derr
+	}
+
+	if s.locked {
+		return noPrompt, errIsLocked
+	}
+
+	if err := s.kpr.DeleteSecret(s.ctx, sec.ID()); err != nil {
+		return noPrompt, keeperError(err)
+	}
+
+	s.emit(collectionPath(sec.Location()), collectionIface+".ItemDeleted", path)
+	return noPrompt, nil
+}
+
+// GetSecret returns the secret of the item, encoded for the session.
+func (o *itemObject) GetSecret(
+	msg dbus.Message,
+	sender dbus.Sender,
+	sessionPath dbus.ObjectPath,
+) (secret, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, derr := s.session(string(sender), sessionPath)
+	if derr != nil {
+		return secret{}, derr
+	}
+
+	sec, derr := s.item(msgPath(msg))
+	if derr != nil {
+		return secret{}, derr
+	}
+
+	if s.locked {
+		return secret{}, errIsLocked
+	}
+
+	return sess.encode(sessionPath, sec.Password())
+}
+
+// SetSecret changes the secret of the item.
+func (o *itemObject) SetSecret(
+	msg dbus.Message,
+	sender dbus.Sender,
+	value secret,
+) *dbus.Error {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, derr := s.session(string(sender), value.Session)
+	if derr != nil {
+		return derr
+	}
+
+	password, derr := sess.decode(value)
+	if derr != nil {
+		return derr
+	}
+
+	return s.changeItem(msgPath(msg), func(sec *secrets.Single) {
+		sec.SetPassword(password)
+	})
+}
+
+// changeItem applies the change to the secret of the item and saves it. The
+// lock must be held.
+func (s *Server) changeItem(path dbus.ObjectPath, change func(*secrets.Single)) *dbus.Error {
+	sec, derr := s.item(path)
+	if derr != nil {
+		return derr
+	}
+
+	if s.locked {
+		return errIsLocked
+	}
+
+	single := secrets.NewSingleFromSecret(sec)
+	change(single)
+
+	if _, err := s.kpr.SetSecret(s.ctx, single); err != nil {
+		return keeperError(err)
+	}
+
+	s.emit(collectionPath(sec.Location()), collectionIface+".ItemChanged", path)
+	return nil
+}
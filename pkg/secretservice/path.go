@@ -0,0 +1,144 @@
+package secretservice
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// msgPath returns the path of the object a method was called on.
+func msgPath(msg dbus.Message) dbus.ObjectPath {
+	path, _ := msg.Headers[dbus.FieldPath].Value().(dbus.ObjectPath)
+	return path
+}
+
+// escape encodes a location or secret ID as an element of an object path,
+// which may only hold ASCII letters, digits, and underscores. Every other byte
+// is written as an underscore followed by two hex digits. The empty string is
+// written as a lone underscore.
+func escape(s string) string {
+	if s == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+
+		b.WriteByte('_')
+		b.WriteString(hex.EncodeToString([]byte{c}))
+	}
+
+	return b.String()
+}
+
+// unescape decodes an element of an object path written by escape.
+func unescape(s string) (string, bool) {
+	if s == "_" {
+		return "", true
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		if i+3 > len(s) {
+			return "", false
+		}
+
+		c, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return "", false
+		}
+
+		b.Write(c)
+		i += 2
+	}
+
+	return b.String(), true
+}
+
+// collectionPath returns the path of the collection for the location.
+func collectionPath(loc string) dbus.ObjectPath {
+	return collectionPrefix + "/" + dbus.ObjectPath(escape(loc))
+}
+
+// itemPath returns the path of the item for the secret.
+func itemPath(sec secrets.Secret) dbus.ObjectPath {
+	return collectionPath(sec.Location()) + "/" + dbus.ObjectPath(escape(sec.ID()))
+}
+
+// parsePath splits the path of a collection or item into the location and, for
+// an item, the secret ID. A collection found through an alias is resolved to
+// its location. The lock must be held.
+func (s *Server) parsePath(path dbus.ObjectPath) (loc string, id string, isItem bool, ok bool) {
+	var elems []string
+	switch {
+	case strings.HasPrefix(string(path), string(collectionPrefix)+"/"):
+		elems = strings.Split(strings.TrimPrefix(string(path), string(collectionPrefix)+"/"), "/")
+		if loc, ok = unescape(elems[0]); !ok {
+			return "", "", false, false
+		}
+	case strings.HasPrefix(string(path), string(aliasPrefix)+"/"):
+		elems = strings.Split(strings.TrimPrefix(string(path), string(aliasPrefix)+"/"), "/")
+		if loc, ok = s.aliases[elems[0]]; !ok {
+			return "", "", false, false
+		}
+	default:
+		return "", "", false, false
+	}
+
+	switch len(elems) {
+	case 1:
+		return loc, "", false, true
+	case 2:
+		id, ok = unescape(elems[1])
+		return loc, id, true, ok
+	default:
+		return "", "", false, false
+	}
+}
+
+// collection returns the location of the collection at the path. The lock must
+// be held.
+func (s *Server) collection(path dbus.ObjectPath) (string, *dbus.Error) {
+	loc, _, isItem, ok := s.parsePath(path)
+	if !ok || isItem {
+		return "", errNoSuchObject
+	}
+
+	if !s.hasLocation(loc) {
+		return "", errNoSuchObject
+	}
+
+	return loc, nil
+}
+
+// item returns the secret of the item at the path. The lock must be held.
+func (s *Server) item(path dbus.ObjectPath) (secrets.Secret, *dbus.Error) {
+	loc, id, isItem, ok := s.parsePath(path)
+	if !ok || !isItem {
+		return nil, errNoSuchObject
+	}
+
+	sec, err := s.kpr.GetSecret(s.ctx, id)
+	if err != nil {
+		return nil, keeperError(err)
+	}
+
+	if sec.Location() != loc || !s.hasLocation(loc) {
+		return nil, errNoSuchObject
+	}
+
+	return sec, nil
+}
@@ -0,0 +1,77 @@
+package secretservice
+
+import (
+	"github.com/godbus/dbus/v5"
+)
+
+// prompt is a request to unlock the collections waiting on the user.
+type prompt struct {
+	objects []dbus.ObjectPath
+}
+
+// promptObject provides the org.freedesktop.Secret.Prompt interface.
+type promptObject struct {
+	s *Server
+}
+
+// takePrompt removes the prompt at the path and returns it.
+func (s *Server) takePrompt(path dbus.ObjectPath) (*prompt, *dbus.Error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, hasPrompt := s.prompts[path]
+	if !hasPrompt {
+		return nil, errNoSuchObject
+	}
+
+	delete(s.prompts, path)
+	return p, nil
+}
+
+// Prompt asks the user for the unlock password. The Completed signal is sent
+// once the user has answered. The window ID is ignored.
+func (o *promptObject) Prompt(msg dbus.Message, _ string) *dbus.Error {
+	s := o.s
+	path := msgPath(msg)
+	p, derr := s.takePrompt(path)
+	if derr != nil {
+		return derr
+	}
+
+	go func() {
+		password, err := s.getPassword(
+			"Unlock Secrets",
+			"An application wants to use the secrets kept by ghost.",
+			"Password",
+			"Unlock",
+		)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err != nil || !s.checkPassword(password) {
+			s.emit(path, promptIface+".Completed", true, dbus.MakeVariant([]dbus.ObjectPath{}))
+			return
+		}
+
+		s.locked = false
+		s.emit(path, promptIface+".Completed", false, dbus.MakeVariant(p.objects))
+	}()
+
+	return nil
+}
+
+// Dismiss dismisses the prompt without asking the user.
+func (o *promptObject) Dismiss(msg dbus.Message) *dbus.Error {
+	s := o.s
+	path := msgPath(msg)
+	if _, derr := s.takePrompt(path); derr != nil {
+		return derr
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.emit(path, promptIface+".Completed", true, dbus.MakeVariant([]dbus.ObjectPath{}))
+	return nil
+}
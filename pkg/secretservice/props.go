@@ -0,0 +1,189 @@
+package secretservice
+
+import (
+	"github.com/godbus/dbus/v5"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// propsObject provides the org.freedesktop.DBus.Properties interface for the
+// service, collections, and items.
+type propsObject struct {
+	s *Server
+}
+
+// errUnknownProperty returns the error for a property that does not exist.
+func errUnknownProperty(name string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", []any{"no property " + name})
+}
+
+// errReadOnly returns the error for setting a property that cannot be set.
+func errReadOnly(name string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly", []any{"property " + name + " cannot be set"})
+}
+
+// Get returns a single property.
+func (o *propsObject) Get(msg dbus.Message, iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := o.GetAll(msg, iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+
+	v, hasProp := props[name]
+	if !hasProp {
+		return dbus.Variant{}, errUnknownProperty(name)
+	}
+
+	return v, nil
+}
+
+// GetAll returns every property of the interface.
+func (o *propsObject) GetAll(msg dbus.Message, iface string) (map[string]dbus.Variant, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := msgPath(msg)
+	if path == servicePath {
+		if iface != serviceIface {
+			return nil, errUnknownInterface(iface)
+		}
+		return s.serviceProps()
+	}
+
+	_, _, isItem, ok := s.parsePath(path)
+	switch {
+	case !ok:
+		return nil, errNoSuchObject
+	case isItem && iface == itemIface:
+		return s.itemProps(path)
+	case !isItem && iface == collectionIface:
+		return s.collectionProps(path)
+	default:
+		return nil, errUnknownInterface(iface)
+	}
+}
+
+// Set changes a property. Only the label and attributes of an item may be
+// changed.
+func (o *propsObject) Set(msg dbus.Message, iface, name string, value dbus.Variant) *dbus.Error {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := msgPath(msg)
+	if iface != itemIface {
+		return errReadOnly(name)
+	}
+
+	switch name {
+	case "Label":
+		label, isString := value.Value().(string)
+		if !isString {
+			return errInvalidArgs("the label must be a string")
+		}
+
+		return s.changeItem(path, func(sec *secrets.Single) {
+			sec.SetName(label)
+		})
+	case "Attributes":
+		attrs, isMap := value.Value().(map[string]string)
+		if !isMap {
+			return errInvalidArgs("the attributes must be a map of strings")
+		}
+
+		return s.changeItem(path, func(sec *secrets.Single) {
+			for k := range sec.Fields() {
+				if _, keep := attrs[k]; !keep {
+					sec.DeleteField(k)
+				}
+			}
+
+			for k, v := range attrs {
+				sec.SetField(k, v)
+			}
+		})
+	case "Locked", "Created", "Modified":
+		return errReadOnly(name)
+	default:
+		return errUnknownProperty(name)
+	}
+}
+
+// errUnknownInterface returns the error for an interface the object does not
+// have.
+func errUnknownInterface(iface string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []any{"no interface " + iface})
+}
+
+// serviceProps returns the properties of the service. The lock must be held.
+func (s *Server) serviceProps() (map[string]dbus.Variant, *dbus.Error) {
+	locs := s.locations()
+	paths := make([]dbus.ObjectPath, len(locs))
+	for i, loc := range locs {
+		paths[i] = collectionPath(loc)
+	}
+
+	return map[string]dbus.Variant{
+		"Collections": dbus.MakeVariant(paths),
+	}, nil
+}
+
+// collectionProps returns the properties of the collection at the path. The
+// lock must be held.
+func (s *Server) collectionProps(path dbus.ObjectPath) (map[string]dbus.Variant, *dbus.Error) {
+	loc, derr := s.collection(path)
+	if derr != nil {
+		return nil, derr
+	}
+
+	secs, err := s.secretsAt(loc)
+	if err != nil {
+		return nil, keeperError(err)
+	}
+
+	var modified uint64
+	items := make([]dbus.ObjectPath, len(secs))
+	for i, sec := range secs {
+		items[i] = itemPath(sec)
+		if t := uint64(sec.LastModified().Unix()); !sec.LastModified().IsZero() && t > modified {
+			modified = t
+		}
+	}
+
+	return map[string]dbus.Variant{
+		"Items":    dbus.MakeVariant(items),
+		"Label":    dbus.MakeVariant(loc),
+		"Locked":   dbus.MakeVariant(s.locked),
+		"Created":  dbus.MakeVariant(uint64(0)),
+		"Modified": dbus.MakeVariant(modified),
+	}, nil
+}
+
+// itemProps returns the properties of the item at the path. The creation time
+// is not kept, so the last modification time is given instead. The lock must
+// be held.
+func (s *Server) itemProps(path dbus.ObjectPath) (map[string]dbus.Variant, *dbus.Error) {
+	sec, derr := s.item(path)
+	if derr != nil {
+		return nil, derr
+	}
+
+	var modified uint64
+	if !sec.LastModified().IsZero() {
+		modified = uint64(sec.LastModified().Unix())
+	}
+
+	attrs := sec.Fields()
+	if attrs == nil {
+		attrs = map[string]string{}
+	}
+
+	return map[string]dbus.Variant{
+		"Locked":     dbus.MakeVariant(s.locked),
+		"Attributes": dbus.MakeVariant(attrs),
+		"Label":      dbus.MakeVariant(sec.Name()),
+		"Created":    dbus.MakeVariant(modified),
+		"Modified":   dbus.MakeVariant(modified),
+	}, nil
+}
@@ -0,0 +1,543 @@
+package secretservice_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+	"github.com/zostay/ghost/pkg/secretservice"
+)
+
+const (
+	servicePath dbus.ObjectPath = "/org/freedesktop/secrets"
+	defaultPath dbus.ObjectPath = "/org/freedesktop/secrets/aliases/default"
+
+	serviceIface    = "org.freedesktop.Secret.Service"
+	collectionIface = "org.freedesktop.Secret.Collection"
+	itemIface       = "org.freedesktop.Secret.Item"
+	promptIface     = "org.freedesktop.Secret.Prompt"
+)
+
+// busConfig is the configuration of the private bus used for testing.
+const busConfig = `<!DOCTYPE busconfig PUBLIC "-//freedesktop//DTD D-Bus Bus Configuration 1.0//EN"
+ "http://www.freedesktop.org/standards/dbus/1.0/busconfig.dtd">
+<busconfig>
+  <type>session</type>
+  <listen>unix:dir=%s</listen>
+  <auth>EXTERNAL</auth>
+  <policy context="default">
+    <allow send_destination="*" eavesdrop="true"/>
+    <allow eavesdrop="true"/>
+    <allow own="*"/>
+  </policy>
+</busconfig>
+`
+
+// secret is a secret as transferred by the Secret Service API.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// startBus starts a private bus and returns its address. The test is skipped
+// if dbus-daemon is not installed.
+func startBus(t *testing.T) string {
+	t.Helper()
+
+	daemon, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("requires dbus-daemon")
+	}
+
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "bus.conf")
+	require.NoError(t, os.WriteFile(cfg, []byte(strings.ReplaceAll(busConfig, "%s", dir)), 0o600))
+
+	cmd := exec.Command(daemon, "--config-file="+cfg, "--nofork", "--print-address=1")
+	out, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	addr, err := bufio.NewReader(out).ReadString('\n')
+	require.NoError(t, err)
+
+	return strings.TrimSpace(addr)
+}
+
+// connect connects to the bus.
+func connect(t *testing.T, addr string) *dbus.Conn {
+	t.Helper()
+
+	conn, err := dbus.Connect(addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// serve starts a server for the keeper on a private bus and returns a client
+// connection to the bus and the address of the bus.
+func serve(
+	t *testing.T,
+	kpr secrets.Keeper,
+	opts ...secretservice.Option,
+) (*dbus.Conn, string) {
+	t.Helper()
+
+	addr := startBus(t)
+	srv := secretservice.NewServer(kpr, opts...)
+	require.NoError(t, srv.Serve(context.Background(), connect(t, addr)))
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return connect(t, addr), addr
+}
+
+// object returns the object of the Secret Service at the path.
+func object(conn *dbus.Conn, path dbus.ObjectPath) dbus.BusObject {
+	return conn.Object(secretservice.BusName, path)
+}
+
+// openPlain opens a session transferring secrets without encryption.
+func openPlain(t *testing.T, conn *dbus.Conn) dbus.ObjectPath {
+	t.Helper()
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err := object(conn, servicePath).Call(serviceIface+".OpenSession", 0,
+		secretservice.AlgorithmPlain, dbus.MakeVariant("")).Store(&output, &session)
+	require.NoError(t, err)
+
+	return session
+}
+
+// createItem creates an item in the default collection.
+func createItem(
+	t *testing.T,
+	conn *dbus.Conn,
+	label string,
+	attrs map[string]string,
+	value secret,
+	replace bool,
+) dbus.ObjectPath {
+	t.Helper()
+
+	props := map[string]dbus.Variant{
+		itemIface + ".Label":      dbus.MakeVariant(label),
+		itemIface + ".Attributes": dbus.MakeVariant(attrs),
+	}
+
+	var item, prompt dbus.ObjectPath
+	err := object(conn, defaultPath).Call(collectionIface+".CreateItem", 0,
+		props, value, replace).Store(&item, &prompt)
+	require.NoError(t, err)
+	assert.Equal(t, dbus.ObjectPath("/"), prompt)
+
+	return item
+}
+
+// searchItems searches every collection.
+func searchItems(
+	t *testing.T,
+	conn *dbus.Conn,
+	attrs map[string]string,
+) ([]dbus.ObjectPath, []dbus.ObjectPath) {
+	t.Helper()
+
+	var unlocked, locked []dbus.ObjectPath
+	err := object(conn, servicePath).Call(serviceIface+".SearchItems", 0, attrs).
+		Store(&unlocked, &locked)
+	require.NoError(t, err)
+
+	return unlocked, locked
+}
+
+// getSecret returns the secret of the item.
+func getSecret(conn *dbus.Conn, item, session dbus.ObjectPath) (secret, error) {
+	var value secret
+	err := object(conn, item).Call(itemIface+".GetSecret", 0, session).Store(&value)
+	return value, err
+}
+
+// itemElem escapes the secret ID as the last element of the path of an item.
+func itemElem(id string) string {
+	var b strings.Builder
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02x", c)
+	}
+	return b.String()
+}
+
+var gitAttrs = map[string]string{
+	"xdg:schema": "org.git.Password",
+	"user":       "alice",
+	"server":     "example.com",
+	"protocol":   "https",
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Existing", "bob", "hunter2",
+		secrets.WithLocation("Personal"),
+		secrets.WithField("server", "example.org")))
+	require.NoError(t, err)
+
+	hidden, err := kpr.SetSecret(ctx, secrets.NewSecret("Hidden", "carol", "private",
+		secrets.WithLocation("Private"),
+		secrets.WithField("server", "example.org")))
+	require.NoError(t, err)
+
+	conn, _ := serve(t, kpr,
+		secretservice.WithAlwaysUnlocked(),
+		secretservice.WithLocations("Personal"))
+	session := openPlain(t, conn)
+
+	item := createItem(t, conn, "Git: https://example.com/", gitAttrs,
+		secret{session, []byte{}, []byte("s3cret"), "text/plain"}, false)
+
+	secs, err := kpr.GetSecretsByName(ctx, "Git: https://example.com/")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, secretservice.DefaultLocation, secs[0].Location())
+	assert.Equal(t, "alice", secs[0].Username())
+	assert.Equal(t, "s3cret", secs[0].Password())
+	assert.Equal(t, gitAttrs, secs[0].Fields())
+
+	t.Run("search", func(t *testing.T) {
+		unlocked, locked := searchItems(t, conn, map[string]string{"server": "example.com"})
+		assert.Equal(t, []dbus.ObjectPath{item}, unlocked)
+		assert.Empty(t, locked)
+
+		// the secret in the Private location is not shared
+		unlocked, _ = searchItems(t, conn, map[string]string{"server": "example.org"})
+		assert.Len(t, unlocked, 1)
+
+		unlocked, _ = searchItems(t, conn, map[string]string{"server": "example.net"})
+		assert.Empty(t, unlocked)
+
+		var found []dbus.ObjectPath
+		err := object(conn, defaultPath).Call(collectionIface+".SearchItems", 0,
+			map[string]string{"user": "alice"}).Store(&found)
+		require.NoError(t, err)
+		assert.Equal(t, []dbus.ObjectPath{item}, found)
+	})
+
+	t.Run("hidden locations", func(t *testing.T) {
+		_, err := getSecret(conn, "/org/freedesktop/secrets/collection/Private/"+
+			dbus.ObjectPath(itemElem(hidden.ID())), session)
+		assert.Error(t, err)
+
+		var coll, prompt dbus.ObjectPath
+		err = object(conn, servicePath).Call(serviceIface+".CreateCollection", 0,
+			map[string]dbus.Variant{collectionIface + ".Label": dbus.MakeVariant("Private")}, "").
+			Store(&coll, &prompt)
+		assert.Error(t, err)
+	})
+
+	t.Run("get secrets", func(t *testing.T) {
+		value, err := getSecret(conn, item, session)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", string(value.Value))
+
+		var values map[dbus.ObjectPath]secret
+		err = object(conn, servicePath).Call(serviceIface+".GetSecrets", 0,
+			[]dbus.ObjectPath{item}, session).Store(&values)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", string(values[item].Value))
+	})
+
+	t.Run("properties", func(t *testing.T) {
+		label, err := object(conn, item).GetProperty(itemIface + ".Label")
+		require.NoError(t, err)
+		assert.Equal(t, "Git: https://example.com/", label.Value())
+
+		attrs, err := object(conn, item).GetProperty(itemIface + ".Attributes")
+		require.NoError(t, err)
+		assert.Equal(t, gitAttrs, attrs.Value())
+
+		colls, err := object(conn, servicePath).GetProperty(serviceIface + ".Collections")
+		require.NoError(t, err)
+		assert.Len(t, colls.Value(), 2)
+
+		items, err := object(conn, defaultPath).GetProperty(collectionIface + ".Items")
+		require.NoError(t, err)
+		assert.Equal(t, []dbus.ObjectPath{item}, items.Value())
+
+		err = object(conn, item).SetProperty(itemIface+".Label", dbus.MakeVariant("Git"))
+		require.NoError(t, err)
+		sec, err := kpr.GetSecret(ctx, secs[0].ID())
+		require.NoError(t, err)
+		assert.Equal(t, "Git", sec.Name())
+	})
+
+	t.Run("replace", func(t *testing.T) {
+		replaced := createItem(t, conn, "Git", gitAttrs,
+			secret{session, []byte{}, []byte("n3w"), "text/plain"}, true)
+		assert.Equal(t, item, replaced)
+
+		value, err := getSecret(conn, item, session)
+		require.NoError(t, err)
+		assert.Equal(t, "n3w", string(value.Value))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		var prompt dbus.ObjectPath
+		err := object(conn, item).Call(itemIface+".Delete", 0).Store(&prompt)
+		require.NoError(t, err)
+
+		_, err = kpr.GetSecret(ctx, secs[0].ID())
+		assert.ErrorIs(t, err, secrets.ErrNotFound)
+
+		_, err = getSecret(conn, item, session)
+		assert.Error(t, err)
+	})
+
+	t.Run("service running", func(t *testing.T) {
+		srv := secretservice.NewServer(kpr)
+		err := srv.Serve(ctx, conn)
+		assert.ErrorIs(t, err, secretservice.ErrServiceRunning)
+	})
+}
+
+// dhPrime is the prime of the Diffie-Hellman group used by libsecret.
+var dhPrime, _ = new(big.Int).SetString(
+	"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1"+
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD"+
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245"+
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE65381"+
+		"FFFFFFFFFFFFFFFF", 16)
+
+// openDH opens an encrypted session as libsecret does and returns it with the
+// AES key.
+func openDH(t *testing.T, conn *dbus.Conn) (dbus.ObjectPath, []byte) {
+	t.Helper()
+
+	priv, err := rand.Int(rand.Reader, dhPrime)
+	require.NoError(t, err)
+	pub := new(big.Int).Exp(big.NewInt(2), priv, dhPrime).Bytes()
+
+	var output dbus.Variant
+	var session dbus.ObjectPath
+	err = object(conn, servicePath).Call(serviceIface+".OpenSession", 0,
+		secretservice.AlgorithmDH, dbus.MakeVariant(pub)).Store(&output, &session)
+	require.NoError(t, err)
+
+	peer, isBytes := output.Value().([]byte)
+	require.True(t, isBytes)
+
+	shared := make([]byte, 128)
+	new(big.Int).Exp(new(big.Int).SetBytes(peer), priv, dhPrime).FillBytes(shared)
+
+	key := make([]byte, 16)
+	_, err = io.ReadFull(hkdf.New(sha256.New, shared, nil, nil), key)
+	require.NoError(t, err)
+
+	return session, key
+}
+
+func TestServer_EncryptedSession(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	conn, addr := serve(t, kpr, secretservice.WithAlwaysUnlocked())
+	session, key := openDH(t, conn)
+
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+
+	iv := make([]byte, aes.BlockSize)
+	_, err = rand.Read(iv)
+	require.NoError(t, err)
+
+	data := append([]byte("s3cret"), bytes.Repeat([]byte{10}, 10)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	item := createItem(t, conn, "Encrypted", gitAttrs,
+		secret{session, iv, data, "text/plain"}, false)
+
+	secs, err := kpr.GetSecretsByName(ctx, "Encrypted")
+	require.NoError(t, err)
+	require.Len(t, secs, 1)
+	assert.Equal(t, "s3cret", secs[0].Password())
+
+	value, err := getSecret(conn, item, session)
+	require.NoError(t, err)
+	require.Len(t, value.Parameters, aes.BlockSize)
+	assert.NotContains(t, string(value.Value), "s3cret")
+
+	cipher.NewCBCDecrypter(block, value.Parameters).CryptBlocks(value.Value, value.Value)
+	assert.Equal(t, "s3cret", string(value.Value[:6]))
+
+	other := connect(t, addr)
+	_, err = getSecret(other, item, session)
+	var derr dbus.Error
+	require.ErrorAs(t, err, &derr)
+	assert.Equal(t, "org.freedesktop.Secret.Error.NoSession", derr.Name)
+}
+
+func TestServer_Unlock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	sec, err := kpr.SetSecret(ctx, secrets.NewSecret("Locked", "alice", "s3cret",
+		secrets.WithLocation(secretservice.DefaultLocation),
+		secrets.WithField("server", "example.com")))
+	require.NoError(t, err)
+
+	answers := make(chan string, 2)
+	answers <- "wrong"
+	answers <- "sesame"
+
+	conn, _ := serve(t, kpr,
+		secretservice.WithUnlockPassword("sesame"),
+		secretservice.WithPasswordFunc(func(_, _, _, _ string) (string, error) {
+			return <-answers, nil
+		}))
+
+	session := openPlain(t, conn)
+	unlocked, locked := searchItems(t, conn, map[string]string{"server": "example.com"})
+	assert.Empty(t, unlocked)
+	require.Len(t, locked, 1)
+	item := locked[0]
+
+	_, err = getSecret(conn, item, session)
+	var derr dbus.Error
+	require.ErrorAs(t, err, &derr)
+	assert.Equal(t, "org.freedesktop.Secret.Error.IsLocked", derr.Name)
+
+	require.NoError(t, conn.AddMatchSignal(
+		dbus.WithMatchInterface(promptIface),
+		dbus.WithMatchMember("Completed")))
+	signals := make(chan *dbus.Signal, 4)
+	conn.Signal(signals)
+
+	unlock := func() bool {
+		t.Helper()
+
+		var done []dbus.ObjectPath
+		var prompt dbus.ObjectPath
+		err := object(conn, servicePath).Call(serviceIface+".Unlock", 0,
+			[]dbus.ObjectPath{defaultPath}).Store(&done, &prompt)
+		require.NoError(t, err)
+		require.NotEqual(t, dbus.ObjectPath("/"), prompt)
+
+		require.NoError(t, object(conn, prompt).Call(promptIface+".Prompt", 0, "").Err)
+
+		select {
+		case sig := <-signals:
+			assert.Equal(t, prompt, sig.Path)
+			return !sig.Body[0].(bool)
+		case <-time.After(10 * time.Second):
+			t.Fatal("prompt did not complete")
+			return false
+		}
+	}
+
+	assert.False(t, unlock())
+	assert.True(t, unlock())
+
+	value, err := getSecret(conn, item, session)
+	require.NoError(t, err)
+	assert.Equal(t, sec.Password(), string(value.Value))
+
+	var done []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err = object(conn, servicePath).Call(serviceIface+".Lock", 0,
+		[]dbus.ObjectPath{defaultPath}).Store(&done, &prompt)
+	require.NoError(t, err)
+	assert.Equal(t, []dbus.ObjectPath{defaultPath}, done)
+
+	_, locked = searchItems(t, conn, map[string]string{"server": "example.com"})
+	assert.Len(t, locked, 1)
+}
+
+func TestServer_LockedByDefault(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Locked", "alice", "s3cret",
+		secrets.WithLocation(secretservice.DefaultLocation),
+		secrets.WithField("server", "example.com")))
+	require.NoError(t, err)
+
+	asked := 0
+	conn, _ := serve(t, kpr,
+		secretservice.WithPasswordFunc(func(_, _, _, _ string) (string, error) {
+			asked++
+			return "", nil
+		}))
+
+	unlocked, locked := searchItems(t, conn, map[string]string{"server": "example.com"})
+	assert.Empty(t, unlocked)
+	assert.Len(t, locked, 1)
+
+	require.NoError(t, conn.AddMatchSignal(
+		dbus.WithMatchInterface(promptIface),
+		dbus.WithMatchMember("Completed")))
+	signals := make(chan *dbus.Signal, 1)
+	conn.Signal(signals)
+
+	var done []dbus.ObjectPath
+	var prompt dbus.ObjectPath
+	err = object(conn, servicePath).Call(serviceIface+".Unlock", 0,
+		[]dbus.ObjectPath{defaultPath}).Store(&done, &prompt)
+	require.NoError(t, err)
+	require.NoError(t, object(conn, prompt).Call(promptIface+".Prompt", 0, "").Err)
+
+	// without an unlock password, not even an empty password unlocks
+	select {
+	case sig := <-signals:
+		assert.True(t, sig.Body[0].(bool))
+	case <-time.After(10 * time.Second):
+		t.Fatal("prompt did not complete")
+	}
+	assert.Equal(t, 1, asked)
+
+	unlocked, _ = searchItems(t, conn, map[string]string{"server": "example.com"})
+	assert.Empty(t, unlocked)
+}
@@ -0,0 +1,510 @@
+// Package secretservice implements the freedesktop.org Secret Service API on
+// D-Bus for a secret keeper, so that desktop applications using libsecret, such
+// as browsers and git-credential-libsecret, can keep their secrets in it.
+//
+// Each location of the secret keeper shared with the server is a collection and
+// each secret in it is an item. The label of an item is the name of the secret
+// and the attributes of an item are the fields of the secret. The default
+// collection is kept at a location chosen when the server is created. Other
+// locations are hidden unless shared with WithLocations.
+//
+// The collections start locked and are unlocked when the user enters the
+// password given with WithUnlockPassword.
+package secretservice
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// BusName is the well-known name owned by the Secret Service on the session
+// bus.
+const BusName = "org.freedesktop.secrets"
+
+// DefaultLocation is the location of the default collection unless another is
+// chosen with WithDefaultLocation.
+const DefaultLocation = "Secret Service"
+
+const (
+	servicePath      dbus.ObjectPath = "/org/freedesktop/secrets"
+	collectionPrefix dbus.ObjectPath = servicePath + "/collection"
+	aliasPrefix      dbus.ObjectPath = servicePath + "/aliases"
+	sessionPrefix    dbus.ObjectPath = servicePath + "/session"
+	promptPrefix     dbus.ObjectPath = servicePath + "/prompt"
+
+	// noPrompt is returned in place of a prompt when none is needed.
+	noPrompt dbus.ObjectPath = "/"
+
+	serviceIface    = "org.freedesktop.Secret.Service"
+	collectionIface = "org.freedesktop.Secret.Collection"
+	itemIface       = "org.freedesktop.Secret.Item"
+	sessionIface    = "org.freedesktop.Secret.Session"
+	promptIface     = "org.freedesktop.Secret.Prompt"
+	propsIface      = "org.freedesktop.DBus.Properties"
+)
+
+// ErrServiceRunning is returned by Serve when another program already provides
+// the Secret Service on the bus.
+var ErrServiceRunning = errors.New("another secret service is already running")
+
+// PasswordFunc asks the user for a password, like keeper.GetPassword.
+type PasswordFunc func(title, desc, prompt, ok string) (string, error)
+
+// Server provides the Secret Service API for a secret keeper.
+type Server struct {
+	kpr             secrets.Keeper
+	defaultLocation string
+	shared          []string
+	unlockPassword  string
+	alwaysUnlocked  bool
+	getPassword     PasswordFunc
+
+	mu       sync.Mutex
+	ctx      context.Context
+	conn     *dbus.Conn
+	locked   bool
+	aliases  map[string]string
+	sessions map[dbus.ObjectPath]*session
+	prompts  map[dbus.ObjectPath]*prompt
+	serial   int
+}
+
+// Option is used to customize the server during construction.
+type Option func(*Server)
+
+// WithDefaultLocation sets the location of the secret keeper used for the
+// default collection, which is where most applications keep their secrets.
+func WithDefaultLocation(location string) Option {
+	return func(s *Server) {
+		s.defaultLocation = location
+	}
+}
+
+// WithLocations shares more locations of the secret keeper as collections,
+// besides the location of the default collection.
+func WithLocations(locations ...string) Option {
+	return func(s *Server) {
+		s.shared = append(s.shared, locations...)
+	}
+}
+
+// WithUnlockPassword sets the password the user must enter to unlock the
+// collections when an application asks to unlock them. Without it, the
+// collections cannot be unlocked.
+func WithUnlockPassword(password string) Option {
+	return func(s *Server) {
+		s.unlockPassword = password
+	}
+}
+
+// WithAlwaysUnlocked leaves the collections unlocked, so that any application
+// on the bus may read the secrets without asking the user.
+func WithAlwaysUnlocked() Option {
+	return func(s *Server) {
+		s.alwaysUnlocked = true
+	}
+}
+
+// WithPasswordFunc sets the function used to ask the user for the unlock
+// password. It is keeper.GetPassword by default.
+func WithPasswordFunc(fn PasswordFunc) Option {
+	return func(s *Server) {
+		s.getPassword = fn
+	}
+}
+
+// NewServer creates a new Secret Service server for the secret keeper.
+func NewServer(kpr secrets.Keeper, opts ...Option) *Server {
+	s := &Server{
+		kpr:             kpr,
+		defaultLocation: DefaultLocation,
+		getPassword:     keeper.GetPassword,
+		aliases:         map[string]string{},
+		sessions:        map[dbus.ObjectPath]*session{},
+		prompts:         map[dbus.ObjectPath]*prompt{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.aliases["default"] = s.defaultLocation
+	s.locked = !s.alwaysUnlocked
+
+	return s
+}
+
+// Serve exports the Secret Service objects on the connection and claims the
+// well-known name of the Secret Service. It returns ErrServiceRunning if some
+// other program has already claimed it. The objects remain until Close is
+// called or the connection is closed. The context is used for every call made
+// to the secret keeper.
+func (s *Server) Serve(ctx context.Context, conn *dbus.Conn) error {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.conn = conn
+	s.mu.Unlock()
+
+	for _, e := range s.exports() {
+		export := conn.Export
+		if e.subtree {
+			export = conn.ExportSubtree
+		}
+
+		if err := export(e.v, e.path, e.iface); err != nil {
+			return fmt.Errorf("unable to export %s: %w", e.iface, err)
+		}
+	}
+
+	if err := s.watchClients(); err != nil {
+		return err
+	}
+
+	reply, err := conn.RequestName(BusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("unable to claim %s: %w", BusName, err)
+	}
+
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return ErrServiceRunning
+	}
+
+	return nil
+}
+
+// Close releases the well-known name and removes the Secret Service objects
+// from the connection. The connection itself is left open.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	conn := s.conn
+	s.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	for _, e := range s.exports() {
+		if e.subtree {
+			_ = conn.ExportSubtree(nil, e.path, e.iface)
+		} else {
+			_ = conn.Export(nil, e.path, e.iface)
+		}
+	}
+
+	_, err := conn.ReleaseName(BusName)
+	return err
+}
+
+// export is an object exported on the bus. A subtree export handles every
+// path below its own.
+type export struct {
+	v       any
+	path    dbus.ObjectPath
+	iface   string
+	subtree bool
+}
+
+// exports lists the objects exported on the bus. The properties are exported
+// alongside each subtree, as a subtree hides the exports above it.
+func (s *Server) exports() []export {
+	return []export{
+		{&serviceObject{s}, servicePath, serviceIface, false},
+		{&propsObject{s}, servicePath, propsIface, false},
+		{&collectionObject{s}, collectionPrefix, collectionIface, true},
+		{&itemObject{s}, collectionPrefix, itemIface, true},
+		{&propsObject{s}, collectionPrefix, propsIface, true},
+		{&collectionObject{s}, aliasPrefix, collectionIface, true},
+		{&propsObject{s}, aliasPrefix, propsIface, true},
+		{&sessionObject{s}, sessionPrefix, sessionIface, true},
+		{&promptObject{s}, promptPrefix, promptIface, true},
+	}
+}
+
+// watchClients closes the sessions of clients that leave the bus without
+// closing them.
+func (s *Server) watchClients() error {
+	err := s.conn.AddMatchSignal(
+		dbus.WithMatchInterface("org.freedesktop.DBus"),
+		dbus.WithMatchMember("NameOwnerChanged"),
+	)
+	if err != nil {
+		return fmt.Errorf("unable to watch for clients leaving: %w", err)
+	}
+
+	signals := make(chan *dbus.Signal, 16)
+	s.conn.Signal(signals)
+	go func() {
+		for sig := range signals {
+			if sig.Name != "org.freedesktop.DBus.NameOwnerChanged" || len(sig.Body) != 3 {
+				continue
+			}
+
+			name, _ := sig.Body[0].(string)
+			newOwner, _ := sig.Body[2].(string)
+			if newOwner == "" {
+				s.closeSessionsOf(name)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// nextPath returns a new path under the prefix for a session or prompt. The
+// lock must be held.
+func (s *Server) nextPath(prefix dbus.ObjectPath) dbus.ObjectPath {
+	s.serial++
+	return dbus.ObjectPath(fmt.Sprintf("%s/s%d", prefix, s.serial))
+}
+
+// emit sends a signal, ignoring failures as there is no one to report them to.
+func (s *Server) emit(path dbus.ObjectPath, name string, values ...any) {
+	_ = s.conn.Emit(path, name, values...)
+}
+
+// checkPassword returns true if the password unlocks the collections. Nothing
+// unlocks them if no unlock password is set.
+func (s *Server) checkPassword(password string) bool {
+	if s.unlockPassword == "" {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(password), []byte(s.unlockPassword)) == 1
+}
+
+// locations returns the locations of every collection, which are the default
+// location and the locations shared with WithLocations. The lock must be held.
+func (s *Server) locations() []string {
+	set := map[string]struct{}{s.defaultLocation: {}}
+	for _, loc := range s.shared {
+		set[loc] = struct{}{}
+	}
+
+	locs := make([]string, 0, len(set))
+	for loc := range set {
+		locs = append(locs, loc)
+	}
+
+	sort.Strings(locs)
+	return locs
+}
+
+// hasLocation returns true if the location is that of a collection. The lock
+// must be held.
+func (s *Server) hasLocation(loc string) bool {
+	for _, l := range s.locations() {
+		if l == loc {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serviceObject provides the org.freedesktop.Secret.Service interface.
+type serviceObject struct {
+	s *Server
+}
+
+// OpenSession opens a session used to transfer secrets with the given
+// algorithm.
+func (o *serviceObject) OpenSession(
+	sender dbus.Sender,
+	algorithm string,
+	input dbus.Variant,
+) (dbus.Variant, dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	sess, output, err := newSession(string(sender), algorithm, input)
+	if err != nil {
+		return dbus.MakeVariant(""), noPrompt, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := s.nextPath(sessionPrefix)
+	s.sessions[path] = sess
+	return output, path, nil
+}
+
+// CreateCollection returns the collection with the given label, giving it the
+// alias, if set. If a collection already has that alias, that collection is
+// returned instead. New collections cannot be created, as only the locations
+// shared with the server are collections.
+func (o *serviceObject) CreateCollection(
+	props map[string]dbus.Variant,
+	alias string,
+) (dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if loc, hasAlias := s.aliases[alias]; hasAlias {
+		return collectionPath(loc), noPrompt, nil
+	}
+
+	label, _ := props[collectionIface+".Label"].Value().(string)
+	if label == "" {
+		label = alias
+	}
+
+	if label == "" {
+		return noPrompt, noPrompt, errInvalidArgs("a collection must have a label")
+	}
+
+	if !s.hasLocation(label) {
+		return noPrompt, noPrompt, errNotSupported("only the locations shared by ghost are collections")
+	}
+
+	if alias != "" {
+		s.aliases[alias] = label
+	}
+
+	return collectionPath(label), noPrompt, nil
+}
+
+// SearchItems returns the items in every collection with all the given
+// attributes, split into those that are unlocked and those that are locked.
+func (o *serviceObject) SearchItems(
+	attrs map[string]string,
+) ([]dbus.ObjectPath, []dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var found []dbus.ObjectPath
+	for _, loc := range s.locations() {
+		items, err := s.searchItems(loc, attrs)
+		if err != nil {
+			return nil, nil, keeperError(err)
+		}
+		found = append(found, items...)
+	}
+
+	if s.locked {
+		return []dbus.ObjectPath{}, nonNil(found), nil
+	}
+	return nonNil(found), []dbus.ObjectPath{}, nil
+}
+
+// Unlock unlocks the collections. If they are locked, a prompt is returned
+// that asks the user for the unlock password.
+func (o *serviceObject) Unlock(
+	objects []dbus.ObjectPath,
+) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.locked {
+		return nonNil(objects), noPrompt, nil
+	}
+
+	path := s.nextPath(promptPrefix)
+	s.prompts[path] = &prompt{objects: nonNil(objects)}
+	return []dbus.ObjectPath{}, path, nil
+}
+
+// Lock locks the collections, unless they are always unlocked.
+func (o *serviceObject) Lock(
+	objects []dbus.ObjectPath,
+) ([]dbus.ObjectPath, dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.alwaysUnlocked {
+		return []dbus.ObjectPath{}, noPrompt, nil
+	}
+
+	s.locked = true
+	return nonNil(objects), noPrompt, nil
+}
+
+// GetSecrets returns the secrets of the given items, encoded for the session.
+// Items that are locked or do not exist are left out.
+func (o *serviceObject) GetSecrets(
+	sender dbus.Sender,
+	items []dbus.ObjectPath,
+	sessionPath dbus.ObjectPath,
+) (map[dbus.ObjectPath]secret, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, err := s.session(string(sender), sessionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[dbus.ObjectPath]secret{}
+	if s.locked {
+		return found, nil
+	}
+
+	for _, path := range items {
+		sec, err := s.item(path)
+		if err != nil {
+			continue
+		}
+
+		found[path], err = sess.encode(sessionPath, sec.Password())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return found, nil
+}
+
+// ReadAlias returns the collection with the given alias or "/" if there is
+// none.
+func (o *serviceObject) ReadAlias(name string) (dbus.ObjectPath, *dbus.Error) {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if loc, hasAlias := s.aliases[name]; hasAlias {
+		return collectionPath(loc), nil
+	}
+	return noPrompt, nil
+}
+
+// SetAlias gives the collection an alias, or removes the alias if the
+// collection is "/".
+func (o *serviceObject) SetAlias(name string, collection dbus.ObjectPath) *dbus.Error {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if collection == noPrompt {
+		delete(s.aliases, name)
+		return nil
+	}
+
+	loc, err := s.collection(collection)
+	if err != nil {
+		return err
+	}
+
+	s.aliases[name] = loc
+	return nil
+}
+
+// nonNil returns the paths or an empty list if there are none, as D-Bus has no
+// nil.
+func nonNil(paths []dbus.ObjectPath) []dbus.ObjectPath {
+	if paths == nil {
+		return []dbus.ObjectPath{}
+	}
+	return paths
+}
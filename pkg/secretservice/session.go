@@ -0,0 +1,211 @@
+package secretservice
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"io"
+	"math/big"
+
+	"github.com/godbus/dbus/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// AlgorithmPlain transfers secrets without encryption.
+	AlgorithmPlain = "plain"
+
+	// AlgorithmDH transfers secrets encrypted with AES-128 in CBC mode using a
+	// key agreed with Diffie-Hellman, as libsecret does by default.
+	AlgorithmDH = "dh-ietf1024-sha256-aes128-cbc-pkcs7"
+)
+
+// dhPrime is the 1024-bit MODP group prime from RFC 2409, used with a
+// generator of 2.
+var dhPrime, _ = new(big.Int).SetString(
+	"FFFFFFFFFFFFFFFFC90FDAA22168C234C4C6628B80DC1CD1"+
+		"29024E088A67CC74020BBEA63B139B22514A08798E3404DD"+
+		"EF9519B3CD3A431B302B0A6DF25F14374FE1356D6D51C245"+
+		"E485B576625E7EC6F44C42E9A637ED6B0BFF5CB6F406B7ED"+
+		"EE386BFB5A899FA5AE9F24117C4B1FE649286651ECE65381"+
+		"FFFFFFFFFFFFFFFF", 16)
+
+// dhGenerator is the generator of the Diffie-Hellman group.
+var dhGenerator = big.NewInt(2)
+
+// secret is a secret as transferred by the Secret Service API.
+type secret struct {
+	Session     dbus.ObjectPath
+	Parameters  []byte
+	Value       []byte
+	ContentType string
+}
+
+// session is a session opened by a client to transfer secrets.
+type session struct {
+	owner string
+	key   []byte
+}
+
+// newSession opens a session for the client with the given algorithm and
+// returns the output for the client.
+func newSession(
+	owner string,
+	algorithm string,
+	input dbus.Variant,
+) (*session, dbus.Variant, *dbus.Error) {
+	switch algorithm {
+	case AlgorithmPlain:
+		return &session{owner: owner}, dbus.MakeVariant(""), nil
+	case AlgorithmDH:
+		peer, isBytes := input.Value().([]byte)
+		if !isBytes {
+			return nil, dbus.Variant{}, errInvalidArgs("the session input must be a public key")
+		}
+
+		pub, key, err := agreeKey(peer)
+		if err != nil {
+			return nil, dbus.Variant{}, errInvalidArgs(err.Error())
+		}
+
+		return &session{owner: owner, key: key}, dbus.MakeVariant(pub), nil
+	default:
+		return nil, dbus.Variant{}, errNotSupported("algorithm " + algorithm + " is not supported")
+	}
+}
+
+// agreeKey generates a key pair and uses it with the peer public key to agree
+// on an AES key. It returns the public key and the AES key.
+func agreeKey(peer []byte) ([]byte, []byte, error) {
+	priv, err := rand.Int(rand.Reader, dhPrime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dhKey(priv, peer)
+}
+
+// dhKey uses the private key with the peer public key to agree on an AES key.
+// It returns the public key for the private key and the AES key.
+func dhKey(priv *big.Int, peer []byte) ([]byte, []byte, error) {
+	y := new(big.Int).SetBytes(peer)
+	top := new(big.Int).Sub(dhPrime, big.NewInt(1))
+	if y.Cmp(big.NewInt(1)) <= 0 || y.Cmp(top) >= 0 {
+		return nil, nil, errBadPublicKey
+	}
+
+	pub := new(big.Int).Exp(dhGenerator, priv, dhPrime).Bytes()
+
+	shared := make([]byte, (dhPrime.BitLen()+7)/8)
+	new(big.Int).Exp(y, priv, dhPrime).FillBytes(shared)
+
+	key := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, nil), key); err != nil {
+		return nil, nil, err
+	}
+
+	return pub, key, nil
+}
+
+// encode returns the value as a secret to send to the client of the session.
+func (sess *session) encode(path dbus.ObjectPath, value string) (secret, *dbus.Error) {
+	sec := secret{
+		Session:     path,
+		Parameters:  []byte{},
+		Value:       []byte(value),
+		ContentType: "text/plain; charset=utf8",
+	}
+
+	if sess.key == nil {
+		return sec, nil
+	}
+
+	block, err := aes.NewCipher(sess.key)
+	if err != nil {
+		return sec, dbus.MakeFailedError(err)
+	}
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return sec, dbus.MakeFailedError(err)
+	}
+
+	n := aes.BlockSize - len(sec.Value)%aes.BlockSize
+	data := append(sec.Value, bytes.Repeat([]byte{byte(n)}, n)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, data)
+
+	sec.Parameters = iv
+	sec.Value = data
+	return sec, nil
+}
+
+// decode returns the value of a secret sent by the client of the session.
+func (sess *session) decode(sec secret) (string, *dbus.Error) {
+	if sess.key == nil {
+		return string(sec.Value), nil
+	}
+
+	block, err := aes.NewCipher(sess.key)
+	if err != nil {
+		return "", dbus.MakeFailedError(err)
+	}
+
+	data := sec.Value
+	if len(sec.Parameters) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return "", errInvalidArgs("the secret is not correctly encrypted")
+	}
+
+	data = append([]byte(nil), data...)
+	cipher.NewCBCDecrypter(block, sec.Parameters).CryptBlocks(data, data)
+
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || !bytes.Equal(data[len(data)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return "", errInvalidArgs("the secret is not correctly encrypted")
+	}
+
+	return string(data[:len(data)-n]), nil
+}
+
+// session returns the session at the path, if it belongs to the client. The
+// lock must be held.
+func (s *Server) session(owner string, path dbus.ObjectPath) (*session, *dbus.Error) {
+	sess, hasSession := s.sessions[path]
+	if !hasSession || sess.owner != owner {
+		return nil, errNoSession
+	}
+	return sess, nil
+}
+
+// closeSessionsOf closes the sessions of a client that has left the bus.
+func (s *Server) closeSessionsOf(owner string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for path, sess := range s.sessions {
+		if sess.owner == owner {
+			delete(s.sessions, path)
+		}
+	}
+}
+
+// sessionObject provides the org.freedesktop.Secret.Session interface.
+type sessionObject struct {
+	s *Server
+}
+
+// Close closes the session.
+func (o *sessionObject) Close(msg dbus.Message, sender dbus.Sender) *dbus.Error {
+	s := o.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := msgPath(msg)
+	if _, err := s.session(string(sender), path); err != nil {
+		return err
+	}
+
+	delete(s.sessions, path)
+	return nil
+}
@@ -0,0 +1,334 @@
+// Package sshagent implements an SSH agent holding private keys kept as
+// secrets, so that the keys need never be copied into ~/.ssh.
+//
+// Keys are loaded from the secrets of type ssh-key. The private key is read
+// from the password of the secret or, if the password holds no key, from an
+// attachment. The rules given to the agent choose which of those secrets are
+// loaded, along with the lifetime, confirmation, and passphrase of each key.
+// Keys added by ssh-add are kept alongside them.
+//
+// The keys may be reloaded while the agent runs, so that keys added to the
+// secret keeper are picked up. A key is only ever loaded from the secret
+// keeper once, so a key removed by its lifetime or by ssh-add stays removed
+// until its secret holds a different key.
+package sshagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/zostay/ghost/pkg/keeper"
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// ErrDenied is returned when the user refuses the use of a key that must be
+// confirmed before use.
+var ErrDenied = errors.New("use of the key was denied")
+
+// ConfirmFunc asks the user to confirm an action, like keeper.Confirm.
+type ConfirmFunc func(title, desc string) (bool, error)
+
+// Agent is an SSH agent holding keys kept by a secret keeper. It honors the
+// lifetime and confirm constraints of each key.
+type Agent struct {
+	kpr      secrets.Keeper
+	rules    []Rule
+	lifetime uint32
+	confirm  bool
+	confirmf ConfirmFunc
+
+	reloadEvery time.Duration
+	logger      *log.Logger
+
+	keyring agent.ExtendedAgent
+
+	mu       sync.Mutex
+	confirms map[string]string
+	locked   bool
+	lastLoad time.Time
+
+	loadMu sync.Mutex
+	loaded map[string]struct{}
+}
+
+var _ agent.ExtendedAgent = &Agent{}
+
+// Option is used to customize the agent during construction.
+type Option func(*Agent)
+
+// WithRules sets the rules choosing the keys loaded from the secret keeper.
+// Without rules, every secret of type ssh-key is loaded.
+func WithRules(rules ...Rule) Option {
+	return func(a *Agent) {
+		a.rules = rules
+	}
+}
+
+// WithLifetime sets the lifetime of keys loaded from the secret keeper when
+// their rule does not set one. Keys are kept until removed by default.
+func WithLifetime(secs uint32) Option {
+	return func(a *Agent) {
+		a.lifetime = secs
+	}
+}
+
+// WithConfirm requires that every key loaded from the secret keeper be
+// confirmed before use.
+func WithConfirm(confirm bool) Option {
+	return func(a *Agent) {
+		a.confirm = confirm
+	}
+}
+
+// WithConfirmFunc sets the function used to ask the user to confirm the use of
+// a key. It is keeper.Confirm by default.
+func WithConfirmFunc(fn ConfirmFunc) Option {
+	return func(a *Agent) {
+		a.confirmf = fn
+	}
+}
+
+// WithReloadInterval reloads the keys from the secret keeper when a client
+// lists the keys and the interval has passed since they were last loaded. The
+// keys are only loaded when Load is called by default.
+func WithReloadInterval(d time.Duration) Option {
+	return func(a *Agent) {
+		a.reloadEvery = d
+	}
+}
+
+// WithLogger sets the logger used to report the errors found while reloading
+// keys. They are not reported by default.
+func WithLogger(logger *log.Logger) Option {
+	return func(a *Agent) {
+		a.logger = logger
+	}
+}
+
+// New creates a new SSH agent for the secret keeper. No keys are loaded until
+// Load is called.
+func New(kpr secrets.Keeper, opts ...Option) *Agent {
+	a := &Agent{
+		kpr:      kpr,
+		confirmf: keeper.Confirm,
+		keyring:  agent.NewKeyring().(agent.ExtendedAgent),
+		confirms: map[string]string{},
+		loaded:   map[string]struct{}{},
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	return a
+}
+
+// SocketName returns the name of the unix socket the ghost service uses for
+// the SSH agent.
+func SocketName() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("ghost.ssh-agent.%d", os.Getuid()))
+}
+
+// Serve answers SSH agent requests on each connection accepted by the listener
+// until the listener is closed.
+func (a *Agent) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		go func() {
+			defer func() { _ = conn.Close() }()
+			_ = agent.ServeAgent(a, conn)
+		}()
+	}
+}
+
+// List returns the identities known to the agent, first reloading the keys
+// from the secret keeper if they are due to be reloaded.
+func (a *Agent) List() ([]*agent.Key, error) {
+	a.reloadIfDue()
+	return a.keyring.List()
+}
+
+// reloadIfDue loads the keys from the secret keeper if the reload interval has
+// passed since they were last loaded and the agent is not locked.
+func (a *Agent) reloadIfDue() {
+	if a.reloadEvery <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	due := !a.locked && time.Since(a.lastLoad) >= a.reloadEvery
+	if due {
+		a.lastLoad = time.Now()
+	}
+	a.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	_, err := a.Load(context.Background())
+	if err != nil && a.logger != nil {
+		a.logger.Printf("failed to reload some SSH keys: %v", err)
+	}
+}
+
+// Add adds a private key to the agent, remembering whether it must be
+// confirmed before use.
+func (a *Agent) Add(key agent.AddedKey) error {
+	signer, err := ssh.NewSignerFromKey(key.PrivateKey)
+	if err != nil {
+		return err
+	}
+
+	if err := a.keyring.Add(key); err != nil {
+		return err
+	}
+
+	pubKeys := []ssh.PublicKey{signer.PublicKey()}
+	if key.Certificate != nil {
+		pubKeys = append(pubKeys, key.Certificate)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, pubKey := range pubKeys {
+		if key.ConfirmBeforeUse {
+			a.confirms[string(pubKey.Marshal())] = key.Comment
+		} else {
+			delete(a.confirms, string(pubKey.Marshal()))
+		}
+	}
+
+	return nil
+}
+
+// Remove removes the key from the agent.
+func (a *Agent) Remove(key ssh.PublicKey) error {
+	a.mu.Lock()
+	delete(a.confirms, string(key.Marshal()))
+	a.mu.Unlock()
+
+	return a.keyring.Remove(key)
+}
+
+// RemoveAll removes every key from the agent.
+func (a *Agent) RemoveAll() error {
+	a.mu.Lock()
+	a.confirms = map[string]string{}
+	a.mu.Unlock()
+
+	return a.keyring.RemoveAll()
+}
+
+// Lock locks the agent with the passphrase. Keys are not reloaded while the
+// agent is locked.
+func (a *Agent) Lock(passphrase []byte) error {
+	if err := a.keyring.Lock(passphrase); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.locked = true
+	a.mu.Unlock()
+	return nil
+}
+
+// Unlock unlocks an agent locked with the passphrase.
+func (a *Agent) Unlock(passphrase []byte) error {
+	if err := a.keyring.Unlock(passphrase); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.locked = false
+	a.mu.Unlock()
+	return nil
+}
+
+// Signers returns signers for the keys that need no confirmation, first
+// reloading the keys from the secret keeper if they are due to be reloaded.
+func (a *Agent) Signers() ([]ssh.Signer, error) {
+	a.reloadIfDue()
+
+	signers, err := a.keyring.Signers()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	usable := make([]ssh.Signer, 0, len(signers))
+	for _, signer := range signers {
+		if _, mustConfirm := a.confirms[string(signer.PublicKey().Marshal())]; !mustConfirm {
+			usable = append(usable, signer)
+		}
+	}
+
+	return usable, nil
+}
+
+// Sign signs the data with the key, asking the user first if the key must be
+// confirmed before use.
+func (a *Agent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	return a.SignWithFlags(key, data, 0)
+}
+
+// SignWithFlags signs the data with the key using the flags, asking the user
+// first if the key must be confirmed before use.
+func (a *Agent) SignWithFlags(
+	key ssh.PublicKey,
+	data []byte,
+	flags agent.SignatureFlags,
+) (*ssh.Signature, error) {
+	if err := a.confirmUse(key); err != nil {
+		return nil, err
+	}
+
+	return a.keyring.SignWithFlags(key, data, flags)
+}
+
+// Extension reports that no extensions are supported.
+func (a *Agent) Extension(extensionType string, contents []byte) ([]byte, error) {
+	return a.keyring.Extension(extensionType, contents)
+}
+
+// confirmUse asks the user to allow the use of the key if it must be confirmed
+// before use.
+func (a *Agent) confirmUse(key ssh.PublicKey) error {
+	a.mu.Lock()
+	comment, mustConfirm := a.confirms[string(key.Marshal())]
+	a.mu.Unlock()
+
+	if !mustConfirm {
+		return nil
+	}
+
+	ok, err := a.confirmf(
+		"Ghost SSH Agent",
+		fmt.Sprintf("Allow use of the SSH key %s (%s)?", comment, ssh.FingerprintSHA256(key)))
+	if err != nil {
+		return fmt.Errorf("unable to confirm use of the key: %w", err)
+	}
+
+	if !ok {
+		return ErrDenied
+	}
+
+	return nil
+}
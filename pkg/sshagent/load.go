@@ -0,0 +1,177 @@
+package sshagent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/zostay/ghost/pkg/secrets"
+)
+
+// SecretType is the type of the secrets holding SSH private keys.
+const SecretType = "ssh-key"
+
+// PassphraseField is the field of a secret that may hold the passphrase of its
+// key when no rule gives one.
+const PassphraseField = "passphrase"
+
+// Rule chooses which secrets are loaded as keys and how. The first rule whose
+// query matches a secret applies to it.
+type Rule struct {
+	// Query matches the secrets the rule applies to. A nil query matches every
+	// secret.
+	Query *secrets.Query
+
+	// Lifetime is the number of seconds the key is kept in the agent once
+	// loaded. If zero, the lifetime given to the agent is used.
+	Lifetime uint32
+
+	// Confirm requires that the user confirm each use of the key.
+	Confirm bool
+
+	// Passphrase decrypts the private key, if it is encrypted.
+	Passphrase string
+}
+
+// ruleFor returns the rule applying to the secret. It returns false if there
+// are rules, but none match.
+func (a *Agent) ruleFor(sec secrets.Secret) (Rule, bool) {
+	if len(a.rules) == 0 {
+		return Rule{}, true
+	}
+
+	for _, r := range a.rules {
+		if r.Query == nil || r.Query.Match(sec) {
+			return r, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// Load adds the keys of the secrets chosen by the rules to the agent. Keys
+// loaded before, even if since removed, are not loaded again. It returns the
+// number of keys loaded. A secret whose key cannot be loaded is skipped and its
+// error is returned with any others after the rest are loaded.
+func (a *Agent) Load(ctx context.Context) (int, error) {
+	a.loadMu.Lock()
+	defer a.loadMu.Unlock()
+
+	a.mu.Lock()
+	a.lastLoad = time.Now()
+	a.mu.Unlock()
+
+	q, err := secrets.ParseQuery("type=" + SecretType)
+	if err != nil {
+		return 0, err
+	}
+
+	secs, err := secrets.Search(ctx, a.kpr, q)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	var errs []error
+	for _, sec := range secs {
+		r, matches := a.ruleFor(sec)
+		if !matches {
+			continue
+		}
+
+		added, err := a.loadSecret(sec, r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("secret %q: %w", sec.Name(), err))
+			continue
+		}
+
+		if added {
+			n++
+		}
+	}
+
+	return n, errors.Join(errs...)
+}
+
+// loadSecret adds the key of the secret to the agent as the rule directs. It
+// returns false if the key was loaded before.
+func (a *Agent) loadSecret(sec secrets.Secret, r Rule) (bool, error) {
+	pem, err := PrivateKeyPEM(sec)
+	if err != nil {
+		return false, err
+	}
+
+	passphrase := r.Passphrase
+	if passphrase == "" {
+		passphrase = sec.GetField(PassphraseField)
+	}
+
+	key, err := ssh.ParseRawPrivateKey(pem)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if passphrase == "" {
+			return false, errors.New("the private key is encrypted, but no passphrase is given")
+		}
+
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(pem, []byte(passphrase))
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("unable to parse the private key: %w", err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	pubKey := string(signer.PublicKey().Marshal())
+	if _, isLoaded := a.loaded[pubKey]; isLoaded {
+		return false, nil
+	}
+
+	lifetime := r.Lifetime
+	if lifetime == 0 {
+		lifetime = a.lifetime
+	}
+
+	err = a.Add(agent.AddedKey{
+		PrivateKey:       key,
+		Comment:          sec.Name(),
+		LifetimeSecs:     lifetime,
+		ConfirmBeforeUse: r.Confirm || a.confirm,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	a.loaded[pubKey] = struct{}{}
+	return true, nil
+}
+
+// PrivateKeyPEM returns the PEM encoded private key held by the secret, which
+// is its password or else the first attachment holding a private key.
+func PrivateKeyPEM(sec secrets.Secret) ([]byte, error) {
+	if pem := []byte(sec.Password()); isPrivateKey(pem) {
+		return pem, nil
+	}
+
+	for _, name := range secrets.Attachments(sec) {
+		if pem, _ := secrets.GetAttachment(sec, name); isPrivateKey(pem) {
+			return pem, nil
+		}
+	}
+
+	return nil, errors.New("no private key found in the password or attachments")
+}
+
+// isPrivateKey returns true if the data looks like a PEM encoded private key.
+func isPrivateKey(data []byte) bool {
+	return bytes.Contains(data, []byte("-----BEGIN ")) &&
+		bytes.Contains(data, []byte("PRIVATE KEY-----"))
+}
@@ -0,0 +1,269 @@
+package sshagent_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"github.com/zostay/ghost/pkg/secrets"
+	"github.com/zostay/ghost/pkg/secrets/memory"
+	"github.com/zostay/ghost/pkg/sshagent"
+)
+
+// newKey returns a new private key and its PEM encoding, encrypted with the
+// passphrase if one is given.
+func newKey(t *testing.T, passphrase string) (ssh.PublicKey, []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	require.NoError(t, err)
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	return sshPub, pem.EncodeToMemory(block)
+}
+
+// mustQuery parses the query terms.
+func mustQuery(t *testing.T, terms ...string) *secrets.Query {
+	t.Helper()
+
+	q, err := secrets.ParseQuery(terms...)
+	require.NoError(t, err)
+	return q
+}
+
+func TestAgent(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	plainPub, plainPEM := newKey(t, "")
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Plain", "", string(plainPEM),
+		secrets.WithType(sshagent.SecretType),
+		secrets.WithLocation("SSH")))
+	require.NoError(t, err)
+
+	encPub, encPEM := newKey(t, "s3cret")
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Encrypted", "", "",
+		secrets.WithType(sshagent.SecretType),
+		secrets.WithLocation("SSH"),
+		secrets.WithAttachment("id_ed25519", encPEM)))
+	require.NoError(t, err)
+
+	fieldPub, fieldPEM := newKey(t, "f13ld")
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Field", "", string(fieldPEM),
+		secrets.WithType(sshagent.SecretType),
+		secrets.WithLocation("SSH"),
+		secrets.WithField(sshagent.PassphraseField, "f13ld")))
+	require.NoError(t, err)
+
+	_, otherPEM := newKey(t, "")
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Other", "", string(otherPEM),
+		secrets.WithType(sshagent.SecretType),
+		secrets.WithLocation("Work")))
+	require.NoError(t, err)
+
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Login", "alice", string(plainPEM),
+		secrets.WithLocation("SSH")))
+	require.NoError(t, err)
+
+	confirmed := 0
+	allow := false
+	a := sshagent.New(kpr,
+		sshagent.WithRules(
+			sshagent.Rule{
+				Query:      mustQuery(t, "name=Encrypted"),
+				Confirm:    true,
+				Passphrase: "s3cret",
+			},
+			sshagent.Rule{
+				Query:    mustQuery(t, "location=SSH"),
+				Lifetime: 3600,
+			},
+		),
+		sshagent.WithConfirmFunc(func(_, _ string) (bool, error) {
+			confirmed++
+			return allow, nil
+		}),
+	)
+
+	n, err := a.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	sockName := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sockName)
+	require.NoError(t, err)
+	defer func() { _ = l.Close() }()
+	go func() { _ = a.Serve(l) }()
+
+	conn, err := net.Dial("unix", sockName)
+	require.NoError(t, err)
+	defer func() { _ = conn.Close() }()
+	client := agent.NewClient(conn)
+
+	keys, err := client.List()
+	require.NoError(t, err)
+	comments := map[string]string{}
+	for _, k := range keys {
+		comments[k.Comment] = string(k.Marshal())
+	}
+	assert.Equal(t, map[string]string{
+		"Plain":     string(plainPub.Marshal()),
+		"Encrypted": string(encPub.Marshal()),
+		"Field":     string(fieldPub.Marshal()),
+	}, comments)
+
+	data := []byte("challenge")
+	sig, err := client.Sign(plainPub, data)
+	require.NoError(t, err)
+	assert.NoError(t, plainPub.Verify(data, sig))
+	assert.Equal(t, 0, confirmed)
+
+	_, err = client.Sign(encPub, data)
+	assert.Error(t, err)
+	assert.Equal(t, 1, confirmed)
+
+	allow = true
+	sig, err = client.Sign(encPub, data)
+	require.NoError(t, err)
+	assert.NoError(t, encPub.Verify(data, sig))
+	assert.Equal(t, 2, confirmed)
+
+	signers, err := a.Signers()
+	require.NoError(t, err)
+	assert.Len(t, signers, 2)
+
+	require.NoError(t, client.Remove(encPub))
+	keys, err = client.List()
+	require.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestAgent_Load_Errors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	_, encPEM := newKey(t, "s3cret")
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Encrypted", "", string(encPEM),
+		secrets.WithType(sshagent.SecretType)))
+	require.NoError(t, err)
+
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Empty", "", "not a key",
+		secrets.WithType(sshagent.SecretType)))
+	require.NoError(t, err)
+
+	_, plainPEM := newKey(t, "")
+	_, err = kpr.SetSecret(ctx, secrets.NewSecret("Plain", "", string(plainPEM),
+		secrets.WithType(sshagent.SecretType)))
+	require.NoError(t, err)
+
+	a := sshagent.New(kpr)
+	n, err := a.Load(ctx)
+	assert.Equal(t, 1, n)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `secret "Encrypted"`)
+	assert.Contains(t, err.Error(), `secret "Empty"`)
+
+	keys, err := a.List()
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "Plain", keys[0].Comment)
+}
+
+func TestAgent_Reload(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	kpr, err := memory.New()
+	require.NoError(t, err)
+
+	// addKey adds a new key to the keeper and returns its public key
+	addKey := func(name string) ssh.PublicKey {
+		pub, pem := newKey(t, "")
+		_, err := kpr.SetSecret(ctx, secrets.NewSecret(name, "", string(pem),
+			secrets.WithType(sshagent.SecretType)))
+		require.NoError(t, err)
+		return pub
+	}
+
+	comments := func(a *sshagent.Agent) []string {
+		keys, err := a.List()
+		require.NoError(t, err)
+
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = k.Comment
+		}
+		return names
+	}
+
+	first := addKey("First")
+
+	// without a reload interval, the keys are only loaded by Load
+	a := sshagent.New(kpr)
+	n, err := a.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	addKey("Second")
+	assert.Equal(t, []string{"First"}, comments(a))
+
+	// a key loaded before is not loaded again
+	n, err = a.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.ElementsMatch(t, []string{"First", "Second"}, comments(a))
+
+	const interval = 50 * time.Millisecond
+	a = sshagent.New(kpr, sshagent.WithReloadInterval(interval))
+	n, err = a.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	addKey("Third")
+	assert.ElementsMatch(t, []string{"First", "Second"}, comments(a))
+
+	time.Sleep(interval)
+	assert.ElementsMatch(t, []string{"First", "Second", "Third"}, comments(a))
+
+	// a removed key stays removed
+	require.NoError(t, a.Remove(first))
+	time.Sleep(interval)
+	assert.ElementsMatch(t, []string{"Second", "Third"}, comments(a))
+
+	// keys are not reloaded while the agent is locked
+	require.NoError(t, a.Lock([]byte("pass")))
+	addKey("Fourth")
+	time.Sleep(interval)
+	assert.Empty(t, comments(a))
+
+	require.NoError(t, a.Unlock([]byte("pass")))
+	signers, err := a.Signers()
+	require.NoError(t, err)
+	assert.Len(t, signers, 3)
+}